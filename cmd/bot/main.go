@@ -77,24 +77,59 @@ func run(ctx context.Context) int {
 		return exitCodeDBConnect
 	}
 	defer db.Close()
-	repo := sqlrepo.NewSQLiteRepository(ctx, db, conf.Learning.StreakLimit, conf.Learning.BatchSize, log)
+	repo := sqlrepo.NewSQLiteRepository(ctx, db, conf.Learning.StreakLimit, conf.Learning.BatchSize, conf.Learning.DrillIntervals,
+		sqlrepo.LeechRules{Threshold: conf.Learning.LeechThreshold, Suspend: conf.Learning.LeechSuspend},
+		sqlrepo.ReviewRules{First: conf.Learning.ReviewFirstInterval, Max: conf.Learning.ReviewMaxInterval}, log)
 
 	// Start Telegram bot
-	bot, err := telegram.NewBot(conf.Telegram.Token, repo, conf.Learning, log,
-		telegram.Recover(log), telegram.LogErrors(log), telegram.AllowedChats(conf.Telegram.AllowedChatIDs))
+	bot, err := telegram.NewBot(conf.Telegram.Token, repo, conf.Learning, conf.Goals,
+		conf.Telegram.AllowedChatIDs, conf.Telegram.GroupChatIDs, log,
+		telegram.Recover(log), telegram.LogErrors(log),
+		telegram.AllowedChats(conf.Telegram.AllowedChatIDs, conf.Telegram.GroupChatIDs))
 	if err != nil {
 		log.ErrorContext(ctx, "failed to create bot", "error", err)
 		return exitCodeBotCreate
 	}
 
 	go schedule.StartWordCheckSchedule(ctx, schedule.WordCheckConfig{
+		ChatIDs: conf.Telegram.AllowedChatIDs,
+		// Groups get their rounds in the same hours as everybody else gets word checks, unpaced.
+		GroupChatIDs:      conf.Telegram.GroupChatIDs,
+		Interval:          conf.Schedule.PublishInterval,
+		Cron:              conf.Schedule.MustCheckCron(),
+		Windows:           conf.Schedule.MustCheckWindows(),
+		Location:          loc,
+		MaxUnanswered:     conf.Schedule.MaxUnanswered,
+		MaxBackoff:        conf.Schedule.MaxBackoff,
+		FastAnswer:        conf.Schedule.FastAnswer,
+		StudySlots:        conf.Schedule.MustStudySlots(),
+		StudySlotInterval: conf.Schedule.StudySlotInterval,
+	}, repo, bot, log)
+	go schedule.StartUpdateBatchSchedule(ctx, schedule.BatchRefillConfig{
 		ChatIDs:  conf.Telegram.AllowedChatIDs,
-		Interval: conf.Schedule.PublishInterval,
-		HourFrom: conf.Schedule.HourFrom,
-		HourTo:   conf.Schedule.HourTo,
+		Schedule: conf.Learning.MustRefillSchedule(),
 		Location: loc,
-	}, bot, log)
-	go schedule.StartUpdateBatchSchedule(ctx, conf.Telegram.AllowedChatIDs, repo, log)
+	}, repo, log)
+	go schedule.StartDailyGoalSchedule(ctx, schedule.DailyGoalConfig{
+		ChatIDs: conf.Telegram.AllowedChatIDs,
+		Rules: sqlrepo.StreakRules{
+			Goal:        sqlrepo.DailyGoal{Answers: conf.Goals.DailyAnswers, NewWords: conf.Goals.DailyNewWords},
+			FreezeEvery: conf.Goals.FreezeEvery,
+			MaxFreezes:  conf.Goals.MaxFreezes,
+		},
+		ReminderHour: conf.Goals.ReminderHour,
+		Location:     loc,
+	}, repo, bot, log)
+	go schedule.StartDigestSchedule(ctx, schedule.DigestConfig{
+		ChatIDs:  conf.Telegram.AllowedChatIDs,
+		Weekly:   conf.Digest.MustWeeklySchedule(),
+		Monthly:  conf.Digest.MustMonthlySchedule(),
+		Location: loc,
+	}, repo, bot, log)
+
+	if conf.Trash.RetentionDays > 0 {
+		go schedule.StartTrashPurgeSchedule(ctx, time.Duration(conf.Trash.RetentionDays)*24*time.Hour, repo, log) //nolint:mnd // hours in a day
+	}
 
 	go bot.Start(ctx)
 
@@ -146,16 +181,46 @@ func loggableConfig(conf *config.Bot) map[string]any {
 	return map[string]any{
 		"dev":              conf.Dev,
 		"allowed-chat-ids": conf.Telegram.AllowedChatIDs,
+		"group-chat-ids":   conf.Telegram.GroupChatIDs,
 		"server-addr":      conf.Server.Addr,
 		"word-check-schedule": map[string]any{
-			"publish-interval": fmt.Sprintf("%v", conf.Schedule.PublishInterval),
-			"hour-from":        conf.Schedule.HourFrom,
-			"hour-to":          conf.Schedule.HourTo,
+			"publish-interval":    fmt.Sprintf("%v", conf.Schedule.PublishInterval),
+			"cron":                conf.Schedule.Cron,
+			"hour-from":           conf.Schedule.HourFrom,
+			"hour-to":             conf.Schedule.HourTo,
+			"windows":             conf.Schedule.Windows,
+			"max-unanswered":      conf.Schedule.MaxUnanswered,
+			"max-backoff":         fmt.Sprintf("%v", conf.Schedule.MaxBackoff),
+			"fast-answer":         fmt.Sprintf("%v", conf.Schedule.FastAnswer),
+			"study-slots":         conf.Schedule.StudySlots,
+			"study-slot-interval": fmt.Sprintf("%v", conf.Schedule.StudySlotInterval),
 		},
 		"learning": map[string]any{
-			"batch-size":          conf.Learning.BatchSize,
-			"streak-limit":        conf.Learning.StreakLimit,
-			"review-rate-percent": conf.Learning.ReviewRatePercent,
+			"batch-size":             conf.Learning.BatchSize,
+			"streak-limit":           conf.Learning.StreakLimit,
+			"review-rate-percent":    conf.Learning.ReviewRatePercent,
+			"to-review-rate-percent": conf.Learning.ToReviewRatePercent,
+			"cloze-rate-percent":     conf.Learning.ClozeRatePercent,
+			"refill-cron":            conf.Learning.RefillCron,
+			"drill-intervals":        fmt.Sprintf("%v", conf.Learning.DrillIntervals),
+			"undo-window":            fmt.Sprintf("%v", conf.Learning.UndoWindow),
+		},
+		"goals": map[string]any{
+			"daily-answers":   conf.Goals.DailyAnswers,
+			"daily-new-words": conf.Goals.DailyNewWords,
+			"reminder-hour":   conf.Goals.ReminderHour,
+			"freeze-every":    conf.Goals.FreezeEvery,
+			"max-freezes":     conf.Goals.MaxFreezes,
+		},
+		"digest": map[string]any{
+			"hour":         conf.Digest.Hour,
+			"weekly":       conf.Digest.Weekly,
+			"monthly":      conf.Digest.Monthly,
+			"weekly-cron":  conf.Digest.WeeklyCron,
+			"monthly-cron": conf.Digest.MonthlyCron,
+		},
+		"trash": map[string]any{
+			"retention-days": conf.Trash.RetentionDays,
 		},
 	}
 }
@@ -8,6 +8,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/labstack/echo/v4"
 
@@ -49,6 +50,20 @@ type stubWordsRepo struct {
 	createErr    error
 	resolveCalls []resolveCall
 	resolveErr   error
+	tagCalls     [][]string
+	suspendCalls []suspendCall
+	limitCalls   []limitCall
+}
+
+type limitCall struct {
+	word, tag string
+	limit     int
+}
+
+type suspendCall struct {
+	word        string
+	suspended   bool
+	buriedUntil time.Time
 }
 
 type resetCall struct {
@@ -110,13 +125,62 @@ func (s *stubWordsRepo) UpdateWordTranslation(_ context.Context, _ int64, _, _,
 	return nil
 }
 func (s *stubWordsRepo) DeleteWordTranslation(_ context.Context, _ int64, _ string) error { return nil }
-func (s *stubWordsRepo) RegisterGuess(_ context.Context, _ int64, _ string) error         { return nil }
-func (s *stubWordsRepo) RegisterMiss(_ context.Context, _ int64, _ string) error          { return nil }
-func (s *stubWordsRepo) MarkToReview(_ context.Context, _ int64, _ string, _ bool) error  { return nil }
-func (s *stubWordsRepo) MarkWordReviewed(_ context.Context, _ int64, _ string) error      { return nil }
 
-func (s *stubWordsRepo) RefillLearningBatch(_ context.Context, _ int64) (int, int, error) {
-	return 0, 0, nil
+// SetWordTags refuses words findWord does not serve, like the real one.
+func (s *stubWordsRepo) SetWordTags(ctx context.Context, chatID int64, word string, tags []string) error {
+	if _, err := s.FindWordTranslation(ctx, chatID, word); err != nil {
+		return err
+	}
+	s.tagCalls = append(s.tagCalls, tags)
+	return nil
+}
+
+// SetWordSuspension refuses words findWord does not serve, like the real one.
+func (s *stubWordsRepo) SetWordSuspension(
+	ctx context.Context, chatID int64, word string, suspended bool, buriedUntil time.Time,
+) error {
+	if _, err := s.FindWordTranslation(ctx, chatID, word); err != nil {
+		return err
+	}
+	s.suspendCalls = append(s.suspendCalls, suspendCall{word, suspended, buriedUntil})
+	return nil
+}
+
+// SetWordStreakLimit refuses words findWord does not serve, like the real one.
+func (s *stubWordsRepo) SetWordStreakLimit(ctx context.Context, chatID int64, word string, limit int) error {
+	if _, err := s.FindWordTranslation(ctx, chatID, word); err != nil {
+		return err
+	}
+	s.limitCalls = append(s.limitCalls, limitCall{word: word, limit: limit})
+	return nil
+}
+
+func (s *stubWordsRepo) GetTagStreakLimits(_ context.Context, _ int64) ([]dal.TagStreakLimit, error) {
+	return nil, nil
+}
+
+func (s *stubWordsRepo) SetTagStreakLimit(_ context.Context, _ int64, tag string, limit int) error {
+	s.limitCalls = append(s.limitCalls, limitCall{tag: tag, limit: limit})
+	return nil
+}
+
+func (s *stubWordsRepo) BuryWord(_ context.Context, _ int64, _ string, _ time.Time) error { return nil }
+
+func (s *stubWordsRepo) GradeCallback(_ context.Context, _ int64, _ string, _ bool) (bool, error) {
+	return false, nil
+}
+func (s *stubWordsRepo) UndoLastAnswer(_ context.Context, _ int64, _ string, _ time.Time) (*dal.UndoneAnswer, error) {
+	return nil, dal.ErrNotFound
+}
+func (s *stubWordsRepo) MarkToReview(_ context.Context, _ int64, _ string, _ bool) error { return nil }
+func (s *stubWordsRepo) MarkWordReviewed(_ context.Context, _ int64, _ string) error     { return nil }
+func (s *stubWordsRepo) PostponeDrill(_ context.Context, _ int64, _ string) error        { return nil }
+func (s *stubWordsRepo) GetReviewLoad(_ context.Context, _ int64) (*dal.ReviewLoad, error) {
+	return &dal.ReviewLoad{}, nil
+}
+
+func (s *stubWordsRepo) RefillLearningBatch(_ context.Context, _ int64) (*dal.RefillReport, error) {
+	return &dal.RefillReport{}, nil
 }
 
 // existingWord builds a findWord stub that serves wt for its own word and reports every other word
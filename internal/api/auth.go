@@ -2,9 +2,14 @@ package api
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -20,18 +25,23 @@ type (
 
 	AuthDependencies struct {
 		Repo             dal.AuthConfirmationRepository
+		Sessions         dal.SessionRepository
 		JWTProcessor     *JWTProcessor
 		CookiesProcessor *CookiesProcessor
 		TelegramClient   TelegramClient
-		AllowedChatIDs   []int64
-		Logger           *slog.Logger
+		// BotToken checks the signature of the Login Widget and Mini App data.
+		BotToken       string
+		AllowedChatIDs []int64
+		Logger         *slog.Logger
 	}
 
 	AuthHandler struct {
 		repo             dal.AuthConfirmationRepository
+		sessions         dal.SessionRepository
 		teleClient       TelegramClient
 		jwtProcessor     *JWTProcessor
 		cookiesProcessor *CookiesProcessor
+		botToken         string
 		allowedChatIDs   map[int64]bool
 
 		log *slog.Logger
@@ -41,10 +51,33 @@ type (
 		ChatID int64 `json:"chat_id" validate:"required,gt=0"`
 	}
 
+	webAppRequest struct {
+		// InitData is Telegram.WebApp.initData as is, a query string.
+		InitData string `json:"init_data" validate:"required"`
+	}
+
 	statusResponse struct {
 		Authenticated bool  `json:"authenticated"`
 		ChatID        int64 `json:"chat_id"`
 	}
+
+	// Session is a signed-in browser. Current marks the one making the request.
+	Session struct {
+		ID         string    `json:"id"`
+		UserAgent  string    `json:"user_agent"`
+		IP         string    `json:"ip"`
+		CreatedAt  time.Time `json:"created_at"`
+		LastSeenAt time.Time `json:"last_seen_at"`
+		ExpiresAt  time.Time `json:"expires_at"`
+		Current    bool      `json:"current"`
+	}
+)
+
+const (
+	// maxUserAgentLength caps the user agent kept with a session.
+	maxUserAgentLength = 256
+	// refreshTokenBytes is how much randomness goes into a refresh token.
+	refreshTokenBytes = 32
 )
 
 func NewAuthHandler(deps AuthDependencies) *AuthHandler {
@@ -54,9 +87,11 @@ func NewAuthHandler(deps AuthDependencies) *AuthHandler {
 	}
 	return &AuthHandler{
 		repo:             deps.Repo,
+		sessions:         deps.Sessions,
 		teleClient:       deps.TelegramClient,
 		jwtProcessor:     deps.JWTProcessor,
 		cookiesProcessor: deps.CookiesProcessor,
+		botToken:         deps.BotToken,
 		allowedChatIDs:   allowedChatIDs,
 
 		log: deps.Logger,
@@ -142,18 +177,270 @@ func (h *AuthHandler) Status(c echo.Context) error {
 
 	res.Authenticated = true
 
-	accessToken, err := h.jwtProcessor.ToAccessToken(chatID)
-	if err != nil {
-		h.log.ErrorContext(c.Request().Context(), "failed to create access token", "error", err)
+	if err = h.signIn(c, chatID, false); err != nil {
+		h.log.ErrorContext(c.Request().Context(), "failed to sign in", "error", err)
 		return c.JSON(http.StatusInternalServerError, InternalServerError)
 	}
 
-	c.SetCookie(h.cookiesProcessor.NewAccessTokenCookie(accessToken))
 	c.SetCookie(h.cookiesProcessor.ExpireAuthTokenCookie())
 	return c.JSON(http.StatusOK, res)
 }
 
+// TelegramWidget signs in with the data the Telegram Login Widget passed to the page, posted as the
+// JSON object it came as. There is nothing to confirm in the chat: Telegram has vouched for the user.
+func (h *AuthHandler) TelegramWidget(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var raw map[string]any
+	decoder := json.NewDecoder(c.Request().Body)
+	decoder.UseNumber()
+	if err := decoder.Decode(&raw); err != nil {
+		h.log.DebugContext(ctx, "failed to decode request", "error", err)
+		return c.JSON(http.StatusBadRequest, BadRequestError)
+	}
+	fields := make(map[string]string, len(raw))
+	for k, v := range raw {
+		switch v := v.(type) {
+		case string:
+			fields[k] = v
+		case json.Number:
+			fields[k] = v.String()
+		default:
+			h.log.DebugContext(ctx, "unexpected login widget field", "field", k)
+			return c.JSON(http.StatusBadRequest, BadRequestError)
+		}
+	}
+
+	chatID, err := verifyLoginWidget(h.botToken, fields, time.Now())
+	if err != nil {
+		h.log.WarnContext(ctx, "invalid login widget data", "error", err)
+		return c.JSON(http.StatusUnauthorized, statusResponse{})
+	}
+	return h.signInTelegramUser(c, chatID, false)
+}
+
+// WebApp signs in with the initData of the Telegram Mini App the web interface was opened as.
+func (h *AuthHandler) WebApp(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var req webAppRequest
+	if err := c.Bind(&req); err != nil {
+		h.log.DebugContext(ctx, "failed to bind request", "error", err)
+		return c.JSON(http.StatusBadRequest, BadRequestError)
+	}
+	if err := c.Validate(&req); err != nil {
+		h.log.DebugContext(ctx, "failed to validate request", "error", err)
+		return err
+	}
+
+	chatID, err := verifyWebAppInitData(h.botToken, req.InitData, time.Now())
+	if err != nil {
+		h.log.WarnContext(ctx, "invalid mini app init data", "error", err)
+		return c.JSON(http.StatusUnauthorized, statusResponse{})
+	}
+	return h.signInTelegramUser(c, chatID, true)
+}
+
+// signInTelegramUser signs in a user Telegram vouched for; miniApp is set when they did so from the
+// Mini App.
+func (h *AuthHandler) signInTelegramUser(c echo.Context, chatID int64, miniApp bool) error {
+	if !h.allowedChatIDs[chatID] {
+		h.log.DebugContext(c.Request().Context(), "chat ID not allowed", "chat_id", chatID)
+		return c.JSON(http.StatusForbidden, ErrorResponse{
+			Message: "chat ID not allowed",
+		})
+	}
+
+	if err := h.signIn(c, chatID, miniApp); err != nil {
+		h.log.ErrorContext(c.Request().Context(), "failed to sign in", "error", err)
+		return c.JSON(http.StatusInternalServerError, InternalServerError)
+	}
+	return c.JSON(http.StatusOK, statusResponse{Authenticated: true, ChatID: chatID})
+}
+
+// Refresh exchanges the refresh cookie for a new access token and a new refresh token. A refresh token
+// works once: presenting one that was already exchanged signs its session out, as it means somebody
+// else has a copy of it.
+func (h *AuthHandler) Refresh(c echo.Context) error {
+	ctx := c.Request().Context()
+	var res statusResponse
+
+	token, ok := h.cookiesProcessor.GetRefreshToken(c)
+	if !ok {
+		h.log.DebugContext(ctx, "refresh token not found")
+		return c.JSON(http.StatusUnauthorized, res)
+	}
+
+	newToken, err := newRefreshToken()
+	if err != nil {
+		h.log.ErrorContext(ctx, "failed to generate refresh token", "error", err)
+		return c.JSON(http.StatusInternalServerError, InternalServerError)
+	}
+
+	session, err := h.sessions.RotateRefreshToken(ctx, token, newToken, time.Now().Add(h.cookiesProcessor.refreshExpiresIn))
+	if err != nil {
+		switch {
+		case errors.Is(err, dal.ErrRefreshTokenReused):
+			h.log.WarnContext(ctx, "refresh token reused, session revoked", "ip", c.RealIP())
+		case errors.Is(err, dal.ErrNotFound):
+			h.log.DebugContext(ctx, "refresh token of no active session")
+		default:
+			h.log.ErrorContext(ctx, "failed to rotate refresh token", "error", err)
+			return c.JSON(http.StatusInternalServerError, InternalServerError)
+		}
+		h.expireSessionCookies(c)
+		return c.JSON(http.StatusUnauthorized, res)
+	}
+
+	if !h.allowedChatIDs[session.ChatID] {
+		h.log.DebugContext(ctx, "chat ID not allowed", "chat_id", session.ChatID)
+		h.expireSessionCookies(c)
+		return c.JSON(http.StatusUnauthorized, res)
+	}
+
+	if err = h.setSessionCookies(c, session.ChatID, session.ID, newToken, session.MiniApp); err != nil {
+		h.log.ErrorContext(ctx, "failed to create access token", "error", err)
+		return c.JSON(http.StatusInternalServerError, InternalServerError)
+	}
+
+	res.Authenticated = true
+	res.ChatID = session.ChatID
+	return c.JSON(http.StatusOK, res)
+}
+
+// LogOut revokes the session of the access cookie, or of the refresh cookie once the access token has
+// expired, and expires both cookies.
 func (h *AuthHandler) LogOut(c echo.Context) error {
-	c.SetCookie(h.cookiesProcessor.ExpireAccessTokenCookie())
+	ctx := c.Request().Context()
+
+	var err error
+	if chatID, sessionID, ok := h.accessSession(c); ok {
+		err = h.sessions.RevokeSession(ctx, chatID, sessionID)
+	} else if token, found := h.cookiesProcessor.GetRefreshToken(c); found {
+		err = h.sessions.RevokeSessionByRefreshToken(ctx, token)
+	}
+	if err != nil && !errors.Is(err, dal.ErrNotFound) {
+		h.log.ErrorContext(ctx, "failed to revoke session", "error", err)
+		return c.JSON(http.StatusInternalServerError, InternalServerError)
+	}
+
+	h.expireSessionCookies(c)
 	return c.JSON(http.StatusOK, nil)
 }
+
+// GetSessions lists the chat's active sessions, most recently seen first.
+func (h *AuthHandler) GetSessions(c echo.Context) error {
+	ctx := c.Request().Context()
+	chatID := appctx.MustChatIDFromContext(ctx)
+	currentID, _ := appctx.SessionIDFromContext(ctx)
+
+	sessions, err := h.sessions.GetSessions(ctx, chatID)
+	if err != nil {
+		h.log.ErrorContext(ctx, "failed to get sessions", "error", err)
+		return c.JSON(http.StatusInternalServerError, InternalServerError)
+	}
+
+	res := make([]Session, len(sessions))
+	for i, s := range sessions {
+		res[i] = Session{
+			ID:         s.ID,
+			UserAgent:  s.UserAgent,
+			IP:         s.IP,
+			CreatedAt:  s.CreatedAt,
+			LastSeenAt: s.LastSeenAt,
+			ExpiresAt:  s.ExpiresAt,
+			Current:    s.ID == currentID,
+		}
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"items": res})
+}
+
+// RevokeSession signs out one of the chat's sessions, which may be the current one.
+func (h *AuthHandler) RevokeSession(c echo.Context) error {
+	ctx := c.Request().Context()
+	chatID := appctx.MustChatIDFromContext(ctx)
+
+	if err := h.sessions.RevokeSession(ctx, chatID, c.Param("id")); err != nil {
+		if errors.Is(err, dal.ErrNotFound) {
+			return c.JSON(http.StatusNotFound, NotFoundError)
+		}
+		h.log.ErrorContext(ctx, "failed to revoke session", "error", err)
+		return c.JSON(http.StatusInternalServerError, InternalServerError)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"status": "ok", "message": "session revoked"})
+}
+
+// startSession creates a session for the chat and returns its ID and first refresh token.
+func (h *AuthHandler) startSession(c echo.Context, chatID int64, miniApp bool) (string, string, error) {
+	userAgent := c.Request().UserAgent()
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+
+	refreshToken, err := newRefreshToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	id := uuid.NewString()
+	err = h.sessions.CreateSession(c.Request().Context(), dal.Session{
+		ID:        id,
+		ChatID:    chatID,
+		UserAgent: userAgent,
+		IP:        c.RealIP(),
+		ExpiresAt: time.Now().Add(h.cookiesProcessor.refreshExpiresIn),
+		MiniApp:   miniApp,
+	}, refreshToken)
+	if err != nil {
+		return "", "", fmt.Errorf("create session: %w", err)
+	}
+	return id, refreshToken, nil
+}
+
+// signIn starts a session for the chat and sets its access and refresh cookies, which a miniApp session
+// needs sent cross-site.
+func (h *AuthHandler) signIn(c echo.Context, chatID int64, miniApp bool) error {
+	sessionID, refreshToken, err := h.startSession(c, chatID, miniApp)
+	if err != nil {
+		return err
+	}
+	return h.setSessionCookies(c, chatID, sessionID, refreshToken, miniApp)
+}
+
+func (h *AuthHandler) setSessionCookies(c echo.Context, chatID int64, sessionID, refreshToken string, miniApp bool) error {
+	accessToken, err := h.jwtProcessor.ToAccessToken(chatID, sessionID)
+	if err != nil {
+		return fmt.Errorf("create access token: %w", err)
+	}
+	c.SetCookie(h.cookiesProcessor.NewAccessTokenCookie(accessToken, miniApp))
+	c.SetCookie(h.cookiesProcessor.NewRefreshTokenCookie(refreshToken, miniApp))
+	return nil
+}
+
+func (h *AuthHandler) expireSessionCookies(c echo.Context) {
+	c.SetCookie(h.cookiesProcessor.ExpireAccessTokenCookie())
+	c.SetCookie(h.cookiesProcessor.ExpireRefreshTokenCookie())
+}
+
+// accessSession returns the chat and session of a valid access cookie.
+func (h *AuthHandler) accessSession(c echo.Context) (int64, string, bool) {
+	token, ok := h.cookiesProcessor.GetAccessToken(c)
+	if !ok {
+		return 0, "", false
+	}
+	chatID, sessionID, err := h.jwtProcessor.ParseAccessToken(token)
+	if err != nil {
+		return 0, "", false
+	}
+	return chatID, sessionID, true
+}
+
+func newRefreshToken() (string, error) {
+	b := make([]byte, refreshTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("read random bytes: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
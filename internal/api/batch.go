@@ -0,0 +1,205 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/context"
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+	"github.com/labstack/echo/v4"
+)
+
+type (
+	BatchHandler struct {
+		repo dal.BatchRepository
+		log  *slog.Logger
+	}
+
+	BatchWordsRequest struct {
+		Words []string `json:"words" validate:"required,min=1,max=500,dive,required,max=255"`
+	}
+
+	RefillStrategyRequest struct {
+		Strategy string `json:"strategy" validate:"required,oneof=random oldest newest most_missed shortest tag frequency"`
+		// Tag is the tag the "tag" strategy draws from; it is ignored by the others.
+		Tag string `json:"tag" validate:"required_if=Strategy tag,max=32"`
+	}
+
+	BatchSizeRequest struct {
+		// Size is how many words the batch is topped up to; 0 goes back to BOT_LEARNING_BATCH_SIZE.
+		Size int `json:"size" validate:"min=0,max=500"`
+	}
+)
+
+func NewBatchHandler(repo dal.BatchRepository, log *slog.Logger) *BatchHandler {
+	return &BatchHandler{
+		repo: repo,
+		log:  log,
+	}
+}
+
+// GetBatch lists the words being learned right now and, in the order they will be admitted, the
+// ones queued behind them.
+func (h *BatchHandler) GetBatch(c echo.Context) error {
+	ctx := c.Request().Context()
+	chatID := context.MustChatIDFromContext(ctx)
+
+	batch, err := h.repo.GetLearningBatch(ctx, chatID)
+	if err != nil {
+		h.log.ErrorContext(ctx, "failed to get learning batch", "error", err)
+		return c.JSON(http.StatusInternalServerError, InternalServerError)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"size":        batch.Size,
+		"custom_size": batch.CustomSize,
+		"strategy":    batch.Strategy,
+		"refill_tag":  batch.RefillTag,
+		"batched":     viewWords(batch.Batched),
+		"queued":      viewWords(batch.Queued),
+	})
+}
+
+// AddWords asks for words to be learned: they join the batch while it has room and queue behind it
+// after that. Unknown, learned and suspended words are skipped.
+func (h *BatchHandler) AddWords(c echo.Context) error {
+	ctx := c.Request().Context()
+	chatID := context.MustChatIDFromContext(ctx)
+
+	var req BatchWordsRequest
+	if err := c.Bind(&req); err != nil {
+		h.log.DebugContext(ctx, "failed to bind request", "error", err)
+		return c.JSON(http.StatusBadRequest, BadRequestError)
+	}
+
+	if err := c.Validate(&req); err != nil {
+		h.log.DebugContext(ctx, "failed to validate request", "error", err)
+		return err
+	}
+
+	added, err := h.repo.AddToLearningBatch(ctx, chatID, req.Words)
+	if err != nil {
+		h.log.ErrorContext(ctx, "failed to add words to learning batch", "error", err)
+		return c.JSON(http.StatusInternalServerError, InternalServerError)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"added": added})
+}
+
+func (h *BatchHandler) RemoveWords(c echo.Context) error {
+	ctx := c.Request().Context()
+	chatID := context.MustChatIDFromContext(ctx)
+
+	var req BatchWordsRequest
+	if err := c.Bind(&req); err != nil {
+		h.log.DebugContext(ctx, "failed to bind request", "error", err)
+		return c.JSON(http.StatusBadRequest, BadRequestError)
+	}
+
+	if err := c.Validate(&req); err != nil {
+		h.log.DebugContext(ctx, "failed to validate request", "error", err)
+		return err
+	}
+
+	removed, err := h.repo.RemoveFromLearningBatch(ctx, chatID, req.Words)
+	if err != nil {
+		h.log.ErrorContext(ctx, "failed to remove words from learning batch", "error", err)
+		return c.JSON(http.StatusInternalServerError, InternalServerError)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"removed": removed})
+}
+
+// ReorderQueue moves the given words to the front of the queue, in that order.
+func (h *BatchHandler) ReorderQueue(c echo.Context) error {
+	ctx := c.Request().Context()
+	chatID := context.MustChatIDFromContext(ctx)
+
+	var req BatchWordsRequest
+	if err := c.Bind(&req); err != nil {
+		h.log.DebugContext(ctx, "failed to bind request", "error", err)
+		return c.JSON(http.StatusBadRequest, BadRequestError)
+	}
+
+	if err := c.Validate(&req); err != nil {
+		h.log.DebugContext(ctx, "failed to validate request", "error", err)
+		return err
+	}
+
+	if err := h.repo.ReorderLearningBatchQueue(ctx, chatID, req.Words); err != nil {
+		h.log.ErrorContext(ctx, "failed to reorder learning batch queue", "error", err)
+		return c.JSON(http.StatusInternalServerError, InternalServerError)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"status": "ok", "message": "queue reordered"})
+}
+
+// Refill tops the batch up now rather than at the next scheduled refill.
+func (h *BatchHandler) Refill(c echo.Context) error {
+	ctx := c.Request().Context()
+	chatID := context.MustChatIDFromContext(ctx)
+
+	report, err := h.repo.RefillLearningBatch(ctx, chatID)
+	if err != nil {
+		h.log.ErrorContext(ctx, "failed to refill learning batch", "error", err)
+		return c.JSON(http.StatusInternalServerError, InternalServerError)
+	}
+
+	fills := make([]echo.Map, len(report.Fills))
+	for i, f := range report.Fills {
+		fills[i] = echo.Map{"strategy": f.Strategy, "added": f.Added}
+	}
+	return c.JSON(http.StatusOK, echo.Map{
+		"evicted": report.Evicted,
+		"added":   report.Added(),
+		"drained": report.Drained,
+		"fills":   fills,
+	})
+}
+
+// SetStrategy changes how the batch is topped up once the queue behind it is drained.
+func (h *BatchHandler) SetStrategy(c echo.Context) error {
+	ctx := c.Request().Context()
+	chatID := context.MustChatIDFromContext(ctx)
+
+	var req RefillStrategyRequest
+	if err := c.Bind(&req); err != nil {
+		h.log.DebugContext(ctx, "failed to bind request", "error", err)
+		return c.JSON(http.StatusBadRequest, BadRequestError)
+	}
+
+	if err := c.Validate(&req); err != nil {
+		h.log.DebugContext(ctx, "failed to validate request", "error", err)
+		return err
+	}
+
+	if err := h.repo.SetRefillStrategy(ctx, chatID, dal.RefillStrategy(req.Strategy), req.Tag); err != nil {
+		h.log.ErrorContext(ctx, "failed to set refill strategy", "error", err)
+		return c.JSON(http.StatusInternalServerError, InternalServerError)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"status": "ok", "message": "refill strategy updated"})
+}
+
+func (h *BatchHandler) SetSize(c echo.Context) error {
+	ctx := c.Request().Context()
+	chatID := context.MustChatIDFromContext(ctx)
+
+	var req BatchSizeRequest
+	if err := c.Bind(&req); err != nil {
+		h.log.DebugContext(ctx, "failed to bind request", "error", err)
+		return c.JSON(http.StatusBadRequest, BadRequestError)
+	}
+
+	if err := c.Validate(&req); err != nil {
+		h.log.DebugContext(ctx, "failed to validate request", "error", err)
+		return err
+	}
+
+	if err := h.repo.SetBatchSize(ctx, chatID, req.Size); err != nil {
+		h.log.ErrorContext(ctx, "failed to set batch size", "error", err)
+		return c.JSON(http.StatusInternalServerError, InternalServerError)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"status": "ok", "message": "batch size updated"})
+}
@@ -0,0 +1,164 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/api"
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+)
+
+// stubBatchRepo implements dal.BatchRepository, recording the words and sizes it is given.
+type stubBatchRepo struct {
+	addCalls     [][]string
+	reorderCalls [][]string
+	sizeCalls    []int
+	// strategyCalls are "strategy:tag".
+	strategyCalls []string
+}
+
+func (s *stubBatchRepo) GetLearningBatch(_ context.Context, _ int64) (*dal.LearningBatch, error) {
+	return &dal.LearningBatch{Size: 10}, nil
+}
+
+func (s *stubBatchRepo) AddToLearningBatch(_ context.Context, _ int64, words []string) (int, error) {
+	s.addCalls = append(s.addCalls, words)
+	return len(words), nil
+}
+
+func (s *stubBatchRepo) RemoveFromLearningBatch(_ context.Context, _ int64, words []string) (int, error) {
+	return len(words), nil
+}
+
+func (s *stubBatchRepo) ReorderLearningBatchQueue(_ context.Context, _ int64, words []string) error {
+	s.reorderCalls = append(s.reorderCalls, words)
+	return nil
+}
+
+func (s *stubBatchRepo) RefillLearningBatch(_ context.Context, _ int64) (*dal.RefillReport, error) {
+	return &dal.RefillReport{}, nil
+}
+
+func (s *stubBatchRepo) SetRefillStrategy(_ context.Context, _ int64, strategy dal.RefillStrategy, tag string) error {
+	s.strategyCalls = append(s.strategyCalls, string(strategy)+":"+tag)
+	return nil
+}
+
+func (s *stubBatchRepo) SetBatchSize(_ context.Context, _ int64, size int) error {
+	s.sizeCalls = append(s.sizeCalls, size)
+	return nil
+}
+
+var _ dal.BatchRepository = (*stubBatchRepo)(nil)
+
+func TestBatchWordsValidation(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		want       []string
+	}{
+		{name: "words", body: `{"words":["b","a"]}`, wantStatus: http.StatusOK, want: []string{"b", "a"}},
+		{name: "no words", body: `{"words":[]}`, wantStatus: http.StatusBadRequest},
+		{name: "empty word", body: `{"words":["a",""]}`, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &stubBatchRepo{}
+			h := api.NewBatchHandler(repo, testLogger())
+
+			c, rec := newRequest(t, "/batch/queue", tt.body)
+			assertHandlerStatus(t, h.ReorderQueue(c), rec.Code, tt.wantStatus)
+
+			if tt.want == nil {
+				if len(repo.reorderCalls) != 0 {
+					t.Errorf("ReorderLearningBatchQueue called with %v, want no call", repo.reorderCalls)
+				}
+				return
+			}
+			// The order is the point of the request; it must reach the repository unchanged.
+			if len(repo.reorderCalls) != 1 || !slices.Equal(repo.reorderCalls[0], tt.want) {
+				t.Errorf("ReorderLearningBatchQueue calls = %v, want [%v]", repo.reorderCalls, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetBatchSize(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		want       []int
+	}{
+		{name: "custom size", body: `{"size":20}`, wantStatus: http.StatusOK, want: []int{20}},
+		{name: "back to default", body: `{"size":0}`, wantStatus: http.StatusOK, want: []int{0}},
+		{name: "negative", body: `{"size":-1}`, wantStatus: http.StatusBadRequest},
+		{name: "too large", body: `{"size":501}`, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &stubBatchRepo{}
+			h := api.NewBatchHandler(repo, testLogger())
+
+			c, rec := newRequest(t, "/batch/size", tt.body)
+			assertHandlerStatus(t, h.SetSize(c), rec.Code, tt.wantStatus)
+
+			if !slices.Equal(repo.sizeCalls, tt.want) {
+				t.Errorf("SetBatchSize calls = %v, want %v", repo.sizeCalls, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetRefillStrategy(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		want       []string
+	}{
+		{name: "strategy", body: `{"strategy":"frequency"}`, wantStatus: http.StatusOK, want: []string{"frequency:"}},
+		{name: "by tag", body: `{"strategy":"tag","tag":"travel"}`, wantStatus: http.StatusOK, want: []string{"tag:travel"}},
+		{name: "tag without a tag", body: `{"strategy":"tag"}`, wantStatus: http.StatusBadRequest},
+		{name: "unknown strategy", body: `{"strategy":"alphabetical"}`, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &stubBatchRepo{}
+			h := api.NewBatchHandler(repo, testLogger())
+
+			c, rec := newRequest(t, "/batch/strategy", tt.body)
+			assertHandlerStatus(t, h.SetStrategy(c), rec.Code, tt.wantStatus)
+
+			if !slices.Equal(repo.strategyCalls, tt.want) {
+				t.Errorf("SetRefillStrategy calls = %v, want %v", repo.strategyCalls, tt.want)
+			}
+		})
+	}
+}
+
+// assertHandlerStatus checks the status of a handler that either wrote a response or returned the
+// *echo.HTTPError a failed validation produces.
+func assertHandlerStatus(t *testing.T, err error, code, want int) {
+	t.Helper()
+
+	if err != nil {
+		var httpErr *echo.HTTPError
+		if !errors.As(err, &httpErr) {
+			t.Fatalf("handler: %v", err)
+		}
+		code = httpErr.Code
+	}
+	if code != want {
+		t.Errorf("status = %d, want %d", code, want)
+	}
+}
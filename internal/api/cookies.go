@@ -2,6 +2,7 @@ package api
 
 import (
 	"net/http"
+	"path"
 	"time"
 
 	"github.com/Roma7-7-7/english-learning-bot/internal/config"
@@ -9,23 +10,26 @@ import (
 )
 
 const (
-	authCookieName   = "auth"
-	accessCookieName = "access"
+	authCookieName    = "auth"
+	accessCookieName  = "access"
+	refreshCookieName = "refresh"
 )
 
 type CookiesProcessor struct {
-	path            string
-	domain          string
-	authExpiresIn   time.Duration
-	accessExpiresIn time.Duration
+	path             string
+	domain           string
+	authExpiresIn    time.Duration
+	accessExpiresIn  time.Duration
+	refreshExpiresIn time.Duration
 }
 
 func NewCookiesProcessor(conf config.Cookie) *CookiesProcessor {
 	return &CookiesProcessor{
-		path:            conf.Path,
-		domain:          conf.Domain,
-		authExpiresIn:   conf.AuthExpiresIn,
-		accessExpiresIn: conf.AccessExpiresIn,
+		path:             conf.Path,
+		domain:           conf.Domain,
+		authExpiresIn:    conf.AuthExpiresIn,
+		accessExpiresIn:  conf.AccessExpiresIn,
+		refreshExpiresIn: conf.RefreshExpiresIn,
 	}
 }
 
@@ -51,7 +55,9 @@ func (p *CookiesProcessor) GetAuthToken(c echo.Context) (string, bool) {
 	return cookie.Value, true
 }
 
-func (p *CookiesProcessor) NewAccessTokenCookie(token string) *http.Cookie {
+// NewAccessTokenCookie is SameSite=None for a crossSite session, one signed in from the Telegram Mini
+// App: the web interface runs in a frame of Telegram's there, where a strict cookie is never sent.
+func (p *CookiesProcessor) NewAccessTokenCookie(token string, crossSite bool) *http.Cookie {
 	return &http.Cookie{
 		Name:     accessCookieName,
 		Path:     p.path,
@@ -60,7 +66,7 @@ func (p *CookiesProcessor) NewAccessTokenCookie(token string) *http.Cookie {
 		Expires:  time.Now().Add(p.accessExpiresIn),
 		Secure:   true,
 		HttpOnly: true,
-		SameSite: http.SameSiteStrictMode,
+		SameSite: sameSite(crossSite),
 		MaxAge:   int(p.accessExpiresIn.Seconds()),
 	}
 }
@@ -87,6 +93,8 @@ func (p *CookiesProcessor) ExpireAuthTokenCookie() *http.Cookie {
 	}
 }
 
+// ExpireAccessTokenCookie is SameSite=None, so that the Mini App can sign out too: the expired cookie
+// carries nothing to protect.
 func (p *CookiesProcessor) ExpireAccessTokenCookie() *http.Cookie {
 	return &http.Cookie{
 		Name:     accessCookieName,
@@ -97,6 +105,56 @@ func (p *CookiesProcessor) ExpireAccessTokenCookie() *http.Cookie {
 		MaxAge:   -1,
 		Secure:   true,
 		HttpOnly: true,
-		SameSite: http.SameSiteStrictMode,
+		SameSite: http.SameSiteNoneMode,
+	}
+}
+
+// NewRefreshTokenCookie is only sent to the /auth endpoints, the one place the refresh token is used.
+// crossSite is as for NewAccessTokenCookie.
+func (p *CookiesProcessor) NewRefreshTokenCookie(token string, crossSite bool) *http.Cookie {
+	return &http.Cookie{
+		Name:     refreshCookieName,
+		Path:     p.refreshPath(),
+		Domain:   p.domain,
+		Value:    token,
+		Expires:  time.Now().Add(p.refreshExpiresIn),
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: sameSite(crossSite),
+		MaxAge:   int(p.refreshExpiresIn.Seconds()),
+	}
+}
+
+func (p *CookiesProcessor) GetRefreshToken(c echo.Context) (string, bool) {
+	cookie, err := c.Cookie(refreshCookieName)
+	if err != nil {
+		return "", false
+	}
+	return cookie.Value, true
+}
+
+// ExpireRefreshTokenCookie is SameSite=None for the same reason as ExpireAccessTokenCookie.
+func (p *CookiesProcessor) ExpireRefreshTokenCookie() *http.Cookie {
+	return &http.Cookie{
+		Name:     refreshCookieName,
+		Path:     p.refreshPath(),
+		Domain:   p.domain,
+		Value:    "",
+		Expires:  time.Unix(0, 0),
+		MaxAge:   -1,
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteNoneMode,
+	}
+}
+
+func (p *CookiesProcessor) refreshPath() string {
+	return path.Join(p.path, "auth")
+}
+
+func sameSite(crossSite bool) http.SameSite {
+	if crossSite {
+		return http.SameSiteNoneMode
 	}
+	return http.SameSiteStrictMode
 }
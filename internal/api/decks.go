@@ -0,0 +1,235 @@
+package api
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/context"
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+	"github.com/labstack/echo/v4"
+)
+
+type (
+	DecksHandler struct {
+		repo dal.DeckRepository
+		// chatIDs are the chats whose decks can be seen and subscribed to: the bot's allowed chats.
+		chatIDs []int64
+		log     *slog.Logger
+	}
+
+	CreateDeckRequest struct {
+		Name string `json:"name" validate:"required,min=1,max=64"`
+	}
+
+	DeckIDRequest struct {
+		ID int64 `query:"id" validate:"required"`
+	}
+
+	DeckWordsRequest struct {
+		DeckID int64    `json:"deck_id" validate:"required"`
+		Words  []string `json:"words" validate:"required,min=1,max=500,dive,required,max=255"`
+	}
+
+	SubscribeDeckRequest struct {
+		DeckID int64 `json:"deck_id" validate:"required"`
+	}
+)
+
+func NewDecksHandler(repo dal.DeckRepository, chatIDs []int64, log *slog.Logger) *DecksHandler {
+	return &DecksHandler{
+		repo:    repo,
+		chatIDs: chatIDs,
+		log:     log,
+	}
+}
+
+// FindDecks lists the decks of every allowed chat. Owners are never exposed by chat ID; "mine" marks
+// the caller's own decks.
+func (h *DecksHandler) FindDecks(c echo.Context) error {
+	ctx := c.Request().Context()
+	chatID := context.MustChatIDFromContext(ctx)
+
+	decks, err := h.repo.FindDecks(ctx, chatID, h.chatIDs)
+	if err != nil {
+		h.log.ErrorContext(ctx, "failed to find decks", "error", err)
+		return c.JSON(http.StatusInternalServerError, InternalServerError)
+	}
+
+	items := make([]echo.Map, len(decks))
+	for i, d := range decks {
+		items[i] = echo.Map{
+			"id":          d.ID,
+			"name":        d.Name,
+			"mine":        d.OwnerChatID == chatID,
+			"words":       d.Words,
+			"subscribers": d.Subscribers,
+			"subscribed":  d.Subscribed,
+		}
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"items": items})
+}
+
+func (h *DecksHandler) CreateDeck(c echo.Context) error {
+	ctx := c.Request().Context()
+	chatID := context.MustChatIDFromContext(ctx)
+
+	var req CreateDeckRequest
+	if err := c.Bind(&req); err != nil {
+		h.log.DebugContext(ctx, "failed to bind request", "error", err)
+		return c.JSON(http.StatusBadRequest, BadRequestError)
+	}
+
+	if err := c.Validate(&req); err != nil {
+		h.log.DebugContext(ctx, "failed to validate request", "error", err)
+		return err
+	}
+
+	deck, err := h.repo.CreateDeck(ctx, chatID, req.Name)
+	if err != nil {
+		if errors.Is(err, dal.ErrAlreadyExists) {
+			return c.JSON(http.StatusConflict, ErrorResponse{"Deck already exists"})
+		}
+		h.log.ErrorContext(ctx, "failed to create deck", "error", err)
+		return c.JSON(http.StatusInternalServerError, InternalServerError)
+	}
+
+	return c.JSON(http.StatusCreated, echo.Map{"id": deck.ID, "name": deck.Name})
+}
+
+func (h *DecksHandler) DeleteDeck(c echo.Context) error {
+	ctx := c.Request().Context()
+	chatID := context.MustChatIDFromContext(ctx)
+
+	var req DeckIDRequest
+	if err := c.Bind(&req); err != nil {
+		h.log.DebugContext(ctx, "failed to bind request", "error", err)
+		return c.JSON(http.StatusBadRequest, BadRequestError)
+	}
+
+	if err := c.Validate(&req); err != nil {
+		h.log.DebugContext(ctx, "failed to validate request", "error", err)
+		return err
+	}
+
+	if err := h.repo.DeleteDeck(ctx, chatID, req.ID); err != nil {
+		if errors.Is(err, dal.ErrNotFound) {
+			return c.JSON(http.StatusNotFound, NotFoundError)
+		}
+		h.log.ErrorContext(ctx, "failed to delete deck", "error", err)
+		return c.JSON(http.StatusInternalServerError, InternalServerError)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"status": "ok", "message": "deck deleted"})
+}
+
+// AddWords puts some of the caller's words into their deck. Words they do not have are skipped.
+func (h *DecksHandler) AddWords(c echo.Context) error {
+	ctx := c.Request().Context()
+	chatID := context.MustChatIDFromContext(ctx)
+
+	var req DeckWordsRequest
+	if err := c.Bind(&req); err != nil {
+		h.log.DebugContext(ctx, "failed to bind request", "error", err)
+		return c.JSON(http.StatusBadRequest, BadRequestError)
+	}
+
+	if err := c.Validate(&req); err != nil {
+		h.log.DebugContext(ctx, "failed to validate request", "error", err)
+		return err
+	}
+
+	added, err := h.repo.AddDeckWords(ctx, chatID, req.DeckID, req.Words)
+	if err != nil {
+		if errors.Is(err, dal.ErrNotFound) {
+			return c.JSON(http.StatusNotFound, NotFoundError)
+		}
+		h.log.ErrorContext(ctx, "failed to add deck words", "error", err)
+		return c.JSON(http.StatusInternalServerError, InternalServerError)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"added": added})
+}
+
+func (h *DecksHandler) RemoveWords(c echo.Context) error {
+	ctx := c.Request().Context()
+	chatID := context.MustChatIDFromContext(ctx)
+
+	var req DeckWordsRequest
+	if err := c.Bind(&req); err != nil {
+		h.log.DebugContext(ctx, "failed to bind request", "error", err)
+		return c.JSON(http.StatusBadRequest, BadRequestError)
+	}
+
+	if err := c.Validate(&req); err != nil {
+		h.log.DebugContext(ctx, "failed to validate request", "error", err)
+		return err
+	}
+
+	if err := h.repo.RemoveDeckWords(ctx, chatID, req.DeckID, req.Words); err != nil {
+		if errors.Is(err, dal.ErrNotFound) {
+			return c.JSON(http.StatusNotFound, NotFoundError)
+		}
+		h.log.ErrorContext(ctx, "failed to remove deck words", "error", err)
+		return c.JSON(http.StatusInternalServerError, InternalServerError)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"status": "ok", "message": "words removed"})
+}
+
+func (h *DecksHandler) Subscribe(c echo.Context) error {
+	ctx := c.Request().Context()
+	chatID := context.MustChatIDFromContext(ctx)
+
+	var req SubscribeDeckRequest
+	if err := c.Bind(&req); err != nil {
+		h.log.DebugContext(ctx, "failed to bind request", "error", err)
+		return c.JSON(http.StatusBadRequest, BadRequestError)
+	}
+
+	if err := c.Validate(&req); err != nil {
+		h.log.DebugContext(ctx, "failed to validate request", "error", err)
+		return err
+	}
+
+	copied, err := h.repo.SubscribeDeck(ctx, chatID, req.DeckID, h.chatIDs)
+	if err != nil {
+		switch {
+		case errors.Is(err, dal.ErrNotFound):
+			return c.JSON(http.StatusNotFound, NotFoundError)
+		case errors.Is(err, dal.ErrOwnDeck):
+			return c.JSON(http.StatusBadRequest, ErrorResponse{"Cannot subscribe to own deck"})
+		}
+		h.log.ErrorContext(ctx, "failed to subscribe to deck", "error", err)
+		return c.JSON(http.StatusInternalServerError, InternalServerError)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"copied": copied})
+}
+
+func (h *DecksHandler) Unsubscribe(c echo.Context) error {
+	ctx := c.Request().Context()
+	chatID := context.MustChatIDFromContext(ctx)
+
+	var req DeckIDRequest
+	if err := c.Bind(&req); err != nil {
+		h.log.DebugContext(ctx, "failed to bind request", "error", err)
+		return c.JSON(http.StatusBadRequest, BadRequestError)
+	}
+
+	if err := c.Validate(&req); err != nil {
+		h.log.DebugContext(ctx, "failed to validate request", "error", err)
+		return err
+	}
+
+	if err := h.repo.UnsubscribeDeck(ctx, chatID, req.ID); err != nil {
+		if errors.Is(err, dal.ErrNotFound) {
+			return c.JSON(http.StatusNotFound, NotFoundError)
+		}
+		h.log.ErrorContext(ctx, "failed to unsubscribe from deck", "error", err)
+		return c.JSON(http.StatusInternalServerError, InternalServerError)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"status": "ok", "message": "unsubscribed"})
+}
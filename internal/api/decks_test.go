@@ -0,0 +1,119 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/api"
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+)
+
+// stubDecksRepo implements dal.DeckRepository. Only subscribing and adding words are configurable;
+// the rest succeed without doing anything.
+type stubDecksRepo struct {
+	subscribeErr    error
+	subscribeOwners [][]int64
+	addCalls        [][]string
+}
+
+func (s *stubDecksRepo) CreateDeck(_ context.Context, owner int64, name string) (*dal.Deck, error) {
+	return &dal.Deck{ID: 1, OwnerChatID: owner, Name: name}, nil
+}
+
+func (s *stubDecksRepo) DeleteDeck(_ context.Context, _, _ int64) error {
+	return nil
+}
+
+func (s *stubDecksRepo) FindDecks(_ context.Context, _ int64, _ []int64) ([]dal.Deck, error) {
+	return nil, nil
+}
+
+func (s *stubDecksRepo) AddDeckWords(_ context.Context, _, _ int64, words []string) (int, error) {
+	s.addCalls = append(s.addCalls, words)
+	return len(words), nil
+}
+
+func (s *stubDecksRepo) RemoveDeckWords(_ context.Context, _, _ int64, _ []string) error {
+	return nil
+}
+
+func (s *stubDecksRepo) SubscribeDeck(_ context.Context, _, _ int64, owners []int64) (int, error) {
+	s.subscribeOwners = append(s.subscribeOwners, owners)
+	return 0, s.subscribeErr
+}
+
+func (s *stubDecksRepo) UnsubscribeDeck(_ context.Context, _, _ int64) error {
+	return nil
+}
+
+var _ dal.DeckRepository = (*stubDecksRepo)(nil)
+
+func TestSubscribeDeck(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{name: "subscribed", wantStatus: 200},
+		{name: "own deck", err: dal.ErrOwnDeck, wantStatus: 400},
+		{name: "deck outside the team", err: dal.ErrNotFound, wantStatus: 404},
+		{name: "database failure", err: errors.New("boom"), wantStatus: 500},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &stubDecksRepo{subscribeErr: tt.err}
+			h := api.NewDecksHandler(repo, []int64{testChatID, 43}, testLogger())
+
+			c, rec := newRequest(t, "/decks/subscriptions", `{"deck_id":7}`)
+			if err := h.Subscribe(c); err != nil {
+				t.Fatalf("Subscribe: %v", err)
+			}
+
+			assertStatus(t, rec, tt.wantStatus)
+			// Only decks of the allowed chats may be subscribed to; the handler must say which those are.
+			if len(repo.subscribeOwners) != 1 || len(repo.subscribeOwners[0]) != 2 {
+				t.Errorf("SubscribeDeck owners = %v, want the allowed chats", repo.subscribeOwners)
+			}
+		})
+	}
+}
+
+func TestAddDeckWordsValidation(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{name: "no deck", body: `{"words":["apple"]}`, wantStatus: 400},
+		{name: "no words", body: `{"deck_id":1,"words":[]}`, wantStatus: 400},
+		{name: "empty word", body: `{"deck_id":1,"words":["apple",""]}`, wantStatus: 400},
+		{name: "valid", body: `{"deck_id":1,"words":["apple","pear"]}`, wantStatus: 200},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &stubDecksRepo{}
+			h := api.NewDecksHandler(repo, []int64{testChatID}, testLogger())
+
+			c, rec := newRequest(t, "/decks/words", tt.body)
+			status := rec.Code
+			if err := h.AddWords(c); err != nil {
+				var httpErr *echo.HTTPError
+				if !errors.As(err, &httpErr) {
+					t.Fatalf("AddWords: %v", err)
+				}
+				status = httpErr.Code
+			}
+			if status != tt.wantStatus {
+				t.Errorf("status = %d, want %d", status, tt.wantStatus)
+			}
+			if wantAdd := tt.wantStatus == 200; wantAdd != (len(repo.addCalls) == 1) {
+				t.Errorf("AddDeckWords calls = %v, want called %v", repo.addCalls, wantAdd)
+			}
+		})
+	}
+}
@@ -19,7 +19,11 @@ type (
 		authExpireIn   time.Duration
 		accessExpireIn time.Duration
 
-		secret []byte
+		// keyID and secret sign every new token; secrets verifies them by the kid header, and holds the
+		// retired secrets too.
+		keyID   string
+		secret  []byte
+		secrets map[string][]byte
 	}
 
 	Claims struct {
@@ -29,13 +33,21 @@ type (
 )
 
 func NewJWTProcessor(conf config.JWT, authExpireIn, accessExpireIn time.Duration) *JWTProcessor {
+	secrets := make(map[string][]byte, len(conf.RetiredSecrets)+1)
+	for kid, secret := range conf.RetiredSecrets {
+		secrets[kid] = []byte(secret)
+	}
+	secrets[conf.KeyID] = []byte(conf.Secret)
+
 	return &JWTProcessor{
 		issuer:         conf.Issuer,
 		audience:       conf.Audience,
 		authExpireIn:   authExpireIn,
 		accessExpireIn: accessExpireIn,
 
-		secret: []byte(conf.Secret),
+		keyID:   conf.KeyID,
+		secret:  []byte(conf.Secret),
+		secrets: secrets,
 	}
 }
 
@@ -48,13 +60,14 @@ func (p *JWTProcessor) ToAuthToken(chatID int64, key string) (string, error) {
 			Issuer:    p.issuer,
 			Subject:   fmt.Sprintf("%d:%s", chatID, key),
 			Audience:  p.audience,
-			ExpiresAt: jwt.NewNumericDate(now.Add(p.accessExpireIn)),
+			ExpiresAt: jwt.NewNumericDate(now.Add(p.authExpireIn)),
 			NotBefore: jwt.NewNumericDate(now),
 			IssuedAt:  jwt.NewNumericDate(now),
 			ID:        uuid.New().String(),
 		},
 	})
 
+	token.Header["kid"] = p.keyID
 	signedString, err := token.SignedString(p.secret)
 	if err != nil {
 		return "", fmt.Errorf("sign token: %w", err)
@@ -65,13 +78,7 @@ func (p *JWTProcessor) ToAuthToken(chatID int64, key string) (string, error) {
 
 func (p *JWTProcessor) ParseAuthToken(token string) (int64, string, error) {
 	var parsed *jwt.Token
-	parsed, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
-		// Validate signing algorithm
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return p.secret, nil
-	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Name}))
+	parsed, err := jwt.Parse(token, p.key, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Name}))
 	if err != nil {
 		return 0, "", fmt.Errorf("parse token: %w", err)
 	}
@@ -102,7 +109,8 @@ func (p *JWTProcessor) ParseAuthToken(token string) (int64, string, error) {
 	return chatID, key, nil
 }
 
-func (p *JWTProcessor) ToAccessToken(chatID int64) (string, error) {
+// ToAccessToken signs an access token for the session with sessionID, which becomes its JWT ID.
+func (p *JWTProcessor) ToAccessToken(chatID int64, sessionID string) (string, error) {
 	now := time.Now()
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{
@@ -114,10 +122,11 @@ func (p *JWTProcessor) ToAccessToken(chatID int64) (string, error) {
 			ExpiresAt: jwt.NewNumericDate(now.Add(p.accessExpireIn)),
 			NotBefore: jwt.NewNumericDate(now),
 			IssuedAt:  jwt.NewNumericDate(now),
-			ID:        uuid.New().String(),
+			ID:        sessionID,
 		},
 	})
 
+	token.Header["kid"] = p.keyID
 	signedString, err := token.SignedString(p.secret)
 	if err != nil {
 		return "", fmt.Errorf("sign token: %w", err)
@@ -126,42 +135,62 @@ func (p *JWTProcessor) ToAccessToken(chatID int64) (string, error) {
 	return signedString, nil
 }
 
-func (p *JWTProcessor) ParseAccessToken(token string) (int64, error) {
+// ParseAccessToken returns the chat ID and the session ID of a valid access token. Whether the session
+// is still active is up to the caller.
+func (p *JWTProcessor) ParseAccessToken(token string) (int64, string, error) {
 	var parsed *jwt.Token
-	parsed, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
-		// Validate signing algorithm
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return p.secret, nil
-	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Name}))
+	parsed, err := jwt.Parse(token, p.key, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Name}))
 	if err != nil {
-		return 0, fmt.Errorf("parse token: %w", err)
+		return 0, "", fmt.Errorf("parse token: %w", err)
 	}
 
 	claims, ok := parsed.Claims.(jwt.MapClaims)
 	if !ok || !parsed.Valid {
-		return 0, errors.New("invalid token claims")
+		return 0, "", errors.New("invalid token claims")
 	}
 
 	// Validate issuer and audience
 	if iss, _ := claims.GetIssuer(); iss != p.issuer {
-		return 0, errors.New("invalid issuer")
+		return 0, "", errors.New("invalid issuer")
 	}
 	if aud, _ := claims.GetAudience(); !containsAll(aud, p.audience) {
-		return 0, errors.New("invalid audience")
+		return 0, "", errors.New("invalid audience")
+	}
+
+	sessionID, _ := claims["jti"].(string)
+	if sessionID == "" {
+		return 0, "", errors.New("missing token ID")
 	}
 
 	subject, err := parsed.Claims.GetSubject()
 	if err != nil {
-		return 0, fmt.Errorf("get subject: %w", err)
+		return 0, "", fmt.Errorf("get subject: %w", err)
 	}
 	var chatID int64
 	_, err = fmt.Sscanf(subject, "%d", &chatID)
 	if err != nil {
-		return 0, fmt.Errorf("parse subject: %w", err)
+		return 0, "", fmt.Errorf("parse subject: %w", err)
+	}
+	return chatID, sessionID, nil
+}
+
+// key picks the secret a token was signed with by its kid header. Tokens signed before key IDs were
+// introduced have none and are checked against the current secret.
+func (p *JWTProcessor) key(token *jwt.Token) (interface{}, error) {
+	// Validate signing algorithm
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+
+	kid, ok := token.Header["kid"].(string)
+	if !ok {
+		return p.secret, nil
+	}
+	secret, ok := p.secrets[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
 	}
-	return chatID, nil
+	return secret, nil
 }
 
 func containsAll(actual, required []string) bool {
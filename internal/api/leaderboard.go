@@ -0,0 +1,218 @@
+package api
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/context"
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+	"github.com/labstack/echo/v4"
+)
+
+type (
+	LeaderboardHandler struct {
+		repo dal.LeaderboardRepository
+		// chatIDs are the chats the leaderboard compares: the bot's allowed chats.
+		chatIDs []int64
+		log     *slog.Logger
+	}
+
+	// LeaderboardQueryParams default to the last seven days, today included.
+	LeaderboardQueryParams struct {
+		From time.Time `query:"from"`
+		To   time.Time `query:"to"`
+	}
+
+	LeaderboardSettingsRequest struct {
+		Visibility string `json:"visibility" validate:"required,oneof=hidden anonymous public"`
+		Name       string `json:"name" validate:"required_if=Visibility public,max=64"`
+	}
+
+	CreateChallengeRequest struct {
+		Title  string `json:"title" validate:"required,min=1,max=128"`
+		Metric string `json:"metric" validate:"required,oneof=answers guessed new_words learned"`
+		Target int    `json:"target" validate:"required,min=1"`
+		// Days is how long the challenge runs, today included.
+		Days int `json:"days" validate:"required,min=1,max=92"`
+	}
+
+	DeleteChallengeRequest struct {
+		ID int64 `query:"id" validate:"required"`
+	}
+)
+
+func NewLeaderboardHandler(repo dal.LeaderboardRepository, chatIDs []int64, log *slog.Logger) *LeaderboardHandler {
+	return &LeaderboardHandler{
+		repo:    repo,
+		chatIDs: chatIDs,
+		log:     log,
+	}
+}
+
+// GetLeaderboard lists the opted-in chats with their results and the active team challenges. Other
+// chats are identified by their leaderboard name only, never by chat ID, and anonymous ones not at
+// all; "me" marks the caller's own entry.
+func (h *LeaderboardHandler) GetLeaderboard(c echo.Context) error {
+	ctx := c.Request().Context()
+	chatID := context.MustChatIDFromContext(ctx)
+
+	var qp LeaderboardQueryParams
+	if err := c.Bind(&qp); err != nil {
+		h.log.DebugContext(ctx, "failed to bind request", "error", err)
+		return c.JSON(http.StatusBadRequest, BadRequestError)
+	}
+	now := time.Now()
+	if qp.To.IsZero() {
+		qp.To = now
+	}
+	if qp.From.IsZero() {
+		qp.From = qp.To.AddDate(0, 0, -6) //nolint:mnd // a week back, the last day included
+	}
+
+	entries, err := h.repo.GetLeaderboard(ctx, h.chatIDs, qp.From, qp.To)
+	if err != nil {
+		h.log.ErrorContext(ctx, "failed to get leaderboard", "error", err)
+		return c.JSON(http.StatusInternalServerError, InternalServerError)
+	}
+
+	challenges, err := h.repo.FindActiveChallenges(ctx, h.chatIDs, now)
+	if err != nil {
+		h.log.ErrorContext(ctx, "failed to find challenges", "error", err)
+		return c.JSON(http.StatusInternalServerError, InternalServerError)
+	}
+
+	visibility, name, err := h.repo.GetLeaderboardVisibility(ctx, chatID)
+	if err != nil {
+		h.log.ErrorContext(ctx, "failed to get leaderboard visibility", "error", err)
+		return c.JSON(http.StatusInternalServerError, InternalServerError)
+	}
+
+	items := make([]echo.Map, len(entries))
+	for i, e := range entries {
+		items[i] = echo.Map{
+			"name":      e.Name,
+			"anonymous": e.Anonymous,
+			"me":        e.ChatID == chatID,
+			"answers":   e.Answers,
+			"accuracy":  e.Accuracy(),
+			"learned":   e.Learned,
+			"new_words": e.NewWords,
+		}
+	}
+
+	viewChallenges := make([]echo.Map, len(challenges))
+	for i, ch := range challenges {
+		viewChallenges[i] = echo.Map{
+			"id":        ch.ID,
+			"title":     ch.Title,
+			"metric":    ch.Metric,
+			"target":    ch.Target,
+			"progress":  ch.Progress,
+			"starts_on": ch.StartsOn.Format(time.DateOnly),
+			"ends_on":   ch.EndsOn.Format(time.DateOnly),
+			"mine":      ch.CreatedBy == chatID,
+		}
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"from":       qp.From.Format(time.DateOnly),
+		"to":         qp.To.Format(time.DateOnly),
+		"items":      items,
+		"challenges": viewChallenges,
+		"settings": echo.Map{
+			"visibility": visibility,
+			"name":       name,
+		},
+	})
+}
+
+func (h *LeaderboardHandler) UpdateSettings(c echo.Context) error {
+	ctx := c.Request().Context()
+	chatID := context.MustChatIDFromContext(ctx)
+
+	var req LeaderboardSettingsRequest
+	if err := c.Bind(&req); err != nil {
+		h.log.DebugContext(ctx, "failed to bind request", "error", err)
+		return c.JSON(http.StatusBadRequest, BadRequestError)
+	}
+
+	if err := c.Validate(&req); err != nil {
+		h.log.DebugContext(ctx, "failed to validate request", "error", err)
+		return err
+	}
+
+	if err := h.repo.SetLeaderboardVisibility(ctx, chatID, dal.LeaderboardVisibility(req.Visibility), req.Name); err != nil {
+		h.log.ErrorContext(ctx, "failed to set leaderboard visibility", "error", err)
+		return c.JSON(http.StatusInternalServerError, InternalServerError)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"status": "ok", "message": "leaderboard settings updated"})
+}
+
+func (h *LeaderboardHandler) CreateChallenge(c echo.Context) error {
+	ctx := c.Request().Context()
+	chatID := context.MustChatIDFromContext(ctx)
+
+	var req CreateChallengeRequest
+	if err := c.Bind(&req); err != nil {
+		h.log.DebugContext(ctx, "failed to bind request", "error", err)
+		return c.JSON(http.StatusBadRequest, BadRequestError)
+	}
+
+	if err := c.Validate(&req); err != nil {
+		h.log.DebugContext(ctx, "failed to validate request", "error", err)
+		return err
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	challenge, err := h.repo.CreateChallenge(ctx, dal.Challenge{
+		CreatedBy: chatID,
+		Title:     req.Title,
+		Metric:    dal.ChallengeMetric(req.Metric),
+		Target:    req.Target,
+		StartsOn:  today,
+		EndsOn:    today.AddDate(0, 0, req.Days-1),
+	})
+	if err != nil {
+		h.log.ErrorContext(ctx, "failed to create challenge", "error", err)
+		return c.JSON(http.StatusInternalServerError, InternalServerError)
+	}
+
+	return c.JSON(http.StatusCreated, echo.Map{
+		"id":        challenge.ID,
+		"title":     challenge.Title,
+		"metric":    challenge.Metric,
+		"target":    challenge.Target,
+		"starts_on": challenge.StartsOn.Format(time.DateOnly),
+		"ends_on":   challenge.EndsOn.Format(time.DateOnly),
+	})
+}
+
+func (h *LeaderboardHandler) DeleteChallenge(c echo.Context) error {
+	ctx := c.Request().Context()
+	chatID := context.MustChatIDFromContext(ctx)
+
+	var req DeleteChallengeRequest
+	if err := c.Bind(&req); err != nil {
+		h.log.DebugContext(ctx, "failed to bind request", "error", err)
+		return c.JSON(http.StatusBadRequest, BadRequestError)
+	}
+
+	if err := c.Validate(&req); err != nil {
+		h.log.DebugContext(ctx, "failed to validate request", "error", err)
+		return err
+	}
+
+	if err := h.repo.DeleteChallenge(ctx, chatID, req.ID); err != nil {
+		if errors.Is(err, dal.ErrNotFound) {
+			return c.JSON(http.StatusNotFound, NotFoundError)
+		}
+		h.log.ErrorContext(ctx, "failed to delete challenge", "error", err)
+		return c.JSON(http.StatusInternalServerError, InternalServerError)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"status": "ok", "message": "challenge deleted"})
+}
@@ -0,0 +1,129 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/api"
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+)
+
+// stubLeaderboardRepo implements dal.LeaderboardRepository, returning canned results and recording
+// settings updates.
+type stubLeaderboardRepo struct {
+	entries     []dal.LeaderboardEntry
+	challenges  []dal.Challenge
+	visibility  dal.LeaderboardVisibility
+	settingsSet []dal.LeaderboardVisibility
+}
+
+func (s *stubLeaderboardRepo) GetLeaderboardVisibility(_ context.Context, _ int64) (dal.LeaderboardVisibility, string, error) {
+	return s.visibility, "", nil
+}
+
+func (s *stubLeaderboardRepo) SetLeaderboardVisibility(_ context.Context, _ int64, v dal.LeaderboardVisibility, _ string) error {
+	s.settingsSet = append(s.settingsSet, v)
+	return nil
+}
+
+func (s *stubLeaderboardRepo) GetLeaderboard(_ context.Context, _ []int64, _, _ time.Time) ([]dal.LeaderboardEntry, error) {
+	return s.entries, nil
+}
+
+func (s *stubLeaderboardRepo) CreateChallenge(_ context.Context, c dal.Challenge) (*dal.Challenge, error) {
+	c.ID = 1
+	return &c, nil
+}
+
+func (s *stubLeaderboardRepo) FindActiveChallenges(_ context.Context, _ []int64, _ time.Time) ([]dal.Challenge, error) {
+	return s.challenges, nil
+}
+
+func (s *stubLeaderboardRepo) DeleteChallenge(_ context.Context, _, _ int64) error {
+	return dal.ErrNotFound
+}
+
+var _ dal.LeaderboardRepository = (*stubLeaderboardRepo)(nil)
+
+// Other chats must never be identifiable beyond the name they chose: no chat IDs, and no name at all
+// for anonymous ones.
+func TestGetLeaderboardHidesOtherChats(t *testing.T) {
+	repo := &stubLeaderboardRepo{
+		visibility: dal.LeaderboardPublic,
+		entries: []dal.LeaderboardEntry{
+			{ChatID: 43, Anonymous: true, Answers: 20, Guessed: 15},
+			{ChatID: testChatID, Name: "me", Answers: 10, Guessed: 8},
+		},
+	}
+	h := api.NewLeaderboardHandler(repo, []int64{testChatID, 43}, testLogger())
+
+	c, rec := newRequest(t, "/leaderboard", "")
+	if err := h.GetLeaderboard(c); err != nil {
+		t.Fatalf("GetLeaderboard: %v", err)
+	}
+
+	assertStatus(t, rec, 200)
+
+	var body struct {
+		Items []map[string]any `json:"items"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("unmarshal body: %v", err)
+	}
+	if len(body.Items) != 2 {
+		t.Fatalf("items = %v, want 2", body.Items)
+	}
+	for _, item := range body.Items {
+		if _, ok := item["chat_id"]; ok {
+			t.Errorf("item %v exposes a chat ID", item)
+		}
+	}
+	if body.Items[0]["name"] != "" || body.Items[0]["me"] != false || body.Items[0]["accuracy"] != float64(75) {
+		t.Errorf("anonymous item = %v", body.Items[0])
+	}
+	if body.Items[1]["me"] != true {
+		t.Errorf("own item = %v, want me = true", body.Items[1])
+	}
+}
+
+func TestUpdateLeaderboardSettingsValidation(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{name: "public needs a name", body: `{"visibility":"public"}`, wantStatus: 400},
+		{name: "unknown visibility", body: `{"visibility":"everyone","name":"x"}`, wantStatus: 400},
+		{name: "anonymous needs no name", body: `{"visibility":"anonymous"}`, wantStatus: 200},
+		{name: "public with a name", body: `{"visibility":"public","name":"Roman"}`, wantStatus: 200},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &stubLeaderboardRepo{}
+			h := api.NewLeaderboardHandler(repo, []int64{testChatID}, testLogger())
+
+			c, rec := newRequest(t, "/leaderboard/settings", tt.body)
+			// Validation failures are returned for HTTPErrorHandler to render rather than written.
+			status := rec.Code
+			if err := h.UpdateSettings(c); err != nil {
+				var httpErr *echo.HTTPError
+				if !errors.As(err, &httpErr) {
+					t.Fatalf("UpdateSettings: %v", err)
+				}
+				status = httpErr.Code
+			}
+			if status != tt.wantStatus {
+				t.Errorf("status = %d, want %d", status, tt.wantStatus)
+			}
+			if wantSet := tt.wantStatus == 200; wantSet != (len(repo.settingsSet) == 1) {
+				t.Errorf("settings updated = %v, want %v", repo.settingsSet, wantSet)
+			}
+		})
+	}
+}
@@ -1,33 +1,113 @@
 package api
 
 import (
+	"errors"
 	"log/slog"
 	"net/http"
+	"strings"
 
 	"github.com/Roma7-7-7/english-learning-bot/internal/context"
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
 	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
 )
 
+// contentSecurityPolicy lets in Telegram's scripts, for the Login Widget and the Mini App, the widget's
+// frame, and Telegram Web framing the web interface as a Mini App. X-Frame-Options is left out: it
+// cannot name an origin, and frame-ancestors takes its place.
+const contentSecurityPolicy = "default-src 'self'; script-src 'self' https://telegram.org; style-src 'self'; " +
+	"img-src 'self' data:; font-src 'self'; frame-src https://oauth.telegram.org; " +
+	"frame-ancestors 'self' https://web.telegram.org"
+
 var unauthorizedResponse = ErrorResponse{"Unauthorized"} //nolint:gochecknoglobals // this is a constant response for unauthorized access
 
-func AuthMiddleware(cookieProc *CookiesProcessor, jwtProc *JWTProcessor, log *slog.Logger) func(next echo.HandlerFunc) echo.HandlerFunc {
+var forbiddenResponse = ErrorResponse{"Token scope does not allow this request"} //nolint:gochecknoglobals // this is a constant response for a token out of scope
+
+// SecureHeaders sets the security headers of every response.
+func SecureHeaders() echo.MiddlewareFunc {
+	return middleware.SecureWithConfig(middleware.SecureConfig{
+		XSSProtection:         "1; mode=block",
+		ContentTypeNosniff:    "nosniff",
+		HSTSMaxAge:            31536000, //nolint:mnd // 1 year
+		HSTSExcludeSubdomains: false,
+		HSTSPreloadEnabled:    true,
+		ContentSecurityPolicy: contentSecurityPolicy,
+		ReferrerPolicy:        "strict-origin-when-cross-origin",
+	})
+}
+
+// AuthMiddleware accepts the access token of an active session only, so that a signed-out token is
+// refused at once rather than when it expires. A request with an "Authorization: Bearer" header is
+// authenticated by the personal access token in it instead, within the token's scopes, and only while
+// the token's chat is still one of allowedChatIDs.
+func AuthMiddleware(
+	cookieProc *CookiesProcessor, jwtProc *JWTProcessor, sessions dal.SessionRepository, tokens dal.APITokenRepository,
+	allowedChatIDs []int64, log *slog.Logger,
+) func(next echo.HandlerFunc) echo.HandlerFunc {
+	allowed := make(map[int64]bool, len(allowedChatIDs))
+	for _, chatID := range allowedChatIDs {
+		allowed[chatID] = true
+	}
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
+			if bearer, found := strings.CutPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer "); found {
+				return authenticateAPIToken(c, next, tokens, allowed, bearer, log)
+			}
+
 			token, ok := cookieProc.GetAccessToken(c)
 			if !ok {
 				return c.JSON(http.StatusUnauthorized, unauthorizedResponse)
 			}
 
-			chatID, err := jwtProc.ParseAccessToken(token)
+			chatID, sessionID, err := jwtProc.ParseAccessToken(token)
 			if err != nil {
 				log.WarnContext(c.Request().Context(), "parse access token", "error", err)
 				return c.JSON(http.StatusUnauthorized, unauthorizedResponse)
 			}
 
+			if err = sessions.TouchSession(c.Request().Context(), chatID, sessionID); err != nil {
+				if errors.Is(err, dal.ErrNotFound) {
+					log.WarnContext(c.Request().Context(), "session revoked or expired", "chat_id", chatID)
+					return c.JSON(http.StatusUnauthorized, unauthorizedResponse)
+				}
+				log.ErrorContext(c.Request().Context(), "failed to touch session", "error", err)
+				return c.JSON(http.StatusInternalServerError, InternalServerError)
+			}
+
 			c.Set("chatID", chatID)
-			c.SetRequest(c.Request().WithContext(context.WithChatID(c.Request().Context(), chatID)))
+			ctx := context.WithSessionID(context.WithChatID(c.Request().Context(), chatID), sessionID)
+			c.SetRequest(c.Request().WithContext(ctx))
 
 			return next(c)
 		}
 	}
 }
+
+func authenticateAPIToken(
+	c echo.Context, next echo.HandlerFunc, tokens dal.APITokenRepository, allowed map[int64]bool, bearer string,
+	log *slog.Logger,
+) error {
+	token, err := tokens.AuthenticateAPIToken(c.Request().Context(), strings.TrimSpace(bearer))
+	if err != nil {
+		if errors.Is(err, dal.ErrNotFound) {
+			log.WarnContext(c.Request().Context(), "unknown or expired api token")
+			return c.JSON(http.StatusUnauthorized, unauthorizedResponse)
+		}
+		log.ErrorContext(c.Request().Context(), "failed to authenticate api token", "error", err)
+		return c.JSON(http.StatusInternalServerError, InternalServerError)
+	}
+
+	if !allowed[token.ChatID] {
+		log.WarnContext(c.Request().Context(), "api token of a chat no longer allowed", "token_id", token.ID, "chat_id", token.ChatID)
+		return c.JSON(http.StatusUnauthorized, unauthorizedResponse)
+	}
+
+	if !tokenAllows(token.Scopes, c.Request().Method, c.Path()) {
+		log.DebugContext(c.Request().Context(), "api token out of scope", "token_id", token.ID, "path", c.Path())
+		return c.JSON(http.StatusForbidden, forbiddenResponse)
+	}
+
+	c.Set("chatID", token.ChatID)
+	c.SetRequest(c.Request().WithContext(context.WithChatID(c.Request().Context(), token.ChatID)))
+	return next(c)
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/context"
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+	"github.com/labstack/echo/v4"
+)
+
+type (
+	PauseHandler struct {
+		repo dal.PauseRepository
+		log  *slog.Logger
+	}
+
+	PauseRequest struct {
+		// Days is how long the pause lasts from now. Pausing a paused chat extends its pause.
+		Days int `json:"days" validate:"required,min=1,max=90"`
+	}
+)
+
+func NewPauseHandler(repo dal.PauseRepository, log *slog.Logger) *PauseHandler {
+	return &PauseHandler{
+		repo: repo,
+		log:  log,
+	}
+}
+
+func (h *PauseHandler) GetPause(c echo.Context) error {
+	ctx := c.Request().Context()
+	chatID := context.MustChatIDFromContext(ctx)
+
+	pause, err := h.repo.GetPause(ctx, chatID)
+	if err != nil {
+		h.log.ErrorContext(ctx, "failed to get pause", "error", err)
+		return c.JSON(http.StatusInternalServerError, InternalServerError)
+	}
+
+	return c.JSON(http.StatusOK, pauseResponse(pause, time.Now()))
+}
+
+// Pause stops word checks and goal reminders for the chat; the days it covers do not break the
+// streak.
+func (h *PauseHandler) Pause(c echo.Context) error {
+	ctx := c.Request().Context()
+	chatID := context.MustChatIDFromContext(ctx)
+
+	var req PauseRequest
+	if err := c.Bind(&req); err != nil {
+		h.log.DebugContext(ctx, "failed to bind request", "error", err)
+		return c.JSON(http.StatusBadRequest, BadRequestError)
+	}
+
+	if err := c.Validate(&req); err != nil {
+		h.log.DebugContext(ctx, "failed to validate request", "error", err)
+		return err
+	}
+
+	now := time.Now()
+	pause, err := h.repo.PauseChat(ctx, chatID, now, now.AddDate(0, 0, req.Days))
+	if err != nil {
+		h.log.ErrorContext(ctx, "failed to pause chat", "error", err)
+		return c.JSON(http.StatusInternalServerError, InternalServerError)
+	}
+
+	return c.JSON(http.StatusOK, pauseResponse(pause, now))
+}
+
+// Resume ends the chat's pause early. Resuming a chat that is not paused does nothing.
+func (h *PauseHandler) Resume(c echo.Context) error {
+	ctx := c.Request().Context()
+	chatID := context.MustChatIDFromContext(ctx)
+
+	if _, err := h.repo.ResumeChat(ctx, chatID, time.Now()); err != nil {
+		h.log.ErrorContext(ctx, "failed to resume chat", "error", err)
+		return c.JSON(http.StatusInternalServerError, InternalServerError)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"paused": false})
+}
+
+func pauseResponse(p *dal.Pause, now time.Time) echo.Map {
+	if !p.Active(now) {
+		return echo.Map{"paused": false}
+	}
+	return echo.Map{"paused": true, "paused_from": p.From, "paused_until": p.Until}
+}
@@ -0,0 +1,87 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/api"
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+)
+
+// stubPauseRepo implements dal.PauseRepository, recording the pauses it is asked for.
+type stubPauseRepo struct {
+	pauses []time.Duration
+}
+
+func (s *stubPauseRepo) GetPause(_ context.Context, _ int64) (*dal.Pause, error) {
+	return &dal.Pause{}, nil
+}
+
+func (s *stubPauseRepo) PauseChat(_ context.Context, _ int64, now, until time.Time) (*dal.Pause, error) {
+	s.pauses = append(s.pauses, until.Sub(now))
+	return &dal.Pause{From: now, Until: until}, nil
+}
+
+func (s *stubPauseRepo) ResumeChat(_ context.Context, _ int64, _ time.Time) (bool, error) {
+	return true, nil
+}
+
+var _ dal.PauseRepository = (*stubPauseRepo)(nil)
+
+func TestPause(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantPause  time.Duration
+	}{
+		{name: "a week", body: `{"days":7}`, wantStatus: 200, wantPause: 7 * 24 * time.Hour},
+		{name: "missing days", body: `{}`, wantStatus: 400},
+		{name: "too long", body: `{"days":91}`, wantStatus: 400},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &stubPauseRepo{}
+			h := api.NewPauseHandler(repo, testLogger())
+
+			c, rec := newRequest(t, "/pause", tt.body)
+			// Validation failures are returned for HTTPErrorHandler to render rather than written.
+			status := rec.Code
+			if err := h.Pause(c); err != nil {
+				var httpErr *echo.HTTPError
+				if !errors.As(err, &httpErr) {
+					t.Fatalf("Pause: %v", err)
+				}
+				status = httpErr.Code
+			}
+			if status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", status, tt.wantStatus)
+			}
+			if tt.wantStatus != 200 {
+				if len(repo.pauses) != 0 {
+					t.Errorf("chat paused for %v on a bad request", repo.pauses)
+				}
+				return
+			}
+
+			if len(repo.pauses) != 1 || repo.pauses[0] != tt.wantPause {
+				t.Errorf("pauses = %v, want [%v]", repo.pauses, tt.wantPause)
+			}
+			var body struct {
+				Paused bool `json:"paused"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("unmarshal body: %v", err)
+			}
+			if !body.Paused {
+				t.Error("response does not say the chat is paused")
+			}
+		})
+	}
+}
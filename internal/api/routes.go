@@ -64,16 +64,7 @@ func NewRouter(ctx context.Context, conf *config.Bot, deps Dependencies) http.Ha
 		Timeout: conf.HTTP.ProcessTimeout,
 	}))
 
-	e.Use(middleware.SecureWithConfig(middleware.SecureConfig{
-		XSSProtection:         "1; mode=block",
-		ContentTypeNosniff:    "nosniff",
-		XFrameOptions:         "DENY",
-		HSTSMaxAge:            31536000, //nolint:mnd // 1 year
-		HSTSExcludeSubdomains: false,
-		HSTSPreloadEnabled:    true,
-		ContentSecurityPolicy: "default-src 'self'; script-src 'self'; style-src 'self'; img-src 'self' data:; font-src 'self'; frame-ancestors 'none'",
-		ReferrerPolicy:        "strict-origin-when-cross-origin",
-	}))
+	e.Use(SecureHeaders())
 
 	e.Use(middleware.BodyLimit("1M"))
 
@@ -91,36 +82,88 @@ func NewRouter(ctx context.Context, conf *config.Bot, deps Dependencies) http.Ha
 	jwtProcessor := NewJWTProcessor(conf.HTTP.JWT, conf.HTTP.Cookie.AuthExpiresIn, conf.HTTP.Cookie.AccessExpiresIn)
 	cookiesProcessor := NewCookiesProcessor(conf.HTTP.Cookie)
 
-	authMiddleware := AuthMiddleware(cookiesProcessor, jwtProcessor, deps.Logger)
+	authMiddleware := AuthMiddleware(
+		cookiesProcessor, jwtProcessor, deps.Repo, deps.Repo, conf.Telegram.AllowedChatIDs, deps.Logger,
+	)
 	auth := NewAuthHandler(AuthDependencies{
 		Repo:             deps.Repo,
+		Sessions:         deps.Repo,
 		JWTProcessor:     jwtProcessor,
 		CookiesProcessor: cookiesProcessor,
 		TelegramClient:   deps.TelegramClient,
+		BotToken:         conf.Telegram.Token,
 		AllowedChatIDs:   conf.Telegram.AllowedChatIDs,
 		Logger:           deps.Logger,
 	})
 
 	e.POST("/auth/login", auth.Login)
 	e.GET("/auth/status", auth.Status)
+	e.POST("/auth/refresh", auth.Refresh)
+	e.POST("/auth/telegram-widget", auth.TelegramWidget)
+	e.POST("/auth/webapp", auth.WebApp)
 	e.POST("/auth/logout", auth.LogOut)
 
 	securedGroup := e.Group("", authMiddleware)
 	securedGroup.GET("/auth/info", auth.Info)
+	securedGroup.GET("/auth/sessions", auth.GetSessions)
+	securedGroup.DELETE("/auth/sessions/:id", auth.RevokeSession)
+
+	tokens := NewTokensHandler(deps.Repo, deps.Logger)
+	securedGroup.GET("/auth/tokens", tokens.GetTokens)
+	securedGroup.POST("/auth/tokens", tokens.CreateToken)
+	securedGroup.DELETE("/auth/tokens/:id", tokens.RevokeToken)
 
 	words := NewWordsHandler(deps.Repo, deps.Logger)
 	securedGroup.GET("/words", words.FindWords)
 	securedGroup.POST("/words", words.CreateWord)
 	securedGroup.PUT("/words", words.UpdateWord)
 	securedGroup.PUT("/words/review", words.MarkToReview)
+	securedGroup.PUT("/words/tags", words.SetTags)
+	securedGroup.PUT("/words/suspend", words.SuspendWord)
+	securedGroup.PUT("/words/streak_limit", words.SetStreakLimit)
+	securedGroup.GET("/tags/streak_limits", words.GetTagStreakLimits)
 	securedGroup.POST("/words/reset", words.ResetStreak)
 	securedGroup.DELETE("/words", words.DeleteWord)
 
-	stats := NewStatsHandler(deps.Repo, deps.Logger)
+	trash := NewTrashHandler(deps.Repo, deps.Logger)
+	securedGroup.GET("/words/trash", trash.GetTrash)
+	securedGroup.POST("/words/restore", trash.RestoreWord)
+	securedGroup.GET("/words/audit", trash.GetWordAudit)
+
+	batch := NewBatchHandler(deps.Repo, deps.Logger)
+	securedGroup.GET("/batch", batch.GetBatch)
+	securedGroup.POST("/batch/words", batch.AddWords)
+	securedGroup.DELETE("/batch/words", batch.RemoveWords)
+	securedGroup.PUT("/batch/queue", batch.ReorderQueue)
+	securedGroup.POST("/batch/refill", batch.Refill)
+	securedGroup.PUT("/batch/size", batch.SetSize)
+	securedGroup.PUT("/batch/strategy", batch.SetStrategy)
+
+	stats := NewStatsHandler(deps.Repo, dal.DailyGoal{Answers: conf.Goals.DailyAnswers, NewWords: conf.Goals.DailyNewWords}, deps.Logger)
 	securedGroup.GET("/stats/total", stats.TotalStats)
 	securedGroup.GET("/stats", stats.GetStats)
 	securedGroup.GET("/stats/range", stats.GetStatsRange)
 
+	pause := NewPauseHandler(deps.Repo, deps.Logger)
+	securedGroup.GET("/pause", pause.GetPause)
+	securedGroup.POST("/pause", pause.Pause)
+	securedGroup.DELETE("/pause", pause.Resume)
+
+	leaderboard := NewLeaderboardHandler(deps.Repo, conf.Telegram.AllowedChatIDs, deps.Logger)
+	securedGroup.GET("/leaderboard", leaderboard.GetLeaderboard)
+	securedGroup.PUT("/leaderboard/settings", leaderboard.UpdateSettings)
+	securedGroup.POST("/challenges", leaderboard.CreateChallenge)
+	securedGroup.DELETE("/challenges", leaderboard.DeleteChallenge)
+
+	decks := NewDecksHandler(deps.Repo, conf.Telegram.AllowedChatIDs, deps.Logger)
+	securedGroup.GET("/decks", decks.FindDecks)
+	securedGroup.POST("/decks", decks.CreateDeck)
+	securedGroup.DELETE("/decks", decks.DeleteDeck)
+	securedGroup.POST("/decks/words", decks.AddWords)
+	securedGroup.DELETE("/decks/words", decks.RemoveWords)
+	securedGroup.POST("/decks/subscriptions", decks.Subscribe)
+	securedGroup.DELETE("/decks/subscriptions", decks.Unsubscribe)
+
 	return e
 }
 
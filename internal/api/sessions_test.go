@@ -0,0 +1,265 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/api"
+	"github.com/Roma7-7-7/english-learning-bot/internal/config"
+	appctx "github.com/Roma7-7-7/english-learning-bot/internal/context"
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+)
+
+// stubSessionRepo implements dal.SessionRepository over a set of active session IDs and the session
+// each refresh token was given to.
+type stubSessionRepo struct {
+	active  map[string]bool
+	refresh map[string]string
+	used    map[string]bool
+	miniApp map[string]bool
+}
+
+func newStubSessionRepo(active ...string) *stubSessionRepo {
+	s := &stubSessionRepo{active: map[string]bool{}, refresh: map[string]string{}, used: map[string]bool{}, miniApp: map[string]bool{}}
+	for _, id := range active {
+		s.active[id] = true
+	}
+	return s
+}
+
+func (s *stubSessionRepo) CreateSession(_ context.Context, session dal.Session, refreshToken string) error {
+	s.active[session.ID] = true
+	s.refresh[refreshToken] = session.ID
+	s.miniApp[session.ID] = session.MiniApp
+	return nil
+}
+
+func (s *stubSessionRepo) RotateRefreshToken(_ context.Context, token, newToken string, expiresAt time.Time) (*dal.Session, error) {
+	id, ok := s.refresh[token]
+	if !ok || !s.active[id] {
+		return nil, dal.ErrNotFound
+	}
+	if s.used[token] {
+		delete(s.active, id)
+		return nil, dal.ErrRefreshTokenReused
+	}
+	s.used[token] = true
+	s.refresh[newToken] = id
+	return &dal.Session{ID: id, ChatID: testChatID, ExpiresAt: expiresAt, MiniApp: s.miniApp[id]}, nil
+}
+
+func (s *stubSessionRepo) RevokeSessionByRefreshToken(_ context.Context, token string) error {
+	id := s.refresh[token]
+	if !s.active[id] {
+		return dal.ErrNotFound
+	}
+	delete(s.active, id)
+	return nil
+}
+
+func (s *stubSessionRepo) TouchSession(_ context.Context, _ int64, id string) error {
+	if !s.active[id] {
+		return dal.ErrNotFound
+	}
+	return nil
+}
+
+func (s *stubSessionRepo) GetSessions(_ context.Context, _ int64) ([]dal.Session, error) {
+	res := make([]dal.Session, 0, len(s.active))
+	for id := range s.active {
+		res = append(res, dal.Session{ID: id, ChatID: testChatID})
+	}
+	return res, nil
+}
+
+func (s *stubSessionRepo) RevokeSession(_ context.Context, _ int64, id string) error {
+	if !s.active[id] {
+		return dal.ErrNotFound
+	}
+	delete(s.active, id)
+	return nil
+}
+
+func (s *stubSessionRepo) RevokeSessions(_ context.Context, _ int64) (int, error) {
+	n := len(s.active)
+	clear(s.active)
+	return n, nil
+}
+
+func testProcessors(conf config.JWT) (*api.JWTProcessor, *api.CookiesProcessor) {
+	return api.NewJWTProcessor(conf, time.Hour, 15*time.Minute), api.NewCookiesProcessor(config.Cookie{
+		Path: "/", Domain: "localhost", AuthExpiresIn: time.Hour, AccessExpiresIn: 15 * time.Minute, RefreshExpiresIn: 24 * time.Hour,
+	})
+}
+
+func TestAuthMiddlewareRejectsRevokedSession(t *testing.T) {
+	jwtProc, cookieProc := testProcessors(config.JWT{Issuer: "test", Audience: []string{"test"}, Secret: "secret", KeyID: "1"})
+	repo := newStubSessionRepo("current")
+
+	token, err := jwtProc.ToAccessToken(testChatID, "current")
+	if err != nil {
+		t.Fatalf("ToAccessToken: %v", err)
+	}
+
+	var sessionID string
+	handler := api.AuthMiddleware(cookieProc, jwtProc, repo, nil, []int64{testChatID}, testLogger())(func(c echo.Context) error {
+		sessionID, _ = appctx.SessionIDFromContext(c.Request().Context())
+		return c.NoContent(http.StatusOK)
+	})
+
+	call := func() int {
+		req := httptest.NewRequest(http.MethodGet, "/auth/info", nil)
+		req.AddCookie(cookieProc.NewAccessTokenCookie(token, false))
+		rec := httptest.NewRecorder()
+		if err := handler(echo.New().NewContext(req, rec)); err != nil {
+			t.Fatalf("handler: %v", err)
+		}
+		return rec.Code
+	}
+
+	if code := call(); code != http.StatusOK || sessionID != "current" {
+		t.Fatalf("active session: status %d, session %q; want 200 with the session in the context", code, sessionID)
+	}
+
+	repo.active["current"] = false
+	if code := call(); code != http.StatusUnauthorized {
+		t.Errorf("revoked session: status %d, want 401", code)
+	}
+}
+
+func TestRevokeSession(t *testing.T) {
+	repo := newStubSessionRepo("current", "other")
+	h := api.NewAuthHandler(api.AuthDependencies{Sessions: repo, Logger: testLogger()})
+
+	revoke := func(id string) int {
+		c, rec := newRequest(t, "/auth/sessions/"+id, "")
+		c.SetParamNames("id")
+		c.SetParamValues(id)
+		if err := h.RevokeSession(c); err != nil {
+			t.Fatalf("RevokeSession: %v", err)
+		}
+		return rec.Code
+	}
+
+	if code := revoke("other"); code != http.StatusOK {
+		t.Errorf("revoke other: status %d, want 200", code)
+	}
+	if code := revoke("other"); code != http.StatusNotFound {
+		t.Errorf("revoke other again: status %d, want 404", code)
+	}
+
+	c, rec := newRequest(t, "/auth/sessions", "")
+	c.SetRequest(c.Request().WithContext(appctx.WithSessionID(c.Request().Context(), "current")))
+	if err := h.GetSessions(c); err != nil {
+		t.Fatalf("GetSessions: %v", err)
+	}
+	var res struct {
+		Items []api.Session `json:"items"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+		t.Fatalf("decode sessions: %v", err)
+	}
+	if len(res.Items) != 1 || res.Items[0].ID != "current" || !res.Items[0].Current {
+		t.Errorf("sessions = %+v, want only the current one, flagged", res.Items)
+	}
+}
+
+func TestRefreshRotatesAndDetectsReuse(t *testing.T) {
+	jwtProc, cookieProc := testProcessors(config.JWT{Issuer: "test", Audience: []string{"test"}, Secret: "secret", KeyID: "1"})
+	repo := newStubSessionRepo()
+	if err := repo.CreateSession(context.Background(), dal.Session{ID: "laptop", ChatID: testChatID}, "first"); err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	h := api.NewAuthHandler(api.AuthDependencies{
+		Sessions:         repo,
+		JWTProcessor:     jwtProc,
+		CookiesProcessor: cookieProc,
+		AllowedChatIDs:   []int64{testChatID},
+		Logger:           testLogger(),
+	})
+
+	refresh := func(token string) *httptest.ResponseRecorder {
+		c, rec := newRequest(t, "/auth/refresh", "")
+		c.Request().AddCookie(cookieProc.NewRefreshTokenCookie(token, false))
+		if err := h.Refresh(c); err != nil {
+			t.Fatalf("Refresh: %v", err)
+		}
+		return rec
+	}
+
+	rec := refresh("first")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("refresh: status %d, want 200", rec.Code)
+	}
+	cookies := map[string]string{}
+	for _, c := range rec.Result().Cookies() {
+		cookies[c.Name] = c.Value
+	}
+	if cookies["refresh"] == "" || cookies["refresh"] == "first" {
+		t.Errorf("refresh cookie = %q, want a new token", cookies["refresh"])
+	}
+	chatID, sessionID, err := jwtProc.ParseAccessToken(cookies["access"])
+	if err != nil || chatID != testChatID || sessionID != "laptop" {
+		t.Errorf("access token of chat %d, session %q (%v); want the laptop session", chatID, sessionID, err)
+	}
+
+	if rec = refresh("first"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("reused token: status %d, want 401", rec.Code)
+	}
+	if repo.active["laptop"] {
+		t.Error("reusing a refresh token left its session signed in")
+	}
+	if rec = refresh(cookies["refresh"]); rec.Code != http.StatusUnauthorized {
+		t.Errorf("rotated token after reuse: status %d, want 401", rec.Code)
+	}
+}
+
+func TestJWTKeyRotation(t *testing.T) {
+	oldProc, _ := testProcessors(config.JWT{Issuer: "test", Audience: []string{"test"}, Secret: "old", KeyID: "1"})
+	newProc, _ := testProcessors(config.JWT{
+		Issuer: "test", Audience: []string{"test"}, Secret: "new", KeyID: "2", RetiredSecrets: map[string]string{"1": "old"},
+	})
+	otherProc, _ := testProcessors(config.JWT{Issuer: "test", Audience: []string{"test"}, Secret: "other", KeyID: "3"})
+
+	for name, proc := range map[string]*api.JWTProcessor{"retired key": oldProc, "current key": newProc} {
+		token, err := proc.ToAccessToken(testChatID, "laptop")
+		if err != nil {
+			t.Fatalf("%s: ToAccessToken: %v", name, err)
+		}
+		if _, _, err = newProc.ParseAccessToken(token); err != nil {
+			t.Errorf("%s: ParseAccessToken = %v, want it accepted", name, err)
+		}
+	}
+
+	token, err := otherProc.ToAccessToken(testChatID, "laptop")
+	if err != nil {
+		t.Fatalf("ToAccessToken: %v", err)
+	}
+	if _, _, err = newProc.ParseAccessToken(token); err == nil {
+		t.Error("a token of an unknown key was accepted")
+	}
+}
+
+func TestAuthTokenLastsForAuthExpiry(t *testing.T) {
+	jwtProc, _ := testProcessors(config.JWT{Issuer: "test", Audience: []string{"test"}, Secret: "secret", KeyID: "1"})
+
+	token, err := jwtProc.ToAuthToken(testChatID, "key")
+	if err != nil {
+		t.Fatalf("ToAuthToken: %v", err)
+	}
+	var claims api.Claims
+	if _, _, err = jwt.NewParser().ParseUnverified(token, &claims); err != nil {
+		t.Fatalf("ParseUnverified: %v", err)
+	}
+	// testProcessors signs auth tokens for an hour and access tokens for 15 minutes.
+	if ttl := time.Until(claims.ExpiresAt.Time); ttl < 59*time.Minute || ttl > time.Hour {
+		t.Errorf("auth token expires in %v, want an hour", ttl)
+	}
+}
@@ -14,6 +14,7 @@ import (
 type (
 	StatsHandler struct {
 		repo dal.StatsRepository
+		goal dal.DailyGoal
 		log  *slog.Logger
 	}
 
@@ -23,9 +24,10 @@ type (
 	}
 )
 
-func NewStatsHandler(repo dal.StatsRepository, log *slog.Logger) *StatsHandler {
+func NewStatsHandler(repo dal.StatsRepository, goal dal.DailyGoal, log *slog.Logger) *StatsHandler {
 	return &StatsHandler{
 		repo: repo,
+		goal: goal,
 		log:  log,
 	}
 }
@@ -39,12 +41,27 @@ func (h *StatsHandler) TotalStats(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, InternalServerError)
 	}
 
+	streak, err := h.repo.GetStreak(c.Request().Context(), chatID, h.goal, time.Now())
+	if err != nil {
+		h.log.ErrorContext(c.Request().Context(), "failed to get streak", "error", err)
+		return c.JSON(http.StatusInternalServerError, InternalServerError)
+	}
+
+	// The learning streak (consecutive days the daily goal was met) is not the same thing as
+	// streak_limit, a word's consecutive correct answers, hence the "daily_" prefixes.
 	return c.JSON(http.StatusOK, echo.Map{
-		"learned":      stats.Learned,
-		"total":        stats.Total,
-		"streak_limit": stats.StreakLimit,
-		"batched":      stats.Batched,
-		"queued":       stats.Queued,
+		"learned":              stats.Learned,
+		"total":                stats.Total,
+		"streak_limit":         stats.StreakLimit,
+		"batched":              stats.Batched,
+		"queued":               stats.Queued,
+		"to_review":            stats.ToReview,
+		"daily_streak":         streak.Days,
+		"daily_streak_freezes": streak.Freezes,
+		"daily_goal_answers":   streak.Goal.Answers,
+		"daily_goal_new_words": streak.Goal.NewWords,
+		"today_answers":        streak.TodayAnswers,
+		"today_new_words":      streak.TodayNewWords,
 	})
 }
 
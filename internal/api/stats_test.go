@@ -13,6 +13,7 @@ import (
 // stubStatsRepo implements dal.StatsRepository, returning canned results.
 type stubStatsRepo struct {
 	totalStats *dal.TotalStats
+	streak     *dal.Streak
 }
 
 func (s *stubStatsRepo) GetTotalStats(_ context.Context, _ int64) (*dal.TotalStats, error) {
@@ -27,6 +28,21 @@ func (s *stubStatsRepo) GetStatsRange(_ context.Context, _ int64, _, _ time.Time
 	return nil, nil
 }
 
+func (s *stubStatsRepo) GetAnswerSummary(_ context.Context, _ int64, _, _ time.Time, _ int) (*dal.AnswerSummary, error) {
+	return &dal.AnswerSummary{}, nil
+}
+
+func (s *stubStatsRepo) GetStreak(_ context.Context, _ int64, goal dal.DailyGoal, _ time.Time) (*dal.Streak, error) {
+	if s.streak == nil {
+		return &dal.Streak{Goal: goal}, nil
+	}
+	return s.streak, nil
+}
+
+func (s *stubStatsRepo) SettleStreak(_ context.Context, _ int64, _ dal.StreakRules, _ time.Time) (*dal.StreakSettlement, error) {
+	return &dal.StreakSettlement{}, nil
+}
+
 var _ dal.StatsRepository = (*stubStatsRepo)(nil)
 
 func TestTotalStatsIncludesBatched(t *testing.T) {
@@ -36,8 +52,9 @@ func TestTotalStatsIncludesBatched(t *testing.T) {
 		StreakLimit: 15,
 		Batched:     4,
 		Queued:      2,
+		ToReview:    5,
 	}}
-	h := api.NewStatsHandler(repo, testLogger())
+	h := api.NewStatsHandler(repo, dal.DailyGoal{Answers: 20}, testLogger())
 
 	c, rec := newRequest(t, "/stats/total", "")
 	if err := h.TotalStats(c); err != nil {
@@ -51,7 +68,41 @@ func TestTotalStatsIncludesBatched(t *testing.T) {
 		t.Fatalf("unmarshal body: %v", err)
 	}
 
-	want := map[string]int{"learned": 3, "total": 12, "streak_limit": 15, "batched": 4, "queued": 2}
+	want := map[string]int{"learned": 3, "total": 12, "streak_limit": 15, "batched": 4, "queued": 2, "to_review": 5}
+	for k, v := range want {
+		if body[k] != v {
+			t.Errorf("body[%q] = %d, want %d", k, body[k], v)
+		}
+	}
+}
+
+func TestTotalStatsIncludesDailyStreak(t *testing.T) {
+	repo := &stubStatsRepo{
+		totalStats: &dal.TotalStats{StreakLimit: 15},
+		streak: &dal.Streak{
+			Days: 6, Freezes: 1, TodayAnswers: 12, TodayNewWords: 1,
+			Goal: dal.DailyGoal{Answers: 20, NewWords: 3},
+		},
+	}
+	h := api.NewStatsHandler(repo, dal.DailyGoal{Answers: 20, NewWords: 3}, testLogger())
+
+	c, rec := newRequest(t, "/stats/total", "")
+	if err := h.TotalStats(c); err != nil {
+		t.Fatalf("TotalStats: %v", err)
+	}
+
+	assertStatus(t, rec, 200)
+
+	var body map[string]int
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("unmarshal body: %v", err)
+	}
+
+	want := map[string]int{
+		"daily_streak": 6, "daily_streak_freezes": 1,
+		"daily_goal_answers": 20, "daily_goal_new_words": 3,
+		"today_answers": 12, "today_new_words": 1,
+	}
 	for k, v := range want {
 		if body[k] != v {
 			t.Errorf("body[%q] = %d, want %d", k, body[k], v)
@@ -0,0 +1,207 @@
+package api_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/api"
+	"github.com/Roma7-7-7/english-learning-bot/internal/config"
+)
+
+const testBotToken = "123456:test-bot-token"
+
+// telegramHash signs fields the way Telegram does: the HMAC-SHA-256 of the "name=value" lines, sorted.
+func telegramHash(secret []byte, fields map[string]string) string {
+	lines := make([]string, 0, len(fields))
+	for k, v := range fields {
+		lines = append(lines, k+"="+v)
+	}
+	sort.Strings(lines)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(strings.Join(lines, "\n")))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func newTelegramAuthHandler(repo *stubSessionRepo) *api.AuthHandler {
+	jwtProc, cookieProc := testProcessors(config.JWT{Issuer: "test", Audience: []string{"test"}, Secret: "secret", KeyID: "1"})
+	return api.NewAuthHandler(api.AuthDependencies{
+		Sessions:         repo,
+		JWTProcessor:     jwtProc,
+		CookiesProcessor: cookieProc,
+		BotToken:         testBotToken,
+		AllowedChatIDs:   []int64{testChatID},
+		Logger:           testLogger(),
+	})
+}
+
+func TestTelegramWidget(t *testing.T) {
+	secret := sha256.Sum256([]byte(testBotToken))
+	signed := func(id int64, authDate time.Time) string {
+		fields := map[string]string{
+			"id":         strconv.FormatInt(id, 10),
+			"first_name": "Ann",
+			"username":   "ann",
+			"auth_date":  strconv.FormatInt(authDate.Unix(), 10),
+		}
+		body := map[string]any{"first_name": "Ann", "username": "ann", "id": id, "auth_date": authDate.Unix()}
+		body["hash"] = telegramHash(secret[:], fields)
+		b, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		return string(b)
+	}
+
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"valid", signed(testChatID, time.Now()), http.StatusOK},
+		{"tampered", strings.Replace(signed(testChatID, time.Now()), `"ann"`, `"eve"`, 1), http.StatusUnauthorized},
+		{"stale", signed(testChatID, time.Now().Add(-2*time.Hour)), http.StatusUnauthorized},
+		{"future", signed(testChatID, time.Now().Add(10*time.Minute)), http.StatusUnauthorized},
+		{"clock skew", signed(testChatID, time.Now().Add(30*time.Second)), http.StatusOK},
+		{"chat not allowed", signed(testChatID+1, time.Now()), http.StatusForbidden},
+		{"no hash", `{"id": 42, "auth_date": 1}`, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newStubSessionRepo()
+			c, rec := newRequest(t, "/auth/telegram-widget", tt.body)
+			if err := newTelegramAuthHandler(repo).TelegramWidget(c); err != nil {
+				t.Fatalf("TelegramWidget: %v", err)
+			}
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+			if signedIn := len(repo.active) == 1; signedIn != (tt.want == http.StatusOK) {
+				t.Errorf("sessions = %v, want one only when signed in", repo.active)
+			}
+		})
+	}
+}
+
+// webAppBody is the POST /auth/webapp body of the Mini App initData of user, signed as Telegram does.
+func webAppBody(t *testing.T, user string, authDate time.Time) string {
+	t.Helper()
+
+	mac := hmac.New(sha256.New, []byte("WebAppData"))
+	mac.Write([]byte(testBotToken))
+	secret := mac.Sum(nil)
+
+	fields := map[string]string{
+		"query_id":  "AAHdF6IQAAAAAN0XohDhrOrc",
+		"user":      user,
+		"auth_date": strconv.FormatInt(authDate.Unix(), 10),
+	}
+	values := url.Values{}
+	for k, v := range fields {
+		values.Set(k, v)
+	}
+	values.Set("hash", telegramHash(secret, fields))
+	b, err := json.Marshal(map[string]string{"init_data": values.Encode()})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return string(b)
+}
+
+func TestWebApp(t *testing.T) {
+	signed := func(user string, authDate time.Time) string {
+		return webAppBody(t, user, authDate)
+	}
+
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"valid", signed(`{"id":42,"first_name":"Ann"}`, time.Now()), http.StatusOK},
+		{"tampered", strings.Replace(signed(`{"id":42,"first_name":"Ann"}`, time.Now()), "Ann", "Eve", 1), http.StatusUnauthorized},
+		{"stale", signed(`{"id":42,"first_name":"Ann"}`, time.Now().Add(-2*time.Hour)), http.StatusUnauthorized},
+		{"future", signed(`{"id":42,"first_name":"Ann"}`, time.Now().Add(10*time.Minute)), http.StatusUnauthorized},
+		{"chat not allowed", signed(`{"id":43,"first_name":"Bob"}`, time.Now()), http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newStubSessionRepo()
+			c, rec := newRequest(t, "/auth/webapp", tt.body)
+			if err := newTelegramAuthHandler(repo).WebApp(c); err != nil {
+				t.Fatalf("WebApp: %v", err)
+			}
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+			if cookies := rec.Result().Cookies(); (len(cookies) > 0) != (tt.want == http.StatusOK) {
+				t.Errorf("cookies = %v, want them set only when signed in", cookies)
+			}
+		})
+	}
+}
+
+// TestWebAppFramedByTelegram covers what the Mini App needs to work inside Telegram Web's frame: headers
+// that let the page be framed and load Telegram's script, and session cookies that are sent
+// cross-site, refreshes included.
+func TestWebAppFramedByTelegram(t *testing.T) {
+	h := newTelegramAuthHandler(newStubSessionRepo())
+	e := echo.New()
+	e.Validator = api.NewCustomValidator()
+	e.Use(api.SecureHeaders())
+	e.POST("/auth/webapp", h.WebApp)
+	e.POST("/auth/refresh", h.Refresh)
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status %d, want 200", req.URL.Path, rec.Code)
+		}
+		return rec
+	}
+	crossSite := func(rec *httptest.ResponseRecorder) *http.Cookie {
+		t.Helper()
+		var refresh *http.Cookie
+		for _, c := range rec.Result().Cookies() {
+			if c.SameSite != http.SameSiteNoneMode || !c.Secure {
+				t.Errorf("cookie %s: SameSite %v, secure %t; want SameSite=None; Secure", c.Name, c.SameSite, c.Secure)
+			}
+			if c.Name == "refresh" {
+				refresh = c
+			}
+		}
+		if refresh == nil {
+			t.Fatal("no refresh cookie set")
+		}
+		return refresh
+	}
+
+	rec := serve(httptest.NewRequest(http.MethodPost, "/auth/webapp", strings.NewReader(webAppBody(t, `{"id":42,"first_name":"Ann"}`, time.Now()))))
+	if got := rec.Header().Get(echo.HeaderXFrameOptions); got != "" {
+		t.Errorf("X-Frame-Options = %q, want none: it would keep Telegram from framing the page", got)
+	}
+	csp := rec.Header().Get(echo.HeaderContentSecurityPolicy)
+	for _, want := range []string{"frame-ancestors 'self' https://web.telegram.org", "script-src 'self' https://telegram.org"} {
+		if !strings.Contains(csp, want) {
+			t.Errorf("Content-Security-Policy = %q, want %q in it", csp, want)
+		}
+	}
+	refresh := crossSite(rec)
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/refresh", strings.NewReader(""))
+	req.AddCookie(refresh)
+	crossSite(serve(req))
+}
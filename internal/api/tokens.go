@@ -0,0 +1,170 @@
+package api
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/context"
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+	"github.com/labstack/echo/v4"
+)
+
+type (
+	TokensHandler struct {
+		repo dal.APITokenRepository
+		log  *slog.Logger
+	}
+
+	// APIToken is a personal access token. Token is only set in the response that creates it.
+	APIToken struct {
+		ID         int64      `json:"id"`
+		Name       string     `json:"name"`
+		Scopes     []string   `json:"scopes"`
+		Token      string     `json:"token,omitempty"`
+		CreatedAt  time.Time  `json:"created_at"`
+		ExpiresAt  *time.Time `json:"expires_at"`
+		LastUsedAt *time.Time `json:"last_used_at"`
+	}
+
+	CreateTokenRequest struct {
+		Name   string   `json:"name" validate:"required,max=64"`
+		Scopes []string `json:"scopes" validate:"required,min=1,dive,oneof=read words:write stats:read"`
+		// ExpiresInDays is how long the token lasts, up to dal.MaxAPITokenDays; 0 makes one that never
+		// expires.
+		ExpiresInDays int `json:"expires_in_days" validate:"min=0,max=365"`
+	}
+)
+
+func NewTokensHandler(repo dal.APITokenRepository, log *slog.Logger) *TokensHandler {
+	return &TokensHandler{
+		repo: repo,
+		log:  log,
+	}
+}
+
+// GetTokens lists the chat's personal access tokens, newest first.
+func (h *TokensHandler) GetTokens(c echo.Context) error {
+	ctx := c.Request().Context()
+	chatID := context.MustChatIDFromContext(ctx)
+
+	tokens, err := h.repo.GetAPITokens(ctx, chatID)
+	if err != nil {
+		h.log.ErrorContext(ctx, "failed to get api tokens", "error", err)
+		return c.JSON(http.StatusInternalServerError, InternalServerError)
+	}
+
+	res := make([]APIToken, len(tokens))
+	for i, t := range tokens {
+		res[i] = toAPIToken(t, "")
+	}
+	return c.JSON(http.StatusOK, echo.Map{"items": res})
+}
+
+// CreateToken creates a personal access token. The response is the only place the token is ever shown.
+func (h *TokensHandler) CreateToken(c echo.Context) error {
+	ctx := c.Request().Context()
+	chatID := context.MustChatIDFromContext(ctx)
+
+	var req CreateTokenRequest
+	if err := c.Bind(&req); err != nil {
+		h.log.DebugContext(ctx, "failed to bind request", "error", err)
+		return c.JSON(http.StatusBadRequest, BadRequestError)
+	}
+	if err := c.Validate(&req); err != nil {
+		h.log.DebugContext(ctx, "failed to validate request", "error", err)
+		return err
+	}
+
+	scopes := make([]dal.TokenScope, len(req.Scopes))
+	for i, s := range req.Scopes {
+		scopes[i] = dal.TokenScope(s)
+	}
+	var expiresAt time.Time
+	if req.ExpiresInDays > 0 {
+		expiresAt = time.Now().AddDate(0, 0, req.ExpiresInDays)
+	}
+
+	created, token, err := h.repo.CreateAPIToken(ctx, chatID, strings.TrimSpace(req.Name), scopes, expiresAt)
+	if err != nil {
+		if errors.Is(err, dal.ErrAlreadyExists) {
+			return c.JSON(http.StatusConflict, ErrorResponse{"Token with this name already exists"})
+		}
+		h.log.ErrorContext(ctx, "failed to create api token", "error", err)
+		return c.JSON(http.StatusInternalServerError, InternalServerError)
+	}
+
+	return c.JSON(http.StatusCreated, toAPIToken(*created, token))
+}
+
+// RevokeToken deletes one of the chat's personal access tokens.
+func (h *TokensHandler) RevokeToken(c echo.Context) error {
+	ctx := c.Request().Context()
+	chatID := context.MustChatIDFromContext(ctx)
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, BadRequestError)
+	}
+
+	if err = h.repo.RevokeAPIToken(ctx, chatID, id); err != nil {
+		if errors.Is(err, dal.ErrNotFound) {
+			return c.JSON(http.StatusNotFound, NotFoundError)
+		}
+		h.log.ErrorContext(ctx, "failed to revoke api token", "error", err)
+		return c.JSON(http.StatusInternalServerError, InternalServerError)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"status": "ok", "message": "token revoked"})
+}
+
+// tokenAllows says whether a personal access token with scopes may call the route at path. Tokens never
+// reach the /auth routes, so that one cannot be used to mint another or to sign browsers out. A words:write
+// token adds and edits words but cannot delete or restore them, which is left to a signed-in browser.
+func tokenAllows(scopes []dal.TokenScope, method, path string) bool {
+	if strings.HasPrefix(path, "/auth/") {
+		return false
+	}
+	read := method == http.MethodGet || method == http.MethodHead
+	for _, s := range scopes {
+		switch s {
+		case dal.ScopeRead:
+			if read {
+				return true
+			}
+		case dal.ScopeWordsWrite:
+			if (path == "/words" || strings.HasPrefix(path, "/words/")) &&
+				method != http.MethodDelete && path != "/words/restore" {
+				return true
+			}
+		case dal.ScopeStatsRead:
+			if read && (path == "/stats" || strings.HasPrefix(path, "/stats/")) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func toAPIToken(t dal.APIToken, token string) APIToken {
+	res := APIToken{
+		ID:        t.ID,
+		Name:      t.Name,
+		Scopes:    make([]string, len(t.Scopes)),
+		Token:     token,
+		CreatedAt: t.CreatedAt,
+	}
+	for i, s := range t.Scopes {
+		res.Scopes[i] = string(s)
+	}
+	if !t.ExpiresAt.IsZero() {
+		res.ExpiresAt = &t.ExpiresAt
+	}
+	if !t.LastUsedAt.IsZero() {
+		res.LastUsedAt = &t.LastUsedAt
+	}
+	return res
+}
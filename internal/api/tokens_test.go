@@ -0,0 +1,137 @@
+package api_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/api"
+	"github.com/Roma7-7-7/english-learning-bot/internal/config"
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+)
+
+// stubTokenRepo implements dal.APITokenRepository over a fixed set of tokens.
+type stubTokenRepo struct {
+	tokens  map[string]dal.APIToken
+	created []dal.APIToken
+}
+
+func (s *stubTokenRepo) CreateAPIToken(
+	_ context.Context, chatID int64, name string, scopes []dal.TokenScope, expiresAt time.Time,
+) (*dal.APIToken, string, error) {
+	for _, t := range s.created {
+		if t.Name == name {
+			return nil, "", dal.ErrAlreadyExists
+		}
+	}
+	t := dal.APIToken{ID: int64(len(s.created) + 1), ChatID: chatID, Name: name, Scopes: scopes, ExpiresAt: expiresAt}
+	s.created = append(s.created, t)
+	return &t, "elb_secret", nil
+}
+
+func (s *stubTokenRepo) AuthenticateAPIToken(_ context.Context, token string) (*dal.APIToken, error) {
+	t, ok := s.tokens[token]
+	if !ok {
+		return nil, dal.ErrNotFound
+	}
+	return &t, nil
+}
+
+func (s *stubTokenRepo) GetAPITokens(_ context.Context, _ int64) ([]dal.APIToken, error) {
+	return s.created, nil
+}
+
+func (s *stubTokenRepo) RevokeAPIToken(_ context.Context, _, _ int64) error {
+	return dal.ErrNotFound
+}
+
+func TestAuthMiddlewareAPITokenScopes(t *testing.T) {
+	jwtProc, cookieProc := testProcessors(config.JWT{Issuer: "test", Audience: []string{"test"}, Secret: "secret", KeyID: "1"})
+	tokens := &stubTokenRepo{tokens: map[string]dal.APIToken{
+		"reader":   {ID: 1, ChatID: testChatID, Scopes: []dal.TokenScope{dal.ScopeRead}},
+		"uploader": {ID: 2, ChatID: testChatID, Scopes: []dal.TokenScope{dal.ScopeWordsWrite}},
+		"stats":    {ID: 3, ChatID: testChatID, Scopes: []dal.TokenScope{dal.ScopeStatsRead}},
+		"departed": {ID: 4, ChatID: testChatID + 1, Scopes: []dal.TokenScope{dal.ScopeRead}},
+	}}
+
+	e := echo.New()
+	secured := e.Group("", api.AuthMiddleware(
+		cookieProc, jwtProc, newStubSessionRepo(), tokens, []int64{testChatID}, testLogger(),
+	))
+	ok := func(c echo.Context) error { return c.NoContent(http.StatusOK) }
+	secured.GET("/words", ok)
+	secured.POST("/words", ok)
+	secured.DELETE("/words", ok)
+	secured.POST("/words/restore", ok)
+	secured.GET("/stats/range", ok)
+	secured.PUT("/batch/size", ok)
+	secured.GET("/auth/tokens", ok)
+
+	tests := []struct {
+		token, method, path string
+		want                int
+	}{
+		{"reader", http.MethodGet, "/words", http.StatusOK},
+		{"reader", http.MethodGet, "/stats/range", http.StatusOK},
+		{"reader", http.MethodPost, "/words", http.StatusForbidden},
+		{"reader", http.MethodGet, "/auth/tokens", http.StatusForbidden},
+		{"uploader", http.MethodPost, "/words", http.StatusOK},
+		{"uploader", http.MethodGet, "/words", http.StatusOK},
+		{"uploader", http.MethodDelete, "/words", http.StatusForbidden},
+		{"uploader", http.MethodPost, "/words/restore", http.StatusForbidden},
+		{"uploader", http.MethodPut, "/batch/size", http.StatusForbidden},
+		{"uploader", http.MethodGet, "/stats/range", http.StatusForbidden},
+		{"stats", http.MethodGet, "/stats/range", http.StatusOK},
+		{"stats", http.MethodGet, "/words", http.StatusForbidden},
+		{"unknown", http.MethodGet, "/words", http.StatusUnauthorized},
+		{"departed", http.MethodGet, "/words", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(""))
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+tt.token)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s %s %s: status %d, want %d", tt.token, tt.method, tt.path, rec.Code, tt.want)
+		}
+	}
+}
+
+func TestCreateToken(t *testing.T) {
+	repo := &stubTokenRepo{}
+	h := api.NewTokensHandler(repo, testLogger())
+
+	create := func(body string) (*httptest.ResponseRecorder, error) {
+		c, rec := newRequest(t, "/auth/tokens", body)
+		return rec, h.CreateToken(c)
+	}
+
+	rec, err := create(`{"name": "upload", "scopes": ["words:write"], "expires_in_days": 30}`)
+	if err != nil || rec.Code != http.StatusCreated {
+		t.Fatalf("create: status %d (%v), want 201", rec.Code, err)
+	}
+	if !strings.Contains(rec.Body.String(), `"token":"elb_secret"`) {
+		t.Errorf("create response %s, want the token in it", rec.Body.String())
+	}
+	if len(repo.created) != 1 || repo.created[0].ExpiresAt.Before(time.Now().AddDate(0, 0, 29)) {
+		t.Errorf("created %+v, want one token expiring in 30 days", repo.created)
+	}
+
+	if rec, _ = create(`{"name": "upload", "scopes": ["read"]}`); rec.Code != http.StatusConflict {
+		t.Errorf("same name again: status %d, want 409", rec.Code)
+	}
+
+	for _, body := range []string{
+		`{"name": "admin", "scopes": ["admin"]}`,
+		`{"name": "none", "scopes": []}`,
+		`{"name": "forever", "scopes": ["read"], "expires_in_days": 366}`,
+	} {
+		rec, err = create(body)
+		assertHandlerStatus(t, err, rec.Code, http.StatusBadRequest)
+	}
+}
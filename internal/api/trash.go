@@ -0,0 +1,156 @@
+package api
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/context"
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+	"github.com/labstack/echo/v4"
+)
+
+type (
+	TrashHandler struct {
+		repo dal.TrashRepository
+		log  *slog.Logger
+	}
+
+	TrashedWord struct {
+		Word          string    `json:"word"`
+		Translation   string    `json:"translation"`
+		Description   string    `json:"description"`
+		GuessedStreak int       `json:"guessed_streak,omitempty"`
+		Tags          []string  `json:"tags,omitempty"`
+		CreatedAt     time.Time `json:"created_at"`
+		DeletedAt     time.Time `json:"deleted_at"`
+	}
+
+	RestoreWordRequest struct {
+		Word string `json:"word" validate:"required,min=1"`
+	}
+
+	WordAuditQueryParams struct {
+		// Word limits the log to one word, under its current name or an old one.
+		Word  string `query:"word"`
+		Limit int    `query:"limit" validate:"required,min=1,max=100"`
+	}
+
+	// WordAuditEntry is one change to a word's text. The old_ fields are the word before the change,
+	// translation and description after it.
+	WordAuditEntry struct {
+		ID             int64     `json:"id"`
+		Word           string    `json:"word"`
+		Action         string    `json:"action"`
+		OldWord        string    `json:"old_word,omitempty"`
+		OldTranslation string    `json:"old_translation,omitempty"`
+		OldDescription string    `json:"old_description,omitempty"`
+		Translation    string    `json:"translation,omitempty"`
+		Description    string    `json:"description,omitempty"`
+		ChangedAt      time.Time `json:"changed_at"`
+	}
+)
+
+func NewTrashHandler(repo dal.TrashRepository, log *slog.Logger) *TrashHandler {
+	return &TrashHandler{
+		repo: repo,
+		log:  log,
+	}
+}
+
+// GetTrash lists the deleted words that can still be restored, most recently deleted first.
+func (h *TrashHandler) GetTrash(c echo.Context) error {
+	ctx := c.Request().Context()
+	chatID := context.MustChatIDFromContext(ctx)
+
+	words, err := h.repo.GetTrash(ctx, chatID)
+	if err != nil {
+		h.log.ErrorContext(ctx, "failed to get trash", "error", err)
+		return c.JSON(http.StatusInternalServerError, InternalServerError)
+	}
+
+	res := make([]TrashedWord, len(words))
+	for i, w := range words {
+		res[i] = TrashedWord{
+			Word:          w.Word,
+			Translation:   w.Translation,
+			Description:   w.Description,
+			GuessedStreak: w.GuessedStreak,
+			Tags:          w.Tags,
+			CreatedAt:     w.CreatedAt,
+			DeletedAt:     w.DeletedAt,
+		}
+	}
+	return c.JSON(http.StatusOK, echo.Map{"items": res})
+}
+
+// RestoreWord brings a deleted word back with its progress. A word of the same name added since is
+// not overwritten: that is a 409.
+func (h *TrashHandler) RestoreWord(c echo.Context) error {
+	ctx := c.Request().Context()
+	chatID := context.MustChatIDFromContext(ctx)
+
+	var req RestoreWordRequest
+	if err := c.Bind(&req); err != nil {
+		h.log.DebugContext(ctx, "failed to bind request", "error", err)
+		return c.JSON(http.StatusBadRequest, BadRequestError)
+	}
+
+	if err := c.Validate(&req); err != nil {
+		h.log.DebugContext(ctx, "failed to validate request", "error", err)
+		return err
+	}
+
+	if err := h.repo.RestoreWordTranslation(ctx, chatID, req.Word); err != nil {
+		if errors.Is(err, dal.ErrNotFound) {
+			return c.JSON(http.StatusNotFound, NotFoundError)
+		}
+		if errors.Is(err, dal.ErrAlreadyExists) {
+			return c.JSON(http.StatusConflict, ErrorResponse{"Word already exists"})
+		}
+		h.log.ErrorContext(ctx, "failed to restore word", "error", err, "word", sanitizeForLog(req.Word))
+		return c.JSON(http.StatusInternalServerError, InternalServerError)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"status": "ok", "message": "word restored"})
+}
+
+// GetWordAudit lists the latest changes to the chat's words, newest first.
+func (h *TrashHandler) GetWordAudit(c echo.Context) error {
+	ctx := c.Request().Context()
+	chatID := context.MustChatIDFromContext(ctx)
+
+	var qp WordAuditQueryParams
+	if err := c.Bind(&qp); err != nil {
+		h.log.DebugContext(ctx, "failed to bind request", "error", err)
+		return c.JSON(http.StatusBadRequest, BadRequestError)
+	}
+
+	if err := c.Validate(&qp); err != nil {
+		h.log.DebugContext(ctx, "failed to validate request", "error", err)
+		return err
+	}
+
+	entries, err := h.repo.GetWordAudit(ctx, chatID, qp.Word, qp.Limit)
+	if err != nil {
+		h.log.ErrorContext(ctx, "failed to get word audit", "error", err)
+		return c.JSON(http.StatusInternalServerError, InternalServerError)
+	}
+
+	res := make([]WordAuditEntry, len(entries))
+	for i, e := range entries {
+		res[i] = WordAuditEntry{
+			ID:             e.ID,
+			Word:           e.Word,
+			Action:         string(e.Action),
+			OldWord:        e.OldWord,
+			OldTranslation: e.OldTranslation,
+			OldDescription: e.OldDescription,
+			Translation:    e.Translation,
+			Description:    e.Description,
+			ChangedAt:      e.ChangedAt,
+		}
+	}
+	return c.JSON(http.StatusOK, echo.Map{"items": res})
+}
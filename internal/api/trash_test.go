@@ -0,0 +1,88 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/api"
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+)
+
+// stubTrashRepo implements dal.TrashRepository over a set of trashed words and the live words they
+// may clash with, recording the words it restores.
+type stubTrashRepo struct {
+	trashed      map[string]bool
+	live         map[string]bool
+	restoreCalls []string
+}
+
+func (s *stubTrashRepo) GetTrash(_ context.Context, _ int64) ([]dal.TrashedWord, error) {
+	return nil, nil
+}
+
+func (s *stubTrashRepo) RestoreWordTranslation(_ context.Context, _ int64, word string) error {
+	switch {
+	case !s.trashed[word]:
+		return dal.ErrNotFound
+	case s.live[word]:
+		return dal.ErrAlreadyExists
+	}
+	s.restoreCalls = append(s.restoreCalls, word)
+	return nil
+}
+
+func (s *stubTrashRepo) PurgeTrash(_ context.Context, _ time.Time) (int, error) {
+	return 0, nil
+}
+
+func (s *stubTrashRepo) GetWordAudit(_ context.Context, _ int64, _ string, _ int) ([]dal.WordAuditEntry, error) {
+	return nil, nil
+}
+
+var _ dal.TrashRepository = (*stubTrashRepo)(nil)
+
+func TestRestoreWord(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantCalls  int
+	}{
+		{name: "trashed", body: `{"word":"apple"}`, wantStatus: http.StatusOK, wantCalls: 1},
+		{name: "not in the trash", body: `{"word":"plum"}`, wantStatus: http.StatusNotFound},
+		{name: "added again since", body: `{"word":"pear"}`, wantStatus: http.StatusConflict},
+		{name: "no word", body: `{}`, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &stubTrashRepo{
+				trashed: map[string]bool{"apple": true, "pear": true},
+				live:    map[string]bool{"pear": true},
+			}
+			h := api.NewTrashHandler(repo, testLogger())
+
+			c, rec := newRequest(t, "/words/restore", tt.body)
+			err := h.RestoreWord(c)
+			status := rec.Code
+			if err != nil {
+				var httpErr *echo.HTTPError
+				if !errors.As(err, &httpErr) {
+					t.Fatalf("RestoreWord: %v", err)
+				}
+				status = httpErr.Code
+			}
+			if status != tt.wantStatus {
+				t.Errorf("status = %d, want %d", status, tt.wantStatus)
+			}
+			if len(repo.restoreCalls) != tt.wantCalls {
+				t.Errorf("restored %v, want %d calls", repo.restoreCalls, tt.wantCalls)
+			}
+		})
+	}
+}
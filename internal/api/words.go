@@ -5,6 +5,7 @@ import (
 	"log/slog"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/Roma7-7-7/english-learning-bot/internal/context"
 	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
@@ -29,6 +30,18 @@ type (
 		// (in the batch itself, or waiting in the admission queue behind it), which is what tells a
 		// caller resolving a conflict whether "add to the batch" would change anything.
 		InBatch bool `json:"in_batch"`
+		// Lapses is read-only: how many times the word has been answered wrong.
+		Lapses int `json:"lapses,omitempty"`
+		// Suspended and BuriedUntil are read-only here; they are set with PUT /words/suspend.
+		Suspended   bool       `json:"suspended"`
+		BuriedUntil *time.Time `json:"buried_until,omitempty"`
+		// StreakLimit is read-only here: the word's own streak limit, or else its tags', 0 when it
+		// goes by the configured one. It is set with PUT /words/streak_limit.
+		StreakLimit int `json:"streak_limit,omitempty"`
+		// NextReviewAt is read-only: when a learned word is next due for review.
+		NextReviewAt *time.Time `json:"next_review_at,omitempty"`
+		// Tags are read-only here; they are set with PUT /words/tags.
+		Tags []string `json:"tags,omitempty"`
 		// OnConflict is only meaningful on create. Left empty, adding a word that already exists is
 		// refused with 409 and the existing entry, so the caller can ask the user what to do; set,
 		// it applies that answer.
@@ -39,7 +52,7 @@ type (
 
 	WordsQueryParams struct {
 		Search   string  `query:"search"`
-		Guessed  Guessed `query:"guessed" validate:"omitempty,oneof=all learned batched to_learn"`
+		Guessed  Guessed `query:"guessed" validate:"omitempty,oneof=all learned batched to_learn suspended leech"`
 		ToReview bool    `query:"to_review"`
 		Offset   uint64  `query:"offset" validate:"min=0"`
 		Limit    uint64  `query:"limit" validate:"required,min=1,max=100"`
@@ -56,6 +69,10 @@ const (
 	GuessedLearned Guessed = "learned"
 	GuessedBatched Guessed = "batched"
 	GuessedToLearn Guessed = "to_learn"
+	// GuessedSuspended lists the suspended words.
+	GuessedSuspended Guessed = "suspended"
+	// GuessedLeech lists the words tagged as leeches.
+	GuessedLeech Guessed = "leech"
 )
 
 // createAttempts bounds the create/read-the-conflict loop in CreateWord. One retry is enough for a
@@ -96,20 +113,8 @@ func (h *WordsHandler) FindWords(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, InternalServerError)
 	}
 
-	viewWords := make([]WordTranslation, len(words))
-	for i, word := range words {
-		viewWords[i] = WordTranslation{
-			Word:          word.Word,
-			Translation:   word.Translation,
-			Description:   word.Description,
-			ToReview:      word.ToReview,
-			GuessedStreak: word.GuessedStreak,
-			InBatch:       word.InBatch,
-		}
-	}
-
 	return c.JSON(http.StatusOK, echo.Map{
-		"items": viewWords,
+		"items": viewWords(words),
 		"total": totalWords,
 	})
 }
@@ -178,6 +183,7 @@ func (h *WordsHandler) CreateWord(c echo.Context) error {
 				ToReview:      existing.ToReview,
 				GuessedStreak: existing.GuessedStreak,
 				InBatch:       existing.InBatch,
+				Lapses:        existing.Lapses,
 			},
 		})
 	}
@@ -261,6 +267,135 @@ func (h *WordsHandler) MarkToReview(c echo.Context) error {
 	return c.JSON(http.StatusOK, echo.Map{"status": "ok", "message": "word marked"})
 }
 
+// SuspendRequest replaces both of a word's rotation states: a suspended word is out of rotation
+// until it is unsuspended, a buried one until buried_until. Leaving buried_until out unburies it.
+type SuspendRequest struct {
+	Word        string     `json:"word" validate:"required,min=1"`
+	Suspended   bool       `json:"suspended"`
+	BuriedUntil *time.Time `json:"buried_until"`
+}
+
+func (h *WordsHandler) SuspendWord(c echo.Context) error {
+	chatID := context.MustChatIDFromContext(c.Request().Context())
+
+	var req SuspendRequest
+	if err := c.Bind(&req); err != nil {
+		h.log.DebugContext(c.Request().Context(), "failed to bind request", "error", err)
+		return c.JSON(http.StatusBadRequest, BadRequestError)
+	}
+
+	if err := c.Validate(&req); err != nil {
+		h.log.DebugContext(c.Request().Context(), "failed to validate request", "error", err)
+		return err
+	}
+
+	var until time.Time
+	if req.BuriedUntil != nil {
+		until = *req.BuriedUntil
+	}
+	if err := h.repo.SetWordSuspension(c.Request().Context(), chatID, req.Word, req.Suspended, until); err != nil {
+		if errors.Is(err, dal.ErrNotFound) {
+			return c.JSON(http.StatusNotFound, NotFoundError)
+		}
+		h.log.ErrorContext(c.Request().Context(), "failed to set word suspension", "error", err)
+		return c.JSON(http.StatusInternalServerError, InternalServerError)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"status": "ok", "message": "word suspension set"})
+}
+
+// StreakLimitRequest sets the streak at which a word, or every word with a tag, counts as learned.
+// A streak limit of 0 removes it: the word goes by its tags' limit, and those by the configured one.
+type StreakLimitRequest struct {
+	Word        string `json:"word" validate:"required_without=Tag,excluded_with=Tag"`
+	Tag         string `json:"tag" validate:"omitempty,max=32,excludes=0x2C"`
+	StreakLimit int    `json:"streak_limit" validate:"min=0,max=1000"`
+}
+
+type TagStreakLimit struct {
+	Tag         string `json:"tag"`
+	StreakLimit int    `json:"streak_limit"`
+}
+
+func (h *WordsHandler) SetStreakLimit(c echo.Context) error {
+	chatID := context.MustChatIDFromContext(c.Request().Context())
+
+	var req StreakLimitRequest
+	if err := c.Bind(&req); err != nil {
+		h.log.DebugContext(c.Request().Context(), "failed to bind request", "error", err)
+		return c.JSON(http.StatusBadRequest, BadRequestError)
+	}
+
+	if err := c.Validate(&req); err != nil {
+		h.log.DebugContext(c.Request().Context(), "failed to validate request", "error", err)
+		return err
+	}
+
+	var err error
+	if req.Tag != "" {
+		err = h.repo.SetTagStreakLimit(c.Request().Context(), chatID, req.Tag, req.StreakLimit)
+	} else {
+		err = h.repo.SetWordStreakLimit(c.Request().Context(), chatID, req.Word, req.StreakLimit)
+	}
+	if err != nil {
+		if errors.Is(err, dal.ErrNotFound) {
+			return c.JSON(http.StatusNotFound, NotFoundError)
+		}
+		h.log.ErrorContext(c.Request().Context(), "failed to set streak limit", "error", err)
+		return c.JSON(http.StatusInternalServerError, InternalServerError)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"status": "ok", "message": "streak limit set"})
+}
+
+func (h *WordsHandler) GetTagStreakLimits(c echo.Context) error {
+	chatID := context.MustChatIDFromContext(c.Request().Context())
+
+	limits, err := h.repo.GetTagStreakLimits(c.Request().Context(), chatID)
+	if err != nil {
+		h.log.ErrorContext(c.Request().Context(), "failed to get tag streak limits", "error", err)
+		return c.JSON(http.StatusInternalServerError, InternalServerError)
+	}
+
+	res := make([]TagStreakLimit, len(limits))
+	for i, l := range limits {
+		res[i] = TagStreakLimit{Tag: l.Tag, StreakLimit: l.Limit}
+	}
+	return c.JSON(http.StatusOK, echo.Map{"items": res})
+}
+
+// SetTagsRequest replaces every tag of a word; an empty list removes them all. Tags are
+// case-insensitive and cannot contain commas.
+type SetTagsRequest struct {
+	Word string   `json:"word" validate:"required,min=1"`
+	Tags []string `json:"tags" validate:"max=20,dive,required,max=32,excludes=0x2C"`
+}
+
+func (h *WordsHandler) SetTags(c echo.Context) error {
+	chatID := context.MustChatIDFromContext(c.Request().Context())
+
+	var req SetTagsRequest
+	if err := c.Bind(&req); err != nil {
+		h.log.DebugContext(c.Request().Context(), "failed to bind request", "error", err)
+		return c.JSON(http.StatusBadRequest, BadRequestError)
+	}
+
+	if err := c.Validate(&req); err != nil {
+		h.log.DebugContext(c.Request().Context(), "failed to validate request", "error", err)
+		return err
+	}
+
+	if err := h.repo.SetWordTags(c.Request().Context(), chatID, req.Word, req.Tags); err != nil {
+		if errors.Is(err, dal.ErrNotFound) {
+			return c.JSON(http.StatusNotFound, NotFoundError)
+		}
+		h.log.ErrorContext(c.Request().Context(), "failed to set word tags", "error", err)
+		return c.JSON(http.StatusInternalServerError, InternalServerError)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"status": "ok", "message": "tags updated"})
+}
+
 type ResetStreakRequest struct {
 	Word string `json:"word" validate:"required,min=1"`
 	// AddToBatch also puts the word back into the active learning batch, so it starts coming up in
@@ -309,7 +444,48 @@ func toDALGuessed(g Guessed) dal.Guessed {
 		return dal.GuessedBatched
 	case GuessedToLearn:
 		return dal.GuessedToLearn
+	case GuessedSuspended:
+		return dal.GuessedSuspended
+	case GuessedLeech:
+		return dal.GuessedLeech
 	default:
 		return dal.GuessedAll
 	}
 }
+
+func viewWords(words []dal.WordTranslation) []WordTranslation {
+	res := make([]WordTranslation, len(words))
+	for i, word := range words {
+		res[i] = WordTranslation{
+			Word:          word.Word,
+			Translation:   word.Translation,
+			Description:   word.Description,
+			ToReview:      word.ToReview,
+			GuessedStreak: word.GuessedStreak,
+			InBatch:       word.InBatch,
+			Lapses:        word.Lapses,
+			Suspended:     word.Suspended,
+			BuriedUntil:   buriedUntil(word.BuriedUntil),
+			StreakLimit:   word.StreakLimit,
+			NextReviewAt:  nextReviewAt(word.NextReviewAt),
+			Tags:          word.Tags,
+		}
+	}
+	return res
+}
+
+// nextReviewAt leaves a word with no review scheduled without a next_review_at.
+func nextReviewAt(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// buriedUntil leaves a word that is not buried, or no longer, without a buried_until.
+func buriedUntil(t time.Time) *time.Time {
+	if t.IsZero() || !t.After(time.Now()) {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,73 @@
+package api_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/api"
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+)
+
+func TestSetStreakLimit(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		want       *limitCall
+	}{
+		{
+			name:       "word",
+			body:       `{"word":"apple","streak_limit":5}`,
+			wantStatus: http.StatusOK,
+			want:       &limitCall{word: "apple", limit: 5},
+		},
+		{
+			name:       "tag",
+			body:       `{"tag":"hard","streak_limit":25}`,
+			wantStatus: http.StatusOK,
+			want:       &limitCall{tag: "hard", limit: 25},
+		},
+		{
+			name:       "remove",
+			body:       `{"word":"apple","streak_limit":0}`,
+			wantStatus: http.StatusOK,
+			want:       &limitCall{word: "apple"},
+		},
+		{name: "neither word nor tag", body: `{"streak_limit":5}`, wantStatus: http.StatusBadRequest},
+		{name: "both word and tag", body: `{"word":"apple","tag":"hard","streak_limit":5}`, wantStatus: http.StatusBadRequest},
+		{name: "negative", body: `{"word":"apple","streak_limit":-1}`, wantStatus: http.StatusBadRequest},
+		{name: "unknown word", body: `{"word":"pear","streak_limit":5}`, wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &stubWordsRepo{findWord: existingWord(dal.WordTranslation{Word: "apple", Translation: "яблуко"})}
+			h := api.NewWordsHandler(repo, testLogger())
+
+			c, rec := newRequest(t, "/words/streak_limit", tt.body)
+			err := h.SetStreakLimit(c)
+			status := rec.Code
+			if err != nil {
+				var httpErr *echo.HTTPError
+				if !errors.As(err, &httpErr) {
+					t.Fatalf("SetStreakLimit: %v", err)
+				}
+				status = httpErr.Code
+			}
+			if status != tt.wantStatus {
+				t.Errorf("status = %d, want %d", status, tt.wantStatus)
+			}
+
+			switch {
+			case tt.want == nil && len(repo.limitCalls) > 0:
+				t.Errorf("streak limit set: %+v", repo.limitCalls)
+			case tt.want == nil:
+			case len(repo.limitCalls) != 1 || repo.limitCalls[0] != *tt.want:
+				t.Errorf("streak limit calls = %+v, want %+v", repo.limitCalls, *tt.want)
+			}
+		})
+	}
+}
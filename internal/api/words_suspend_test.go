@@ -0,0 +1,77 @@
+package api_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/api"
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+)
+
+func TestSuspendWord(t *testing.T) {
+	until := time.Date(2026, time.March, 3, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		want       *suspendCall
+	}{
+		{
+			name:       "suspend",
+			body:       `{"word":"apple","suspended":true}`,
+			wantStatus: http.StatusOK,
+			want:       &suspendCall{word: "apple", suspended: true},
+		},
+		{
+			name:       "bury",
+			body:       `{"word":"apple","buried_until":"2026-03-03T00:00:00Z"}`,
+			wantStatus: http.StatusOK,
+			want:       &suspendCall{word: "apple", buriedUntil: until},
+		},
+		{
+			name:       "back into rotation",
+			body:       `{"word":"apple","suspended":false}`,
+			wantStatus: http.StatusOK,
+			want:       &suspendCall{word: "apple"},
+		},
+		{name: "empty word", body: `{"word":"","suspended":true}`, wantStatus: http.StatusBadRequest},
+		{name: "unknown word", body: `{"word":"pear","suspended":true}`, wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &stubWordsRepo{findWord: existingWord(dal.WordTranslation{Word: "apple", Translation: "яблуко"})}
+			h := api.NewWordsHandler(repo, testLogger())
+
+			c, rec := newRequest(t, "/words/suspend", tt.body)
+			err := h.SuspendWord(c)
+			status := rec.Code
+			if err != nil {
+				var httpErr *echo.HTTPError
+				if !errors.As(err, &httpErr) {
+					t.Fatalf("SuspendWord: %v", err)
+				}
+				status = httpErr.Code
+			}
+			if status != tt.wantStatus {
+				t.Errorf("status = %d, want %d", status, tt.wantStatus)
+			}
+
+			switch {
+			case tt.want == nil && len(repo.suspendCalls) > 0:
+				t.Errorf("SetWordSuspension called: %+v", repo.suspendCalls)
+			case tt.want == nil:
+			case len(repo.suspendCalls) != 1:
+				t.Errorf("SetWordSuspension calls = %+v, want one", repo.suspendCalls)
+			case repo.suspendCalls[0].word != tt.want.word || repo.suspendCalls[0].suspended != tt.want.suspended ||
+				!repo.suspendCalls[0].buriedUntil.Equal(tt.want.buriedUntil):
+				t.Errorf("SetWordSuspension = %+v, want %+v", repo.suspendCalls[0], *tt.want)
+			}
+		})
+	}
+}
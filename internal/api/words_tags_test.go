@@ -0,0 +1,51 @@
+package api_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/api"
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+)
+
+func TestSetTags(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{name: "tags", body: `{"word":"apple","tags":["food","a1"]}`, wantStatus: http.StatusOK},
+		{name: "clear tags", body: `{"word":"apple","tags":[]}`, wantStatus: http.StatusOK},
+		{name: "comma in a tag", body: `{"word":"apple","tags":["food,drink"]}`, wantStatus: http.StatusBadRequest},
+		{name: "empty tag", body: `{"word":"apple","tags":[""]}`, wantStatus: http.StatusBadRequest},
+		{name: "unknown word", body: `{"word":"pear","tags":["food"]}`, wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &stubWordsRepo{findWord: existingWord(dal.WordTranslation{Word: "apple", Translation: "яблуко"})}
+			h := api.NewWordsHandler(repo, testLogger())
+
+			c, rec := newRequest(t, "/words/tags", tt.body)
+			// Validation failures are returned for HTTPErrorHandler to render rather than written.
+			err := h.SetTags(c)
+			status := rec.Code
+			if err != nil {
+				var httpErr *echo.HTTPError
+				if !errors.As(err, &httpErr) {
+					t.Fatalf("SetTags: %v", err)
+				}
+				status = httpErr.Code
+			}
+			if status != tt.wantStatus {
+				t.Errorf("status = %d, want %d", status, tt.wantStatus)
+			}
+			if wantSet := tt.wantStatus == http.StatusOK; wantSet != (len(repo.tagCalls) == 1) {
+				t.Errorf("SetWordTags calls = %v, want called %v", repo.tagCalls, wantSet)
+			}
+		})
+	}
+}
@@ -11,10 +11,30 @@ import (
 
 type (
 	WordCheckSchedule struct {
+		// PublishInterval is how often word checks are sent, unless Cron is set. A backoff starts
+		// from it either way.
 		PublishInterval time.Duration `envconfig:"PUBLISH_INTERVAL" default:"15m"`
-		HourFrom        int           `envconfig:"HOUR_FROM" default:"9"`
-		HourTo          int           `envconfig:"HOUR_TO" default:"22"`
-		Location        string        `envconfig:"LOCATION" default:"Europe/Kyiv"`
+		// Cron, when set, is a cron expression the checks are sent on instead of every
+		// PublishInterval. See ParseCron.
+		Cron string `envconfig:"CRON"`
+		// HourFrom and HourTo are the hours checks are sent between; HourFrom after HourTo wraps past
+		// midnight. Windows, when set, replaces them with "HH:MM-HH:MM" windows. See CheckWindows.
+		HourFrom int      `envconfig:"HOUR_FROM" default:"9"`
+		HourTo   int      `envconfig:"HOUR_TO" default:"22"`
+		Windows  []string `envconfig:"WINDOWS"`
+		Location string   `envconfig:"LOCATION" default:"Europe/Kyiv"`
+		// MaxUnanswered is how many word checks may pile up unanswered before the schedule stops
+		// sending more to the chat. It then backs off, doubling the wait up to MaxBackoff, until the
+		// chat answers something. 0 disables it.
+		MaxUnanswered int           `envconfig:"MAX_UNANSWERED" default:"3"`
+		MaxBackoff    time.Duration `envconfig:"MAX_BACKOFF" default:"2h"`
+		// FastAnswer doubles the check rate for chats whose median answer over the last day came
+		// within it. 0 disables it.
+		FastAnswer time.Duration `envconfig:"FAST_ANSWER" default:"2m"`
+		// StudySlots are daily "HH:MM-HH:MM" windows in Location during which checks are sent every
+		// StudySlotInterval instead, even outside the regular windows. See ParseTimeSlots.
+		StudySlots        []string      `envconfig:"STUDY_SLOTS"`
+		StudySlotInterval time.Duration `envconfig:"STUDY_SLOT_INTERVAL" default:"3m"`
 	}
 
 	// Learning holds the knobs of the spaced-repetition loop.
@@ -24,9 +44,74 @@ type (
 		// StreakLimit is the number of consecutive correct answers after which a word counts as
 		// learned and leaves the batch.
 		StreakLimit int `envconfig:"STREAK_LIMIT" default:"15"`
-		// ReviewRatePercent is the share of scheduled word checks that re-test an already learned
-		// word instead of one from the active batch. 0 disables reviews entirely.
+		// ReviewRatePercent is the largest share of scheduled word checks that may re-test an already
+		// learned word instead of one from the active batch. The share itself follows how many
+		// learned words are due for review. 0 disables reviews entirely.
 		ReviewRatePercent int `envconfig:"REVIEW_RATE_PERCENT" default:"20"`
+		// ReviewFirstInterval is how long after a word is learned its first review is due. Every
+		// review passed after that at least doubles the wait, up to ReviewMaxInterval.
+		ReviewFirstInterval time.Duration `envconfig:"REVIEW_FIRST_INTERVAL" default:"72h"`
+		ReviewMaxInterval   time.Duration `envconfig:"REVIEW_MAX_INTERVAL" default:"4320h"`
+		// ToReviewRatePercent is the share of scheduled word checks spent on the words marked to
+		// review, as long as there are any. They are drawn before learned-word reviews. 0 leaves
+		// them to /review.
+		ToReviewRatePercent int `envconfig:"TO_REVIEW_RATE_PERCENT" default:"50"`
+		// ClozeRatePercent is the share of word checks sent as a fill-in-the-blank sentence instead
+		// of the bare word, to the chats that left /cards at mixed. Only words whose description holds
+		// a sentence using them can become one, the rest always get the classic card. 0 keeps those
+		// chats on classic cards.
+		ClozeRatePercent int `envconfig:"CLOZE_RATE_PERCENT" default:"0"`
+		// RefillCron is the cron expression the learning batch is topped up on.
+		RefillCron string `envconfig:"REFILL_CRON" default:"@hourly"`
+		// DrillIntervals are the waits before a missed word is asked again: the first after the miss,
+		// the next after each answer, the last one repeating. The word leaves the drill once it is
+		// answered correctly twice in a row. Empty turns the drill off.
+		DrillIntervals []time.Duration `envconfig:"DRILL_INTERVALS" default:"10m,1h,4h"`
+		// LeechThreshold is how many misses make a word a leech: it is tagged "leech" and the chat is
+		// told to give it a mnemonic or an example. Every LeechThreshold/2 misses after that do it
+		// again. 0 disables leech detection.
+		LeechThreshold int `envconfig:"LEECH_THRESHOLD" default:"8"`
+		// LeechSuspend also takes leeches out of the learning batch until their tag is removed.
+		LeechSuspend bool `envconfig:"LEECH_SUSPEND" default:"false"`
+		// UndoWindow is how long after answering a word check the answer can still be taken back,
+		// with the undo button or /undo. 0 disables undo.
+		UndoWindow time.Duration `envconfig:"UNDO_WINDOW" default:"10m"`
+	}
+
+	// Goals configures the daily goal and the learning streak built on it.
+	Goals struct {
+		// DailyAnswers is how many words have to be answered, right or wrong, for a day to count
+		// towards the streak.
+		DailyAnswers int `envconfig:"DAILY_ANSWERS" default:"20"`
+		// DailyNewWords is how many brand-new words have to be added on top of that. 0 means no
+		// requirement.
+		DailyNewWords int `envconfig:"DAILY_NEW_WORDS" default:"0"`
+		// ReminderHour is the hour, in the schedule location, at which chats that have not met their
+		// goal yet get a nudge.
+		ReminderHour int `envconfig:"REMINDER_HOUR" default:"20"`
+		// FreezeEvery is the streak length, in days, that earns a streak freeze. 0 disables freezes.
+		FreezeEvery int `envconfig:"FREEZE_EVERY" default:"7"`
+		// MaxFreezes caps how many unspent freezes a chat can hold.
+		MaxFreezes int `envconfig:"MAX_FREEZES" default:"2"`
+	}
+
+	// Digest configures the progress summaries sent at the end of a week and of a month.
+	Digest struct {
+		// Hour is the hour, in the schedule location, at which digests are sent: on Sundays for the
+		// weekly one, on the last day of the month for the monthly one. WeeklyCron and MonthlyCron,
+		// when set, replace it with cron expressions. See WeeklySchedule and MonthlySchedule.
+		Hour        int    `envconfig:"HOUR" default:"19"`
+		WeeklyCron  string `envconfig:"WEEKLY_CRON"`
+		MonthlyCron string `envconfig:"MONTHLY_CRON"`
+		Weekly      bool   `envconfig:"WEEKLY" default:"true"`
+		Monthly     bool   `envconfig:"MONTHLY" default:"true"`
+	}
+
+	// Trash configures how long deleted words can still be restored.
+	Trash struct {
+		// RetentionDays is how many days after it was deleted a word is purged for good. 0 keeps
+		// deleted words until they are restored.
+		RetentionDays int `envconfig:"RETENTION_DAYS" default:"30"`
 	}
 
 	DB struct {
@@ -41,13 +126,21 @@ type (
 		Issuer   string   `envconfig:"ISSUER" default:"english-learning-api"`
 		Audience []string `envconfig:"AUDIENCE" required:"true"`
 		Secret   string   `envconfig:"SECRET" required:"false"`
+		// KeyID names Secret in the kid header of the tokens it signs.
+		KeyID string `envconfig:"KEY_ID" default:"1"`
+		// RetiredSecrets are the secrets rotated out, by key ID, as "kid:secret,kid:secret". Tokens they
+		// signed are still accepted until they expire; nothing new is signed with them.
+		RetiredSecrets map[string]string `envconfig:"RETIRED_SECRETS"`
 	}
 
 	Cookie struct {
 		Path            string        `envconfig:"CPATH" default:"/"` // not using PATH here because it may conflict with os.Path
 		Domain          string        `envconfig:"DOMAIN" required:"true"`
 		AuthExpiresIn   time.Duration `envconfig:"AUTH_EXPIRES_IN" default:"24h"`
-		AccessExpiresIn time.Duration `envconfig:"ACCESS_EXPIRES_IN" default:"720h"`
+		AccessExpiresIn time.Duration `envconfig:"ACCESS_EXPIRES_IN" default:"15m"`
+		// RefreshExpiresIn is how long a session lasts without being refreshed, that is without the web
+		// interface being opened.
+		RefreshExpiresIn time.Duration `envconfig:"REFRESH_EXPIRES_IN" default:"720h"`
 	}
 
 	HTTP struct {
@@ -66,6 +159,9 @@ type (
 	Telegram struct {
 		Token          string  `required:"false"`
 		AllowedChatIDs []int64 `envconfig:"ALLOWED_CHAT_IDS" required:"false"`
+		// GroupChatIDs are group chats the bot quizzes as a whole. Anybody who is a member of the group
+		// may answer, and every member's progress is kept separately.
+		GroupChatIDs []int64 `envconfig:"GROUP_CHAT_IDS" required:"false"`
 	}
 
 	BuildInfo struct {
@@ -79,6 +175,9 @@ type (
 		Telegram  Telegram          `envconfig:"TELEGRAM"`
 		Schedule  WordCheckSchedule `envconfig:"SCHEDULE"`
 		Learning  Learning          `envconfig:"LEARNING"`
+		Goals     Goals             `envconfig:"GOALS"`
+		Digest    Digest            `envconfig:"DIGEST"`
+		Trash     Trash             `envconfig:"TRASH"`
 		HTTP      HTTP              `envconfig:"HTTP"`
 		Server    Server            `envconfig:"SERVER"`
 		BuildInfo BuildInfo
@@ -93,6 +192,50 @@ func (s WordCheckSchedule) TimeLocation() (*time.Location, error) {
 	return loc, nil
 }
 
+// CheckCron parses Cron, returning nil when checks are sent every PublishInterval instead.
+func (s WordCheckSchedule) CheckCron() (*Cron, error) {
+	if s.Cron == "" {
+		return nil, nil //nolint:nilnil // no cron is not an error
+	}
+	c, err := ParseCron(s.Cron)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (s WordCheckSchedule) MustCheckCron() *Cron {
+	c, err := s.CheckCron()
+	if err != nil {
+		panic(fmt.Sprintf("failed to parse word check cron %q: %v", s.Cron, err))
+	}
+	return c
+}
+
+// CheckWindows returns the windows checks are sent in: Windows if set, HourFrom-HourTo otherwise.
+func (s WordCheckSchedule) CheckWindows() ([]TimeSlot, error) {
+	if len(s.Windows) == 0 {
+		return []TimeSlot{{From: time.Duration(s.HourFrom) * time.Hour, To: time.Duration(s.HourTo) * time.Hour}}, nil
+	}
+	return ParseTimeSlots(s.Windows)
+}
+
+func (s WordCheckSchedule) MustCheckWindows() []TimeSlot {
+	windows, err := s.CheckWindows()
+	if err != nil {
+		panic(fmt.Sprintf("failed to parse word check windows %v: %v", s.Windows, err))
+	}
+	return windows
+}
+
+func (s WordCheckSchedule) MustStudySlots() []TimeSlot {
+	slots, err := ParseTimeSlots(s.StudySlots)
+	if err != nil {
+		panic(fmt.Sprintf("failed to parse study slots %v: %v", s.StudySlots, err))
+	}
+	return slots
+}
+
 func (s WordCheckSchedule) MustTimeLocation() *time.Location {
 	loc, err := s.TimeLocation()
 	if err != nil {
@@ -101,6 +244,55 @@ func (s WordCheckSchedule) MustTimeLocation() *time.Location {
 	return loc
 }
 
+func (l Learning) MustRefillSchedule() Cron {
+	c, err := ParseCron(l.RefillCron)
+	if err != nil {
+		panic(fmt.Sprintf("failed to parse refill cron %q: %v", l.RefillCron, err))
+	}
+	return c
+}
+
+// WeeklySchedule returns when the weekly digest is sent: WeeklyCron if set, Sundays at Hour otherwise.
+func (d Digest) WeeklySchedule() (Cron, error) {
+	if d.WeeklyCron != "" {
+		return ParseCron(d.WeeklyCron)
+	}
+	return ParseCron(fmt.Sprintf("0 %d * * 0", d.Hour))
+}
+
+// MustWeeklySchedule returns the weekly digest schedule, or nil when the weekly digest is disabled.
+func (d Digest) MustWeeklySchedule() *Cron {
+	if !d.Weekly {
+		return nil
+	}
+	c, err := d.WeeklySchedule()
+	if err != nil {
+		panic(fmt.Sprintf("failed to parse weekly digest cron %q: %v", d.WeeklyCron, err))
+	}
+	return &c
+}
+
+// MonthlySchedule returns when the monthly digest is sent: MonthlyCron if set, the last day of the
+// month at Hour otherwise.
+func (d Digest) MonthlySchedule() (Cron, error) {
+	if d.MonthlyCron != "" {
+		return ParseCron(d.MonthlyCron)
+	}
+	return ParseCron(fmt.Sprintf("0 %d L * *", d.Hour))
+}
+
+// MustMonthlySchedule returns the monthly digest schedule, or nil when the monthly digest is disabled.
+func (d Digest) MustMonthlySchedule() *Cron {
+	if !d.Monthly {
+		return nil
+	}
+	c, err := d.MonthlySchedule()
+	if err != nil {
+		panic(fmt.Sprintf("failed to parse monthly digest cron %q: %v", d.MonthlyCron, err))
+	}
+	return &c
+}
+
 func GetBot(ctx context.Context) (*Bot, error) {
 	res := &Bot{}
 	if err := envconfig.Process("BOT", res); err != nil {
@@ -122,24 +314,77 @@ func validateBot(conf *Bot) (*Bot, error) {
 	if len(conf.Telegram.AllowedChatIDs) == 0 {
 		errs = append(errs, "allowed chat ids are required")
 	}
+	for _, id := range conf.Telegram.GroupChatIDs {
+		// Telegram gives every group and supergroup a negative ID, and every private chat a positive one.
+		if id >= 0 {
+			errs = append(errs, fmt.Sprintf("group chat id %d must be negative", id))
+		}
+	}
 	if conf.HTTP.JWT.Secret == "" {
 		errs = append(errs, "jwt secret is required")
 	}
-	if conf.Schedule.PublishInterval == 0 {
+	if conf.HTTP.JWT.KeyID == "" {
+		errs = append(errs, "jwt key id is required")
+	}
+	for kid, secret := range conf.HTTP.JWT.RetiredSecrets {
+		if kid == conf.HTTP.JWT.KeyID {
+			errs = append(errs, fmt.Sprintf("retired jwt key id %q is the current one", kid))
+		}
+		if secret == "" {
+			errs = append(errs, fmt.Sprintf("retired jwt secret %q is empty", kid))
+		}
+	}
+	if conf.HTTP.Cookie.AccessExpiresIn <= 0 {
+		errs = append(errs, fmt.Sprintf("access expires in %v must be positive", conf.HTTP.Cookie.AccessExpiresIn))
+	} else if conf.HTTP.Cookie.RefreshExpiresIn <= conf.HTTP.Cookie.AccessExpiresIn {
+		errs = append(errs, fmt.Sprintf("refresh expires in %v must be longer than access expires in %v",
+			conf.HTTP.Cookie.RefreshExpiresIn, conf.HTTP.Cookie.AccessExpiresIn))
+	}
+	if conf.Schedule.PublishInterval == 0 && conf.Schedule.Cron == "" {
 		errs = append(errs, "publish interval is required")
 	}
+	if _, err := conf.Schedule.CheckCron(); err != nil {
+		errs = append(errs, fmt.Sprintf("invalid word check cron: %s", err))
+	}
 	if conf.Schedule.HourFrom < 0 || conf.Schedule.HourFrom > 23 {
 		errs = append(errs, fmt.Sprintf("hour from %d must be in range 0-23", conf.Schedule.HourFrom))
 	}
 	if conf.Schedule.HourTo < 0 || conf.Schedule.HourTo > 23 {
 		errs = append(errs, fmt.Sprintf("hour to %d must be in range 0-23", conf.Schedule.HourTo))
 	}
-	if conf.Schedule.HourFrom >= conf.Schedule.HourTo {
-		errs = append(errs, fmt.Sprintf("hour from %d must be less than hour to %d", conf.Schedule.HourFrom, conf.Schedule.HourTo))
+	if conf.Schedule.HourFrom == conf.Schedule.HourTo {
+		errs = append(errs, fmt.Sprintf("hour from and hour to must differ, both are %d", conf.Schedule.HourFrom))
+	}
+	if _, err := conf.Schedule.CheckWindows(); err != nil {
+		errs = append(errs, fmt.Sprintf("invalid word check windows: %s", err))
 	}
 	if _, err := conf.Schedule.TimeLocation(); err != nil {
 		errs = append(errs, fmt.Sprintf("invalid timezone: %s", err))
 	}
+	if conf.Schedule.MaxUnanswered < 0 {
+		errs = append(errs, fmt.Sprintf("max unanswered %d must not be negative", conf.Schedule.MaxUnanswered))
+	}
+	if conf.Schedule.MaxUnanswered > 0 && conf.Schedule.MaxBackoff < conf.Schedule.PublishInterval {
+		errs = append(errs, fmt.Sprintf("max backoff %v must not be shorter than the publish interval %v",
+			conf.Schedule.MaxBackoff, conf.Schedule.PublishInterval))
+	}
+	if _, err := ParseTimeSlots(conf.Schedule.StudySlots); err != nil {
+		errs = append(errs, fmt.Sprintf("invalid study slots: %s", err))
+	}
+	if len(conf.Schedule.StudySlots) > 0 && conf.Schedule.StudySlotInterval <= 0 {
+		errs = append(errs, "study slot interval is required with study slots")
+	}
+	if _, err := ParseCron(conf.Learning.RefillCron); err != nil {
+		errs = append(errs, fmt.Sprintf("invalid refill cron: %s", err))
+	}
+	for _, d := range conf.Learning.DrillIntervals {
+		if d <= 0 {
+			errs = append(errs, fmt.Sprintf("drill interval %v must be positive", d))
+		}
+	}
+	if conf.Learning.LeechThreshold < 0 {
+		errs = append(errs, fmt.Sprintf("leech threshold %d must not be negative", conf.Learning.LeechThreshold))
+	}
 	if conf.Learning.BatchSize <= 0 {
 		errs = append(errs, fmt.Sprintf("learning batch size %d must be greater than 0", conf.Learning.BatchSize))
 	}
@@ -149,6 +394,52 @@ func validateBot(conf *Bot) (*Bot, error) {
 	if conf.Learning.ReviewRatePercent < 0 || conf.Learning.ReviewRatePercent > 100 {
 		errs = append(errs, fmt.Sprintf("learning review rate %d must be in range 0-100", conf.Learning.ReviewRatePercent))
 	}
+	if conf.Learning.ReviewFirstInterval <= 0 {
+		errs = append(errs, fmt.Sprintf("review first interval %v must be positive", conf.Learning.ReviewFirstInterval))
+	} else if conf.Learning.ReviewMaxInterval < conf.Learning.ReviewFirstInterval {
+		errs = append(errs, fmt.Sprintf("review max interval %v must not be shorter than the first interval %v",
+			conf.Learning.ReviewMaxInterval, conf.Learning.ReviewFirstInterval))
+	}
+	if conf.Learning.ToReviewRatePercent < 0 || conf.Learning.ToReviewRatePercent > 100 {
+		errs = append(errs, fmt.Sprintf("learning to review rate %d must be in range 0-100", conf.Learning.ToReviewRatePercent))
+	}
+	if conf.Learning.ClozeRatePercent < 0 || conf.Learning.ClozeRatePercent > 100 {
+		errs = append(errs, fmt.Sprintf("learning cloze rate %d must be in range 0-100", conf.Learning.ClozeRatePercent))
+	}
+	if conf.Learning.UndoWindow < 0 {
+		errs = append(errs, fmt.Sprintf("undo window %v must not be negative", conf.Learning.UndoWindow))
+	}
+
+	if conf.Goals.DailyAnswers <= 0 {
+		errs = append(errs, fmt.Sprintf("daily answers goal %d must be greater than 0", conf.Goals.DailyAnswers))
+	}
+	if conf.Goals.DailyNewWords < 0 {
+		errs = append(errs, fmt.Sprintf("daily new words goal %d must not be negative", conf.Goals.DailyNewWords))
+	}
+	if conf.Goals.ReminderHour < 0 || conf.Goals.ReminderHour > 23 {
+		errs = append(errs, fmt.Sprintf("goal reminder hour %d must be in range 0-23", conf.Goals.ReminderHour))
+	}
+	if conf.Goals.FreezeEvery < 0 {
+		errs = append(errs, fmt.Sprintf("streak freeze interval %d must not be negative", conf.Goals.FreezeEvery))
+	}
+	if conf.Goals.MaxFreezes < 0 {
+		errs = append(errs, fmt.Sprintf("max streak freezes %d must not be negative", conf.Goals.MaxFreezes))
+	}
+
+	if conf.Trash.RetentionDays < 0 {
+		errs = append(errs, fmt.Sprintf("trash retention %d days must not be negative", conf.Trash.RetentionDays))
+	}
+
+	if conf.Digest.Hour < 0 || conf.Digest.Hour > 23 {
+		errs = append(errs, fmt.Sprintf("digest hour %d must be in range 0-23", conf.Digest.Hour))
+	} else {
+		if _, err := conf.Digest.WeeklySchedule(); err != nil {
+			errs = append(errs, fmt.Sprintf("invalid weekly digest cron: %s", err))
+		}
+		if _, err := conf.Digest.MonthlySchedule(); err != nil {
+			errs = append(errs, fmt.Sprintf("invalid monthly digest cron: %s", err))
+		}
+	}
 
 	if len(errs) > 0 {
 		return nil, fmt.Errorf("invalid config: %s", strings.Join(errs, ", "))
@@ -2,8 +2,10 @@ package config_test
 
 import (
 	"context"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/Roma7-7-7/english-learning-bot/internal/config"
 )
@@ -34,6 +36,13 @@ func TestGetBotLearningDefaults(t *testing.T) {
 	if conf.Learning.StreakLimit != 15 {
 		t.Errorf("StreakLimit = %d, want 15", conf.Learning.StreakLimit)
 	}
+	if want := []time.Duration{10 * time.Minute, time.Hour, 4 * time.Hour}; !slices.Equal(conf.Learning.DrillIntervals, want) {
+		t.Errorf("DrillIntervals = %v, want %v", conf.Learning.DrillIntervals, want)
+	}
+	if conf.Learning.LeechThreshold != 8 || conf.Learning.LeechSuspend {
+		t.Errorf("LeechThreshold = %d, LeechSuspend = %v, want 8 and false",
+			conf.Learning.LeechThreshold, conf.Learning.LeechSuspend)
+	}
 }
 
 func TestGetBotLearningFromEnv(t *testing.T) {
@@ -70,6 +79,96 @@ func TestGetBotRejectsInvalidLearningConfig(t *testing.T) {
 			env:     map[string]string{"BOT_LEARNING_STREAK_LIMIT": "-1"},
 			wantErr: "learning streak limit",
 		},
+		{
+			name:    "zero drill interval",
+			env:     map[string]string{"BOT_LEARNING_DRILL_INTERVALS": "10m,0s"},
+			wantErr: "drill interval 0s must be positive",
+		},
+		{
+			name:    "negative leech threshold",
+			env:     map[string]string{"BOT_LEARNING_LEECH_THRESHOLD": "-1"},
+			wantErr: "leech threshold -1 must not be negative",
+		},
+		{
+			name:    "cloze rate above 100",
+			env:     map[string]string{"BOT_LEARNING_CLOZE_RATE_PERCENT": "101"},
+			wantErr: "learning cloze rate",
+		},
+		{
+			name:    "zero first review interval",
+			env:     map[string]string{"BOT_LEARNING_REVIEW_FIRST_INTERVAL": "0s"},
+			wantErr: "review first interval 0s must be positive",
+		},
+		{
+			name:    "max review interval shorter than the first",
+			env:     map[string]string{"BOT_LEARNING_REVIEW_FIRST_INTERVAL": "720h", "BOT_LEARNING_REVIEW_MAX_INTERVAL": "72h"},
+			wantErr: "review max interval",
+		},
+		{
+			name:    "negative undo window",
+			env:     map[string]string{"BOT_LEARNING_UNDO_WINDOW": "-1m"},
+			wantErr: "undo window -1m0s must not be negative",
+		},
+		{
+			name:    "negative trash retention",
+			env:     map[string]string{"BOT_TRASH_RETENTION_DAYS": "-1"},
+			wantErr: "trash retention -1 days must not be negative",
+		},
+		{
+			name:    "retired jwt key id in use",
+			env:     map[string]string{"BOT_HTTP_JWT_RETIRED_SECRETS": "1:old"},
+			wantErr: `retired jwt key id "1" is the current one`,
+		},
+		{
+			name:    "refresh shorter than access",
+			env:     map[string]string{"BOT_HTTP_COOKIE_REFRESH_EXPIRES_IN": "10m"},
+			wantErr: "refresh expires in 10m0s must be longer than access expires in 15m0s",
+		},
+		{
+			name:    "negative to review rate",
+			env:     map[string]string{"BOT_LEARNING_TO_REVIEW_RATE_PERCENT": "-1"},
+			wantErr: "learning to review rate",
+		},
+		{
+			name:    "malformed study slot",
+			env:     map[string]string{"BOT_SCHEDULE_STUDY_SLOTS": "07:30-08:00,evening"},
+			wantErr: "invalid study slots",
+		},
+		{
+			name:    "backoff shorter than the interval",
+			env:     map[string]string{"BOT_SCHEDULE_MAX_BACKOFF": "5m"},
+			wantErr: "max backoff",
+		},
+		{
+			name:    "malformed word check cron",
+			env:     map[string]string{"BOT_SCHEDULE_CRON": "*/15 9-22 * *"},
+			wantErr: "invalid word check cron",
+		},
+		{
+			name:    "empty hour window",
+			env:     map[string]string{"BOT_SCHEDULE_HOUR_FROM": "9", "BOT_SCHEDULE_HOUR_TO": "9"},
+			wantErr: "hour from and hour to must differ",
+		},
+		{
+			name:    "malformed window",
+			env:     map[string]string{"BOT_SCHEDULE_WINDOWS": "08:00-25:00"},
+			wantErr: "invalid word check windows",
+		},
+		{
+			name:    "monthly digest that never fires",
+			env:     map[string]string{"BOT_DIGEST_MONTHLY_CRON": "0 19 31 2 *"},
+			wantErr: "invalid monthly digest cron",
+		},
+		{
+			name:    "digest hour out of range",
+			env:     map[string]string{"BOT_DIGEST_HOUR": "24"},
+			wantErr: "digest hour",
+		},
+		{
+			name:    "private chat as a group",
+			env:     map[string]string{"BOT_TELEGRAM_GROUP_CHAT_IDS": "-100123,42"},
+			wantErr: "group chat id 42",
+		},
 	}
 
 	for _, tt := range tests {
@@ -89,3 +188,86 @@ func TestGetBotRejectsInvalidLearningConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestStudySlots(t *testing.T) {
+	setRequired(t)
+	t.Setenv("BOT_SCHEDULE_STUDY_SLOTS", "07:30-08:00, 23:00-00:30")
+
+	conf, err := config.GetBot(context.Background())
+	if err != nil {
+		t.Fatalf("GetBot: %v", err)
+	}
+	slots := conf.Schedule.MustStudySlots()
+	if len(slots) != 2 {
+		t.Fatalf("slots = %v, want 2", slots)
+	}
+
+	at := func(hour, minute int) time.Time {
+		return time.Date(2026, time.March, 2, hour, minute, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		slot config.TimeSlot
+		at   time.Time
+		want bool
+	}{
+		{slot: slots[0], at: at(7, 30), want: true},
+		{slot: slots[0], at: at(7, 59), want: true},
+		{slot: slots[0], at: at(8, 0), want: false},
+		{slot: slots[0], at: at(7, 29), want: false},
+		// The second slot wraps past midnight.
+		{slot: slots[1], at: at(23, 15), want: true},
+		{slot: slots[1], at: at(0, 15), want: true},
+		{slot: slots[1], at: at(0, 30), want: false},
+		{slot: slots[1], at: at(22, 59), want: false},
+	}
+	for _, tt := range tests {
+		if got := tt.slot.Contains(tt.at); got != tt.want {
+			t.Errorf("%+v.Contains(%s) = %v, want %v", tt.slot, tt.at.Format("15:04"), got, tt.want)
+		}
+	}
+}
+
+// Windows may cross midnight, either as hours or as explicit windows.
+func TestCheckWindows(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want []config.TimeSlot
+	}{
+		{
+			name: "hours",
+			env:  map[string]string{"BOT_SCHEDULE_HOUR_FROM": "9", "BOT_SCHEDULE_HOUR_TO": "22"},
+			want: []config.TimeSlot{{From: 9 * time.Hour, To: 22 * time.Hour}},
+		},
+		{
+			name: "hours past midnight",
+			env:  map[string]string{"BOT_SCHEDULE_HOUR_FROM": "18", "BOT_SCHEDULE_HOUR_TO": "2"},
+			want: []config.TimeSlot{{From: 18 * time.Hour, To: 2 * time.Hour}},
+		},
+		{
+			name: "windows replace the hours",
+			env:  map[string]string{"BOT_SCHEDULE_WINDOWS": "08:00-09:00, 18:00-23:30"},
+			want: []config.TimeSlot{
+				{From: 8 * time.Hour, To: 9 * time.Hour},
+				{From: 18 * time.Hour, To: 23*time.Hour + 30*time.Minute},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setRequired(t)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			conf, err := config.GetBot(context.Background())
+			if err != nil {
+				t.Fatalf("GetBot: %v", err)
+			}
+			if got := conf.Schedule.MustCheckWindows(); !slices.Equal(got, tt.want) {
+				t.Errorf("windows = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
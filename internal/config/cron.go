@@ -0,0 +1,239 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSearchYears bounds how far ahead Cron.Next looks. Anything that matches at all matches within
+// one leap-year cycle; the margin covers a 29th of February on a Friday and the like.
+const cronSearchYears = 8
+
+// cronShorthands are the @ expressions Cron understands, and what they stand for.
+var cronShorthands = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+}
+
+// Cron is a parsed five-field cron expression: minute, hour, day of month, month and day of week.
+//
+// Every field takes *, numbers, ranges (1-5), lists (1,15) and steps (*/15, 9-17/2). The day of month
+// also takes L, the last day of the month, and the day of week takes 0-7, with both 0 and 7 meaning
+// Sunday. As in cron, when both day fields are restricted a day matching either of them fires.
+// @hourly, @daily, @weekly and @monthly are shorthands.
+type Cron struct {
+	expr string
+
+	// Each field is a bitset of the values it matches.
+	minutes, hours, days, months, weekdays uint64
+	// lastDay is set when the day of month includes L.
+	lastDay bool
+	// anyDay and anyWeekday are set when the field is *, which changes how the two day fields
+	// combine.
+	anyDay, anyWeekday bool
+}
+
+type cronField struct {
+	name     string
+	min, max int
+}
+
+var (
+	cronMinute  = cronField{name: "minute", min: 0, max: 59}
+	cronHour    = cronField{name: "hour", min: 0, max: 23}
+	cronDay     = cronField{name: "day of month", min: 1, max: 31}
+	cronMonth   = cronField{name: "month", min: 1, max: 12}
+	cronWeekday = cronField{name: "day of week", min: 0, max: 7}
+)
+
+// ParseCron parses a cron expression. An expression that can never fire, such as "0 0 30 2 *", is an
+// error too.
+func ParseCron(expr string) (Cron, error) {
+	expr = strings.TrimSpace(expr)
+	res := Cron{expr: expr}
+
+	spec := expr
+	if strings.HasPrefix(spec, "@") {
+		var ok bool
+		if spec, ok = cronShorthands[spec]; !ok {
+			return Cron{}, fmt.Errorf("unknown cron shorthand %q", expr)
+		}
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 { //nolint:mnd // minute, hour, day of month, month, day of week
+		return Cron{}, fmt.Errorf("cron expression %q must have 5 fields, has %d", expr, len(fields))
+	}
+
+	var err error
+	if res.minutes, err = parseCronField(fields[0], cronMinute); err != nil {
+		return Cron{}, err
+	}
+	if res.hours, err = parseCronField(fields[1], cronHour); err != nil {
+		return Cron{}, err
+	}
+	days := fields[2]
+	if rest, ok := cutCronLastDay(days); ok {
+		res.lastDay = true
+		days = rest
+	}
+	if days != "" {
+		if res.days, err = parseCronField(days, cronDay); err != nil {
+			return Cron{}, err
+		}
+	}
+	if res.months, err = parseCronField(fields[3], cronMonth); err != nil {
+		return Cron{}, err
+	}
+	if res.weekdays, err = parseCronField(fields[4], cronWeekday); err != nil {
+		return Cron{}, err
+	}
+	// 7 is another name for Sunday.
+	if res.weekdays&(1<<7) != 0 {
+		res.weekdays = res.weekdays&^(1<<7) | 1
+	}
+	res.anyDay = fields[2] == "*"
+	res.anyWeekday = fields[4] == "*"
+
+	probe := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC) //nolint:mnd // any start will do
+	if res.Next(probe).IsZero() {
+		return Cron{}, fmt.Errorf("cron expression %q never fires", expr)
+	}
+	return res, nil
+}
+
+// MustParseCron is ParseCron for expressions that are known to be valid.
+func MustParseCron(expr string) Cron {
+	c, err := ParseCron(expr)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// cutCronLastDay removes L from a day of month list, reporting whether it was there.
+func cutCronLastDay(field string) (string, bool) {
+	parts := strings.Split(field, ",")
+	kept := parts[:0]
+	found := false
+	for _, p := range parts {
+		if p == "L" {
+			found = true
+			continue
+		}
+		kept = append(kept, p)
+	}
+	return strings.Join(kept, ","), found
+}
+
+// parseCronField parses a comma-separated list of *, values, ranges and steps into a bitset.
+func parseCronField(field string, f cronField) (uint64, error) {
+	var res uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepStr); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step %q in %s %q", stepStr, f.name, field)
+			}
+		}
+
+		lo, hi := f.min, f.max
+		switch {
+		case rng == "*":
+		case strings.Contains(rng, "-"):
+			loStr, hiStr, _ := strings.Cut(rng, "-")
+			var err error
+			if lo, err = parseCronValue(loStr, f); err != nil {
+				return 0, err
+			}
+			if hi, err = parseCronValue(hiStr, f); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("invalid range %q in %s", rng, f.name)
+			}
+		default:
+			v, err := parseCronValue(rng, f)
+			if err != nil {
+				return 0, err
+			}
+			lo = v
+			// "5/15" means from 5 on, every 15.
+			if !hasStep {
+				hi = v
+			}
+		}
+
+		for v := lo; v <= hi; v += step {
+			res |= 1 << v
+		}
+	}
+	return res, nil
+}
+
+func parseCronValue(s string, f cronField) (int, error) {
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q", f.name, s)
+	}
+	if v < f.min || v > f.max {
+		return 0, fmt.Errorf("%s %d must be in range %d-%d", f.name, v, f.min, f.max)
+	}
+	return v, nil
+}
+
+// Next returns the first time the expression fires strictly after after, in after's location, or the
+// zero time if it never does.
+func (c Cron) Next(after time.Time) time.Time {
+	loc := after.Location()
+	t := time.Date(after.Year(), after.Month(), after.Day(), after.Hour(), after.Minute(), 0, 0, loc).Add(time.Minute)
+	limit := after.Year() + cronSearchYears
+
+	for t.Year() <= limit {
+		if c.months&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if c.hours&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if c.minutes&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (c Cron) dayMatches(t time.Time) bool {
+	day := c.days&(1<<uint(t.Day())) != 0 ||
+		c.lastDay && t.AddDate(0, 0, 1).Day() == 1
+	weekday := c.weekdays&(1<<uint(t.Weekday())) != 0
+
+	switch {
+	case c.anyDay && c.anyWeekday:
+		return true
+	case c.anyDay:
+		return weekday
+	case c.anyWeekday:
+		return day
+	default:
+		return day || weekday
+	}
+}
+
+func (c Cron) String() string {
+	return c.expr
+}
@@ -0,0 +1,85 @@
+package config_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/config"
+)
+
+func TestCronNext(t *testing.T) {
+	// A Monday.
+	from := time.Date(2026, time.March, 2, 10, 7, 30, 0, time.UTC)
+	at := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2026, month, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		expr string
+		want []time.Time
+	}{
+		{expr: "*/15 * * * *", want: []time.Time{at(3, 2, 10, 15), at(3, 2, 10, 30), at(3, 2, 10, 45)}},
+		{expr: "0 9-17/4 * * *", want: []time.Time{at(3, 2, 13, 0), at(3, 2, 17, 0), at(3, 3, 9, 0)}},
+		{expr: "30 8,20 * * 1-5", want: []time.Time{at(3, 2, 20, 30), at(3, 3, 8, 30), at(3, 3, 20, 30)}},
+		{expr: "0 19 * * 7", want: []time.Time{at(3, 8, 19, 0), at(3, 15, 19, 0)}},
+		{expr: "0 19 L * *", want: []time.Time{at(3, 31, 19, 0), at(4, 30, 19, 0)}},
+		// Both day fields restricted: the 15th or any Sunday.
+		{expr: "0 0 15 * 0", want: []time.Time{at(3, 8, 0, 0), at(3, 15, 0, 0), at(3, 22, 0, 0)}},
+		{expr: "@hourly", want: []time.Time{at(3, 2, 11, 0), at(3, 2, 12, 0)}},
+		{expr: "@monthly", want: []time.Time{at(4, 1, 0, 0), at(5, 1, 0, 0)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			c, err := config.ParseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseCron: %v", err)
+			}
+			next := from
+			for _, want := range tt.want {
+				if next = c.Next(next); !next.Equal(want) {
+					t.Fatalf("Next = %s, want %s", next, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseCronRejects(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"@yearly",
+		"0 0 30 2 *",
+	} {
+		if _, err := config.ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) succeeded, want an error", expr)
+		}
+	}
+}
+
+func TestTimeSlotNextStart(t *testing.T) {
+	slot := config.TimeSlot{From: 18 * time.Hour, To: 2 * time.Hour}
+
+	tests := []struct {
+		after time.Time
+		want  time.Time
+	}{
+		{after: time.Date(2026, time.March, 2, 10, 0, 0, 0, time.UTC), want: time.Date(2026, time.March, 2, 18, 0, 0, 0, time.UTC)},
+		{after: time.Date(2026, time.March, 2, 18, 0, 0, 0, time.UTC), want: time.Date(2026, time.March, 3, 18, 0, 0, 0, time.UTC)},
+		{after: time.Date(2026, time.March, 2, 23, 0, 0, 0, time.UTC), want: time.Date(2026, time.March, 3, 18, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got := slot.NextStart(tt.after); !got.Equal(tt.want) {
+			t.Errorf("NextStart(%s) = %s, want %s", tt.after, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TimeSlot is a daily window, as offsets from midnight. A slot whose To is not after its From wraps
+// past midnight.
+type TimeSlot struct {
+	From time.Duration
+	To   time.Duration
+}
+
+// ParseTimeSlots parses "HH:MM-HH:MM" windows, such as "08:00-09:00" or "22:30-01:00".
+func ParseTimeSlots(raw []string) ([]TimeSlot, error) {
+	res := make([]TimeSlot, 0, len(raw))
+	for _, r := range raw {
+		from, to, ok := strings.Cut(strings.TrimSpace(r), "-")
+		if !ok {
+			return nil, fmt.Errorf("time slot %q must look like HH:MM-HH:MM", r)
+		}
+		var (
+			slot TimeSlot
+			err  error
+		)
+		if slot.From, err = parseClock(from); err != nil {
+			return nil, fmt.Errorf("time slot %q: %w", r, err)
+		}
+		if slot.To, err = parseClock(to); err != nil {
+			return nil, fmt.Errorf("time slot %q: %w", r, err)
+		}
+		if slot.From == slot.To {
+			return nil, fmt.Errorf("time slot %q is empty", r)
+		}
+		res = append(res, slot)
+	}
+	return res, nil
+}
+
+// parseClock reads "HH:MM" as an offset from midnight.
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("parse time %q: %w", s, err)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// Contains reports whether t's wall clock falls within the slot.
+func (s TimeSlot) Contains(t time.Time) bool {
+	clock := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute +
+		time.Duration(t.Second())*time.Second
+	if s.From < s.To {
+		return clock >= s.From && clock < s.To
+	}
+	return clock >= s.From || clock < s.To
+}
+
+// NextStart returns the first time strictly after after, in after's location, at which the slot opens.
+func (s TimeSlot) NextStart(after time.Time) time.Time {
+	day := time.Date(after.Year(), after.Month(), after.Day(), 0, 0, 0, 0, after.Location())
+	start := clockOn(day, s.From)
+	if !start.After(after) {
+		start = clockOn(day.AddDate(0, 0, 1), s.From)
+	}
+	return start
+}
+
+// clockOn returns the wall clock offset on day, which is midnight. It goes by the clock rather than
+// by elapsed time, so a daylight saving change that day does not shift it.
+func clockOn(day time.Time, offset time.Duration) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), 0, int(offset/time.Minute), 0, 0, day.Location())
+}
@@ -20,3 +20,15 @@ func MustChatIDFromContext(ctx context.Context) int64 {
 	}
 	return chatID
 }
+
+type sessionIDKey struct{}
+
+// WithSessionID keeps the ID of the session the request was authenticated with.
+func WithSessionID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, sessionIDKey{}, id)
+}
+
+func SessionIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(sessionIDKey{}).(string)
+	return id, ok
+}
@@ -0,0 +1,98 @@
+package dal
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// logAnswer appends a graded answer to answer_log. It has to run after the streak update it
+// belongs to, since it records the streak the answer left the word with.
+func logAnswer(ctx context.Context, e execer, chatID int64, word string, guessed bool) error {
+	query := qb.Insert("answer_log").
+		Columns("chat_id", "word", "guessed", "streak_after").
+		Select(squirrel.Select("chat_id", "word").
+			Column("?", guessed).
+			Column("guessed_streak").
+			From("word_translations").
+			Where("chat_id = ? AND word = ?", chatID, word))
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return fmt.Errorf("build insert query: %w", err)
+	}
+
+	if _, err = e.ExecContext(ctx, sql, args...); err != nil {
+		return fmt.Errorf("log answer: %w", err)
+	}
+	return nil
+}
+
+// GetAnswerSummary reports the up to limit most missed words between from and to inclusive, and every
+// word that graduated in that time. Days are compared in local time, the same way statistics books
+// them.
+func (r *SQLiteRepository) GetAnswerSummary(ctx context.Context, chatID int64, from, to time.Time, limit int) (*AnswerSummary, error) {
+	inPeriod := squirrel.Expr("date(answered_at, 'localtime') BETWEEN ? AND ?", from.Format(dateLayout), to.Format(dateLayout))
+	res := &AnswerSummary{}
+
+	hardest := qb.Select("word", "COUNT(*) AS misses").
+		From("answer_log").
+		Where(squirrel.Eq{"chat_id": chatID, "guessed": false}).
+		Where(inPeriod).
+		GroupBy("word").
+		OrderBy("misses DESC", "word").
+		Limit(uint64(limit)) //nolint:gosec // limit is a small constant chosen by the caller
+
+	sqlQuery, args, err := hardest.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build hardest words query: %w", err)
+	}
+	rows, err := r.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("get hardest words: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var wm WordMisses
+		if err = rows.Scan(&wm.Word, &wm.Misses); err != nil {
+			return nil, fmt.Errorf("scan hardest word: %w", err)
+		}
+		res.Hardest = append(res.Hardest, wm)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate hardest words: %w", err)
+	}
+
+	// A guess that lands exactly on the limit is the one that graduated the word; anything above it
+	// is a review of a word that was already learned.
+	graduated := qb.Select("DISTINCT word").
+		From("answer_log").
+		Where(squirrel.Eq{"chat_id": chatID, "guessed": true}).
+		Where("streak_after = " + streakLimitOfWord("answer_log.chat_id", "answer_log.word", r.streakLimit)).
+		Where(inPeriod).
+		OrderBy("word")
+
+	sqlQuery, args, err = graduated.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build graduated words query: %w", err)
+	}
+	gRows, err := r.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("get graduated words: %w", err)
+	}
+	defer gRows.Close()
+	for gRows.Next() {
+		var word string
+		if err = gRows.Scan(&word); err != nil {
+			return nil, fmt.Errorf("scan graduated word: %w", err)
+		}
+		res.Graduated = append(res.Graduated, word)
+	}
+	if err = gRows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate graduated words: %w", err)
+	}
+
+	return res, nil
+}
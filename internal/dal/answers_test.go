@@ -0,0 +1,84 @@
+package dal_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+)
+
+func TestGetAnswerSummary(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+	r.AddWord("graduate", 14)
+	r.AddWord("reviewed", 20)
+	r.AddWord("hard", 3)
+	r.AddWord("harder", 3)
+
+	answers := []struct {
+		word    string
+		guessed bool
+	}{
+		{"graduate", true}, // 14 -> 15: graduates
+		{"reviewed", true}, // already learned, a review only
+		{"hard", false},
+		{"harder", false},
+		{"harder", false},
+	}
+	for _, a := range answers {
+		var err error
+		if a.guessed {
+			err = r.Guess(dal.TestChatID, a.word)
+		} else {
+			_, err = r.Miss(dal.TestChatID, a.word)
+		}
+		if err != nil {
+			t.Fatalf("register %q: %v", a.word, err)
+		}
+	}
+
+	now := time.Now()
+	got, err := r.GetAnswerSummary(ctx, dal.TestChatID, now, now, 5)
+	if err != nil {
+		t.Fatalf("GetAnswerSummary: %v", err)
+	}
+
+	wantHardest := []dal.WordMisses{{Word: "harder", Misses: 2}, {Word: "hard", Misses: 1}}
+	if !slices.Equal(got.Hardest, wantHardest) {
+		t.Errorf("Hardest = %v, want %v", got.Hardest, wantHardest)
+	}
+	if want := []string{"graduate"}; !slices.Equal(got.Graduated, want) {
+		t.Errorf("Graduated = %v, want %v", got.Graduated, want)
+	}
+
+	yesterday := now.AddDate(0, 0, -1)
+	got, err = r.GetAnswerSummary(ctx, dal.TestChatID, yesterday, yesterday, 5)
+	if err != nil {
+		t.Fatalf("GetAnswerSummary: %v", err)
+	}
+	if len(got.Hardest) != 0 || len(got.Graduated) != 0 {
+		t.Errorf("summary outside the period = %+v, want empty", got)
+	}
+}
+
+// The range used to be bound as timestamps, which sort after the bare date stored for the first day
+// and so dropped it.
+func TestGetStatsRangeIncludesFirstDay(t *testing.T) {
+	r := dal.NewTestRepo(t)
+	from := time.Date(2026, 3, 9, 15, 30, 0, 0, time.UTC)
+	r.SeedDay(from, 4, 0)
+	r.SeedDay(from.AddDate(0, 0, 1), 6, 0)
+
+	got, err := r.GetStatsRange(context.Background(), dal.TestChatID, from, from.AddDate(0, 0, 6))
+	if err != nil {
+		t.Fatalf("GetStatsRange: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("len = %d, want 2", len(got))
+	}
+	if got[0].WordsGuessed != 4 {
+		t.Errorf("first day guessed = %d, want 4", got[0].WordsGuessed)
+	}
+}
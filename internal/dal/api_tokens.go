@@ -0,0 +1,163 @@
+package dal
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+)
+
+const (
+	// apiTokenPrefix makes a personal access token easy to recognize, say by a secret scanner.
+	apiTokenPrefix = "elb_"
+	// apiTokenBytes is how much randomness goes into a personal access token.
+	apiTokenBytes = 32
+)
+
+//nolint:gochecknoglobals // a slice cannot be a constant
+var apiTokenColumns = []string{"id", "chat_id", "name", "scopes", "created_at", "expires_at", "last_used_at"}
+
+// CreateAPIToken creates a personal access token and returns it along with the token itself, which is
+// not stored and cannot be looked up again. A zero expiresAt makes a token that never expires. A name
+// the chat already gave a token is ErrAlreadyExists.
+func (r *SQLiteRepository) CreateAPIToken(
+	ctx context.Context, chatID int64, name string, scopes []TokenScope, expiresAt time.Time,
+) (*APIToken, string, error) {
+	b := make([]byte, apiTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return nil, "", fmt.Errorf("read random bytes: %w", err)
+	}
+	token := apiTokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+
+	expires := sql.NullInt64{Int64: expiresAt.Unix(), Valid: !expiresAt.IsZero()}
+	sqlQuery, args, err := qb.Insert("api_tokens").
+		Columns("chat_id", "name", "token_hash", "scopes", "expires_at").
+		Values(chatID, name, hashToken(token), joinScopes(scopes), squirrel.Expr("datetime(?, 'unixepoch')", expires)).
+		Suffix("ON CONFLICT (chat_id, name) DO NOTHING").
+		Suffix("RETURNING " + strings.Join(apiTokenColumns, ", ")).
+		ToSql()
+	if err != nil {
+		return nil, "", fmt.Errorf("build insert query: %w", err)
+	}
+
+	res, err := scanAPIToken(r.db.QueryRowContext(ctx, sqlQuery, args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, "", ErrAlreadyExists
+		}
+		return nil, "", fmt.Errorf("create api token: %w", err)
+	}
+	return res, token, nil
+}
+
+// AuthenticateAPIToken looks up an unexpired personal access token and marks it as used now,
+// reporting ErrNotFound for an unknown, revoked or expired token.
+func (r *SQLiteRepository) AuthenticateAPIToken(ctx context.Context, token string) (*APIToken, error) {
+	sqlQuery, args, err := qb.Update("api_tokens").
+		Set("last_used_at", squirrel.Expr("CURRENT_TIMESTAMP")).
+		Where(squirrel.Eq{"token_hash": hashToken(token)}).
+		Where("(expires_at IS NULL OR expires_at > datetime('now'))").
+		Suffix("RETURNING " + strings.Join(apiTokenColumns, ", ")).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build update query: %w", err)
+	}
+
+	res, err := scanAPIToken(r.db.QueryRowContext(ctx, sqlQuery, args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("authenticate api token: %w", err)
+	}
+	return res, nil
+}
+
+// GetAPITokens lists the chat's personal access tokens, expired ones included, newest first.
+func (r *SQLiteRepository) GetAPITokens(ctx context.Context, chatID int64) ([]APIToken, error) {
+	sqlQuery, args, err := qb.Select(apiTokenColumns...).
+		From("api_tokens").
+		Where(squirrel.Eq{"chat_id": chatID}).
+		OrderBy("id DESC").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build select query: %w", err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("get api tokens: %w", err)
+	}
+	defer rows.Close()
+
+	res := make([]APIToken, 0)
+	for rows.Next() {
+		t, err := scanAPIToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan api token: %w", err)
+		}
+		res = append(res, *t)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate api tokens: %w", err)
+	}
+	return res, nil
+}
+
+// RevokeAPIToken deletes a personal access token of the chat, reporting ErrNotFound if it has none
+// with this ID.
+func (r *SQLiteRepository) RevokeAPIToken(ctx context.Context, chatID, id int64) error {
+	sqlQuery, args, err := qb.Delete("api_tokens").
+		Where(squirrel.Eq{"chat_id": chatID, "id": id}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build delete query: %w", err)
+	}
+	res, err := r.db.ExecContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return fmt.Errorf("revoke api token: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected: %w", err)
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAPIToken(row rowScanner) (*APIToken, error) {
+	var (
+		t                   APIToken
+		scopes              string
+		expiresAt, lastUsed sql.NullTime
+	)
+	if err := row.Scan(&t.ID, &t.ChatID, &t.Name, &scopes, &t.CreatedAt, &expiresAt, &lastUsed); err != nil {
+		return nil, err //nolint:wrapcheck // wrapped by the callers, which tell sql.ErrNoRows apart
+	}
+	for _, s := range strings.Split(scopes, ",") {
+		t.Scopes = append(t.Scopes, TokenScope(s))
+	}
+	t.ExpiresAt = expiresAt.Time
+	t.LastUsedAt = lastUsed.Time
+	return &t, nil
+}
+
+func joinScopes(scopes []TokenScope) string {
+	res := make([]string, len(scopes))
+	for i, s := range scopes {
+		res[i] = string(s)
+	}
+	return strings.Join(res, ",")
+}
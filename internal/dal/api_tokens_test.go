@@ -0,0 +1,66 @@
+package dal_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+)
+
+func TestAPITokens(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+	scopes := []dal.TokenScope{dal.ScopeWordsWrite, dal.ScopeStatsRead}
+
+	created, token, err := r.CreateAPIToken(ctx, dal.TestChatID, "upload", scopes, time.Time{})
+	if err != nil {
+		t.Fatalf("CreateAPIToken: %v", err)
+	}
+	if !strings.HasPrefix(token, "elb_") || !slices.Equal(created.Scopes, scopes) || !created.ExpiresAt.IsZero() {
+		t.Fatalf("created %+v with token %q, want an elb_ token with both scopes that never expires", created, token)
+	}
+	if _, _, err = r.CreateAPIToken(ctx, dal.TestChatID, "upload", scopes, time.Time{}); !errors.Is(err, dal.ErrAlreadyExists) {
+		t.Errorf("second token named upload = %v, want ErrAlreadyExists", err)
+	}
+
+	authenticated, err := r.AuthenticateAPIToken(ctx, token)
+	if err != nil {
+		t.Fatalf("AuthenticateAPIToken: %v", err)
+	}
+	if authenticated.ID != created.ID || authenticated.ChatID != dal.TestChatID || authenticated.LastUsedAt.IsZero() {
+		t.Errorf("authenticated %+v, want the upload token marked as used", authenticated)
+	}
+	if _, err = r.AuthenticateAPIToken(ctx, token+"x"); !errors.Is(err, dal.ErrNotFound) {
+		t.Errorf("AuthenticateAPIToken of an unknown token = %v, want ErrNotFound", err)
+	}
+
+	_, expired, err := r.CreateAPIToken(ctx, dal.TestChatID, "old", []dal.TokenScope{dal.ScopeRead}, time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("CreateAPIToken(old): %v", err)
+	}
+	if _, err = r.AuthenticateAPIToken(ctx, expired); !errors.Is(err, dal.ErrNotFound) {
+		t.Errorf("AuthenticateAPIToken of an expired token = %v, want ErrNotFound", err)
+	}
+
+	tokens, err := r.GetAPITokens(ctx, dal.TestChatID)
+	if err != nil {
+		t.Fatalf("GetAPITokens: %v", err)
+	}
+	if len(tokens) != 2 || tokens[0].Name != "old" || tokens[1].Name != "upload" {
+		t.Fatalf("tokens = %+v, want old and upload, newest first", tokens)
+	}
+
+	if err = r.RevokeAPIToken(ctx, dal.TestChatID+1, created.ID); !errors.Is(err, dal.ErrNotFound) {
+		t.Errorf("RevokeAPIToken of another chat = %v, want ErrNotFound", err)
+	}
+	if err = r.RevokeAPIToken(ctx, dal.TestChatID, created.ID); err != nil {
+		t.Fatalf("RevokeAPIToken: %v", err)
+	}
+	if _, err = r.AuthenticateAPIToken(ctx, token); !errors.Is(err, dal.ErrNotFound) {
+		t.Errorf("AuthenticateAPIToken of a revoked token = %v, want ErrNotFound", err)
+	}
+}
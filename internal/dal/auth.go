@@ -122,6 +122,7 @@ func (r *SQLiteRepository) cleanupAuthConfirmations(ctx context.Context) {
 			if err != nil {
 				r.log.ErrorContext(ctx, "failed to cleanup auth confirmations", "error", err)
 			}
+			r.cleanupSessions(ctx)
 		}
 	}
 }
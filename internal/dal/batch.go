@@ -0,0 +1,269 @@
+package dal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// The batch is mostly changed implicitly: misses, ResetStreak, ResolveWordConflict and new
+// words request membership, and RefillLearningBatch evicts and tops it up. The methods here let a chat
+// change it by hand on top of that, through the same admission rules.
+
+// GetLearningBatch returns the chat's batch, alphabetically, and the queue behind it, in the order it
+// is drained.
+func (r *SQLiteRepository) GetLearningBatch(ctx context.Context, chatID int64) (*LearningBatch, error) {
+	size, custom, err := batchSizeOf(ctx, r.db, chatID, r.batchSize)
+	if err != nil {
+		return nil, fmt.Errorf("get batch size: %w", err)
+	}
+	res := &LearningBatch{Size: size, CustomSize: custom}
+	if res.Strategy, res.RefillTag, err = refillStrategyOf(ctx, r.db, chatID); err != nil {
+		return nil, fmt.Errorf("get refill strategy: %w", err)
+	}
+
+	batched := qb.Select(wordTranslationColumns()...).
+		From("word_translations wt").
+		Join("learning_batches lb ON lb.chat_id = wt.chat_id AND lb.word = wt.word").
+		Where(squirrel.Eq{"wt.chat_id": chatID}).
+		OrderBy("wt.word")
+	if res.Batched, err = findWords(ctx, r.db, batched); err != nil {
+		return nil, fmt.Errorf("find batched words: %w", err)
+	}
+
+	queued := qb.Select(wordTranslationColumns()...).
+		From("word_translations wt").
+		Join("learning_batch_queue lbq ON lbq.chat_id = wt.chat_id AND lbq.word = wt.word").
+		Where(squirrel.Eq{"wt.chat_id": chatID}).
+		OrderBy("lbq.queued_seq")
+	if res.Queued, err = findWords(ctx, r.db, queued); err != nil {
+		return nil, fmt.Errorf("find queued words: %w", err)
+	}
+	return res, nil
+}
+
+// AddToLearningBatch requests batch membership for words in the order given, exactly as a miss would:
+// into the batch while there is room, into the queue behind it after that. It returns how many of
+// them were admitted or queued. Words that do not exist, are learned, suspended, or already batched
+// or queued are skipped; a learned word would only be evicted again by the next refill, see
+// ResetStreak for those.
+func (r *SQLiteRepository) AddToLearningBatch(ctx context.Context, chatID int64, words []string) (int, error) {
+	var added int
+	err := r.inTx(ctx, func(e execer) error {
+		sqlQuery, args, err := qb.Select("wt.word").
+			From("word_translations wt").
+			Where(squirrel.Eq{"wt.chat_id": chatID, "wt.word": words}).
+			Where("wt.guessed_streak < " + streakLimitOf("wt", r.streakLimit)).
+			ToSql()
+		if err != nil {
+			return fmt.Errorf("build select query: %w", err)
+		}
+		eligible, err := queryStrings(ctx, e, sqlQuery, args...)
+		if err != nil {
+			return fmt.Errorf("find words: %w", err)
+		}
+
+		// Requested in the order given, so that the words that overflow are queued in that order.
+		for _, word := range words {
+			if !slices.Contains(eligible, word) {
+				continue
+			}
+			before, err := wordInBatchOrQueue(ctx, e, chatID, word)
+			if err != nil {
+				return err
+			}
+			if before {
+				continue
+			}
+			if err = requestBatchMembership(ctx, e, chatID, word, r.batchSize); err != nil {
+				return fmt.Errorf("request batch membership: %w", err)
+			}
+			after, err := wordInBatchOrQueue(ctx, e, chatID, word)
+			if err != nil {
+				return err
+			}
+			if after {
+				added++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return added, nil
+}
+
+// RemoveFromLearningBatch takes words out of the batch and the queue behind it, returning how many it
+// found there. They keep their progress, and the next refill may draw them again like any other word
+// that is still being learned; suspending a word keeps it out for good.
+func (r *SQLiteRepository) RemoveFromLearningBatch(ctx context.Context, chatID int64, words []string) (int, error) {
+	var removed int
+	err := r.inTx(ctx, func(e execer) error {
+		for _, table := range []string{"learning_batches", "learning_batch_queue"} {
+			sqlQuery, args, err := qb.Delete(table).Where(squirrel.Eq{"chat_id": chatID, "word": words}).ToSql()
+			if err != nil {
+				return fmt.Errorf("build delete query: %w", err)
+			}
+			res, err := e.ExecContext(ctx, sqlQuery, args...)
+			if err != nil {
+				return fmt.Errorf("delete from %s: %w", table, err)
+			}
+			affected, err := res.RowsAffected()
+			if err != nil {
+				return fmt.Errorf("get rows affected: %w", err)
+			}
+			removed += int(affected)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return removed, nil
+}
+
+// ReorderLearningBatchQueue moves words to the front of the queue, in the order given. The rest of
+// the queue follows in its current order, and words that are not queued are ignored. Words queued
+// later still go to the back.
+func (r *SQLiteRepository) ReorderLearningBatchQueue(ctx context.Context, chatID int64, words []string) error {
+	return r.inTx(ctx, func(e execer) error {
+		sqlQuery, args, err := qb.Select("word").
+			From("learning_batch_queue").
+			Where(squirrel.Eq{"chat_id": chatID}).
+			OrderBy("queued_seq").
+			ToSql()
+		if err != nil {
+			return fmt.Errorf("build select query: %w", err)
+		}
+		queued, err := queryStrings(ctx, e, sqlQuery, args...)
+		if err != nil {
+			return fmt.Errorf("find queued words: %w", err)
+		}
+
+		placed := make(map[string]bool, len(queued))
+		for _, w := range queued {
+			placed[w] = false
+		}
+		order := make([]string, 0, len(queued))
+		for _, list := range [][]string{words, queued} {
+			for _, w := range list {
+				if done, ok := placed[w]; ok && !done {
+					order = append(order, w)
+					placed[w] = true
+				}
+			}
+		}
+
+		for i, w := range order {
+			if sqlQuery, args, err = qb.Update("learning_batch_queue").
+				Set("queued_seq", i+1).
+				Where(squirrel.Eq{"chat_id": chatID, "word": w}).
+				ToSql(); err != nil {
+				return fmt.Errorf("build update query: %w", err)
+			}
+			if _, err = e.ExecContext(ctx, sqlQuery, args...); err != nil {
+				return fmt.Errorf("reorder queue: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// SetBatchSize changes how many words the chat's batch is topped up to; 0 goes back to the
+// configured size. Shrinking the batch evicts nothing: the refill simply adds nothing until words
+// graduate out of it.
+func (r *SQLiteRepository) SetBatchSize(ctx context.Context, chatID int64, size int) error {
+	if size < 0 {
+		return fmt.Errorf("batch size %d must not be negative", size)
+	}
+	var value any
+	if size > 0 {
+		value = size
+	}
+
+	sqlQuery, args, err := qb.Insert("chat_settings").
+		Columns("chat_id", "batch_size").
+		Values(chatID, value).
+		Suffix("ON CONFLICT (chat_id) DO UPDATE SET batch_size = EXCLUDED.batch_size").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build query: %w", err)
+	}
+	if _, err = r.db.ExecContext(ctx, sqlQuery, args...); err != nil {
+		return fmt.Errorf("set batch size: %w", err)
+	}
+	return nil
+}
+
+// batchSizeOf returns the chat's batch size and whether it is the chat's own rather than
+// defaultSize.
+func batchSizeOf(ctx context.Context, e execer, chatID int64, defaultSize int) (int, bool, error) {
+	sqlQuery, args, err := qb.Select("batch_size").
+		From("chat_settings").
+		Where(squirrel.Eq{"chat_id": chatID}).
+		ToSql()
+	if err != nil {
+		return 0, false, fmt.Errorf("build query: %w", err)
+	}
+
+	var size sql.NullInt64
+	if err = e.QueryRowContext(ctx, sqlQuery, args...).Scan(&size); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, false, fmt.Errorf("get batch size: %w", err)
+	}
+	if !size.Valid {
+		return defaultSize, false, nil
+	}
+	return int(size.Int64), true, nil
+}
+
+func findWords(ctx context.Context, e execer, query squirrel.SelectBuilder) ([]WordTranslation, error) {
+	sqlQuery, args, err := query.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build select query: %w", err)
+	}
+
+	rows, err := e.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("find words: %w", err)
+	}
+	defer rows.Close()
+
+	var res []WordTranslation
+	for rows.Next() {
+		wt, err := hydrateWordTranslation(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, *wt)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate words: %w", err)
+	}
+	return res, nil
+}
+
+func queryStrings(ctx context.Context, e execer, sqlQuery string, args ...any) ([]string, error) {
+	rows, err := e.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+	defer rows.Close()
+
+	var res []string
+	for rows.Next() {
+		var s string
+		if err = rows.Scan(&s); err != nil {
+			return nil, fmt.Errorf("scan: %w", err)
+		}
+		res = append(res, s)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate: %w", err)
+	}
+	return res, nil
+}
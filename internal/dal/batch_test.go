@@ -0,0 +1,188 @@
+package dal_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+)
+
+func TestGetLearningBatch(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+	for _, w := range []string{"b", "a", "late", "early"} {
+		r.AddWord(w, 0)
+	}
+	r.SeedBatch("b", "a")
+	r.SeedQueue("early", "late")
+
+	got, err := r.GetLearningBatch(ctx, dal.TestChatID)
+	if err != nil {
+		t.Fatalf("GetLearningBatch: %v", err)
+	}
+	if got.Size != dal.TestBatchSize || got.CustomSize {
+		t.Errorf("size = %d (custom %t), want %d (default)", got.Size, got.CustomSize, dal.TestBatchSize)
+	}
+	if w := words(got.Batched); !slices.Equal(w, []string{"a", "b"}) {
+		t.Errorf("batched = %v, want [a b]", w)
+	}
+	if w := words(got.Queued); !slices.Equal(w, []string{"early", "late"}) {
+		t.Errorf("queued = %v, want [early late]", w)
+	}
+}
+
+func TestAddToLearningBatch(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+	r.SetDefaultBatchSize(2)
+	r.AddWord("batched", 0)
+	r.AddWord("fresh", 3)
+	r.AddWord("overflow", 0)
+	r.AddWord("learned", dal.TestStreakLimit)
+	r.AddWord("suspended", 0)
+	r.SeedBatch("batched")
+	if err := r.SetWordSuspension(ctx, dal.TestChatID, "suspended", true, time.Time{}); err != nil {
+		t.Fatalf("SetWordSuspension: %v", err)
+	}
+
+	added, err := r.AddToLearningBatch(ctx, dal.TestChatID,
+		[]string{"batched", "fresh", "overflow", "learned", "suspended", "missing"})
+	if err != nil {
+		t.Fatalf("AddToLearningBatch: %v", err)
+	}
+	if added != 2 {
+		t.Errorf("added = %d, want 2", added)
+	}
+	if got := r.BatchWords(); !slices.Equal(got, []string{"batched", "fresh"}) {
+		t.Errorf("batch = %v, want [batched fresh]", got)
+	}
+	if got := r.QueueWords(); !slices.Equal(got, []string{"overflow"}) {
+		t.Errorf("queue = %v, want [overflow]", got)
+	}
+	// Progress is left alone.
+	if got := r.StreakOf("fresh"); got != 3 {
+		t.Errorf("streak of fresh = %d, want 3", got)
+	}
+}
+
+func TestRemoveFromLearningBatch(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+	for _, w := range []string{"batched", "queued", "kept", "outside"} {
+		r.AddWord(w, 0)
+	}
+	r.SeedBatch("batched", "kept")
+	r.SeedQueue("queued")
+
+	removed, err := r.RemoveFromLearningBatch(ctx, dal.TestChatID, []string{"batched", "queued", "outside"})
+	if err != nil {
+		t.Fatalf("RemoveFromLearningBatch: %v", err)
+	}
+	if removed != 2 {
+		t.Errorf("removed = %d, want 2", removed)
+	}
+	if got := r.BatchWords(); !slices.Equal(got, []string{"kept"}) {
+		t.Errorf("batch = %v, want [kept]", got)
+	}
+	if got := r.QueueWords(); len(got) != 0 {
+		t.Errorf("queue = %v, want it empty", got)
+	}
+}
+
+func TestReorderLearningBatchQueue(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+	for _, w := range []string{"a", "b", "c", "d", "batched"} {
+		r.AddWord(w, 0)
+	}
+	r.SeedBatch("batched")
+	r.SeedQueue("a", "b", "c", "d")
+
+	if err := r.ReorderLearningBatchQueue(ctx, dal.TestChatID, []string{"c", "batched", "a", "c"}); err != nil {
+		t.Fatalf("ReorderLearningBatchQueue: %v", err)
+	}
+	if got := r.QueueWords(); !slices.Equal(got, []string{"c", "a", "b", "d"}) {
+		t.Errorf("queue = %v, want [c a b d]", got)
+	}
+
+	// A word queued afterwards still goes to the back.
+	r.SetDefaultBatchSize(1)
+	r.AddWord("e", 0)
+	if _, err := r.AddToLearningBatch(ctx, dal.TestChatID, []string{"e"}); err != nil {
+		t.Fatalf("AddToLearningBatch: %v", err)
+	}
+	if got := r.QueueWords(); !slices.Equal(got, []string{"c", "a", "b", "d", "e"}) {
+		t.Errorf("queue = %v, want [c a b d e]", got)
+	}
+}
+
+func TestSetBatchSizeOverridesDefault(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+	for _, w := range []string{"a", "b", "c", "d"} {
+		r.AddWord(w, 0)
+	}
+
+	if err := r.SQLiteRepository.SetBatchSize(ctx, dal.TestChatID, 2); err != nil {
+		t.Fatalf("SetBatchSize: %v", err)
+	}
+	report, err := r.RefillLearningBatch(ctx, dal.TestChatID)
+	if err != nil {
+		t.Fatalf("RefillLearningBatch: %v", err)
+	}
+	if added := report.Added(); added != 2 {
+		t.Fatalf("added = %d, want 2", added)
+	}
+	// The chat's size is a hard cap for admission too.
+	if _, err := r.Miss(dal.TestChatID, firstNotIn(r.BatchWords(), "a", "b", "c", "d")); err != nil {
+		t.Fatalf("Miss: %v", err)
+	}
+	if got := len(r.BatchWords()); got != 2 {
+		t.Errorf("batch holds %d words, want 2", got)
+	}
+	if got := len(r.QueueWords()); got != 1 {
+		t.Errorf("queue holds %d words, want 1", got)
+	}
+
+	got, err := r.GetLearningBatch(ctx, dal.TestChatID)
+	if err != nil {
+		t.Fatalf("GetLearningBatch: %v", err)
+	}
+	if got.Size != 2 || !got.CustomSize {
+		t.Errorf("size = %d (custom %t), want 2 (custom)", got.Size, got.CustomSize)
+	}
+
+	// 0 goes back to the configured size.
+	if err = r.SQLiteRepository.SetBatchSize(ctx, dal.TestChatID, 0); err != nil {
+		t.Fatalf("SetBatchSize(0): %v", err)
+	}
+	if got, err = r.GetLearningBatch(ctx, dal.TestChatID); err != nil {
+		t.Fatalf("GetLearningBatch: %v", err)
+	}
+	if got.Size != dal.TestBatchSize || got.CustomSize {
+		t.Errorf("size = %d (custom %t), want %d (default)", got.Size, got.CustomSize, dal.TestBatchSize)
+	}
+
+	if err = r.SQLiteRepository.SetBatchSize(ctx, dal.TestChatID, -1); err == nil {
+		t.Error("SetBatchSize(-1) succeeded, want an error")
+	}
+}
+
+func words(wts []dal.WordTranslation) []string {
+	res := make([]string, 0, len(wts))
+	for _, wt := range wts {
+		res = append(res, wt.Word)
+	}
+	return res
+}
+
+func firstNotIn(exclude []string, candidates ...string) string {
+	for _, c := range candidates {
+		if !slices.Contains(exclude, c) {
+			return c
+		}
+	}
+	return ""
+}
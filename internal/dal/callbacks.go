@@ -6,6 +6,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
+	"slices"
 	"time"
 
 	"github.com/Masterminds/squirrel"
@@ -26,8 +28,8 @@ func (r *SQLiteRepository) InsertCallback(ctx context.Context, data CallbackData
 	serializedData := string(jsonData)
 
 	query := qb.Insert("callback_data").
-		Columns("uuid", "chat_id", "data", "expires_at").
-		Values(squirrel.Expr("hex(randomblob(4))"), data.ChatID, serializedData, data.ExpiresAt).
+		Columns("uuid", "chat_id", "data", "expires_at", "created_at").
+		Values(squirrel.Expr("hex(randomblob(4))"), data.ChatID, serializedData, data.ExpiresAt, squirrel.Expr("CURRENT_TIMESTAMP")).
 		Suffix("ON CONFLICT (uuid, chat_id) DO UPDATE SET data = EXCLUDED.data").
 		Suffix("RETURNING uuid")
 
@@ -46,7 +48,7 @@ func (r *SQLiteRepository) InsertCallback(ctx context.Context, data CallbackData
 }
 
 func (r *SQLiteRepository) FindCallback(ctx context.Context, chatID int64, uuid string) (*CallbackData, error) {
-	query := qb.Select("data", "expires_at").
+	query := qb.Select("data", "expires_at", "COALESCE(answer, '')", "answered_at").
 		From("callback_data").
 		Where(squirrel.Eq{
 			"chat_id": chatID,
@@ -59,11 +61,13 @@ func (r *SQLiteRepository) FindCallback(ctx context.Context, chatID int64, uuid
 	}
 
 	var (
-		rawData   any
-		expiresAt time.Time
+		rawData    any
+		expiresAt  time.Time
+		answer     string
+		answeredAt sql.NullTime
 	)
 
-	err = r.db.QueryRowContext(ctx, sqlQuery, args...).Scan(&rawData, &expiresAt)
+	err = r.db.QueryRowContext(ctx, sqlQuery, args...).Scan(&rawData, &expiresAt, &answer, &answeredAt)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrNotFound
@@ -84,10 +88,155 @@ func (r *SQLiteRepository) FindCallback(ctx context.Context, chatID int64, uuid
 	res.ChatID = chatID
 	res.ID = uuid
 	res.ExpiresAt = expiresAt
+	res.Answer = CallbackAnswer(answer)
+	res.AnsweredAt = answeredAt.Time
 
 	return &res, nil
 }
 
+// GradeCallback claims the callback and applies the answer in one transaction, so that a double tap,
+// or Telegram delivering the same callback twice, grades the word only once: the second one finds the
+// callback claimed and changes nothing.
+func (r *SQLiteRepository) GradeCallback(ctx context.Context, chatID int64, uuid string, guessed bool) (bool, error) {
+	var leech bool
+	err := r.inTx(ctx, func(e execer) error {
+		word, err := claimCallback(ctx, e, chatID, uuid, guessed)
+		if err != nil {
+			return err
+		}
+		if err = recordAnswer(ctx, e, chatID, word, guessed, uuid); err != nil {
+			return err
+		}
+		if guessed {
+			return r.registerGuess(ctx, e, chatID, word)
+		}
+		leech, err = r.registerMiss(ctx, e, chatID, word)
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+	return leech, nil
+}
+
+// claimCallback marks the callback answered and returns its word. It reports ErrAlreadyAnswered when
+// it has been answered before.
+func claimCallback(ctx context.Context, e execer, chatID int64, uuid string, guessed bool) (string, error) {
+	answer := CallbackMissed
+	if guessed {
+		answer = CallbackGuessed
+	}
+
+	sqlQuery, args, err := qb.Update("callback_data").
+		Set("answered_at", squirrel.Expr("CURRENT_TIMESTAMP")).
+		Set("answer", answer).
+		Where(squirrel.Eq{"chat_id": chatID, "uuid": uuid, "answered_at": nil}).
+		Suffix("RETURNING json_extract(data, '$.word')").
+		ToSql()
+	if err != nil {
+		return "", fmt.Errorf("build update query: %w", err)
+	}
+
+	var word string
+	err = e.QueryRowContext(ctx, sqlQuery, args...).Scan(&word)
+	if err == nil {
+		return word, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("claim callback: %w", err)
+	}
+
+	if sqlQuery, args, err = qb.Select("COUNT(*)").
+		From("callback_data").
+		Where(squirrel.Eq{"chat_id": chatID, "uuid": uuid}).
+		ToSql(); err != nil {
+		return "", fmt.Errorf("build select query: %w", err)
+	}
+	var count int
+	if err = e.QueryRowContext(ctx, sqlQuery, args...).Scan(&count); err != nil {
+		return "", fmt.Errorf("find callback: %w", err)
+	}
+	if count == 0 {
+		return "", ErrNotFound
+	}
+	return "", ErrAlreadyAnswered
+}
+
+// reopenCallback lets the callback be graded again once its answer has been undone.
+func reopenCallback(ctx context.Context, e execer, chatID int64, uuid string) error {
+	sqlQuery, args, err := qb.Update("callback_data").
+		Set("answered_at", nil).
+		Set("answer", nil).
+		Where(squirrel.Eq{"chat_id": chatID, "uuid": uuid}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build update query: %w", err)
+	}
+	if _, err = e.ExecContext(ctx, sqlQuery, args...); err != nil {
+		return fmt.Errorf("reopen callback: %w", err)
+	}
+	return nil
+}
+
+// GetCheckPace reads how the chat kept up with the word checks sent to it since since. Every callback
+// stands for a check; quiz cards are left out since the chat asked for those. A check counts as
+// answered by the first answer to its word after it was sent, and as unanswered if the chat has not
+// answered anything at all since.
+func (r *SQLiteRepository) GetCheckPace(ctx context.Context, chatID int64, since time.Time) (*CheckPace, error) {
+	query := qb.Select().
+		Column("cd.created_at > COALESCE((SELECT MAX(answered_at) FROM answer_log WHERE chat_id = ?), '')", chatID).
+		Column("(julianday((SELECT MIN(al.answered_at) FROM answer_log al "+
+			"WHERE al.chat_id = cd.chat_id AND al.word = json_extract(cd.data, '$.word') AND al.answered_at >= cd.created_at)) "+
+			"- julianday(cd.created_at)) * 86400").
+		From("callback_data cd").
+		Where(squirrel.Eq{"cd.chat_id": chatID}).
+		Where("cd.created_at >= datetime(?, 'unixepoch')", since.Unix()).
+		Where("json_extract(cd.data, '$.quiz_id') IS NULL")
+
+	sqlQuery, args, err := query.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build select query: %w", err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("find checks: %w", err)
+	}
+	defer rows.Close()
+
+	var (
+		res    CheckPace
+		delays []time.Duration
+	)
+	for rows.Next() {
+		var (
+			unanswered bool
+			delay      sql.NullFloat64
+		)
+		if err = rows.Scan(&unanswered, &delay); err != nil {
+			return nil, fmt.Errorf("scan check: %w", err)
+		}
+		if unanswered {
+			res.Unanswered++
+		}
+		if delay.Valid {
+			// julianday is a float, so the difference is off by microseconds from the whole seconds
+			// the timestamps are stored in.
+			delays = append(delays, time.Duration(math.Round(delay.Float64))*time.Second)
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate checks: %w", err)
+	}
+
+	res.Answered = len(delays)
+	if len(delays) > 0 {
+		slices.Sort(delays)
+		res.AnswerDelay = delays[len(delays)/2]
+	}
+	return &res, nil
+}
+
 func (r *SQLiteRepository) cleanupCallbacksJob(ctx context.Context) {
 	for {
 		select {
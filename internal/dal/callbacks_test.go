@@ -0,0 +1,170 @@
+package dal_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+)
+
+func TestGetCheckPace(t *testing.T) {
+	r := dal.NewTestRepo(t)
+
+	// Answered after 1, 3 and 5 minutes.
+	r.SeedCheck("apple", 60*time.Minute)
+	r.SeedAnswer("apple", 59*time.Minute)
+	r.SeedCheck("pear", 50*time.Minute)
+	r.SeedAnswer("pear", 47*time.Minute)
+	r.SeedCheck("plum", 40*time.Minute)
+	r.SeedAnswer("plum", 35*time.Minute)
+	// Never answered, but the chat answered something after it was sent.
+	r.SeedCheck("fig", 38*time.Minute)
+	// Sent after the last answer.
+	r.SeedCheck("kiwi", 20*time.Minute)
+	r.SeedCheck("lime", 10*time.Minute)
+	// Too old to count.
+	r.SeedCheck("date", 3*time.Hour)
+
+	got, err := r.GetCheckPace(context.Background(), dal.TestChatID, time.Now().Add(-2*time.Hour))
+	if err != nil {
+		t.Fatalf("GetCheckPace: %v", err)
+	}
+
+	want := dal.CheckPace{Unanswered: 2, Answered: 3, AnswerDelay: 3 * time.Minute}
+	if *got != want {
+		t.Errorf("pace = %+v, want %+v", *got, want)
+	}
+}
+
+func TestGetCheckPaceIgnoresQuizCards(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+
+	if _, err := r.InsertCallback(ctx, dal.CallbackData{
+		ChatID:    dal.TestChatID,
+		Word:      "apple",
+		QuizID:    1,
+		ExpiresAt: time.Now().Add(time.Hour),
+	}); err != nil {
+		t.Fatalf("InsertCallback: %v", err)
+	}
+	if _, err := r.InsertCallback(ctx, dal.CallbackData{
+		ChatID:    dal.TestChatID,
+		Word:      "pear",
+		ExpiresAt: time.Now().Add(time.Hour),
+	}); err != nil {
+		t.Fatalf("InsertCallback: %v", err)
+	}
+
+	got, err := r.GetCheckPace(ctx, dal.TestChatID, time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("GetCheckPace: %v", err)
+	}
+	if got.Unanswered != 1 {
+		t.Errorf("Unanswered = %d, want only the word check", got.Unanswered)
+	}
+}
+
+func TestGradeCallbackOnce(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+	r.AddWord("apple", 3)
+
+	id, err := r.InsertCallback(ctx, dal.CallbackData{ChatID: dal.TestChatID, Word: "apple", ExpiresAt: time.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("InsertCallback: %v", err)
+	}
+
+	if _, err = r.GradeCallback(ctx, dal.TestChatID, id, true); err != nil {
+		t.Fatalf("GradeCallback: %v", err)
+	}
+	if _, err = r.GradeCallback(ctx, dal.TestChatID, id, true); !errors.Is(err, dal.ErrAlreadyAnswered) {
+		t.Fatalf("second GradeCallback = %v, want ErrAlreadyAnswered", err)
+	}
+	if _, err = r.GradeCallback(ctx, dal.TestChatID, id, false); !errors.Is(err, dal.ErrAlreadyAnswered) {
+		t.Fatalf("GradeCallback with the other answer = %v, want ErrAlreadyAnswered", err)
+	}
+	if got := r.StreakOf("apple"); got != 4 {
+		t.Errorf("streak = %d, want 4: graded once", got)
+	}
+	if guessed, missed, _ := r.TodayStats(); guessed != 1 || missed != 0 {
+		t.Errorf("today = %d guessed, %d missed; want 1, 0", guessed, missed)
+	}
+
+	data, err := r.FindCallback(ctx, dal.TestChatID, id)
+	if err != nil {
+		t.Fatalf("FindCallback: %v", err)
+	}
+	if data.Answer != dal.CallbackGuessed || data.AnsweredAt.IsZero() {
+		t.Errorf("callback answer = %q at %v, want it recorded", data.Answer, data.AnsweredAt)
+	}
+
+	if _, err = r.GradeCallback(ctx, dal.TestChatID, "unknown", true); !errors.Is(err, dal.ErrNotFound) {
+		t.Errorf("GradeCallback of an unknown callback = %v, want ErrNotFound", err)
+	}
+}
+
+func TestUndoReopensCallback(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+	r.AddWord("apple", 3)
+	since := time.Now().Add(-time.Minute)
+
+	id, err := r.InsertCallback(ctx, dal.CallbackData{ChatID: dal.TestChatID, Word: "apple", ExpiresAt: time.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("InsertCallback: %v", err)
+	}
+	if _, err = r.GradeCallback(ctx, dal.TestChatID, id, false); err != nil {
+		t.Fatalf("GradeCallback: %v", err)
+	}
+	if _, err = r.UndoLastAnswer(ctx, dal.TestChatID, id, since); err != nil {
+		t.Fatalf("UndoLastAnswer: %v", err)
+	}
+
+	data, err := r.FindCallback(ctx, dal.TestChatID, id)
+	if err != nil {
+		t.Fatalf("FindCallback: %v", err)
+	}
+	if data.Answer != "" || !data.AnsweredAt.IsZero() {
+		t.Errorf("callback answer = %q at %v, want it cleared", data.Answer, data.AnsweredAt)
+	}
+	if _, err = r.GradeCallback(ctx, dal.TestChatID, id, true); err != nil {
+		t.Fatalf("GradeCallback after undo: %v", err)
+	}
+	if got := r.StreakOf("apple"); got != 4 {
+		t.Errorf("streak = %d, want 4", got)
+	}
+}
+
+func TestUndoOnlyTheAnswerOnThatCard(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+	r.AddWord("apple", 3)
+	since := time.Now().Add(-time.Minute)
+
+	var ids []string
+	for _, guessed := range []bool{true, false} {
+		id, err := r.InsertCallback(ctx, dal.CallbackData{ChatID: dal.TestChatID, Word: "apple", ExpiresAt: time.Now().Add(time.Hour)})
+		if err != nil {
+			t.Fatalf("InsertCallback: %v", err)
+		}
+		if _, err = r.GradeCallback(ctx, dal.TestChatID, id, guessed); err != nil {
+			t.Fatalf("GradeCallback: %v", err)
+		}
+		ids = append(ids, id)
+	}
+
+	// Undo on the older card of the same word must not take back the miss given on the newer one.
+	if _, err := r.UndoLastAnswer(ctx, dal.TestChatID, ids[0], since); !errors.Is(err, dal.ErrNotFound) {
+		t.Errorf("undo on the older card = %v, want ErrNotFound", err)
+	}
+	undone, err := r.UndoLastAnswer(ctx, dal.TestChatID, ids[1], since)
+	if err != nil {
+		t.Fatalf("UndoLastAnswer: %v", err)
+	}
+	if undone.Guessed || undone.Streak != 4 {
+		t.Errorf("undone = %+v, want the miss taken back to streak 4", *undone)
+	}
+}
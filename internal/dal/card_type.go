@@ -0,0 +1,56 @@
+package dal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/Masterminds/squirrel"
+)
+
+//nolint:gochecknoglobals // a slice cannot be a constant
+var cardTypes = []CardType{CardMixed, CardClassic, CardCloze}
+
+// SetCardType changes the kind of card the chat's word checks are sent as.
+func (r *SQLiteRepository) SetCardType(ctx context.Context, chatID int64, cardType CardType) error {
+	if !slices.Contains(cardTypes, cardType) {
+		return fmt.Errorf("unknown card type: %q", cardType)
+	}
+
+	sqlQuery, args, err := qb.Insert("chat_settings").
+		Columns("chat_id", "card_type").
+		Values(chatID, cardType).
+		Suffix("ON CONFLICT (chat_id) DO UPDATE SET card_type = EXCLUDED.card_type").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build query: %w", err)
+	}
+	if _, err = r.db.ExecContext(ctx, sqlQuery, args...); err != nil {
+		return fmt.Errorf("set card type: %w", err)
+	}
+	return nil
+}
+
+// GetCardType returns the kind of card the chat's word checks are sent as, CardMixed unless it chose
+// another.
+func (r *SQLiteRepository) GetCardType(ctx context.Context, chatID int64) (CardType, error) {
+	sqlQuery, args, err := qb.Select("card_type").
+		From("chat_settings").
+		Where(squirrel.Eq{"chat_id": chatID}).
+		ToSql()
+	if err != nil {
+		return "", fmt.Errorf("build query: %w", err)
+	}
+
+	var cardType CardType
+	err = r.db.QueryRowContext(ctx, sqlQuery, args...).Scan(&cardType)
+	if errors.Is(err, sql.ErrNoRows) {
+		return CardMixed, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("get card type: %w", err)
+	}
+	return cardType, nil
+}
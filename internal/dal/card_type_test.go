@@ -0,0 +1,40 @@
+package dal_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+)
+
+func TestSetCardType(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+
+	got, err := r.GetCardType(ctx, dal.TestChatID)
+	if err != nil {
+		t.Fatalf("GetCardType: %v", err)
+	}
+	if got != dal.CardMixed {
+		t.Errorf("card type of a new chat = %q, want %q", got, dal.CardMixed)
+	}
+
+	if err = r.SetCardType(ctx, dal.TestChatID, "flashcard"); err == nil {
+		t.Error("SetCardType accepted an unknown card type")
+	}
+
+	// Another setting created the row already.
+	if err = r.SetTimezone(ctx, dal.TestChatID, "Europe/Kyiv"); err != nil {
+		t.Fatalf("SetTimezone: %v", err)
+	}
+	if got, err = r.GetCardType(ctx, dal.TestChatID); err != nil || got != dal.CardMixed {
+		t.Errorf("GetCardType = %q, %v, want %q", got, err, dal.CardMixed)
+	}
+
+	if err = r.SetCardType(ctx, dal.TestChatID, dal.CardCloze); err != nil {
+		t.Fatalf("SetCardType: %v", err)
+	}
+	if got, err = r.GetCardType(ctx, dal.TestChatID); err != nil || got != dal.CardCloze {
+		t.Errorf("GetCardType = %q, %v, want %q", got, err, dal.CardCloze)
+	}
+}
@@ -0,0 +1,385 @@
+package dal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// CreateDeck creates an empty deck, reporting ErrAlreadyExists if the owner already has one with the
+// same name.
+func (r *SQLiteRepository) CreateDeck(ctx context.Context, ownerChatID int64, name string) (*Deck, error) {
+	query := qb.Insert("decks").
+		Columns("owner_chat_id", "name").
+		Values(ownerChatID, name).
+		Suffix("ON CONFLICT (owner_chat_id, name) DO NOTHING").
+		Suffix("RETURNING id, created_at")
+
+	sqlQuery, args, err := query.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build insert query: %w", err)
+	}
+
+	res := &Deck{OwnerChatID: ownerChatID, Name: name}
+	if err = r.db.QueryRowContext(ctx, sqlQuery, args...).Scan(&res.ID, &res.CreatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAlreadyExists
+		}
+		return nil, fmt.Errorf("create deck: %w", err)
+	}
+	return res, nil
+}
+
+// DeleteDeck removes a deck with its word list and subscriptions. The subscribers keep the words
+// they were given, with their progress: those are theirs now, they just stop receiving updates.
+func (r *SQLiteRepository) DeleteDeck(ctx context.Context, ownerChatID, deckID int64) error {
+	return r.inTx(ctx, func(e execer) error {
+		if err := checkDeckOwner(ctx, e, ownerChatID, deckID); err != nil {
+			return err
+		}
+
+		// Foreign keys are not enforced on the connection, so the cascade is spelled out.
+		for _, table := range []string{"deck_words", "deck_subscriptions"} {
+			sqlQuery, args, err := qb.Delete(table).Where(squirrel.Eq{"deck_id": deckID}).ToSql()
+			if err != nil {
+				return fmt.Errorf("build delete query: %w", err)
+			}
+			if _, err = e.ExecContext(ctx, sqlQuery, args...); err != nil {
+				return fmt.Errorf("delete from %s: %w", table, err)
+			}
+		}
+
+		sqlQuery, args, err := qb.Delete("decks").Where(squirrel.Eq{"id": deckID}).ToSql()
+		if err != nil {
+			return fmt.Errorf("build delete query: %w", err)
+		}
+		if _, err = e.ExecContext(ctx, sqlQuery, args...); err != nil {
+			return fmt.Errorf("delete deck: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *SQLiteRepository) FindDecks(ctx context.Context, chatID int64, ownerChatIDs []int64) ([]Deck, error) {
+	query := qb.Select(
+		"d.id", "d.owner_chat_id", "d.name", "d.created_at",
+		"(SELECT COUNT(*) FROM deck_words dw "+
+			"JOIN word_translations wt ON wt.chat_id = d.owner_chat_id AND wt.word = dw.word WHERE dw.deck_id = d.id)",
+		"(SELECT COUNT(*) FROM deck_subscriptions ds WHERE ds.deck_id = d.id)",
+	).
+		Column(squirrel.Expr("EXISTS (SELECT 1 FROM deck_subscriptions ds WHERE ds.deck_id = d.id AND ds.chat_id = ?)", chatID)).
+		From("decks d").
+		Where(squirrel.Eq{"d.owner_chat_id": ownerChatIDs}).
+		OrderBy("d.name", "d.id")
+
+	sqlQuery, args, err := query.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build query: %w", err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("find decks: %w", err)
+	}
+	defer rows.Close()
+
+	var res []Deck
+	for rows.Next() {
+		var d Deck
+		if err = rows.Scan(&d.ID, &d.OwnerChatID, &d.Name, &d.CreatedAt, &d.Words, &d.Subscribers, &d.Subscribed); err != nil {
+			return nil, fmt.Errorf("scan deck: %w", err)
+		}
+		res = append(res, d)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate decks: %w", err)
+	}
+
+	return res, nil
+}
+
+// AddDeckWords puts some of the owner's words into a deck and copies them to every subscriber
+// straight away. Words the owner does not have are skipped; the result is how many were added.
+func (r *SQLiteRepository) AddDeckWords(ctx context.Context, ownerChatID, deckID int64, words []string) (int, error) {
+	var added int
+	err := r.inTx(ctx, func(e execer) error {
+		if err := checkDeckOwner(ctx, e, ownerChatID, deckID); err != nil {
+			return err
+		}
+
+		query := qb.Insert("deck_words").
+			Columns("deck_id", "word").
+			Select(squirrel.Select().
+				Column("?", deckID).
+				Column("word").
+				From("word_translations").
+				Where(squirrel.Eq{"chat_id": ownerChatID, "word": words})).
+			Suffix("ON CONFLICT (deck_id, word) DO NOTHING")
+
+		sqlQuery, args, err := query.ToSql()
+		if err != nil {
+			return fmt.Errorf("build insert query: %w", err)
+		}
+		res, err := e.ExecContext(ctx, sqlQuery, args...)
+		if err != nil {
+			return fmt.Errorf("add deck words: %w", err)
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("get rows affected: %w", err)
+		}
+		added = int(affected)
+
+		if _, err = copyDeckWords(ctx, e, squirrel.Eq{"d.id": deckID, "dw.word": words}); err != nil {
+			return fmt.Errorf("copy to subscribers: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return added, nil
+}
+
+// RemoveDeckWords takes words out of a deck. Subscribers keep their copies.
+func (r *SQLiteRepository) RemoveDeckWords(ctx context.Context, ownerChatID, deckID int64, words []string) error {
+	return r.inTx(ctx, func(e execer) error {
+		if err := checkDeckOwner(ctx, e, ownerChatID, deckID); err != nil {
+			return err
+		}
+
+		sqlQuery, args, err := qb.Delete("deck_words").Where(squirrel.Eq{"deck_id": deckID, "word": words}).ToSql()
+		if err != nil {
+			return fmt.Errorf("build delete query: %w", err)
+		}
+		if _, err = e.ExecContext(ctx, sqlQuery, args...); err != nil {
+			return fmt.Errorf("remove deck words: %w", err)
+		}
+		return nil
+	})
+}
+
+// SubscribeDeck subscribes the chat to a deck and copies the deck's words into its vocabulary. A word
+// the chat already has gets the deck's translation and keeps its progress, exactly like resolving a
+// conflict with ResolveUpdateOnly. The result is how many words were copied or updated.
+//
+// The copies do not request batch membership: a deck can hold hundreds of words, and queueing them
+// all would hold back the chat's own new words for weeks. Refills draw them in like any other word.
+func (r *SQLiteRepository) SubscribeDeck(ctx context.Context, chatID, deckID int64, ownerChatIDs []int64) (int, error) {
+	var copied int
+	err := r.inTx(ctx, func(e execer) error {
+		owner, err := deckOwner(ctx, e, deckID)
+		if err != nil {
+			return err
+		}
+		if owner == chatID {
+			return ErrOwnDeck
+		}
+		if !slices.Contains(ownerChatIDs, owner) {
+			return ErrNotFound
+		}
+
+		query := qb.Insert("deck_subscriptions").
+			Columns("deck_id", "chat_id").
+			Values(deckID, chatID).
+			Suffix("ON CONFLICT (deck_id, chat_id) DO NOTHING")
+
+		sqlQuery, args, err := query.ToSql()
+		if err != nil {
+			return fmt.Errorf("build insert query: %w", err)
+		}
+		if _, err = e.ExecContext(ctx, sqlQuery, args...); err != nil {
+			return fmt.Errorf("subscribe: %w", err)
+		}
+
+		if copied, err = copyDeckWords(ctx, e, squirrel.Eq{"d.id": deckID, "ds.chat_id": chatID}); err != nil {
+			return fmt.Errorf("copy deck words: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return copied, nil
+}
+
+// UnsubscribeDeck stops the owner's updates from reaching the chat. The words already copied stay.
+func (r *SQLiteRepository) UnsubscribeDeck(ctx context.Context, chatID, deckID int64) error {
+	sqlQuery, args, err := qb.Delete("deck_subscriptions").Where(squirrel.Eq{"deck_id": deckID, "chat_id": chatID}).ToSql()
+	if err != nil {
+		return fmt.Errorf("build delete query: %w", err)
+	}
+
+	res, err := r.db.ExecContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return fmt.Errorf("unsubscribe: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected: %w", err)
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func deckOwner(ctx context.Context, e execer, deckID int64) (int64, error) {
+	sqlQuery, args, err := qb.Select("owner_chat_id").From("decks").Where(squirrel.Eq{"id": deckID}).ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("build query: %w", err)
+	}
+
+	var owner int64
+	if err = e.QueryRowContext(ctx, sqlQuery, args...).Scan(&owner); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrNotFound
+		}
+		return 0, fmt.Errorf("get deck owner: %w", err)
+	}
+	return owner, nil
+}
+
+// checkDeckOwner reports ErrNotFound unless the deck exists and belongs to ownerChatID, so that
+// nobody can tell somebody else's deck from a missing one.
+func checkDeckOwner(ctx context.Context, e execer, ownerChatID, deckID int64) error {
+	owner, err := deckOwner(ctx, e, deckID)
+	if err != nil {
+		return err
+	}
+	if owner != ownerChatID {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// copyDeckWords copies the owner's current translation of every deck word matching where into the
+// vocabulary of each subscriber of its deck, with ResolveUpdateOnly semantics: missing words are
+// created from scratch, existing ones get the owner's translation and description and keep their
+// progress. where may refer to decks d, deck_words dw and deck_subscriptions ds.
+//
+// Each copy is written by upsertWordTranslation, the same write ResolveWordConflict makes for
+// ResolveUpdateOnly. ResolveWordConflict itself cannot be called here: it opens its own transaction,
+// and would hand the subscriber's copy on to the subscribers of their own decks.
+func copyDeckWords(ctx context.Context, e execer, where squirrel.Sqlizer) (int, error) {
+	copies, err := findDeckWordCopies(ctx, e, where)
+	if err != nil {
+		return 0, err
+	}
+	for _, c := range copies {
+		if err = upsertWordTranslation(ctx, e, c.chatID, c.word, c.translation, c.description); err != nil {
+			return 0, fmt.Errorf("copy %q: %w", c.word, err)
+		}
+	}
+	return len(copies), nil
+}
+
+type deckWordCopy struct {
+	chatID                         int64
+	word, translation, description string
+}
+
+// findDeckWordCopies reads every copy copyDeckWords has to write before it writes any: the rows have
+// to be closed before the transaction can be written to.
+func findDeckWordCopies(ctx context.Context, e execer, where squirrel.Sqlizer) ([]deckWordCopy, error) {
+	sqlQuery, args, err := qb.Select("ds.chat_id", "wt.word", "wt.translation", "COALESCE(wt.description, '')").
+		From("deck_subscriptions ds").
+		Join("decks d ON d.id = ds.deck_id").
+		Join("deck_words dw ON dw.deck_id = d.id").
+		Join("word_translations wt ON wt.chat_id = d.owner_chat_id AND wt.word = dw.word").
+		Where(where).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build select query: %w", err)
+	}
+
+	rows, err := e.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("find deck words: %w", err)
+	}
+	defer rows.Close()
+
+	var res []deckWordCopy
+	for rows.Next() {
+		var c deckWordCopy
+		if err = rows.Scan(&c.chatID, &c.word, &c.translation, &c.description); err != nil {
+			return nil, fmt.Errorf("scan deck word: %w", err)
+		}
+		res = append(res, c)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate deck words: %w", err)
+	}
+	return res, nil
+}
+
+// propagateDeckWordEdit hands an edit of the owner's word to the subscribers of every deck holding
+// it. A rename moves their copies too - along with their progress - unless a subscriber already has
+// a word spelled the new way, in which case that one is updated and the old copy is left alone.
+//
+// It has to run after the owner's own row has been updated, since the copies are taken from it.
+func propagateDeckWordEdit(ctx context.Context, e execer, ownerChatID int64, word, updatedWord string) error {
+	if updatedWord != word {
+		ownerDecks := squirrel.Select("id").From("decks").Where(squirrel.Eq{"owner_chat_id": ownerChatID})
+
+		subscribers := squirrel.Select("ds.chat_id").
+			From("deck_subscriptions ds").
+			Join("deck_words dw ON dw.deck_id = ds.deck_id").
+			Where(squirrel.Eq{"dw.word": word}).
+			Where(squirrel.Expr("ds.deck_id IN (?)", ownerDecks))
+		taken := squirrel.Select("1").
+			From("word_translations x").
+			Where("x.chat_id = word_translations.chat_id AND x.word = ?", updatedWord)
+
+		rename := qb.Update("word_translations").
+			Set("word", updatedWord).
+			Where(squirrel.Eq{"word": word}).
+			Where(squirrel.Expr("chat_id IN (?)", subscribers)).
+			Where(squirrel.Expr("NOT EXISTS (?)", taken))
+		sqlQuery, args, err := rename.ToSql()
+		if err != nil {
+			return fmt.Errorf("build update query: %w", err)
+		}
+		if _, err = e.ExecContext(ctx, sqlQuery, args...); err != nil {
+			return fmt.Errorf("rename subscriber copies: %w", err)
+		}
+
+		renameInDecks := qb.Update("deck_words").
+			Set("word", updatedWord).
+			Where(squirrel.Eq{"word": word}).
+			Where(squirrel.Expr("deck_id IN (?)", ownerDecks))
+		sqlQuery, args, err = renameInDecks.ToSql()
+		if err != nil {
+			return fmt.Errorf("build update query: %w", err)
+		}
+		if _, err = e.ExecContext(ctx, sqlQuery, args...); err != nil {
+			return fmt.Errorf("rename deck words: %w", err)
+		}
+	}
+
+	if _, err := copyDeckWords(ctx, e, squirrel.Eq{"d.owner_chat_id": ownerChatID, "dw.word": updatedWord}); err != nil {
+		return fmt.Errorf("copy to subscribers: %w", err)
+	}
+	return nil
+}
+
+// removeFromDecks takes a word the owner deleted out of their decks, so that adding it again later
+// does not silently put it back into them.
+func removeFromDecks(ctx context.Context, e execer, ownerChatID int64, word string) error {
+	query := qb.Delete("deck_words").
+		Where(squirrel.Eq{"word": word}).
+		Where(squirrel.Expr("deck_id IN (?)", squirrel.Select("id").From("decks").Where(squirrel.Eq{"owner_chat_id": ownerChatID})))
+
+	sqlQuery, args, err := query.ToSql()
+	if err != nil {
+		return fmt.Errorf("build delete query: %w", err)
+	}
+	if _, err = e.ExecContext(ctx, sqlQuery, args...); err != nil {
+		return fmt.Errorf("remove from decks: %w", err)
+	}
+	return nil
+}
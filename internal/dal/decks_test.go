@@ -0,0 +1,197 @@
+package dal_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+)
+
+const subscriberChatID int64 = 43
+
+var deckChats = []int64{dal.TestChatID, subscriberChatID}
+
+// newSubscribedDeck creates a deck of the owner's "apple" and "pear", subscribes subscriberChatID
+// to it, and returns its ID.
+func newSubscribedDeck(t *testing.T, r *dal.TestRepo) int64 {
+	t.Helper()
+	ctx := context.Background()
+
+	r.AddWord("apple", 3)
+	r.AddWord("pear", 0)
+	r.AddWord("private", 0)
+
+	deck, err := r.CreateDeck(ctx, dal.TestChatID, "fruit")
+	if err != nil {
+		t.Fatalf("CreateDeck: %v", err)
+	}
+	added, err := r.AddDeckWords(ctx, dal.TestChatID, deck.ID, []string{"apple", "pear", "missing"})
+	if err != nil {
+		t.Fatalf("AddDeckWords: %v", err)
+	}
+	if added != 2 {
+		t.Fatalf("added = %d, want 2: words the owner does not have are skipped", added)
+	}
+
+	if _, err = r.SubscribeDeck(ctx, subscriberChatID, deck.ID, deckChats); err != nil {
+		t.Fatalf("SubscribeDeck: %v", err)
+	}
+	return deck.ID
+}
+
+func subscriberWord(t *testing.T, r *dal.TestRepo, word string) *dal.WordTranslation {
+	t.Helper()
+
+	wt, err := r.FindWordTranslation(context.Background(), subscriberChatID, word)
+	if err != nil {
+		t.Fatalf("FindWordTranslation(%q): %v", word, err)
+	}
+	return wt
+}
+
+func TestSubscribeDeckCopiesWordsWithFreshProgress(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+	// The subscriber already knows "apple": the deck's translation wins, the progress stays.
+	r.AddChatWord(subscriberChatID, "apple", 7)
+	newSubscribedDeck(t, r)
+
+	if wt := subscriberWord(t, r, "apple"); wt.Translation != "apple-translation" || wt.GuessedStreak != 7 {
+		t.Errorf("existing word = %+v, want the deck's translation and streak 7", wt)
+	}
+	if wt := subscriberWord(t, r, "pear"); wt.GuessedStreak != 0 {
+		t.Errorf("copied word streak = %d, want 0", wt.GuessedStreak)
+	}
+	if _, err := r.FindWordTranslation(ctx, subscriberChatID, "private"); !errors.Is(err, dal.ErrNotFound) {
+		t.Errorf("word outside the deck was copied: err = %v", err)
+	}
+}
+
+func TestOwnerEditsPropagateWithoutResettingProgress(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+	newSubscribedDeck(t, r)
+	if err := r.Guess(subscriberChatID, "pear"); err != nil {
+		t.Fatalf("Guess: %v", err)
+	}
+
+	if err := r.UpdateWordTranslation(ctx, dal.TestChatID, "pear", "pear", "груша", "a fruit"); err != nil {
+		t.Fatalf("UpdateWordTranslation: %v", err)
+	}
+	if wt := subscriberWord(t, r, "pear"); wt.Translation != "груша" || wt.Description != "a fruit" || wt.GuessedStreak != 1 {
+		t.Errorf("after update = %+v, want the new text and streak 1", wt)
+	}
+
+	if err := r.ResolveWordConflict(ctx, dal.TestChatID, "pear", "груша!", "", dal.ResolveResetAndBatch); err != nil {
+		t.Fatalf("ResolveWordConflict: %v", err)
+	}
+	if wt := subscriberWord(t, r, "pear"); wt.Translation != "груша!" || wt.GuessedStreak != 1 {
+		t.Errorf("after the owner's reset = %+v, want the new text and the subscriber's streak kept", wt)
+	}
+}
+
+func TestOwnerRenamePropagates(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+	deckID := newSubscribedDeck(t, r)
+
+	if err := r.UpdateWordTranslation(ctx, dal.TestChatID, "apple", "apples", "яблука", ""); err != nil {
+		t.Fatalf("UpdateWordTranslation: %v", err)
+	}
+
+	if wt := subscriberWord(t, r, "apples"); wt.Translation != "яблука" {
+		t.Errorf("renamed copy = %+v", wt)
+	}
+	if _, err := r.FindWordTranslation(ctx, subscriberChatID, "apple"); !errors.Is(err, dal.ErrNotFound) {
+		t.Errorf("old spelling still there: err = %v", err)
+	}
+
+	decks, err := r.FindDecks(ctx, subscriberChatID, deckChats)
+	if err != nil {
+		t.Fatalf("FindDecks: %v", err)
+	}
+	if len(decks) != 1 || decks[0].ID != deckID || decks[0].Words != 2 || !decks[0].Subscribed {
+		t.Errorf("decks = %+v, want the renamed word still in the subscribed deck", decks)
+	}
+}
+
+func TestDeletedWordLeavesDeck(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+	newSubscribedDeck(t, r)
+
+	if err := r.DeleteWordTranslation(ctx, dal.TestChatID, "pear"); err != nil {
+		t.Fatalf("DeleteWordTranslation: %v", err)
+	}
+	// Adding it back must not silently put it back into the deck.
+	if err := r.CreateWordTranslation(ctx, dal.TestChatID, "pear", "new", ""); err != nil {
+		t.Fatalf("CreateWordTranslation: %v", err)
+	}
+
+	decks, err := r.FindDecks(ctx, dal.TestChatID, deckChats)
+	if err != nil {
+		t.Fatalf("FindDecks: %v", err)
+	}
+	if len(decks) != 1 || decks[0].Words != 1 {
+		t.Errorf("decks = %+v, want 1 word left", decks)
+	}
+	if wt := subscriberWord(t, r, "pear"); wt.Translation != "pear-translation" {
+		t.Errorf("subscriber copy = %+v, want it kept as it was", wt)
+	}
+}
+
+func TestSubscribeDeckRejections(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+	deck, err := r.CreateDeck(ctx, dal.TestChatID, "fruit")
+	if err != nil {
+		t.Fatalf("CreateDeck: %v", err)
+	}
+
+	if _, err = r.SubscribeDeck(ctx, dal.TestChatID, deck.ID, deckChats); !errors.Is(err, dal.ErrOwnDeck) {
+		t.Errorf("own deck: err = %v, want ErrOwnDeck", err)
+	}
+	if _, err = r.SubscribeDeck(ctx, subscriberChatID, deck.ID, []int64{subscriberChatID}); !errors.Is(err, dal.ErrNotFound) {
+		t.Errorf("deck of a chat outside the team: err = %v, want ErrNotFound", err)
+	}
+	if _, err = r.CreateDeck(ctx, dal.TestChatID, "fruit"); !errors.Is(err, dal.ErrAlreadyExists) {
+		t.Errorf("duplicate name: err = %v, want ErrAlreadyExists", err)
+	}
+	if err = r.DeleteDeck(ctx, subscriberChatID, deck.ID); !errors.Is(err, dal.ErrNotFound) {
+		t.Errorf("deleting somebody else's deck: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestDeleteDeckKeepsSubscriberCopies(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+	deckID := newSubscribedDeck(t, r)
+
+	if err := r.DeleteDeck(ctx, dal.TestChatID, deckID); err != nil {
+		t.Fatalf("DeleteDeck: %v", err)
+	}
+	subscriberWord(t, r, "apple")
+
+	// No subscription is left for an edit to travel through.
+	if err := r.UpdateWordTranslation(ctx, dal.TestChatID, "apple", "apple", "changed", ""); err != nil {
+		t.Fatalf("UpdateWordTranslation: %v", err)
+	}
+	if wt := subscriberWord(t, r, "apple"); wt.Translation != "apple-translation" {
+		t.Errorf("copy after the deck was deleted = %+v, want it untouched", wt)
+	}
+}
+
+func TestSubscribeDeckDropsTrashedCopies(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+	r.AddChatWord(subscriberChatID, "pear", 5)
+	if err := r.DeleteWordTranslation(ctx, subscriberChatID, "pear"); err != nil {
+		t.Fatalf("DeleteWordTranslation: %v", err)
+	}
+	newSubscribedDeck(t, r)
+
+	if trash, err := r.GetTrash(ctx, subscriberChatID); err != nil || len(trash) != 0 {
+		t.Errorf("trash = %+v (%v), want the deleted pear dropped once the deck brought it back", trash, err)
+	}
+}
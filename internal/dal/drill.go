@@ -0,0 +1,145 @@
+package dal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// drillPasses is how many correct answers in a row take a word out of the drill.
+const drillPasses = 2
+
+// PostponeDrill pushes a drilled word's next ask back by its current interval. It is called when the
+// word is sent rather than answered, so an ignored drill does not come up again on every check.
+func (r *SQLiteRepository) PostponeDrill(ctx context.Context, chatID int64, word string) error {
+	if len(r.drillIntervals) == 0 {
+		return nil
+	}
+
+	return r.inTx(ctx, func(e execer) error {
+		step, _, err := drillState(ctx, e, chatID, word)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				return nil
+			}
+			return fmt.Errorf("get drill state: %w", err)
+		}
+		return r.saveDrill(ctx, e, chatID, word, step, -1)
+	})
+}
+
+// drillMiss puts a missed word into the drill, due after the first interval, or moves one already
+// there on to the next interval and starts its count of correct answers over.
+func (r *SQLiteRepository) drillMiss(ctx context.Context, e execer, chatID int64, word string) error {
+	if len(r.drillIntervals) == 0 {
+		return nil
+	}
+
+	step, _, err := drillState(ctx, e, chatID, word)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		step = 0
+	case err != nil:
+		return fmt.Errorf("get drill state: %w", err)
+	default:
+		step++
+	}
+	return r.saveDrill(ctx, e, chatID, word, step, 0)
+}
+
+// drillGuess counts a correct answer for a drilled word: it leaves the drill after drillPasses in a
+// row, and waits out the next interval otherwise. Words that are not drilled are left alone.
+func (r *SQLiteRepository) drillGuess(ctx context.Context, e execer, chatID int64, word string) error {
+	step, guessed, err := drillState(ctx, e, chatID, word)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		}
+		return fmt.Errorf("get drill state: %w", err)
+	}
+
+	if guessed+1 >= drillPasses || len(r.drillIntervals) == 0 {
+		return deleteDrillWord(ctx, e, chatID, word)
+	}
+	return r.saveDrill(ctx, e, chatID, word, step+1, guessed+1)
+}
+
+// saveDrill stores the word's drill state, due one interval for step from now. A negative guessed
+// keeps the stored count.
+func (r *SQLiteRepository) saveDrill(ctx context.Context, e execer, chatID int64, word string, step, guessed int) error {
+	step = min(step, len(r.drillIntervals)-1)
+	due := squirrel.Expr("datetime('now', ?)", fmt.Sprintf("+%d seconds", int(r.drillIntervals[step]/time.Second)))
+
+	conflict := "ON CONFLICT (chat_id, word) DO UPDATE SET step = EXCLUDED.step, due_at = EXCLUDED.due_at"
+	if guessed >= 0 {
+		conflict += ", guessed = EXCLUDED.guessed"
+	}
+	query := qb.Insert("drill_words").
+		Columns("chat_id", "word", "step", "guessed", "due_at").
+		Values(chatID, word, step, max(guessed, 0), due).
+		Suffix(conflict)
+
+	sqlQuery, args, err := query.ToSql()
+	if err != nil {
+		return fmt.Errorf("build query: %w", err)
+	}
+
+	if _, err = e.ExecContext(ctx, sqlQuery, args...); err != nil {
+		return fmt.Errorf("save drill: %w", err)
+	}
+	return nil
+}
+
+func drillState(ctx context.Context, e execer, chatID int64, word string) (step, guessed int, err error) {
+	query := qb.Select("step", "guessed").
+		From("drill_words").
+		Where(squirrel.Eq{"chat_id": chatID, "word": word})
+
+	sqlQuery, args, err := query.ToSql()
+	if err != nil {
+		return 0, 0, fmt.Errorf("build query: %w", err)
+	}
+
+	if err = e.QueryRowContext(ctx, sqlQuery, args...).Scan(&step, &guessed); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, 0, ErrNotFound
+		}
+		return 0, 0, fmt.Errorf("get drill state: %w", err)
+	}
+	return step, guessed, nil
+}
+
+func deleteDrillWord(ctx context.Context, e execer, chatID int64, word string) error {
+	sqlQuery, args, err := qb.Delete("drill_words").Where(squirrel.Eq{"chat_id": chatID, "word": word}).ToSql()
+	if err != nil {
+		return fmt.Errorf("build delete query: %w", err)
+	}
+	if _, err = e.ExecContext(ctx, sqlQuery, args...); err != nil {
+		return fmt.Errorf("delete drill: %w", err)
+	}
+	return nil
+}
+
+// renameDrillWord moves a word's drill state along with a rename. Foreign keys are not enforced on
+// the connection, so the ON UPDATE CASCADE in the schema never fires.
+func renameDrillWord(ctx context.Context, e execer, chatID int64, word, updatedWord string) error {
+	if word == updatedWord {
+		return nil
+	}
+
+	sqlQuery, args, err := qb.Update("drill_words").
+		Set("word", updatedWord).
+		Where(squirrel.Eq{"chat_id": chatID, "word": word}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build update query: %w", err)
+	}
+	if _, err = e.ExecContext(ctx, sqlQuery, args...); err != nil {
+		return fmt.Errorf("rename drill: %w", err)
+	}
+	return nil
+}
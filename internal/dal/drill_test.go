@@ -0,0 +1,135 @@
+package dal_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+)
+
+type drillState struct {
+	step, guessed int
+	dueIn         time.Duration
+}
+
+func assertDrill(t *testing.T, r *dal.TestRepo, word string, want *drillState) {
+	t.Helper()
+
+	step, guessed, dueIn, ok := r.DrillOf(word)
+	switch {
+	case want == nil && ok:
+		t.Errorf("%q is still drilled: step %d, guessed %d", word, step, guessed)
+	case want == nil:
+	case !ok:
+		t.Errorf("%q is not drilled", word)
+	case (drillState{step, guessed, dueIn}) != *want:
+		t.Errorf("%q drill = %+v, want %+v", word, drillState{step, guessed, dueIn}, *want)
+	}
+}
+
+func TestDrillSteps(t *testing.T) {
+	tests := []struct {
+		name    string
+		answers []bool
+		want    *drillState
+	}{
+		{name: "a guess outside the drill", answers: []bool{true}},
+		{name: "a miss starts it", answers: []bool{false}, want: &drillState{dueIn: 10 * time.Minute}},
+		{
+			name:    "a guess moves it on",
+			answers: []bool{false, true},
+			want:    &drillState{step: 1, guessed: 1, dueIn: time.Hour},
+		},
+		{name: "two guesses in a row end it", answers: []bool{false, true, true}},
+		{
+			name:    "a miss in the drill starts the count over",
+			answers: []bool{false, true, false},
+			want:    &drillState{step: 2, dueIn: 4 * time.Hour},
+		},
+		{
+			name:    "the last interval repeats",
+			answers: []bool{false, false, false, false},
+			want:    &drillState{step: 2, dueIn: 4 * time.Hour},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := dal.NewTestRepo(t)
+			r.AddWord("word", 3)
+
+			for _, guessed := range tt.answers {
+				var err error
+				if guessed {
+					err = r.Guess(dal.TestChatID, "word")
+				} else {
+					_, err = r.Miss(dal.TestChatID, "word")
+				}
+				if err != nil {
+					t.Fatalf("register answer: %v", err)
+				}
+			}
+			assertDrill(t, r, "word", tt.want)
+		})
+	}
+}
+
+func TestFindDrillWord(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+	for _, w := range []string{"apple", "pear", "plum"} {
+		r.AddWord(w, 0)
+		if _, err := r.Miss(dal.TestChatID, w); err != nil {
+			t.Fatalf("Miss: %v", err)
+		}
+	}
+
+	filter := dal.FindRandomWordFilter{Drill: true}
+	if _, err := r.FindRandomWordTranslation(ctx, dal.TestChatID, filter); !errors.Is(err, dal.ErrNotFound) {
+		t.Fatalf("FindRandomWordTranslation before anything is due = %v, want ErrNotFound", err)
+	}
+
+	r.MakeDrillDue("pear", time.Minute)
+	r.MakeDrillDue("plum", time.Hour)
+	got, err := r.FindRandomWordTranslation(ctx, dal.TestChatID, filter)
+	if err != nil {
+		t.Fatalf("FindRandomWordTranslation: %v", err)
+	}
+	if got.Word != "plum" {
+		t.Errorf("word = %q, want plum, due longest", got.Word)
+	}
+
+	// Asking it puts it off until it is answered, without counting as an answer.
+	if err = r.PostponeDrill(ctx, dal.TestChatID, "plum"); err != nil {
+		t.Fatalf("PostponeDrill: %v", err)
+	}
+	assertDrill(t, r, "plum", &drillState{dueIn: 10 * time.Minute})
+	if got, err = r.FindRandomWordTranslation(ctx, dal.TestChatID, filter); err != nil || got.Word != "pear" {
+		t.Errorf("FindRandomWordTranslation = %v, %v, want pear", got, err)
+	}
+}
+
+func TestDrillFollowsWordEdits(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+	r.AddWord("aple", 0)
+	r.AddWord("pear", 0)
+	for _, w := range []string{"aple", "pear"} {
+		if _, err := r.Miss(dal.TestChatID, w); err != nil {
+			t.Fatalf("Miss: %v", err)
+		}
+	}
+
+	if err := r.UpdateWordTranslation(ctx, dal.TestChatID, "aple", "apple", "яблуко", ""); err != nil {
+		t.Fatalf("UpdateWordTranslation: %v", err)
+	}
+	if err := r.DeleteWordTranslation(ctx, dal.TestChatID, "pear"); err != nil {
+		t.Fatalf("DeleteWordTranslation: %v", err)
+	}
+
+	assertDrill(t, r, "aple", nil)
+	assertDrill(t, r, "apple", &drillState{dueIn: 10 * time.Minute})
+	assertDrill(t, r, "pear", nil)
+}
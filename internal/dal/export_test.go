@@ -3,11 +3,14 @@ package dal
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	_ "modernc.org/sqlite"
 )
@@ -22,6 +25,12 @@ const TestStreakLimit int = 15
 
 const TestBatchSize int = 50
 
+//nolint:gochecknoglobals // a slice cannot be a constant
+var TestDrillIntervals = []time.Duration{10 * time.Minute, time.Hour, 4 * time.Hour}
+
+//nolint:gochecknoglobals // a struct cannot be a constant
+var TestReviewRules = ReviewRules{First: 72 * time.Hour, Max: 180 * 24 * time.Hour}
+
 // TestRepo is a repository backed by a fresh in-memory database with the production schema applied,
 // plus the assertion helpers the tests need. It embeds *SQLiteRepository, so every repository method
 // is available on it directly.
@@ -54,7 +63,8 @@ func NewTestRepo(t *testing.T) *TestRepo {
 		t.Fatalf("apply schema: %v", err)
 	}
 
-	repo := newSQLRepository(db, TestStreakLimit, TestBatchSize, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	repo := newSQLRepository(db, TestStreakLimit, TestBatchSize, TestDrillIntervals, LeechRules{}, TestReviewRules,
+		slog.New(slog.NewTextHandler(io.Discard, nil)))
 	return &TestRepo{SQLiteRepository: repo, t: t}
 }
 
@@ -63,11 +73,17 @@ func (r *TestRepo) SetStreakLimit(limit int) {
 	r.streakLimit = limit
 }
 
-// SetBatchSize retunes the hard cap the repository admits into learning_batches with.
-func (r *TestRepo) SetBatchSize(size int) {
+// SetDefaultBatchSize retunes the hard cap the repository admits into learning_batches with, for
+// chats that have not set their own.
+func (r *TestRepo) SetDefaultBatchSize(size int) {
 	r.batchSize = size
 }
 
+// SetLeechRules turns leech detection on for the tests about it; it is off by default.
+func (r *TestRepo) SetLeechRules(rules LeechRules) {
+	r.leech = rules
+}
+
 // AddWord inserts a word directly at the given streak, bypassing CreateWordTranslation so that
 // building a fixture never triggers batch admission as a side effect - tests that want to exercise
 // admission call CreateWordTranslation themselves.
@@ -83,6 +99,53 @@ func (r *TestRepo) AddWord(word string, streak int) {
 	}
 }
 
+// AddChatWord is AddWord for any chat, for the tests that share words between chats.
+func (r *TestRepo) AddChatWord(chatID int64, word string, streak int) {
+	r.t.Helper()
+
+	_, err := r.db.ExecContext(context.Background(),
+		"INSERT INTO word_translations (chat_id, word, translation, guessed_streak) VALUES (?, ?, ?, ?)",
+		chatID, word, word+"-translation", streak)
+	if err != nil {
+		r.t.Fatalf("add word %q for chat %d: %v", word, chatID, err)
+	}
+}
+
+// AgeWord moves a word's creation time ago into the past.
+func (r *TestRepo) AgeWord(word string, ago time.Duration) {
+	r.t.Helper()
+
+	_, err := r.db.ExecContext(context.Background(),
+		"UPDATE word_translations SET created_at = datetime('now', ?) WHERE chat_id = ? AND word = ?",
+		secondsAgo(ago), TestChatID, word)
+	if err != nil {
+		r.t.Fatalf("age word %q: %v", word, err)
+	}
+}
+
+// AgeTrash moves the time a word was deleted ago into the past.
+func (r *TestRepo) AgeTrash(word string, ago time.Duration) {
+	r.t.Helper()
+
+	_, err := r.db.ExecContext(context.Background(),
+		"UPDATE word_trash SET deleted_at = datetime('now', ?) WHERE chat_id = ? AND word = ?",
+		secondsAgo(ago), TestChatID, word)
+	if err != nil {
+		r.t.Fatalf("age trashed word %q: %v", word, err)
+	}
+}
+
+// SetLapses sets how many times a word has been missed.
+func (r *TestRepo) SetLapses(word string, lapses int) {
+	r.t.Helper()
+
+	_, err := r.db.ExecContext(context.Background(),
+		"UPDATE word_translations SET lapses = ? WHERE chat_id = ? AND word = ?", lapses, TestChatID, word)
+	if err != nil {
+		r.t.Fatalf("set lapses of %q: %v", word, err)
+	}
+}
+
 // SeedBatch puts words into the learning batch directly, bypassing the admission rules.
 func (r *TestRepo) SeedBatch(words ...string) {
 	r.t.Helper()
@@ -222,6 +285,40 @@ func (r *TestRepo) TodayStats() (int, int, int) {
 	return guessed, missed, totalLearned
 }
 
+// SeedDay writes a statistics row for the given day directly: answers are booked as guesses, added as
+// brand-new words.
+func (r *TestRepo) SeedDay(day time.Time, answers, added int) {
+	r.t.Helper()
+
+	_, err := r.db.ExecContext(context.Background(),
+		"INSERT INTO statistics (chat_id, date, words_guessed, words_added) VALUES (?, ?, ?, ?)",
+		TestChatID, day.Format(dateLayout), answers, added)
+	if err != nil {
+		r.t.Fatalf("seed day %s: %v", day.Format(dateLayout), err)
+	}
+}
+
+// SeedChatDay writes a statistics row for any chat, for the tests that compare chats with each other.
+func (r *TestRepo) SeedChatDay(chatID int64, day time.Time, guessed, missed int) {
+	r.t.Helper()
+
+	_, err := r.db.ExecContext(context.Background(),
+		"INSERT INTO statistics (chat_id, date, words_guessed, words_missed) VALUES (?, ?, ?, ?)",
+		chatID, day.Format(dateLayout), guessed, missed)
+	if err != nil {
+		r.t.Fatalf("seed chat %d day %s: %v", chatID, day.Format(dateLayout), err)
+	}
+}
+
+// SeedFreezes sets how many unspent streak freezes the chat holds.
+func (r *TestRepo) SeedFreezes(freezes int) {
+	r.t.Helper()
+
+	if err := saveChatStreakState(context.Background(), r.db, TestChatID, "", freezes); err != nil {
+		r.t.Fatalf("seed freezes: %v", err)
+	}
+}
+
 // HasColumn reports whether the applied schema defines table.column.
 func (r *TestRepo) HasColumn(table, column string) bool {
 	r.t.Helper()
@@ -248,3 +345,117 @@ func (r *TestRepo) HasColumn(table, column string) bool {
 	}
 	return false
 }
+
+// SeedCheck records a word check for word sent ago, the way InsertCallback would have.
+func (r *TestRepo) SeedCheck(word string, ago time.Duration) {
+	r.t.Helper()
+
+	_, err := r.db.ExecContext(context.Background(),
+		"INSERT INTO callback_data (chat_id, uuid, data, expires_at, created_at) VALUES (?, hex(randomblob(4)), ?, ?, datetime('now', ?))",
+		TestChatID, `{"word":"`+word+`"}`, time.Now().Add(time.Hour), secondsAgo(ago))
+	if err != nil {
+		r.t.Fatalf("seed check %q: %v", word, err)
+	}
+}
+
+// Guess answers a word check for word correctly, the way tapping ✅ does: the check is sent through
+// InsertCallback and graded with GradeCallback.
+func (r *TestRepo) Guess(chatID int64, word string) error {
+	_, err := r.answer(chatID, word, true)
+	return err
+}
+
+// Miss answers a word check for word wrongly, like Guess, and reports whether the miss made the word
+// a leech.
+func (r *TestRepo) Miss(chatID int64, word string) (bool, error) {
+	return r.answer(chatID, word, false)
+}
+
+func (r *TestRepo) answer(chatID int64, word string, guessed bool) (bool, error) {
+	ctx := context.Background()
+	uuid, err := r.InsertCallback(ctx, CallbackData{ChatID: chatID, Word: word, ExpiresAt: time.Now().Add(time.Hour)})
+	if err != nil {
+		return false, err
+	}
+	return r.GradeCallback(ctx, chatID, uuid, guessed)
+}
+
+// SeedAnswer logs an answer to word given ago.
+func (r *TestRepo) SeedAnswer(word string, ago time.Duration) {
+	r.t.Helper()
+
+	_, err := r.db.ExecContext(context.Background(),
+		"INSERT INTO answer_log (chat_id, word, guessed, streak_after, answered_at) VALUES (?, ?, 1, 1, datetime('now', ?))",
+		TestChatID, word, secondsAgo(ago))
+	if err != nil {
+		r.t.Fatalf("seed answer %q: %v", word, err)
+	}
+}
+
+func secondsAgo(d time.Duration) string {
+	return fmt.Sprintf("-%d seconds", int(d.Seconds()))
+}
+
+// DrillOf returns a drilled word's step and correct answers in a row, and how long until it is due,
+// rounded to minutes. ok is false when the word is not drilled.
+func (r *TestRepo) DrillOf(word string) (step, guessed int, dueIn time.Duration, ok bool) {
+	r.t.Helper()
+
+	var dueInSeconds float64
+	err := r.db.QueryRowContext(context.Background(),
+		"SELECT step, guessed, (julianday(due_at) - julianday('now')) * 86400 FROM drill_words WHERE chat_id = ? AND word = ?",
+		TestChatID, word).Scan(&step, &guessed, &dueInSeconds)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, 0, 0, false
+	}
+	if err != nil {
+		r.t.Fatalf("get drill for %q: %v", word, err)
+	}
+	return step, guessed, (time.Duration(dueInSeconds) * time.Second).Round(time.Minute), true
+}
+
+// MakeDrillDue makes a drilled word due ago.
+func (r *TestRepo) MakeDrillDue(word string, ago time.Duration) {
+	r.t.Helper()
+
+	_, err := r.db.ExecContext(context.Background(),
+		"UPDATE drill_words SET due_at = datetime('now', ?) WHERE chat_id = ? AND word = ?", secondsAgo(ago), TestChatID, word)
+	if err != nil {
+		r.t.Fatalf("make drill due %q: %v", word, err)
+	}
+}
+
+// ReviewOf returns a word's wait between its last two reviews and how long until the next one is
+// due, rounded to hours. ok is false when the word has no review scheduled.
+func (r *TestRepo) ReviewOf(word string) (interval, dueIn time.Duration, ok bool) {
+	r.t.Helper()
+
+	var (
+		seconds      int64
+		dueInSeconds sql.NullFloat64
+	)
+	err := r.db.QueryRowContext(context.Background(),
+		"SELECT review_interval_seconds, (julianday(next_review_at) - julianday('now')) * 86400 FROM word_translations WHERE chat_id = ? AND word = ?",
+		TestChatID, word).Scan(&seconds, &dueInSeconds)
+	if err != nil {
+		r.t.Fatalf("get review of %q: %v", word, err)
+	}
+	if !dueInSeconds.Valid {
+		return 0, 0, false
+	}
+	return time.Duration(seconds) * time.Second, (time.Duration(dueInSeconds.Float64) * time.Second).Round(time.Hour), true
+}
+
+// ScheduleReview sets a learned word's review schedule directly: learned ago, with the given last
+// wait, and due in dueIn, which is negative for a word that is overdue.
+func (r *TestRepo) ScheduleReview(word string, learnedAgo, interval, dueIn time.Duration) {
+	r.t.Helper()
+
+	_, err := r.db.ExecContext(context.Background(),
+		`UPDATE word_translations SET learned_at = datetime('now', ?), review_interval_seconds = ?,
+		 next_review_at = datetime('now', ?) WHERE chat_id = ? AND word = ?`,
+		secondsAgo(learnedAgo), int64(interval.Seconds()), fmt.Sprintf("%d seconds", int(dueIn.Seconds())), TestChatID, word)
+	if err != nil {
+		r.t.Fatalf("schedule review of %q: %v", word, err)
+	}
+}
@@ -0,0 +1,52 @@
+package dal
+
+import (
+	_ "embed"
+	"math"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// frequencyList is the word list RefillFrequency ranks words by, most frequent first. It is bundled
+// with the binary so that the strategy works offline.
+//
+//go:embed frequency_en.txt
+var frequencyList string
+
+//nolint:gochecknoglobals // parsed once, on first use
+var frequencyRanks = sync.OnceValue(func() map[string]int {
+	res := make(map[string]int)
+	for _, line := range strings.Split(frequencyList, "\n") {
+		word := strings.TrimSpace(line)
+		if word == "" || strings.HasPrefix(word, "#") {
+			continue
+		}
+		if _, ok := res[word]; !ok {
+			res[word] = len(res) + 1
+		}
+	}
+	return res
+})
+
+// frequencyRank ranks word by the frequency list: 1 is the most frequent word. A phrase ranks as its
+// rarest word, and anything with a word that is not on the list ranks after everything that is.
+func frequencyRank(word string) int {
+	parts := strings.FieldsFunc(strings.ToLower(word), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+	if len(parts) == 0 {
+		return math.MaxInt32
+	}
+
+	ranks := frequencyRanks()
+	res := 0
+	for _, p := range parts {
+		rank, ok := ranks[p]
+		if !ok {
+			return math.MaxInt32
+		}
+		res = max(res, rank)
+	}
+	return res
+}
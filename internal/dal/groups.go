@@ -0,0 +1,198 @@
+package dal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// FindGroupWord picks the next word for a group round. A word stays in rotation until every member
+// who has ever answered in the group has learned it; before anybody has answered, every word is
+// fair game.
+func (r *SQLiteRepository) FindGroupWord(ctx context.Context, groupChatID int64) (*WordTranslation, error) {
+	query := qb.Select(wordTranslationColumns()...).
+		From("word_translations wt").
+		Where(squirrel.Eq{"wt.chat_id": groupChatID}).
+		Where(squirrel.Or{
+			squirrel.Expr("NOT EXISTS (SELECT 1 FROM group_members gm WHERE gm.group_chat_id = wt.chat_id)"),
+			squirrel.Expr("EXISTS (SELECT 1 FROM group_members gm " +
+				"LEFT JOIN group_progress gp ON gp.group_chat_id = gm.group_chat_id AND gp.user_id = gm.user_id AND gp.word = wt.word " +
+				"WHERE gm.group_chat_id = wt.chat_id AND COALESCE(gp.guessed_streak, 0) < " + streakLimitOf("wt", r.streakLimit) + ")"),
+		}).
+		OrderBy("random()").
+		Limit(1)
+
+	sqlQuery, args, err := query.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build select query: %w", err)
+	}
+
+	wt, err := hydrateWordTranslation(r.db.QueryRowContext(ctx, sqlQuery, args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("find group word: %w", err)
+	}
+	return wt, nil
+}
+
+// RegisterGroupAnswer applies one member's answer in a round. The member is added to the group on
+// their first answer, and their name is refreshed on every one after it, so the scoreboards follow
+// Telegram renames.
+func (r *SQLiteRepository) RegisterGroupAnswer(ctx context.Context, groupChatID int64, roundID, word string, member GroupAnswer) (int, error) {
+	var streak int
+	err := r.inTx(ctx, func(e execer) error {
+		answered, err := hasGroupAnswer(ctx, e, roundID, member.UserID)
+		if err != nil {
+			return err
+		}
+		if answered {
+			return ErrAlreadyExists
+		}
+
+		query := qb.Insert("group_members").
+			Columns("group_chat_id", "user_id", "name").
+			Values(groupChatID, member.UserID, member.Name).
+			Suffix("ON CONFLICT (group_chat_id, user_id) DO UPDATE SET name = EXCLUDED.name")
+
+		sqlQuery, args, err := query.ToSql()
+		if err != nil {
+			return fmt.Errorf("build insert query: %w", err)
+		}
+		if _, err = e.ExecContext(ctx, sqlQuery, args...); err != nil {
+			return fmt.Errorf("upsert group member: %w", err)
+		}
+
+		onConflict := "ON CONFLICT (group_chat_id, user_id, word) DO UPDATE SET guessed_streak = 0"
+		initial := 0
+		if member.Guessed {
+			onConflict = "ON CONFLICT (group_chat_id, user_id, word) DO UPDATE SET guessed_streak = guessed_streak + 1"
+			initial = 1
+		}
+		query = qb.Insert("group_progress").
+			Columns("group_chat_id", "user_id", "word", "guessed_streak").
+			Values(groupChatID, member.UserID, word, initial).
+			Suffix(onConflict).
+			Suffix("RETURNING guessed_streak")
+
+		if sqlQuery, args, err = query.ToSql(); err != nil {
+			return fmt.Errorf("build insert query: %w", err)
+		}
+		if err = e.QueryRowContext(ctx, sqlQuery, args...).Scan(&streak); err != nil {
+			return fmt.Errorf("update group progress: %w", err)
+		}
+
+		query = qb.Insert("group_answers").
+			Columns("round_id", "group_chat_id", "user_id", "word", "guessed", "streak_after").
+			Values(roundID, groupChatID, member.UserID, word, member.Guessed, streak)
+
+		if sqlQuery, args, err = query.ToSql(); err != nil {
+			return fmt.Errorf("build insert query: %w", err)
+		}
+		if _, err = e.ExecContext(ctx, sqlQuery, args...); err != nil {
+			return fmt.Errorf("log group answer: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return streak, nil
+}
+
+func hasGroupAnswer(ctx context.Context, e execer, roundID string, userID int64) (bool, error) {
+	query := qb.Select("COUNT(*)").
+		From("group_answers").
+		Where(squirrel.Eq{"round_id": roundID, "user_id": userID})
+
+	sqlQuery, args, err := query.ToSql()
+	if err != nil {
+		return false, fmt.Errorf("build select query: %w", err)
+	}
+
+	var count int
+	if err = e.QueryRowContext(ctx, sqlQuery, args...).Scan(&count); err != nil {
+		return false, fmt.Errorf("find group answer: %w", err)
+	}
+	return count > 0, nil
+}
+
+func (r *SQLiteRepository) GetGroupRound(ctx context.Context, groupChatID int64, roundID string) ([]GroupAnswer, error) {
+	query := qb.Select("ga.user_id", "COALESCE(gm.name, '')", "ga.guessed", "ga.streak_after").
+		From("group_answers ga").
+		LeftJoin("group_members gm ON gm.group_chat_id = ga.group_chat_id AND gm.user_id = ga.user_id").
+		Where(squirrel.Eq{"ga.group_chat_id": groupChatID, "ga.round_id": roundID}).
+		OrderBy("ga.rowid")
+
+	sqlQuery, args, err := query.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build query: %w", err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("get group round: %w", err)
+	}
+	defer rows.Close()
+
+	var res []GroupAnswer
+	for rows.Next() {
+		var a GroupAnswer
+		if err = rows.Scan(&a.UserID, &a.Name, &a.Guessed, &a.StreakAfter); err != nil {
+			return nil, fmt.Errorf("scan group answer: %w", err)
+		}
+		res = append(res, a)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate group answers: %w", err)
+	}
+
+	return res, nil
+}
+
+// GetGroupScoreboard returns every member's answers between from and to inclusive, most answers
+// first, together with how many of the group's words each has learned. Members who did not answer
+// in the period are listed with zeros.
+func (r *SQLiteRepository) GetGroupScoreboard(ctx context.Context, groupChatID int64, from, to time.Time) ([]GroupMemberScore, error) {
+	query := qb.Select("gm.user_id", "gm.name", "COALESCE(ga.answers, 0) AS answers", "COALESCE(ga.guessed, 0)", "COALESCE(gp.learned, 0)").
+		From("group_members gm").
+		LeftJoin("(SELECT user_id, COUNT(*) AS answers, SUM(guessed) AS guessed FROM group_answers "+
+			"WHERE group_chat_id = ? AND date(answered_at, 'localtime') BETWEEN ? AND ? GROUP BY user_id) ga "+
+			"ON ga.user_id = gm.user_id", groupChatID, from.Format(dateLayout), to.Format(dateLayout)).
+		LeftJoin("(SELECT gp.user_id, COUNT(*) AS learned FROM group_progress gp "+
+			"JOIN word_translations wt ON wt.chat_id = gp.group_chat_id AND wt.word = gp.word "+
+			"WHERE gp.group_chat_id = ? AND gp.guessed_streak >= "+streakLimitOf("wt", r.streakLimit)+
+			" GROUP BY gp.user_id) gp ON gp.user_id = gm.user_id", groupChatID).
+		Where(squirrel.Eq{"gm.group_chat_id": groupChatID}).
+		OrderBy("answers DESC", "gm.user_id")
+
+	sqlQuery, args, err := query.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build query: %w", err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("get group scoreboard: %w", err)
+	}
+	defer rows.Close()
+
+	var res []GroupMemberScore
+	for rows.Next() {
+		var s GroupMemberScore
+		if err = rows.Scan(&s.UserID, &s.Name, &s.Answers, &s.Guessed, &s.Learned); err != nil {
+			return nil, fmt.Errorf("scan group member score: %w", err)
+		}
+		res = append(res, s)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate group scoreboard: %w", err)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,131 @@
+package dal_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+)
+
+const groupChatID int64 = -100
+
+var (
+	alice = dal.GroupAnswer{UserID: 1, Name: "Alice"}
+	bob   = dal.GroupAnswer{UserID: 2, Name: "Bob"}
+)
+
+func answer(t *testing.T, r *dal.TestRepo, roundID, word string, member dal.GroupAnswer, guessed bool) int {
+	t.Helper()
+
+	member.Guessed = guessed
+	streak, err := r.RegisterGroupAnswer(context.Background(), groupChatID, roundID, word, member)
+	if err != nil {
+		t.Fatalf("RegisterGroupAnswer(%s, %s): %v", roundID, member.Name, err)
+	}
+	return streak
+}
+
+func TestRegisterGroupAnswerKeepsProgressPerMember(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+	r.AddChatWord(groupChatID, "apple", 0)
+
+	if got := answer(t, r, "r1", "apple", alice, true); got != 1 {
+		t.Errorf("Alice's streak after round 1 = %d, want 1", got)
+	}
+	if got := answer(t, r, "r1", "apple", bob, false); got != 0 {
+		t.Errorf("Bob's streak after round 1 = %d, want 0", got)
+	}
+	if got := answer(t, r, "r2", "apple", alice, true); got != 2 {
+		t.Errorf("Alice's streak after round 2 = %d, want 2", got)
+	}
+	if got := answer(t, r, "r3", "apple", alice, false); got != 0 {
+		t.Errorf("Alice's streak after a miss = %d, want 0", got)
+	}
+
+	if _, err := r.RegisterGroupAnswer(ctx, groupChatID, "r1", "apple", alice); !errors.Is(err, dal.ErrAlreadyExists) {
+		t.Errorf("second answer in a round: err = %v, want ErrAlreadyExists", err)
+	}
+
+	// The group's own word is not touched: every member's progress is theirs.
+	wt, err := r.FindWordTranslation(ctx, groupChatID, "apple")
+	if err != nil {
+		t.Fatalf("FindWordTranslation: %v", err)
+	}
+	if wt.GuessedStreak != 0 {
+		t.Errorf("group word streak = %d, want 0", wt.GuessedStreak)
+	}
+
+	round, err := r.GetGroupRound(ctx, groupChatID, "r1")
+	if err != nil {
+		t.Fatalf("GetGroupRound: %v", err)
+	}
+	want := []dal.GroupAnswer{
+		{UserID: 1, Name: "Alice", Guessed: true, StreakAfter: 1},
+		{UserID: 2, Name: "Bob", Guessed: false, StreakAfter: 0},
+	}
+	if len(round) != len(want) || round[0] != want[0] || round[1] != want[1] {
+		t.Errorf("round = %+v, want %+v", round, want)
+	}
+}
+
+func TestFindGroupWordSkipsWordsEveryMemberLearned(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+	r.AddChatWord(groupChatID, "apple", 0)
+
+	// Before anybody answers, every word is in rotation.
+	if _, err := r.FindGroupWord(ctx, groupChatID); err != nil {
+		t.Fatalf("FindGroupWord before any answer: %v", err)
+	}
+
+	for i := range dal.TestStreakLimit {
+		answer(t, r, fmt.Sprintf("a%d", i), "apple", alice, true)
+	}
+	answer(t, r, "b", "apple", bob, true)
+	if _, err := r.FindGroupWord(ctx, groupChatID); err != nil {
+		t.Errorf("word Bob has not learned yet was skipped: %v", err)
+	}
+
+	for i := 1; i < dal.TestStreakLimit; i++ {
+		answer(t, r, fmt.Sprintf("b%d", i), "apple", bob, true)
+	}
+	if _, err := r.FindGroupWord(ctx, groupChatID); !errors.Is(err, dal.ErrNotFound) {
+		t.Errorf("word everybody learned: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestGetGroupScoreboard(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+	r.AddChatWord(groupChatID, "apple", 0)
+	r.AddChatWord(groupChatID, "pear", 0)
+
+	answer(t, r, "r1", "apple", alice, true)
+	answer(t, r, "r1", "apple", bob, false)
+	answer(t, r, "r2", "pear", alice, false)
+	for i := range dal.TestStreakLimit {
+		answer(t, r, fmt.Sprintf("b%d", i), "pear", bob, true)
+	}
+	// Bob has changed their Telegram name since.
+	renamed := bob
+	renamed.Name = "Robert"
+	answer(t, r, "r3", "apple", renamed, true)
+
+	today := time.Now()
+	got, err := r.GetGroupScoreboard(ctx, groupChatID, today.AddDate(0, 0, -6), today)
+	if err != nil {
+		t.Fatalf("GetGroupScoreboard: %v", err)
+	}
+
+	want := []dal.GroupMemberScore{
+		{UserID: 2, Name: "Robert", Answers: dal.TestStreakLimit + 2, Guessed: dal.TestStreakLimit + 1, Learned: 1},
+		{UserID: 1, Name: "Alice", Answers: 2, Guessed: 1},
+	}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("scoreboard = %+v, want %+v", got, want)
+	}
+}
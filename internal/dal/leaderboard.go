@@ -0,0 +1,227 @@
+package dal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// GetLeaderboardVisibility returns the chat's leaderboard privacy setting and the name it is listed
+// under. A chat that never chose is hidden.
+func (r *SQLiteRepository) GetLeaderboardVisibility(ctx context.Context, chatID int64) (LeaderboardVisibility, string, error) {
+	query := qb.Select("leaderboard_visibility", "leaderboard_name").
+		From("chat_settings").
+		Where(squirrel.Eq{"chat_id": chatID})
+
+	sqlQuery, args, err := query.ToSql()
+	if err != nil {
+		return "", "", fmt.Errorf("build query: %w", err)
+	}
+
+	var (
+		visibility LeaderboardVisibility
+		name       string
+	)
+	if err = r.db.QueryRowContext(ctx, sqlQuery, args...).Scan(&visibility, &name); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return LeaderboardHidden, "", nil
+		}
+		return "", "", fmt.Errorf("get leaderboard visibility: %w", err)
+	}
+	return visibility, name, nil
+}
+
+func (r *SQLiteRepository) SetLeaderboardVisibility(ctx context.Context, chatID int64, visibility LeaderboardVisibility, name string) error {
+	switch visibility {
+	case LeaderboardHidden, LeaderboardAnonymous, LeaderboardPublic:
+	default:
+		return fmt.Errorf("unknown leaderboard visibility: %q", visibility)
+	}
+
+	query := qb.Insert("chat_settings").
+		Columns("chat_id", "leaderboard_visibility", "leaderboard_name").
+		Values(chatID, visibility, name).
+		Suffix("ON CONFLICT (chat_id) DO UPDATE SET " +
+			"leaderboard_visibility = EXCLUDED.leaderboard_visibility, leaderboard_name = EXCLUDED.leaderboard_name")
+
+	sqlQuery, args, err := query.ToSql()
+	if err != nil {
+		return fmt.Errorf("build query: %w", err)
+	}
+
+	if _, err = r.db.ExecContext(ctx, sqlQuery, args...); err != nil {
+		return fmt.Errorf("set leaderboard visibility: %w", err)
+	}
+	return nil
+}
+
+// GetLeaderboard returns the results of every opted-in chat among chatIDs between from and to
+// inclusive, most answers first. Chats that opted in but did nothing are listed with zeros. Words
+// learned are counted from answer_log, the same way the digest counts them.
+func (r *SQLiteRepository) GetLeaderboard(ctx context.Context, chatIDs []int64, from, to time.Time) ([]LeaderboardEntry, error) {
+	fromStr, toStr := from.Format(dateLayout), to.Format(dateLayout)
+
+	query := qb.Select(
+		"cs.chat_id", "cs.leaderboard_name", "cs.leaderboard_visibility",
+		"COALESCE(st.answers, 0) AS answers", "COALESCE(st.guessed, 0)", "COALESCE(st.added, 0)", "COALESCE(al.learned, 0)",
+	).
+		From("chat_settings cs").
+		LeftJoin("(SELECT chat_id, SUM(words_guessed + words_missed) AS answers, SUM(words_guessed) AS guessed, "+
+			"SUM(words_added) AS added FROM statistics WHERE date BETWEEN ? AND ? GROUP BY chat_id) st "+
+			"ON st.chat_id = cs.chat_id", fromStr, toStr).
+		LeftJoin("(SELECT chat_id, COUNT(DISTINCT word) AS learned FROM answer_log "+
+			"WHERE guessed = 1 AND streak_after = "+streakLimitOfWord("answer_log.chat_id", "answer_log.word", r.streakLimit)+
+			" AND date(answered_at, 'localtime') BETWEEN ? AND ? GROUP BY chat_id) al "+
+			"ON al.chat_id = cs.chat_id", fromStr, toStr).
+		Where(squirrel.Eq{"cs.chat_id": chatIDs}).
+		Where(squirrel.NotEq{"cs.leaderboard_visibility": LeaderboardHidden}).
+		OrderBy("answers DESC", "cs.chat_id")
+
+	sqlQuery, args, err := query.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build query: %w", err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("get leaderboard: %w", err)
+	}
+	defer rows.Close()
+
+	var res []LeaderboardEntry
+	for rows.Next() {
+		var (
+			e          LeaderboardEntry
+			visibility LeaderboardVisibility
+		)
+		if err = rows.Scan(&e.ChatID, &e.Name, &visibility, &e.Answers, &e.Guessed, &e.NewWords, &e.Learned); err != nil {
+			return nil, fmt.Errorf("scan leaderboard entry: %w", err)
+		}
+		if visibility == LeaderboardAnonymous {
+			e.Anonymous = true
+			e.Name = ""
+		}
+		res = append(res, e)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate leaderboard: %w", err)
+	}
+
+	return res, nil
+}
+
+func (r *SQLiteRepository) CreateChallenge(ctx context.Context, challenge Challenge) (*Challenge, error) {
+	switch challenge.Metric {
+	case MetricAnswers, MetricGuessed, MetricNewWords, MetricLearned:
+	default:
+		return nil, fmt.Errorf("unknown challenge metric: %q", challenge.Metric)
+	}
+	if challenge.Target <= 0 {
+		return nil, fmt.Errorf("challenge target %d must be greater than 0", challenge.Target)
+	}
+	if challenge.EndsOn.Before(challenge.StartsOn) {
+		return nil, errors.New("challenge ends before it starts")
+	}
+
+	query := qb.Insert("challenges").
+		Columns("created_by", "title", "metric", "target", "starts_on", "ends_on").
+		Values(challenge.CreatedBy, challenge.Title, challenge.Metric, challenge.Target,
+			challenge.StartsOn.Format(dateLayout), challenge.EndsOn.Format(dateLayout)).
+		Suffix("RETURNING id")
+
+	sqlQuery, args, err := query.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build query: %w", err)
+	}
+
+	if err = r.db.QueryRowContext(ctx, sqlQuery, args...).Scan(&challenge.ID); err != nil {
+		return nil, fmt.Errorf("insert challenge: %w", err)
+	}
+	return &challenge, nil
+}
+
+// FindActiveChallenges returns the challenges created by any of chatIDs that run on today, soonest
+// to end first, with their progress summed over the opted-in chats among chatIDs.
+func (r *SQLiteRepository) FindActiveChallenges(ctx context.Context, chatIDs []int64, today time.Time) ([]Challenge, error) {
+	todayStr := today.Format(dateLayout)
+
+	query := qb.Select("id", "created_by", "title", "metric", "target", "starts_on", "ends_on").
+		From("challenges").
+		Where(squirrel.Eq{"created_by": chatIDs}).
+		Where(squirrel.LtOrEq{"starts_on": todayStr}).
+		Where(squirrel.GtOrEq{"ends_on": todayStr}).
+		OrderBy("ends_on", "id")
+
+	sqlQuery, args, err := query.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build query: %w", err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("find challenges: %w", err)
+	}
+	defer rows.Close()
+
+	var res []Challenge
+	for rows.Next() {
+		var (
+			c                      Challenge
+			startsOnStr, endsOnStr string
+		)
+		if err = rows.Scan(&c.ID, &c.CreatedBy, &c.Title, &c.Metric, &c.Target, &startsOnStr, &endsOnStr); err != nil {
+			return nil, fmt.Errorf("scan challenge: %w", err)
+		}
+		if c.StartsOn, err = time.Parse(dateLayout, startsOnStr); err != nil {
+			return nil, fmt.Errorf("parse start date: %w", err)
+		}
+		if c.EndsOn, err = time.Parse(dateLayout, endsOnStr); err != nil {
+			return nil, fmt.Errorf("parse end date: %w", err)
+		}
+		res = append(res, c)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate challenges: %w", err)
+	}
+
+	for i := range res {
+		entries, err := r.GetLeaderboard(ctx, chatIDs, res[i].StartsOn, res[i].EndsOn)
+		if err != nil {
+			return nil, fmt.Errorf("get challenge progress: %w", err)
+		}
+		for _, e := range entries {
+			res[i].Progress += e.Value(res[i].Metric)
+		}
+	}
+
+	return res, nil
+}
+
+// DeleteChallenge removes a challenge. Only the chat that created it may, so anybody else's attempt
+// is ErrNotFound.
+func (r *SQLiteRepository) DeleteChallenge(ctx context.Context, chatID, id int64) error {
+	query := qb.Delete("challenges").
+		Where(squirrel.Eq{"id": id, "created_by": chatID})
+
+	sqlQuery, args, err := query.ToSql()
+	if err != nil {
+		return fmt.Errorf("build delete query: %w", err)
+	}
+
+	res, err := r.db.ExecContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return fmt.Errorf("delete challenge: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get affected rows: %w", err)
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package dal_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+)
+
+const (
+	otherChatID  int64 = 43
+	hiddenChatID int64 = 44
+	// foreignChatID opted in but is not one of the chats being compared.
+	foreignChatID int64 = 45
+)
+
+var leaderboardChats = []int64{dal.TestChatID, otherChatID, hiddenChatID}
+
+func seedLeaderboard(t *testing.T, r *dal.TestRepo) {
+	t.Helper()
+	ctx := context.Background()
+
+	for chatID, v := range map[int64]dal.LeaderboardVisibility{
+		dal.TestChatID: dal.LeaderboardPublic,
+		otherChatID:    dal.LeaderboardAnonymous,
+		foreignChatID:  dal.LeaderboardPublic,
+	} {
+		if err := r.SetLeaderboardVisibility(ctx, chatID, v, "chat"); err != nil {
+			t.Fatalf("SetLeaderboardVisibility: %v", err)
+		}
+	}
+
+	r.SeedChatDay(dal.TestChatID, daysAgo(1), 8, 2)
+	r.SeedChatDay(dal.TestChatID, daysAgo(10), 50, 0) // outside the period
+	r.SeedChatDay(otherChatID, daysAgo(2), 15, 5)
+	r.SeedChatDay(hiddenChatID, daysAgo(1), 100, 0)
+	r.SeedChatDay(foreignChatID, daysAgo(1), 100, 0)
+}
+
+func TestGetLeaderboard(t *testing.T) {
+	r := dal.NewTestRepo(t)
+	seedLeaderboard(t, r)
+
+	got, err := r.GetLeaderboard(context.Background(), leaderboardChats, daysAgo(6), testToday)
+	if err != nil {
+		t.Fatalf("GetLeaderboard: %v", err)
+	}
+
+	want := []dal.LeaderboardEntry{
+		{ChatID: otherChatID, Anonymous: true, Answers: 20, Guessed: 15},
+		{ChatID: dal.TestChatID, Name: "chat", Answers: 10, Guessed: 8},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("entries = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("entry %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestGetLeaderboardVisibilityDefaultsToHidden(t *testing.T) {
+	r := dal.NewTestRepo(t)
+
+	v, name, err := r.GetLeaderboardVisibility(context.Background(), dal.TestChatID)
+	if err != nil {
+		t.Fatalf("GetLeaderboardVisibility: %v", err)
+	}
+	if v != dal.LeaderboardHidden || name != "" {
+		t.Errorf("visibility, name = %q, %q, want hidden and no name", v, name)
+	}
+}
+
+func TestSetLeaderboardVisibilityRejectsUnknownValue(t *testing.T) {
+	r := dal.NewTestRepo(t)
+
+	if err := r.SetLeaderboardVisibility(context.Background(), dal.TestChatID, "everyone", ""); err == nil {
+		t.Fatal("SetLeaderboardVisibility accepted an unknown visibility")
+	}
+}
+
+func TestFindActiveChallenges(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+	seedLeaderboard(t, r)
+
+	active, err := r.CreateChallenge(ctx, dal.Challenge{
+		CreatedBy: otherChatID, Title: "answer 50", Metric: dal.MetricAnswers, Target: 50,
+		StartsOn: daysAgo(6), EndsOn: testToday.AddDate(0, 0, 1),
+	})
+	if err != nil {
+		t.Fatalf("CreateChallenge: %v", err)
+	}
+	for _, c := range []dal.Challenge{
+		{CreatedBy: dal.TestChatID, Title: "over", Metric: dal.MetricAnswers, Target: 5, StartsOn: daysAgo(20), EndsOn: daysAgo(10)},
+		{CreatedBy: foreignChatID, Title: "not ours", Metric: dal.MetricAnswers, Target: 5, StartsOn: daysAgo(1), EndsOn: testToday},
+	} {
+		if _, err = r.CreateChallenge(ctx, c); err != nil {
+			t.Fatalf("CreateChallenge: %v", err)
+		}
+	}
+
+	got, err := r.FindActiveChallenges(ctx, leaderboardChats, testToday)
+	if err != nil {
+		t.Fatalf("FindActiveChallenges: %v", err)
+	}
+	if len(got) != 1 || got[0].ID != active.ID {
+		t.Fatalf("challenges = %+v, want only %q", got, active.Title)
+	}
+	// 10 from the public chat and 20 from the anonymous one; the hidden and foreign chats do not
+	// count.
+	if got[0].Progress != 30 {
+		t.Errorf("Progress = %d, want 30", got[0].Progress)
+	}
+}
+
+func TestCreateChallengeRejectsInvalid(t *testing.T) {
+	tests := []struct {
+		name      string
+		challenge dal.Challenge
+	}{
+		{name: "unknown metric", challenge: dal.Challenge{Metric: "minutes", Target: 1, StartsOn: testToday, EndsOn: testToday}},
+		{name: "no target", challenge: dal.Challenge{Metric: dal.MetricAnswers, StartsOn: testToday, EndsOn: testToday}},
+		{name: "ends before it starts", challenge: dal.Challenge{Metric: dal.MetricAnswers, Target: 1, StartsOn: testToday, EndsOn: daysAgo(1)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := dal.NewTestRepo(t)
+			if _, err := r.CreateChallenge(context.Background(), tt.challenge); err == nil {
+				t.Error("CreateChallenge accepted an invalid challenge")
+			}
+		})
+	}
+}
+
+func TestDeleteChallengeOnlyByCreator(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+
+	c, err := r.CreateChallenge(ctx, dal.Challenge{
+		CreatedBy: otherChatID, Title: "t", Metric: dal.MetricLearned, Target: 30, StartsOn: testToday, EndsOn: testToday,
+	})
+	if err != nil {
+		t.Fatalf("CreateChallenge: %v", err)
+	}
+
+	if err = r.DeleteChallenge(ctx, dal.TestChatID, c.ID); !errors.Is(err, dal.ErrNotFound) {
+		t.Errorf("DeleteChallenge by another chat: err = %v, want ErrNotFound", err)
+	}
+	if err = r.DeleteChallenge(ctx, otherChatID, c.ID); err != nil {
+		t.Errorf("DeleteChallenge by its creator: %v", err)
+	}
+}
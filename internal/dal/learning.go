@@ -7,23 +7,31 @@ import (
 	"github.com/Masterminds/squirrel"
 )
 
-// RegisterGuess records a correct answer: the word's streak grows and today's counters follow it.
-func (r *SQLiteRepository) RegisterGuess(ctx context.Context, chatID int64, word string) error {
-	return r.inTx(ctx, func(e execer) error {
-		if err := increaseGuessedStreak(ctx, e, chatID, word); err != nil {
-			return fmt.Errorf("increase guessed streak: %w", err)
-		}
-		if err := incrementWordGuessed(ctx, e, chatID); err != nil {
-			return fmt.Errorf("increment word guessed: %w", err)
-		}
-		if err := updateTotalWordsLearned(ctx, e, chatID, r.streakLimit); err != nil {
-			return fmt.Errorf("update total words learned: %w", err)
-		}
-		return nil
-	})
+// registerGuess applies a correct answer: the word's streak grows and today's counters follow it. A
+// learned word gets its next review scheduled; see review.go. It can be undone; see undo.go.
+func (r *SQLiteRepository) registerGuess(ctx context.Context, e execer, chatID int64, word string) error {
+	if err := increaseGuessedStreak(ctx, e, chatID, word); err != nil {
+		return fmt.Errorf("increase guessed streak: %w", err)
+	}
+	if err := r.scheduleReview(ctx, e, chatID, word); err != nil {
+		return fmt.Errorf("schedule review: %w", err)
+	}
+	if err := logAnswer(ctx, e, chatID, word, true); err != nil {
+		return fmt.Errorf("log answer: %w", err)
+	}
+	if err := r.drillGuess(ctx, e, chatID, word); err != nil {
+		return fmt.Errorf("drill guess: %w", err)
+	}
+	if err := incrementWordGuessed(ctx, e, chatID); err != nil {
+		return fmt.Errorf("increment word guessed: %w", err)
+	}
+	if err := updateTotalWordsLearned(ctx, e, chatID, r.streakLimit); err != nil {
+		return fmt.Errorf("update total words learned: %w", err)
+	}
+	return nil
 }
 
-// RegisterMiss records a wrong answer: the word's streak drops back to zero, the word requests batch
+// registerMiss applies a wrong answer: the word's streak drops back to zero, the word requests batch
 // membership again, and today's counters follow.
 //
 // Requesting membership is what stops a forgotten word from disappearing again. It matters most for
@@ -31,28 +39,52 @@ func (r *SQLiteRepository) RegisterGuess(ctx context.Context, chatID int64, word
 // already queued behind it, the request is a no-op. BOT_LEARNING_BATCH_SIZE is a hard cap: if the
 // batch is full the word is appended to learning_batch_queue instead of being lost, and is drained
 // oldest-first the next time RefillLearningBatch runs.
-func (r *SQLiteRepository) RegisterMiss(ctx context.Context, chatID int64, word string) error {
-	return r.inTx(ctx, func(e execer) error {
-		if err := resetGuessedStreak(ctx, e, chatID, word); err != nil {
-			return fmt.Errorf("reset guessed streak: %w", err)
-		}
-		if err := requestBatchMembership(ctx, e, chatID, word, r.batchSize); err != nil {
-			return fmt.Errorf("request batch membership: %w", err)
-		}
-		if err := incrementWordMissed(ctx, e, chatID); err != nil {
-			return fmt.Errorf("increment word missed: %w", err)
+//
+// A batch of dozens of words brings a missed word back only by chance, so it also enters the drill,
+// which asks it again at short intervals until it is answered correctly twice in a row.
+//
+// Every miss is also a lapse, and a word with enough of them becomes a leech; see leech.go. A
+// suspended word, leech or not, skips both of the above.
+//
+// A miss can be undone like a guess; see undo.go. It reports whether the miss made the word a leech.
+func (r *SQLiteRepository) registerMiss(ctx context.Context, e execer, chatID int64, word string) (bool, error) {
+	if err := resetGuessedStreak(ctx, e, chatID, word); err != nil {
+		return false, fmt.Errorf("reset guessed streak: %w", err)
+	}
+	if err := logAnswer(ctx, e, chatID, word, false); err != nil {
+		return false, fmt.Errorf("log answer: %w", err)
+	}
+	leech, err := r.registerLapse(ctx, e, chatID, word)
+	if err != nil {
+		return false, fmt.Errorf("register lapse: %w", err)
+	}
+
+	suspended, err := wordSuspended(ctx, e, chatID, word)
+	if err != nil {
+		return false, fmt.Errorf("check suspension: %w", err)
+	}
+	if !suspended {
+		if err = requestBatchMembership(ctx, e, chatID, word, r.batchSize); err != nil {
+			return false, fmt.Errorf("request batch membership: %w", err)
 		}
-		if err := updateTotalWordsLearned(ctx, e, chatID, r.streakLimit); err != nil {
-			return fmt.Errorf("update total words learned: %w", err)
+		if err = r.drillMiss(ctx, e, chatID, word); err != nil {
+			return false, fmt.Errorf("drill miss: %w", err)
 		}
-		return nil
-	})
+	}
+
+	if err = incrementWordMissed(ctx, e, chatID); err != nil {
+		return false, fmt.Errorf("increment word missed: %w", err)
+	}
+	if err = updateTotalWordsLearned(ctx, e, chatID, r.streakLimit); err != nil {
+		return false, fmt.Errorf("update total words learned: %w", err)
+	}
+	return leech, nil
 }
 
 // ResetStreak drops a word's streak back to zero on purpose, optionally requesting batch membership
 // so that it is asked again soon.
 //
-// Unlike RegisterMiss this is a deliberate correction rather than a wrong answer, so it does not
+// Unlike a miss this is a deliberate correction rather than a wrong answer, so it does not
 // touch the daily guessed/missed counters. Requesting membership when the batch is full queues the
 // word instead of overflowing the configured size; RefillLearningBatch drains it oldest-first once
 // there is room again.
@@ -102,6 +134,10 @@ func (r *SQLiteRepository) ResolveWordConflict(
 				return fmt.Errorf("request batch membership: %w", err)
 			}
 		}
+		// Whatever the owner decided about their own progress, subscribers only get the new text.
+		if err := propagateDeckWordEdit(ctx, e, chatID, word, word); err != nil {
+			return fmt.Errorf("propagate to deck subscribers: %w", err)
+		}
 
 		if err := updateTotalWordsLearned(ctx, e, chatID, r.streakLimit); err != nil {
 			return fmt.Errorf("update total words learned: %w", err)
@@ -135,19 +171,19 @@ func (r *SQLiteRepository) MarkWordReviewed(ctx context.Context, chatID int64, w
 }
 
 // RefillLearningBatch evicts words that reached the streak limit from the learning batch, then tops
-// it back up to batchSize: first draining learning_batch_queue oldest-first (words that explicitly
-// requested membership - a miss, a deliberate reset, a conflict resolution, or a new word - while the
-// batch was full), and only once the queue is exhausted falling back to a random pick from the wider
-// pool of still-eligible words nobody has explicitly asked to re-admit.
+// it back up to the chat's batch size: first draining learning_batch_queue oldest-first (words that
+// explicitly requested membership - a miss, a deliberate reset, a conflict resolution, or a new word -
+// while the batch was full), and only once the queue is exhausted falling back to the wider pool of
+// still-eligible words nobody has explicitly asked to re-admit, picked by the chat's RefillStrategy.
 //
 // The drain always runs before the fallback, so a still-queued word can never be skipped by the
-// random pick while it waits its turn.
-func (r *SQLiteRepository) RefillLearningBatch(ctx context.Context, chatID int64) (int, int, error) {
-	var evicted, added int
+// strategy while it waits its turn. Both pass over suspended and buried words; see suspension.go.
+func (r *SQLiteRepository) RefillLearningBatch(ctx context.Context, chatID int64) (*RefillReport, error) {
+	report := &RefillReport{}
 
 	err := r.inTx(ctx, func(e execer) error {
 		var err error
-		if evicted, err = deleteFromLearningBatchGeGuessedStreak(ctx, e, chatID, r.streakLimit); err != nil {
+		if report.Evicted, err = deleteFromLearningBatchGeGuessedStreak(ctx, e, chatID, r.streakLimit); err != nil {
 			return fmt.Errorf("delete from learning batch: %w", err)
 		}
 
@@ -156,62 +192,50 @@ func (r *SQLiteRepository) RefillLearningBatch(ctx context.Context, chatID int64
 			return fmt.Errorf("get batched word translations count: %w", err)
 		}
 
-		room := r.batchSize - batched
+		batchSize, _, err := batchSizeOf(ctx, e, chatID, r.batchSize)
+		if err != nil {
+			return fmt.Errorf("get batch size: %w", err)
+		}
+
+		room := batchSize - batched
 		if room <= 0 {
 			return nil
 		}
 
-		drained, err := drainLearningBatchQueue(ctx, e, chatID, r.streakLimit, room)
-		if err != nil {
+		if report.Drained, err = drainLearningBatchQueue(ctx, e, chatID, r.streakLimit, room); err != nil {
 			return fmt.Errorf("drain learning batch queue: %w", err)
 		}
-		added += drained
-		room -= drained
+		room -= report.Drained
 		if room <= 0 {
 			return nil
 		}
 
-		filled, err := fillLearningBatch(ctx, e, chatID, r.streakLimit, room)
+		strategy, tag, err := refillStrategyOf(ctx, e, chatID)
 		if err != nil {
-			return fmt.Errorf("fill learning batch: %w", err)
+			return fmt.Errorf("get refill strategy: %w", err)
+		}
+		strategies := []RefillStrategy{strategy}
+		if strategy != RefillRandom {
+			strategies = append(strategies, RefillRandom)
+		}
+
+		for _, s := range strategies {
+			filled, err := fillLearningBatch(ctx, e, chatID, r.streakLimit, room, s, tag)
+			if err != nil {
+				return fmt.Errorf("fill learning batch by %s: %w", s, err)
+			}
+			if filled > 0 || s == strategy {
+				report.Fills = append(report.Fills, RefillFill{Strategy: s, Added: filled})
+			}
+			if room -= filled; room <= 0 {
+				break
+			}
 		}
-		added += filled
 		return nil
 	})
 	if err != nil {
-		return 0, 0, err
-	}
-
-	return evicted, added, nil
-}
-
-func fillLearningBatch(ctx context.Context, e execer, chatID int64, guessedStreakLimit, limit int) (int, error) {
-	// The nested select is built with the package-level builder (":?" placeholders) so that the
-	// outer builder's Dollar format is applied exactly once, over the whole statement.
-	query := qb.Insert("learning_batches").
-		Columns("chat_id", "word").
-		Select(squirrel.Select("chat_id", "word").
-			From("word_translations").
-			Where("chat_id = ? AND guessed_streak < ?", chatID, guessedStreakLimit).
-			Where("word NOT IN (SELECT word FROM learning_batches WHERE chat_id = ?)", chatID).
-			OrderBy("random()").
-			Limit(uint64(limit))). //nolint:gosec // limit is bounded by batchSize
-		Suffix("ON CONFLICT DO NOTHING")
-
-	sql, args, err := query.ToSql()
-	if err != nil {
-		return 0, fmt.Errorf("build insert query: %w", err)
-	}
-
-	res, err := e.ExecContext(ctx, sql, args...)
-	if err != nil {
-		return 0, fmt.Errorf("add to learning batch: %w", err)
-	}
-
-	affected, err := res.RowsAffected()
-	if err != nil {
-		return 0, fmt.Errorf("get rows affected: %w", err)
+		return nil, err
 	}
 
-	return int(affected), nil
+	return report, nil
 }
@@ -8,13 +8,12 @@ import (
 	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
 )
 
-func TestRegisterGuess(t *testing.T) {
-	ctx := context.Background()
+func TestGuess(t *testing.T) {
 	r := dal.NewTestRepo(t)
 	r.AddWord("word", 14)
 
-	if err := r.RegisterGuess(ctx, dal.TestChatID, "word"); err != nil {
-		t.Fatalf("RegisterGuess: %v", err)
+	if err := r.Guess(dal.TestChatID, "word"); err != nil {
+		t.Fatalf("Guess: %v", err)
 	}
 
 	if got := r.StreakOf("word"); got != 15 {
@@ -31,13 +30,12 @@ func TestRegisterGuess(t *testing.T) {
 
 // A missed word must land back in the learning batch, otherwise a word forgotten during review
 // silently disappears again.
-func TestRegisterMissDemotesLearnedWordIntoBatch(t *testing.T) {
-	ctx := context.Background()
+func TestMissDemotesLearnedWordIntoBatch(t *testing.T) {
 	r := dal.NewTestRepo(t)
 	r.AddWord("word", 20)
 
-	if err := r.RegisterMiss(ctx, dal.TestChatID, "word"); err != nil {
-		t.Fatalf("RegisterMiss: %v", err)
+	if _, err := r.Miss(dal.TestChatID, "word"); err != nil {
+		t.Fatalf("Miss: %v", err)
 	}
 
 	if got := r.StreakOf("word"); got != 0 {
@@ -55,14 +53,13 @@ func TestRegisterMissDemotesLearnedWordIntoBatch(t *testing.T) {
 	}
 }
 
-func TestRegisterMissOnBatchedWordIsIdempotent(t *testing.T) {
-	ctx := context.Background()
+func TestMissOnBatchedWordIsIdempotent(t *testing.T) {
 	r := dal.NewTestRepo(t)
 	r.AddWord("word", 3)
 	r.SeedBatch("word")
 
-	if err := r.RegisterMiss(ctx, dal.TestChatID, "word"); err != nil {
-		t.Fatalf("RegisterMiss: %v", err)
+	if _, err := r.Miss(dal.TestChatID, "word"); err != nil {
+		t.Fatalf("Miss: %v", err)
 	}
 
 	if got := len(r.BatchWords()); got != 1 {
@@ -71,10 +68,10 @@ func TestRegisterMissOnBatchedWordIsIdempotent(t *testing.T) {
 }
 
 // The batch is now a hard cap: a miss that would otherwise overflow it queues the word instead.
-func TestRegisterMissQueuesWhenBatchIsFull(t *testing.T) {
+func TestMissQueuesWhenBatchIsFull(t *testing.T) {
 	ctx := context.Background()
 	r := dal.NewTestRepo(t)
-	r.SetBatchSize(3)
+	r.SetDefaultBatchSize(3)
 
 	for i := range 3 {
 		word := fmt.Sprintf("learning-%d", i)
@@ -83,8 +80,8 @@ func TestRegisterMissQueuesWhenBatchIsFull(t *testing.T) {
 	}
 	r.AddWord("forgotten", 20)
 
-	if err := r.RegisterMiss(ctx, dal.TestChatID, "forgotten"); err != nil {
-		t.Fatalf("RegisterMiss: %v", err)
+	if _, err := r.Miss(dal.TestChatID, "forgotten"); err != nil {
+		t.Fatalf("Miss: %v", err)
 	}
 
 	if got := len(r.BatchWords()); got != 3 {
@@ -98,10 +95,11 @@ func TestRegisterMissQueuesWhenBatchIsFull(t *testing.T) {
 	}
 
 	// The next refill must drain it once room appears.
-	evicted, added, err := r.RefillLearningBatch(ctx, dal.TestChatID)
+	report, err := r.RefillLearningBatch(ctx, dal.TestChatID)
 	if err != nil {
 		t.Fatalf("RefillLearningBatch: %v", err)
 	}
+	evicted, added := report.Evicted, report.Added()
 	if evicted != 0 || added != 0 {
 		t.Errorf("evicted/added = %d/%d, want 0/0 (still no room)", evicted, added)
 	}
@@ -111,10 +109,9 @@ func TestRegisterMissQueuesWhenBatchIsFull(t *testing.T) {
 }
 
 // A word that misses twice while already queued must not be duplicated or reordered.
-func TestRegisterMissOnQueuedWordIsIdempotent(t *testing.T) {
-	ctx := context.Background()
+func TestMissOnQueuedWordIsIdempotent(t *testing.T) {
 	r := dal.NewTestRepo(t)
-	r.SetBatchSize(1)
+	r.SetDefaultBatchSize(1)
 
 	r.AddWord("resident", 1)
 	r.SeedBatch("resident")
@@ -122,11 +119,11 @@ func TestRegisterMissOnQueuedWordIsIdempotent(t *testing.T) {
 	r.AddWord("b", 20)
 	r.SeedQueue("a", "b")
 
-	if err := r.RegisterMiss(ctx, dal.TestChatID, "a"); err != nil {
-		t.Fatalf("RegisterMiss: %v", err)
+	if _, err := r.Miss(dal.TestChatID, "a"); err != nil {
+		t.Fatalf("Miss: %v", err)
 	}
-	if err := r.RegisterMiss(ctx, dal.TestChatID, "a"); err != nil {
-		t.Fatalf("RegisterMiss: %v", err)
+	if _, err := r.Miss(dal.TestChatID, "a"); err != nil {
+		t.Fatalf("Miss: %v", err)
 	}
 
 	if got := r.QueueWords(); len(got) != 2 || got[0] != "a" || got[1] != "b" {
@@ -165,15 +162,15 @@ func TestResetStreak(t *testing.T) {
 }
 
 // A deliberate reset is a correction, not a wrong answer, so it must not pollute the daily
-// guessed/missed counters the way RegisterMiss does.
+// guessed/missed counters the way a miss does.
 func TestResetStreakDoesNotCountAsMiss(t *testing.T) {
 	ctx := context.Background()
 	r := dal.NewTestRepo(t)
 	r.AddWord("word", 20)
 	r.AddWord("other", 20)
 	// Create today's statistics row via a real answer.
-	if err := r.RegisterGuess(ctx, dal.TestChatID, "other"); err != nil {
-		t.Fatalf("RegisterGuess: %v", err)
+	if err := r.Guess(dal.TestChatID, "other"); err != nil {
+		t.Fatalf("Guess: %v", err)
 	}
 
 	if err := r.ResetStreak(ctx, dal.TestChatID, "word", true); err != nil {
@@ -254,7 +251,7 @@ func TestResolveWordConflictRejectsUnknownResolution(t *testing.T) {
 func TestRefillLearningBatchEvictsAndFills(t *testing.T) {
 	ctx := context.Background()
 	r := dal.NewTestRepo(t)
-	r.SetBatchSize(3)
+	r.SetDefaultBatchSize(3)
 
 	r.AddWord("learned", 15)
 	r.AddWord("beyond", 20)
@@ -264,10 +261,11 @@ func TestRefillLearningBatchEvictsAndFills(t *testing.T) {
 	// Both finished words start out batched and must be evicted.
 	r.SeedBatch("learned", "beyond")
 
-	evicted, added, err := r.RefillLearningBatch(ctx, dal.TestChatID)
+	report, err := r.RefillLearningBatch(ctx, dal.TestChatID)
 	if err != nil {
 		t.Fatalf("RefillLearningBatch: %v", err)
 	}
+	evicted, added := report.Evicted, report.Added()
 
 	if evicted != 2 {
 		t.Errorf("evicted = %d, want 2", evicted)
@@ -293,7 +291,7 @@ func TestRefillLearningBatchEvictsAndFills(t *testing.T) {
 func TestRefillLearningBatchStopsWhenOverFull(t *testing.T) {
 	ctx := context.Background()
 	r := dal.NewTestRepo(t)
-	r.SetBatchSize(3)
+	r.SetDefaultBatchSize(3)
 
 	for i := range 5 {
 		word := fmt.Sprintf("learning-%d", i)
@@ -303,10 +301,11 @@ func TestRefillLearningBatchStopsWhenOverFull(t *testing.T) {
 	r.AddWord("spare", 1)
 
 	// Batch already holds 5 against a limit of 3: nothing may be added, nothing evicted.
-	evicted, added, err := r.RefillLearningBatch(ctx, dal.TestChatID)
+	report, err := r.RefillLearningBatch(ctx, dal.TestChatID)
 	if err != nil {
 		t.Fatalf("RefillLearningBatch: %v", err)
 	}
+	evicted, added := report.Evicted, report.Added()
 
 	if evicted != 0 {
 		t.Errorf("evicted = %d, want 0", evicted)
@@ -325,17 +324,18 @@ func TestRefillLearningBatchStopsWhenOverFull(t *testing.T) {
 func TestRefillLearningBatchSkipsAlreadyBatched(t *testing.T) {
 	ctx := context.Background()
 	r := dal.NewTestRepo(t)
-	r.SetBatchSize(4)
+	r.SetDefaultBatchSize(4)
 
 	for i := range 4 {
 		r.AddWord(fmt.Sprintf("learning-%d", i), 1)
 	}
 	r.SeedBatch("learning-0")
 
-	_, added, err := r.RefillLearningBatch(ctx, dal.TestChatID)
+	report, err := r.RefillLearningBatch(ctx, dal.TestChatID)
 	if err != nil {
 		t.Fatalf("RefillLearningBatch: %v", err)
 	}
+	added := report.Added()
 
 	if added != 3 {
 		t.Errorf("added = %d, want 3 (the one already batched must not be re-added)", added)
@@ -348,13 +348,14 @@ func TestRefillLearningBatchSkipsAlreadyBatched(t *testing.T) {
 func TestRefillLearningBatchWithNothingToLearn(t *testing.T) {
 	ctx := context.Background()
 	r := dal.NewTestRepo(t)
-	r.SetBatchSize(10)
+	r.SetDefaultBatchSize(10)
 	r.AddWord("learned", 20)
 
-	evicted, added, err := r.RefillLearningBatch(ctx, dal.TestChatID)
+	report, err := r.RefillLearningBatch(ctx, dal.TestChatID)
 	if err != nil {
 		t.Fatalf("RefillLearningBatch: %v", err)
 	}
+	evicted, added := report.Evicted, report.Added()
 	if evicted != 0 || added != 0 {
 		t.Errorf("evicted/added = %d/%d, want 0/0", evicted, added)
 	}
@@ -385,7 +386,7 @@ func TestResetStreakRecordsLearnedCountOnAQuietDay(t *testing.T) {
 func TestResetStreakQueuesWhenBatchIsFull(t *testing.T) {
 	ctx := context.Background()
 	r := dal.NewTestRepo(t)
-	r.SetBatchSize(1)
+	r.SetDefaultBatchSize(1)
 	r.AddWord("resident", 1)
 	r.SeedBatch("resident")
 	r.AddWord("word", 20)
@@ -405,7 +406,7 @@ func TestResetStreakQueuesWhenBatchIsFull(t *testing.T) {
 func TestResolveWordConflictResetAndBatchQueuesWhenBatchIsFull(t *testing.T) {
 	ctx := context.Background()
 	r := dal.NewTestRepo(t)
-	r.SetBatchSize(1)
+	r.SetDefaultBatchSize(1)
 	r.AddWord("resident", 1)
 	r.SeedBatch("resident")
 	r.AddWord("apple", 18)
@@ -444,7 +445,7 @@ func TestCreateWordTranslationEntersBatchImmediatelyWhenRoom(t *testing.T) {
 func TestCreateWordTranslationQueuesWhenBatchIsFull(t *testing.T) {
 	ctx := context.Background()
 	r := dal.NewTestRepo(t)
-	r.SetBatchSize(1)
+	r.SetDefaultBatchSize(1)
 	r.AddWord("resident", 1)
 	r.SeedBatch("resident")
 
@@ -465,7 +466,7 @@ func TestCreateWordTranslationQueuesWhenBatchIsFull(t *testing.T) {
 func TestRefillLearningBatchDrainsQueueBeforeRandomFill(t *testing.T) {
 	ctx := context.Background()
 	r := dal.NewTestRepo(t)
-	r.SetBatchSize(2)
+	r.SetDefaultBatchSize(2)
 
 	r.AddWord("never-queued", 1)
 	r.AddWord("a", 1)
@@ -473,10 +474,11 @@ func TestRefillLearningBatchDrainsQueueBeforeRandomFill(t *testing.T) {
 	r.AddWord("c", 1)
 	r.SeedQueue("a", "b", "c")
 
-	_, added, err := r.RefillLearningBatch(ctx, dal.TestChatID)
+	report, err := r.RefillLearningBatch(ctx, dal.TestChatID)
 	if err != nil {
 		t.Fatalf("RefillLearningBatch: %v", err)
 	}
+	added := report.Added()
 
 	if added != 2 {
 		t.Fatalf("added = %d, want 2", added)
@@ -503,16 +505,17 @@ func TestRefillLearningBatchDrainsQueueBeforeRandomFill(t *testing.T) {
 func TestRefillLearningBatchFallsBackToRandomFillWhenQueueExhausted(t *testing.T) {
 	ctx := context.Background()
 	r := dal.NewTestRepo(t)
-	r.SetBatchSize(3)
+	r.SetDefaultBatchSize(3)
 
 	r.AddWord("never-queued", 1)
 	r.AddWord("queued", 1)
 	r.SeedQueue("queued")
 
-	_, added, err := r.RefillLearningBatch(ctx, dal.TestChatID)
+	report, err := r.RefillLearningBatch(ctx, dal.TestChatID)
 	if err != nil {
 		t.Fatalf("RefillLearningBatch: %v", err)
 	}
+	added := report.Added()
 
 	if added != 2 {
 		t.Fatalf("added = %d, want 2 (one drained, one from the fallback)", added)
@@ -530,17 +533,18 @@ func TestRefillLearningBatchFallsBackToRandomFillWhenQueueExhausted(t *testing.T
 func TestRefillLearningBatchDrainOpensRoomAfterEviction(t *testing.T) {
 	ctx := context.Background()
 	r := dal.NewTestRepo(t)
-	r.SetBatchSize(1)
+	r.SetDefaultBatchSize(1)
 
 	r.AddWord("graduated", 20)
 	r.SeedBatch("graduated")
 	r.AddWord("waiting", 1)
 	r.SeedQueue("waiting")
 
-	evicted, added, err := r.RefillLearningBatch(ctx, dal.TestChatID)
+	report, err := r.RefillLearningBatch(ctx, dal.TestChatID)
 	if err != nil {
 		t.Fatalf("RefillLearningBatch: %v", err)
 	}
+	evicted, added := report.Evicted, report.Added()
 
 	if evicted != 1 {
 		t.Errorf("evicted = %d, want 1", evicted)
@@ -563,29 +567,29 @@ func TestRefillLearningBatchDrainOpensRoomAfterEviction(t *testing.T) {
 func TestBatchAndQueueAreMutuallyExclusive(t *testing.T) {
 	ctx := context.Background()
 	r := dal.NewTestRepo(t)
-	r.SetBatchSize(2)
+	r.SetDefaultBatchSize(2)
 
 	r.AddWord("resident-1", 1)
 	r.AddWord("resident-2", 1)
 	r.SeedBatch("resident-1", "resident-2")
 
 	// All of these arrive while the batch is already full, so they must all end up queued, not
-	// batched - and calling CreateWordTranslation, RegisterMiss and ResolveWordConflict again on the
+	// batched - and calling CreateWordTranslation, a miss and ResolveWordConflict again on the
 	// same words afterward must not create any overlap either.
 	if err := r.CreateWordTranslation(ctx, dal.TestChatID, "new", "translation", ""); err != nil {
 		t.Fatalf("CreateWordTranslation: %v", err)
 	}
 	r.AddWord("missed", 20)
-	if err := r.RegisterMiss(ctx, dal.TestChatID, "missed"); err != nil {
-		t.Fatalf("RegisterMiss: %v", err)
+	if _, err := r.Miss(dal.TestChatID, "missed"); err != nil {
+		t.Fatalf("Miss: %v", err)
 	}
 	r.AddWord("conflicted", 20)
 	err := r.ResolveWordConflict(ctx, dal.TestChatID, "conflicted", "t", "", dal.ResolveResetAndBatch)
 	if err != nil {
 		t.Fatalf("ResolveWordConflict: %v", err)
 	}
-	if err := r.RegisterMiss(ctx, dal.TestChatID, "missed"); err != nil {
-		t.Fatalf("RegisterMiss (again): %v", err)
+	if _, err := r.Miss(dal.TestChatID, "missed"); err != nil {
+		t.Fatalf("Miss (again): %v", err)
 	}
 
 	batched := r.BatchWords()
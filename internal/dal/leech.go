@@ -0,0 +1,61 @@
+package dal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// A leech is a word that keeps being missed. Left alone it bounces between a miss and the
+// batch forever, taking up a place that other words could use, when what it needs is a mnemonic or an
+// example. Every miss counts as a lapse, and once a word's lapses reach LeechRules.Threshold it is
+// tagged LeechTag, again every Threshold/2 lapses after that if it keeps failing. With
+// LeechRules.Suspend it is also suspended; see suspension.go.
+
+// registerLapse counts a lapse against the word and reports whether that made it a leech, tagging
+// and, if configured to, suspending it.
+func (r *SQLiteRepository) registerLapse(ctx context.Context, e execer, chatID int64, word string) (bool, error) {
+	sqlQuery, args, err := qb.Update("word_translations").
+		Set("lapses", squirrel.Expr("lapses + 1")).
+		Where(squirrel.Eq{"chat_id": chatID, "word": word}).
+		Suffix("RETURNING lapses").
+		ToSql()
+	if err != nil {
+		return false, fmt.Errorf("build update query: %w", err)
+	}
+
+	var lapses int
+	if err = e.QueryRowContext(ctx, sqlQuery, args...).Scan(&lapses); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("increment lapses: %w", err)
+	}
+	if !r.leech.Reached(lapses) {
+		return false, nil
+	}
+
+	if sqlQuery, args, err = qb.Insert("word_tags").
+		Columns("chat_id", "word", "tag").
+		Values(chatID, word, LeechTag).
+		Suffix("ON CONFLICT (chat_id, word, tag) DO NOTHING").
+		ToSql(); err != nil {
+		return false, fmt.Errorf("build insert query: %w", err)
+	}
+	if _, err = e.ExecContext(ctx, sqlQuery, args...); err != nil {
+		return false, fmt.Errorf("tag leech: %w", err)
+	}
+
+	if r.leech.Suspend {
+		if err = updateSuspension(ctx, e, chatID, word, map[string]any{"suspended": true}); err != nil {
+			return false, fmt.Errorf("suspend leech: %w", err)
+		}
+		if err = takeOutOfRotation(ctx, e, chatID, word); err != nil {
+			return false, fmt.Errorf("suspend leech: %w", err)
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,137 @@
+package dal_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+)
+
+func TestMissFlagsLeech(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+	r.SetLeechRules(dal.LeechRules{Threshold: 4})
+	r.AddWord("word", 0)
+
+	// Flagged on reaching the threshold, then again every half of it.
+	want := []bool{false, false, false, true, false, true, false, true}
+	for i, w := range want {
+		leech, err := r.Miss(dal.TestChatID, "word")
+		if err != nil {
+			t.Fatalf("Miss: %v", err)
+		}
+		if leech != w {
+			t.Errorf("miss %d: leech = %v, want %v", i+1, leech, w)
+		}
+	}
+
+	wt, err := r.FindWordTranslation(ctx, dal.TestChatID, "word")
+	if err != nil {
+		t.Fatalf("FindWordTranslation: %v", err)
+	}
+	if wt.Lapses != len(want) {
+		t.Errorf("Lapses = %d, want %d", wt.Lapses, len(want))
+	}
+	if !slices.Equal(wt.Tags, []string{dal.LeechTag}) {
+		t.Errorf("Tags = %v, want [%s]", wt.Tags, dal.LeechTag)
+	}
+	if !r.IsBatched("word") {
+		t.Error("leech left the batch without Suspend")
+	}
+}
+
+func TestMissLeavesLeechesAloneWhenDisabled(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+	r.AddWord("word", 0)
+
+	for range 20 {
+		leech, err := r.Miss(dal.TestChatID, "word")
+		if err != nil {
+			t.Fatalf("Miss: %v", err)
+		}
+		if leech {
+			t.Fatal("leech reported with detection off")
+		}
+	}
+
+	wt, err := r.FindWordTranslation(ctx, dal.TestChatID, "word")
+	if err != nil {
+		t.Fatalf("FindWordTranslation: %v", err)
+	}
+	if wt.Lapses != 20 || len(wt.Tags) != 0 {
+		t.Errorf("Lapses = %d, Tags = %v, want 20 lapses and no tags", wt.Lapses, wt.Tags)
+	}
+}
+
+func TestSuspendedLeechStaysOutOfTheBatch(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+	r.SetLeechRules(dal.LeechRules{Threshold: 2, Suspend: true})
+	r.AddWord("leech", 0)
+	r.AddWord("other", 0)
+
+	for range 2 {
+		if _, err := r.Miss(dal.TestChatID, "leech"); err != nil {
+			t.Fatalf("Miss: %v", err)
+		}
+	}
+	wt, err := r.FindWordTranslation(ctx, dal.TestChatID, "leech")
+	if err != nil {
+		t.Fatalf("FindWordTranslation: %v", err)
+	}
+	if !wt.Suspended {
+		t.Error("leech is not suspended")
+	}
+	if r.IsBatched("leech") || r.IsQueued("leech") {
+		t.Error("suspended leech is still batched or queued")
+	}
+	assertDrill(t, r, "leech", nil)
+
+	// Missing it again does not bring it back either.
+	if _, err := r.Miss(dal.TestChatID, "leech"); err != nil {
+		t.Fatalf("Miss: %v", err)
+	}
+	if _, err := r.RefillLearningBatch(ctx, dal.TestChatID); err != nil {
+		t.Fatalf("RefillLearningBatch: %v", err)
+	}
+	if got := r.BatchWords(); !slices.Equal(got, []string{"other"}) {
+		t.Errorf("batch = %v, want [other]", got)
+	}
+
+	// Unsuspending it brings it back.
+	if err := r.SetWordSuspension(ctx, dal.TestChatID, "leech", false, time.Time{}); err != nil {
+		t.Fatalf("SetWordSuspension: %v", err)
+	}
+	if _, err := r.RefillLearningBatch(ctx, dal.TestChatID); err != nil {
+		t.Fatalf("RefillLearningBatch: %v", err)
+	}
+	if !r.IsBatched("leech") {
+		t.Error("word not refilled after it was unsuspended")
+	}
+}
+
+func TestFindWordTranslationsGuessedLeech(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+	r.SetLeechRules(dal.LeechRules{Threshold: 1})
+	r.AddWord("leech", 0)
+	r.AddWord("other", 0)
+
+	if _, err := r.Miss(dal.TestChatID, "leech"); err != nil {
+		t.Fatalf("Miss: %v", err)
+	}
+
+	got, total, err := r.FindWordTranslations(ctx, dal.TestChatID, dal.WordTranslationsFilter{
+		Guessed: dal.GuessedLeech,
+		Limit:   10,
+	})
+	if err != nil {
+		t.Fatalf("FindWordTranslations: %v", err)
+	}
+	if total != 1 || len(got) != 1 || got[0].Word != "leech" {
+		t.Errorf("got %d of %d words, want just leech: %+v", len(got), total, got)
+	}
+}
@@ -9,6 +9,12 @@ var (
 	ErrNotFound = errors.New("not found")
 	// ErrAlreadyExists is returned by inserts that refuse to overwrite what is already stored.
 	ErrAlreadyExists = errors.New("already exists")
+	// ErrAlreadyAnswered is returned when a word check is graded a second time.
+	ErrAlreadyAnswered = errors.New("already answered")
+	// ErrRefreshTokenReused is returned when a refresh token is presented after it was exchanged.
+	ErrRefreshTokenReused = errors.New("refresh token reused")
+	// ErrOwnDeck is returned when a chat tries to subscribe to a deck it owns.
+	ErrOwnDeck = errors.New("deck is owned by the chat")
 )
 
 type (
@@ -19,10 +25,24 @@ type (
 		Description   string
 		GuessedStreak int
 		ToReview      bool
+		// Lapses counts the times the word has been answered wrong; see leech.go.
+		Lapses int
+		// Suspended and BuriedUntil keep the word out of rotation, for good or until then; see
+		// suspension.go. BuriedUntil is zero for a word that was never buried.
+		Suspended   bool
+		BuriedUntil time.Time
+		// NextReviewAt is when a learned word is due for review; see review.go. It is zero for a word
+		// that is not learned, or was learned before reviews were scheduled.
+		NextReviewAt time.Time
+		// StreakLimit is the word's own streak limit, or else its tags'; see streak_limits.go. It is
+		// 0 for a word that goes by the configured one.
+		StreakLimit int
 		// InBatch reports whether the word has already requested batch membership: it is either in
 		// the active learning batch (one of the words being asked about right now) or waiting in
 		// learning_batch_queue behind it. Either way, requesting membership again is a no-op.
-		InBatch   bool
+		InBatch bool
+		// Tags are the word's labels, sorted.
+		Tags      []string
 		CreatedAt time.Time
 		UpdatedAt time.Time
 	}
@@ -43,10 +63,53 @@ type (
 		Confirmed bool
 	}
 
+	Deck struct {
+		ID          int64
+		OwnerChatID int64
+		Name        string
+		Words       int
+		Subscribers int
+		// Subscribed says whether the chat the decks were looked up for subscribes to this one.
+		Subscribed bool
+		CreatedAt  time.Time
+	}
+
+	// Session is a signed-in browser, keyed by the JWT ID of its access tokens. ExpiresAt is when its
+	// refresh token runs out.
+	Session struct {
+		ID         string
+		ChatID     int64
+		UserAgent  string
+		IP         string
+		CreatedAt  time.Time
+		LastSeenAt time.Time
+		ExpiresAt  time.Time
+		// MiniApp is set for a session signed in from the Telegram Mini App, whose cookies have to reach
+		// the web interface framed by Telegram.
+		MiniApp bool
+	}
+
+	// APIToken is a personal access token, without the token itself. ExpiresAt is zero for a token that
+	// never expires, LastUsedAt for one that was never used.
+	APIToken struct {
+		ID         int64
+		ChatID     int64
+		Name       string
+		Scopes     []TokenScope
+		CreatedAt  time.Time
+		ExpiresAt  time.Time
+		LastUsedAt time.Time
+	}
+
 	CallbackData struct {
-		ChatID    int64     `json:"-"`
-		ID        string    `json:"-"`
-		Word      string    `json:"word"`
+		ChatID int64  `json:"-"`
+		ID     string `json:"-"`
+		Word   string `json:"word"`
+		// QuizID is set on the buttons of a quiz card.
+		QuizID    int64     `json:"quiz_id,omitempty"`
 		ExpiresAt time.Time `json:"-"`
+		// Answer is how the word check was graded, empty while it has not been; see GradeCallback.
+		Answer     CallbackAnswer `json:"-"`
+		AnsweredAt time.Time      `json:"-"`
 	}
 )
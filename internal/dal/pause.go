@@ -0,0 +1,105 @@
+package dal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+)
+
+func (r *SQLiteRepository) GetPause(ctx context.Context, chatID int64) (*Pause, error) {
+	return chatPause(ctx, r.db, chatID)
+}
+
+// PauseChat pauses the chat from now until until. A chat that is already paused keeps its pause's
+// start, so the days behind it stay covered.
+func (r *SQLiteRepository) PauseChat(ctx context.Context, chatID int64, now, until time.Time) (*Pause, error) {
+	if !until.After(now) {
+		return nil, errors.New("pause must end in the future")
+	}
+	if until.After(now.AddDate(0, 0, MaxPauseDays)) {
+		return nil, fmt.Errorf("pause must not be longer than %d days", MaxPauseDays)
+	}
+
+	res := &Pause{From: now, Until: until}
+	err := r.inTx(ctx, func(e execer) error {
+		current, err := chatPause(ctx, e, chatID)
+		if err != nil {
+			return fmt.Errorf("get pause: %w", err)
+		}
+		if current.Active(now) {
+			res.From = current.From
+		}
+		return saveChatPause(ctx, e, chatID, *res)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (r *SQLiteRepository) ResumeChat(ctx context.Context, chatID int64, now time.Time) (bool, error) {
+	resumed := false
+	err := r.inTx(ctx, func(e execer) error {
+		current, err := chatPause(ctx, e, chatID)
+		if err != nil {
+			return fmt.Errorf("get pause: %w", err)
+		}
+		if !current.Active(now) {
+			return nil
+		}
+		// The pause is cut short rather than cleared, so the day it ends on is still covered.
+		current.Until = now
+		resumed = true
+		return saveChatPause(ctx, e, chatID, *current)
+	})
+	if err != nil {
+		return false, err
+	}
+	return resumed, nil
+}
+
+// chatPause returns the chat's latest pause, or a zero one if it was never paused.
+func chatPause(ctx context.Context, e execer, chatID int64) (*Pause, error) {
+	query := qb.Select("paused_from", "paused_until").
+		From("chat_settings").
+		Where(squirrel.Eq{"chat_id": chatID})
+
+	sqlQuery, args, err := query.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build query: %w", err)
+	}
+
+	var from, until sql.NullTime
+	if err = e.QueryRowContext(ctx, sqlQuery, args...).Scan(&from, &until); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &Pause{}, nil
+		}
+		return nil, fmt.Errorf("get pause: %w", err)
+	}
+	if !from.Valid || !until.Valid {
+		return &Pause{}, nil
+	}
+	return &Pause{From: from.Time, Until: until.Time}, nil
+}
+
+func saveChatPause(ctx context.Context, e execer, chatID int64, p Pause) error {
+	query := qb.Insert("chat_settings").
+		Columns("chat_id", "paused_from", "paused_until").
+		Values(chatID, p.From, p.Until).
+		Suffix("ON CONFLICT (chat_id) DO UPDATE SET " +
+			"paused_from = EXCLUDED.paused_from, paused_until = EXCLUDED.paused_until")
+
+	sqlQuery, args, err := query.ToSql()
+	if err != nil {
+		return fmt.Errorf("build query: %w", err)
+	}
+
+	if _, err = e.ExecContext(ctx, sqlQuery, args...); err != nil {
+		return fmt.Errorf("save pause: %w", err)
+	}
+	return nil
+}
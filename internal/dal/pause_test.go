@@ -0,0 +1,82 @@
+package dal_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+)
+
+func TestPauseChat(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+	now := time.Date(2026, time.March, 2, 10, 0, 0, 0, time.UTC)
+
+	got, err := r.GetPause(ctx, dal.TestChatID)
+	if err != nil {
+		t.Fatalf("GetPause: %v", err)
+	}
+	if got.Active(now) {
+		t.Fatal("a chat that was never paused is paused")
+	}
+
+	if _, err = r.PauseChat(ctx, dal.TestChatID, now, now.Add(-time.Hour)); err == nil {
+		t.Error("PauseChat accepted a pause that ended already")
+	}
+
+	if _, err = r.PauseChat(ctx, dal.TestChatID, now, now.AddDate(0, 0, 7)); err != nil {
+		t.Fatalf("PauseChat: %v", err)
+	}
+	// Extending it keeps the original start.
+	later := now.Add(24 * time.Hour)
+	p, err := r.PauseChat(ctx, dal.TestChatID, later, now.AddDate(0, 0, 10))
+	if err != nil {
+		t.Fatalf("PauseChat: %v", err)
+	}
+	if !p.From.Equal(now) || !p.Until.Equal(now.AddDate(0, 0, 10)) {
+		t.Errorf("pause = %s - %s, want %s - %s", p.From, p.Until, now, now.AddDate(0, 0, 10))
+	}
+
+	got, err = r.GetPause(ctx, dal.TestChatID)
+	if err != nil {
+		t.Fatalf("GetPause: %v", err)
+	}
+	if !got.Active(later) || !got.From.Equal(now) {
+		t.Errorf("stored pause = %+v, want it active from %s", got, now)
+	}
+}
+
+func TestResumeChat(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+	now := time.Date(2026, time.March, 2, 10, 0, 0, 0, time.UTC)
+
+	resumed, err := r.ResumeChat(ctx, dal.TestChatID, now)
+	if err != nil {
+		t.Fatalf("ResumeChat: %v", err)
+	}
+	if resumed {
+		t.Error("resumed a chat that was not paused")
+	}
+
+	if _, err = r.PauseChat(ctx, dal.TestChatID, now, now.AddDate(0, 0, 7)); err != nil {
+		t.Fatalf("PauseChat: %v", err)
+	}
+	later := now.Add(2 * time.Hour)
+	if resumed, err = r.ResumeChat(ctx, dal.TestChatID, later); err != nil || !resumed {
+		t.Fatalf("ResumeChat = %v, %v, want true", resumed, err)
+	}
+
+	got, err := r.GetPause(ctx, dal.TestChatID)
+	if err != nil {
+		t.Fatalf("GetPause: %v", err)
+	}
+	if got.Active(later) {
+		t.Error("chat is still paused after resuming")
+	}
+	// The hours it was paused for stay covered.
+	if !got.Covers(now, later) {
+		t.Errorf("pause = %+v no longer covers %s - %s", got, now, later)
+	}
+}
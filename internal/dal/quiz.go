@@ -0,0 +1,261 @@
+package dal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Masterminds/squirrel"
+)
+
+func (r *SQLiteRepository) StartQuiz(ctx context.Context, chatID int64, source QuizSource, tag string, size int) (*QuizSession, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("quiz size %d must be greater than 0", size)
+	}
+	tag = normalizeTag(tag)
+	words, err := quizWordsQuery(chatID, source, tag, size)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &QuizSession{ChatID: chatID, Source: source, Tag: tag}
+	err = r.inTx(ctx, func(e execer) error {
+		if err := finishQuizzes(ctx, e, chatID); err != nil {
+			return err
+		}
+
+		sqlQuery, args, err := words.ToSql()
+		if err != nil {
+			return fmt.Errorf("build select query: %w", err)
+		}
+		rows, err := e.QueryContext(ctx, sqlQuery, args...)
+		if err != nil {
+			return fmt.Errorf("find quiz words: %w", err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var c QuizCard
+			if err = rows.Scan(&c.Word); err != nil {
+				return fmt.Errorf("scan quiz word: %w", err)
+			}
+			res.Cards = append(res.Cards, c)
+		}
+		if err = rows.Err(); err != nil {
+			return fmt.Errorf("iterate quiz words: %w", err)
+		}
+		if len(res.Cards) == 0 {
+			return ErrNotFound
+		}
+
+		query := qb.Insert("quiz_sessions").
+			Columns("chat_id", "source", "tag").
+			Values(chatID, source, tag).
+			Suffix("RETURNING id")
+		if sqlQuery, args, err = query.ToSql(); err != nil {
+			return fmt.Errorf("build insert query: %w", err)
+		}
+		if err = e.QueryRowContext(ctx, sqlQuery, args...).Scan(&res.ID); err != nil {
+			return fmt.Errorf("insert quiz session: %w", err)
+		}
+
+		cards := qb.Insert("quiz_cards").Columns("session_id", "position", "word")
+		for i, c := range res.Cards {
+			cards = cards.Values(res.ID, i, c.Word)
+		}
+		if sqlQuery, args, err = cards.ToSql(); err != nil {
+			return fmt.Errorf("build insert query: %w", err)
+		}
+		if _, err = e.ExecContext(ctx, sqlQuery, args...); err != nil {
+			return fmt.Errorf("insert quiz cards: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// quizWordsQuery selects up to size words of the chat from source.
+func quizWordsQuery(chatID int64, source QuizSource, tag string, size int) (squirrel.SelectBuilder, error) {
+	query := qb.Select("wt.word").
+		From("word_translations wt").
+		Where(squirrel.Eq{"wt.chat_id": chatID}).
+		Where(inRotation("wt")).
+		Limit(uint64(size)) //nolint:gosec // size is checked to be positive
+
+	switch source {
+	case QuizBatch:
+		return query.Join("learning_batches lb ON lb.chat_id = wt.chat_id AND lb.word = wt.word").
+			OrderBy("random()"), nil
+	case QuizReview:
+		return query.Where(squirrel.Eq{"wt.to_review": true}).
+			OrderBy("random()"), nil
+	case QuizMisses:
+		return query.Join("(SELECT word, MAX(answered_at) AS missed_at FROM answer_log "+
+			"WHERE chat_id = ? AND guessed = 0 AND answered_at >= datetime('now', ?) GROUP BY word) m ON m.word = wt.word",
+			chatID, fmt.Sprintf("-%d days", QuizMissesDays)).
+			OrderBy("m.missed_at DESC", "wt.word"), nil
+	case QuizTag:
+		if tag == "" {
+			return query, errors.New("quiz by tag needs a tag")
+		}
+		return query.Join("word_tags tg ON tg.chat_id = wt.chat_id AND tg.word = wt.word").
+			Where(squirrel.Eq{"tg.tag": tag}).
+			OrderBy("random()"), nil
+	default:
+		return query, fmt.Errorf("unknown quiz source: %q", source)
+	}
+}
+
+func (r *SQLiteRepository) FindActiveQuiz(ctx context.Context, chatID int64) (*QuizSession, error) {
+	return findActiveQuiz(ctx, r.db, chatID)
+}
+
+func (r *SQLiteRepository) GradeQuizCard(ctx context.Context, chatID, quizID int64, word string, guessed bool) (*QuizSession, error) {
+	var res *QuizSession
+	err := r.inTx(ctx, func(e execer) error {
+		s, err := findActiveQuiz(ctx, e, chatID)
+		if err != nil {
+			return err
+		}
+		card, ok := s.Current()
+		if s.ID != quizID || !ok || card.Word != word {
+			return ErrNotFound
+		}
+
+		// A quiz answer moves the session on, which undoing it would not take back, so it cannot be
+		// undone. It changes the word all the same, so an earlier answer cannot be undone either.
+		if err = forgetAnswer(ctx, e, chatID); err != nil {
+			return err
+		}
+		if guessed {
+			err = r.registerGuess(ctx, e, chatID, word)
+		} else {
+			_, err = r.registerMiss(ctx, e, chatID, word)
+		}
+		if err != nil {
+			return err
+		}
+
+		sqlQuery, args, err := qb.Update("quiz_cards").
+			Set("guessed", guessed).
+			Where(squirrel.Eq{"session_id": s.ID, "position": s.Position}).
+			ToSql()
+		if err != nil {
+			return fmt.Errorf("build update query: %w", err)
+		}
+		if _, err = e.ExecContext(ctx, sqlQuery, args...); err != nil {
+			return fmt.Errorf("grade quiz card: %w", err)
+		}
+
+		s.Cards[s.Position] = QuizCard{Word: word, Graded: true, Guessed: guessed}
+		s.Position++
+		s.Finished = s.Position == len(s.Cards)
+
+		query := qb.Update("quiz_sessions").
+			Set("position", s.Position).
+			Where(squirrel.Eq{"id": s.ID})
+		if s.Finished {
+			query = query.Set("finished_at", squirrel.Expr("CURRENT_TIMESTAMP"))
+		}
+		if sqlQuery, args, err = query.ToSql(); err != nil {
+			return fmt.Errorf("build update query: %w", err)
+		}
+		if _, err = e.ExecContext(ctx, sqlQuery, args...); err != nil {
+			return fmt.Errorf("advance quiz session: %w", err)
+		}
+
+		res = s
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// StopQuiz finishes the chat's active session early. The cards that were not reached stay ungraded.
+func (r *SQLiteRepository) StopQuiz(ctx context.Context, chatID int64) (*QuizSession, error) {
+	var res *QuizSession
+	err := r.inTx(ctx, func(e execer) error {
+		s, err := findActiveQuiz(ctx, e, chatID)
+		if err != nil {
+			return err
+		}
+		if err = finishQuizzes(ctx, e, chatID); err != nil {
+			return err
+		}
+		s.Finished = true
+		res = s
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func finishQuizzes(ctx context.Context, e execer, chatID int64) error {
+	sqlQuery, args, err := qb.Update("quiz_sessions").
+		Set("finished_at", squirrel.Expr("CURRENT_TIMESTAMP")).
+		Where(squirrel.Eq{"chat_id": chatID, "finished_at": nil}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build update query: %w", err)
+	}
+	if _, err = e.ExecContext(ctx, sqlQuery, args...); err != nil {
+		return fmt.Errorf("finish quiz sessions: %w", err)
+	}
+	return nil
+}
+
+func findActiveQuiz(ctx context.Context, e execer, chatID int64) (*QuizSession, error) {
+	sqlQuery, args, err := qb.Select("id", "source", "tag", "position").
+		From("quiz_sessions").
+		Where(squirrel.Eq{"chat_id": chatID, "finished_at": nil}).
+		OrderBy("id DESC").
+		Limit(1).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build select query: %w", err)
+	}
+
+	s := &QuizSession{ChatID: chatID}
+	if err = e.QueryRowContext(ctx, sqlQuery, args...).Scan(&s.ID, &s.Source, &s.Tag, &s.Position); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("find quiz session: %w", err)
+	}
+
+	if sqlQuery, args, err = qb.Select("word", "guessed").
+		From("quiz_cards").
+		Where(squirrel.Eq{"session_id": s.ID}).
+		OrderBy("position").
+		ToSql(); err != nil {
+		return nil, fmt.Errorf("build select query: %w", err)
+	}
+	rows, err := e.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("find quiz cards: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			c       QuizCard
+			guessed sql.NullBool
+		)
+		if err = rows.Scan(&c.Word, &guessed); err != nil {
+			return nil, fmt.Errorf("scan quiz card: %w", err)
+		}
+		c.Graded, c.Guessed = guessed.Valid, guessed.Bool
+		s.Cards = append(s.Cards, c)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate quiz cards: %w", err)
+	}
+
+	return s, nil
+}
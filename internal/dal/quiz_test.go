@@ -0,0 +1,167 @@
+package dal_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+)
+
+func quizWords(s *dal.QuizSession) []string {
+	words := make([]string, 0, len(s.Cards))
+	for _, c := range s.Cards {
+		words = append(words, c.Word)
+	}
+	return words
+}
+
+func TestQuizSessionGradesEachCardOnce(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+	r.AddWord("apple", 3)
+	r.AddWord("pear", 3)
+	r.AddWord("plum", 3)
+	r.SeedBatch("apple", "pear")
+
+	started, err := r.StartQuiz(ctx, dal.TestChatID, dal.QuizBatch, "", 10)
+	if err != nil {
+		t.Fatalf("StartQuiz: %v", err)
+	}
+	if len(started.Cards) != 2 {
+		t.Fatalf("cards = %v, want the 2 batch words", quizWords(started))
+	}
+
+	// The session is read back from the database, as it would be after a restart.
+	s, err := r.FindActiveQuiz(ctx, dal.TestChatID)
+	if err != nil {
+		t.Fatalf("FindActiveQuiz: %v", err)
+	}
+	first, _ := s.Current()
+	if s.ID != started.ID || first.Word != started.Cards[0].Word {
+		t.Fatalf("active quiz = %+v, want %+v", s, started)
+	}
+
+	if s, err = r.GradeQuizCard(ctx, dal.TestChatID, s.ID, first.Word, true); err != nil {
+		t.Fatalf("GradeQuizCard: %v", err)
+	}
+	if got := r.StreakOf(first.Word); got != 4 {
+		t.Errorf("streak of %q = %d, want 4", first.Word, got)
+	}
+	if _, err = r.GradeQuizCard(ctx, dal.TestChatID, s.ID, first.Word, true); !errors.Is(err, dal.ErrNotFound) {
+		t.Errorf("grading a card twice: err = %v, want ErrNotFound", err)
+	}
+
+	second, _ := s.Current()
+	if s, err = r.GradeQuizCard(ctx, dal.TestChatID, s.ID, second.Word, false); err != nil {
+		t.Fatalf("GradeQuizCard: %v", err)
+	}
+	if got := r.StreakOf(second.Word); got != 0 {
+		t.Errorf("streak of %q = %d, want 0", second.Word, got)
+	}
+	if guessed, graded := s.Score(); !s.Finished || guessed != 1 || graded != 2 {
+		t.Errorf("finished = %v, score = %d/%d, want finished with 1/2", s.Finished, guessed, graded)
+	}
+	if _, err = r.FindActiveQuiz(ctx, dal.TestChatID); !errors.Is(err, dal.ErrNotFound) {
+		t.Errorf("FindActiveQuiz after the last card: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestStartQuizSources(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+	r.AddWord("apple", 0)
+	r.AddWord("pear", 0)
+	r.AddWord("plum", 0)
+	if err := r.MarkToReview(ctx, dal.TestChatID, "apple", true); err != nil {
+		t.Fatalf("MarkToReview: %v", err)
+	}
+	if _, err := r.Miss(dal.TestChatID, "pear"); err != nil {
+		t.Fatalf("Miss: %v", err)
+	}
+	if err := r.SetWordTags(ctx, dal.TestChatID, "plum", []string{"Fruit"}); err != nil {
+		t.Fatalf("SetWordTags: %v", err)
+	}
+
+	tests := []struct {
+		source dal.QuizSource
+		tag    string
+		want   string
+	}{
+		{source: dal.QuizReview, want: "apple"},
+		{source: dal.QuizMisses, want: "pear"},
+		{source: dal.QuizTag, tag: " fruit ", want: "plum"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.source), func(t *testing.T) {
+			s, err := r.StartQuiz(ctx, dal.TestChatID, tt.source, tt.tag, 10)
+			if err != nil {
+				t.Fatalf("StartQuiz: %v", err)
+			}
+			if got := quizWords(s); len(got) != 1 || got[0] != tt.want {
+				t.Errorf("cards = %v, want [%s]", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := r.StartQuiz(ctx, dal.TestChatID, dal.QuizTag, "vegetables", 10); !errors.Is(err, dal.ErrNotFound) {
+		t.Errorf("StartQuiz on an unused tag: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestStartQuizSkipsWordsOutOfRotation(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+	for _, word := range []string{"apple", "pear", "plum"} {
+		r.AddWord(word, 0)
+		if err := r.SetWordTags(ctx, dal.TestChatID, word, []string{"fruit"}); err != nil {
+			t.Fatalf("SetWordTags: %v", err)
+		}
+	}
+	if err := r.SetWordSuspension(ctx, dal.TestChatID, "apple", true, time.Time{}); err != nil {
+		t.Fatalf("SetWordSuspension: %v", err)
+	}
+	if err := r.BuryWord(ctx, dal.TestChatID, "pear", time.Now().Add(24*time.Hour)); err != nil {
+		t.Fatalf("BuryWord: %v", err)
+	}
+
+	s, err := r.StartQuiz(ctx, dal.TestChatID, dal.QuizTag, "fruit", 10)
+	if err != nil {
+		t.Fatalf("StartQuiz: %v", err)
+	}
+	if got := quizWords(s); len(got) != 1 || got[0] != "plum" {
+		t.Errorf("cards = %v, want [plum]", got)
+	}
+}
+
+func TestStartQuizFinishesThePreviousSession(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+	r.AddWord("apple", 0)
+	r.AddWord("pear", 0)
+	r.SeedBatch("apple", "pear")
+
+	old, err := r.StartQuiz(ctx, dal.TestChatID, dal.QuizBatch, "", 1)
+	if err != nil {
+		t.Fatalf("StartQuiz: %v", err)
+	}
+	if _, err = r.StartQuiz(ctx, dal.TestChatID, dal.QuizBatch, "", 2); err != nil {
+		t.Fatalf("StartQuiz: %v", err)
+	}
+
+	if _, err = r.GradeQuizCard(ctx, dal.TestChatID, old.ID, old.Cards[0].Word, true); !errors.Is(err, dal.ErrNotFound) {
+		t.Errorf("grading the replaced session: err = %v, want ErrNotFound", err)
+	}
+
+	s, err := r.StopQuiz(ctx, dal.TestChatID)
+	if err != nil {
+		t.Fatalf("StopQuiz: %v", err)
+	}
+	if guessed, graded := s.Score(); !s.Finished || len(s.Cards) != 2 || graded != 0 || guessed != 0 {
+		t.Errorf("stopped quiz = %+v, want 2 ungraded cards", s)
+	}
+	if _, err = r.StopQuiz(ctx, dal.TestChatID); !errors.Is(err, dal.ErrNotFound) {
+		t.Errorf("StopQuiz without a session: err = %v, want ErrNotFound", err)
+	}
+}
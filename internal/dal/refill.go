@@ -0,0 +1,136 @@
+package dal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// SetRefillStrategy changes how the chat's batch is topped up once the queue is drained.
+func (r *SQLiteRepository) SetRefillStrategy(ctx context.Context, chatID int64, strategy RefillStrategy, tag string) error {
+	if !slices.Contains(refillStrategies, strategy) {
+		return fmt.Errorf("unknown refill strategy: %q", strategy)
+	}
+	tag = normalizeTag(tag)
+	switch {
+	case strategy == RefillTag && tag == "":
+		return errors.New("refill by tag needs a tag")
+	case strategy != RefillTag:
+		tag = ""
+	}
+
+	sqlQuery, args, err := qb.Insert("chat_settings").
+		Columns("chat_id", "refill_strategy", "refill_tag").
+		Values(chatID, strategy, tag).
+		Suffix("ON CONFLICT (chat_id) DO UPDATE SET refill_strategy = EXCLUDED.refill_strategy, refill_tag = EXCLUDED.refill_tag").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build query: %w", err)
+	}
+	if _, err = r.db.ExecContext(ctx, sqlQuery, args...); err != nil {
+		return fmt.Errorf("set refill strategy: %w", err)
+	}
+	return nil
+}
+
+//nolint:gochecknoglobals // a slice cannot be a constant
+var refillStrategies = []RefillStrategy{
+	RefillRandom, RefillOldest, RefillNewest, RefillMostMissed, RefillShortest, RefillTag, RefillFrequency,
+}
+
+func refillStrategyOf(ctx context.Context, e execer, chatID int64) (RefillStrategy, string, error) {
+	sqlQuery, args, err := qb.Select("refill_strategy", "refill_tag").
+		From("chat_settings").
+		Where(squirrel.Eq{"chat_id": chatID}).
+		ToSql()
+	if err != nil {
+		return "", "", fmt.Errorf("build query: %w", err)
+	}
+
+	var (
+		strategy RefillStrategy
+		tag      string
+	)
+	err = e.QueryRowContext(ctx, sqlQuery, args...).Scan(&strategy, &tag)
+	if errors.Is(err, sql.ErrNoRows) {
+		return RefillRandom, "", nil
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("get refill strategy: %w", err)
+	}
+	return strategy, tag, nil
+}
+
+// fillLearningBatch admits up to limit of the chat's words that are still being learned and not
+// batched yet, picked by strategy.
+func fillLearningBatch(
+	ctx context.Context, e execer, chatID int64, guessedStreakLimit, limit int, strategy RefillStrategy, tag string,
+) (int, error) {
+	words, err := pickRefillWords(ctx, e, chatID, guessedStreakLimit, limit, strategy, tag)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, word := range words {
+		if err = addToLearningBatch(ctx, e, chatID, word); err != nil {
+			return 0, err
+		}
+	}
+	return len(words), nil
+}
+
+func pickRefillWords(
+	ctx context.Context, e execer, chatID int64, guessedStreakLimit, limit int, strategy RefillStrategy, tag string,
+) ([]string, error) {
+	query := qb.Select("wt.word").
+		From("word_translations wt").
+		Where("wt.chat_id = ? AND wt.guessed_streak < "+streakLimitOf("wt", guessedStreakLimit), chatID).
+		Where("wt.word NOT IN (SELECT word FROM learning_batches WHERE chat_id = ?)", chatID).
+		Where(inRotation("wt"))
+
+	// The frequency list lives outside the database, so that strategy ranks every candidate here
+	// instead of in the query; random() still breaks the ties between words that are not on it.
+	if strategy != RefillFrequency {
+		query = query.Limit(uint64(limit)) //nolint:gosec // limit is room, itself bounded by the batch size
+	}
+
+	switch strategy {
+	case RefillRandom, RefillFrequency:
+		query = query.OrderBy("random()")
+	case RefillOldest:
+		query = query.OrderBy("wt.created_at", "wt.word")
+	case RefillNewest:
+		query = query.OrderBy("wt.created_at DESC", "wt.word")
+	case RefillMostMissed:
+		query = query.OrderBy("wt.lapses DESC", "random()")
+	case RefillShortest:
+		query = query.OrderBy("length(wt.word)", "random()")
+	case RefillTag:
+		query = query.
+			Where("EXISTS (SELECT 1 FROM word_tags tg WHERE tg.chat_id = wt.chat_id AND tg.word = wt.word AND tg.tag = ?)", tag).
+			OrderBy("random()")
+	default:
+		return nil, fmt.Errorf("unknown refill strategy: %q", strategy)
+	}
+
+	sqlQuery, args, err := query.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build select query: %w", err)
+	}
+	words, err := queryStrings(ctx, e, sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("find words: %w", err)
+	}
+
+	if strategy == RefillFrequency {
+		slices.SortStableFunc(words, func(a, b string) int {
+			return frequencyRank(a) - frequencyRank(b)
+		})
+		words = words[:min(limit, len(words))]
+	}
+	return words, nil
+}
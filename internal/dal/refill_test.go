@@ -0,0 +1,109 @@
+package dal_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+)
+
+func TestRefillStrategies(t *testing.T) {
+	tests := []struct {
+		strategy dal.RefillStrategy
+		want     []string
+	}{
+		{strategy: dal.RefillOldest, want: []string{"old", "tiny"}},
+		{strategy: dal.RefillNewest, want: []string{"fresh", "missed"}},
+		{strategy: dal.RefillMostMissed, want: []string{"missed", "old"}},
+		{strategy: dal.RefillShortest, want: []string{"of", "old"}},
+		{strategy: dal.RefillFrequency, want: []string{"of", "time"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.strategy), func(t *testing.T) {
+			ctx := context.Background()
+			r := dal.NewTestRepo(t)
+			r.SetDefaultBatchSize(2)
+			// From oldest to newest.
+			for i, w := range []string{"old", "tiny", "of", "time", "serendipity", "missed", "fresh"} {
+				r.AddWord(w, 0)
+				r.AgeWord(w, time.Duration(10-i)*time.Hour)
+			}
+			r.SetLapses("missed", 5)
+			r.SetLapses("old", 2)
+			if err := r.SetRefillStrategy(ctx, dal.TestChatID, tt.strategy, ""); err != nil {
+				t.Fatalf("SetRefillStrategy: %v", err)
+			}
+
+			report, err := r.RefillLearningBatch(ctx, dal.TestChatID)
+			if err != nil {
+				t.Fatalf("RefillLearningBatch: %v", err)
+			}
+			got := r.BatchWords()
+			slices.Sort(tt.want)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("batch = %v, want %v", got, tt.want)
+			}
+			wantFills := []dal.RefillFill{{Strategy: tt.strategy, Added: 2}}
+			if !slices.Equal(report.Fills, wantFills) {
+				t.Errorf("fills = %v, want %v", report.Fills, wantFills)
+			}
+		})
+	}
+}
+
+func TestRefillByTagFallsBackToRandom(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+	r.SetDefaultBatchSize(3)
+	for _, w := range []string{"tagged", "queued", "a", "b", "c"} {
+		r.AddWord(w, 0)
+	}
+	r.SeedQueue("queued")
+	if err := r.SetWordTags(ctx, dal.TestChatID, "tagged", []string{"travel"}); err != nil {
+		t.Fatalf("SetWordTags: %v", err)
+	}
+	if err := r.SetRefillStrategy(ctx, dal.TestChatID, dal.RefillTag, "Travel"); err != nil {
+		t.Fatalf("SetRefillStrategy: %v", err)
+	}
+
+	report, err := r.RefillLearningBatch(ctx, dal.TestChatID)
+	if err != nil {
+		t.Fatalf("RefillLearningBatch: %v", err)
+	}
+	if !r.IsBatched("queued") || !r.IsBatched("tagged") {
+		t.Errorf("batch = %v, want the queued and the tagged word in it", r.BatchWords())
+	}
+	if report.Drained != 1 {
+		t.Errorf("drained = %d, want 1", report.Drained)
+	}
+	wantFills := []dal.RefillFill{{Strategy: dal.RefillTag, Added: 1}, {Strategy: dal.RefillRandom, Added: 1}}
+	if !slices.Equal(report.Fills, wantFills) {
+		t.Errorf("fills = %v, want %v", report.Fills, wantFills)
+	}
+	if got := report.Added(); got != 3 {
+		t.Errorf("added = %d, want 3", got)
+	}
+
+	batch, err := r.GetLearningBatch(ctx, dal.TestChatID)
+	if err != nil {
+		t.Fatalf("GetLearningBatch: %v", err)
+	}
+	if batch.Strategy != dal.RefillTag || batch.RefillTag != "travel" {
+		t.Errorf("strategy = %s %q, want tag \"travel\"", batch.Strategy, batch.RefillTag)
+	}
+}
+
+func TestSetRefillStrategyRejects(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+
+	if err := r.SetRefillStrategy(ctx, dal.TestChatID, "alphabetical", ""); err == nil {
+		t.Error("unknown strategy was accepted")
+	}
+	if err := r.SetRefillStrategy(ctx, dal.TestChatID, dal.RefillTag, " "); err == nil {
+		t.Error("tag strategy without a tag was accepted")
+	}
+}
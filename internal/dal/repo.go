@@ -24,8 +24,16 @@ const (
 	GuessedLearned Guessed = "learned"
 	GuessedBatched Guessed = "batched"
 	GuessedToLearn Guessed = "to_learn"
+	// GuessedSuspended matches the suspended words.
+	GuessedSuspended Guessed = "suspended"
+	// GuessedLeech matches the words tagged LeechTag.
+	GuessedLeech Guessed = "leech"
 )
 
+// LeechTag is the tag a word gets once it has been missed often enough to count as a leech. It is an
+// ordinary tag, which can be removed once the word has been given a mnemonic.
+const LeechTag = "leech"
+
 const (
 	// ResolveResetAndBatch treats the word as forgotten: streak back to 0 and straight into the
 	// active learning batch.
@@ -37,6 +45,103 @@ const (
 	ResolveUpdateOnly ConflictResolution = "update_only"
 )
 
+const (
+	// LeaderboardHidden keeps a chat off the leaderboard and out of team challenges. It is the
+	// default: nobody is compared with anybody until they opt in.
+	LeaderboardHidden LeaderboardVisibility = "hidden"
+	// LeaderboardAnonymous lists the chat without a name and counts it towards team challenges.
+	LeaderboardAnonymous LeaderboardVisibility = "anonymous"
+	// LeaderboardPublic lists the chat under its leaderboard name.
+	LeaderboardPublic LeaderboardVisibility = "public"
+)
+
+const (
+	// MetricAnswers counts every graded answer, right or wrong.
+	MetricAnswers ChallengeMetric = "answers"
+	// MetricGuessed counts right answers only.
+	MetricGuessed ChallengeMetric = "guessed"
+	// MetricNewWords counts brand-new words added.
+	MetricNewWords ChallengeMetric = "new_words"
+	// MetricLearned counts words whose streak reached the learned threshold.
+	MetricLearned ChallengeMetric = "learned"
+)
+
+const (
+	// RefillRandom tops the batch up with any words still being learned. It is the default.
+	RefillRandom RefillStrategy = "random"
+	// RefillOldest prefers the words added longest ago.
+	RefillOldest RefillStrategy = "oldest"
+	// RefillNewest prefers the words added most recently.
+	RefillNewest RefillStrategy = "newest"
+	// RefillMostMissed prefers the words with the most lapses.
+	RefillMostMissed RefillStrategy = "most_missed"
+	// RefillShortest prefers the shortest words.
+	RefillShortest RefillStrategy = "shortest"
+	// RefillTag takes the words with a tag. Once those run out, RefillRandom fills the rest.
+	RefillTag RefillStrategy = "tag"
+	// RefillFrequency prefers the most common words, by the frequency list bundled with the bot; see
+	// frequency.go.
+	RefillFrequency RefillStrategy = "frequency"
+)
+
+const (
+	// CardMixed sends BOT_LEARNING_CLOZE_RATE_PERCENT of the word checks as cloze cards and the rest
+	// as classic ones. It is the default.
+	CardMixed CardType = "mixed"
+	// CardClassic always sends the bare word.
+	CardClassic CardType = "classic"
+	// CardCloze sends a cloze card whenever the word's description has a sentence to blank it out of.
+	CardCloze CardType = "cloze"
+)
+
+const (
+	CallbackGuessed CallbackAnswer = "guessed"
+	CallbackMissed  CallbackAnswer = "missed"
+)
+
+const (
+	AuditCreate AuditAction = "create"
+	AuditUpdate AuditAction = "update"
+	// AuditDelete moves the word to the trash, AuditRestore brings it back from there and AuditPurge
+	// drops it from there for good.
+	AuditDelete  AuditAction = "delete"
+	AuditRestore AuditAction = "restore"
+	AuditPurge   AuditAction = "purge"
+)
+
+const (
+	// ScopeRead lets a token make any GET request.
+	ScopeRead TokenScope = "read"
+	// ScopeWordsWrite lets a token read and change the words.
+	ScopeWordsWrite TokenScope = "words:write"
+	// ScopeStatsRead lets a token read the statistics.
+	ScopeStatsRead TokenScope = "stats:read"
+
+	// MaxAPITokenDays caps how long a personal access token can be made to last.
+	MaxAPITokenDays = 365
+)
+
+const (
+	// QuizBatch draws the cards from the active learning batch.
+	QuizBatch QuizSource = "batch"
+	// QuizReview draws the words marked to review.
+	QuizReview QuizSource = "review"
+	// QuizMisses draws the words missed in the last QuizMissesDays, most recently missed first.
+	QuizMisses QuizSource = "misses"
+	// QuizTag draws the words with a tag.
+	QuizTag QuizSource = "tag"
+
+	// QuizMissesDays is how far back QuizMisses looks.
+	QuizMissesDays = 7
+
+	// MaxPauseDays is the longest a chat can be paused for at once.
+	MaxPauseDays = 90
+
+	// reviewGrowth is how much longer the wait until the next review of a learned word gets with
+	// every review it passes; see ReviewRules.Next.
+	reviewGrowth = 2
+)
+
 type (
 	Guessed              string
 	StreakLimitDirection int
@@ -44,6 +149,23 @@ type (
 	// ConflictResolution says what to do about learning progress when a word that already exists is
 	// added again.
 	ConflictResolution string
+	// LeaderboardVisibility is a chat's privacy setting for the shared leaderboard.
+	LeaderboardVisibility string
+	// ChallengeMetric is what a team challenge counts.
+	ChallengeMetric string
+	// QuizSource is where a quiz session draws its cards from.
+	QuizSource string
+	// RefillStrategy is how RefillLearningBatch picks the words it tops the batch up with once the
+	// admission queue is drained.
+	RefillStrategy string
+	// CardType is the kind of card a chat's word checks are sent as.
+	CardType string
+	// AuditAction is what a WordAuditEntry did to the word.
+	AuditAction string
+	// CallbackAnswer is how a word check was graded.
+	CallbackAnswer string
+	// TokenScope is what a personal access token may be used for.
+	TokenScope string
 
 	WordTranslationsFilter struct {
 		Word     string
@@ -54,10 +176,21 @@ type (
 	}
 
 	FindRandomWordFilter struct {
-		Batched              bool
+		Batched bool
+		// Drill picks the drilled word that has been due longest. The other fields are ignored.
+		Drill bool
+		// ToReview picks among the words marked to review, batched or not, least recently reviewed
+		// first. The other fields are ignored.
+		ToReview             bool
 		StreakLimitDirection StreakLimitDirection // ignored if Batched = true
 		StreakLimit          int                  // ignored if Batched = true
 		Order                RandomOrder          // ignored if Batched = true
+		// Due leaves out the words whose next review is not due yet; see ReviewRules. Ignored if
+		// Batched = true.
+		Due bool
+		// Learned picks among the learned words, each by its own streak limit, in place of
+		// StreakLimitDirection and StreakLimit. Ignored if Batched = true.
+		Learned bool
 	}
 
 	TotalStats struct {
@@ -69,7 +202,8 @@ type (
 		Early   int
 		Total   int
 		// StreakLimit and NearlyFrom are echoed back so that callers can label the buckets without
-		// hardcoding the configured threshold.
+		// hardcoding the configured threshold. A word with a streak limit of its own, or from its
+		// tags, is bucketed by that limit instead.
 		StreakLimit int
 		NearlyFrom  int
 		// Batched is how many words are currently sitting in the active learning batch.
@@ -77,6 +211,237 @@ type (
 		// Queued is how many words are waiting in learning_batch_queue for room to open up in the
 		// batch.
 		Queued int
+		// ToReview is how many words are marked to review.
+		ToReview int
+	}
+
+	// DailyGoal is what a chat has to do in a day for that day to count towards its streak.
+	DailyGoal struct {
+		// Answers is how many words have to be answered, right or wrong.
+		Answers int
+		// NewWords is how many brand-new words have to be added. 0 means no requirement.
+		NewWords int
+	}
+
+	// StreakRules are the daily goal plus how streak freezes are earned.
+	StreakRules struct {
+		Goal DailyGoal
+		// FreezeEvery is the streak length, in days, that earns one freeze; every multiple of it
+		// earns another. 0 disables freezes.
+		FreezeEvery int
+		// MaxFreezes caps how many unspent freezes a chat can hold.
+		MaxFreezes int
+	}
+
+	Streak struct {
+		// Days is the number of consecutive days on which the daily goal was met or a freeze covered
+		// it. Today only counts once its goal is met, so a day that is still in progress never
+		// breaks the streak.
+		Days    int
+		Freezes int
+		// TodayAnswers and TodayNewWords are today's progress towards Goal; GoalMet says whether
+		// today already counts.
+		TodayAnswers  int
+		TodayNewWords int
+		Goal          DailyGoal
+		GoalMet       bool
+	}
+
+	// StreakSettlement reports what SettleStreak did to the settled day.
+	StreakSettlement struct {
+		// Frozen is set when a freeze was spent on a missed day.
+		Frozen bool
+		// Awarded is set when the day completed a streak that earns a freeze.
+		Awarded bool
+		// Paused is set when a missed day was covered by a pause rather than a freeze.
+		Paused bool
+	}
+
+	// LeechRules say when a word that keeps being missed counts as a leech.
+	LeechRules struct {
+		// Threshold is how many lapses make a word a leech; every Threshold/2 lapses after that flag it
+		// again. 0 disables leech detection.
+		Threshold int
+		// Suspend suspends leeches as well.
+		Suspend bool
+	}
+
+	// ReviewRules space out the reviews of learned words. Each one that is passed pushes the next
+	// one further out, the further the longer the word has been known.
+	ReviewRules struct {
+		// First is how long after a word is learned its first review is due.
+		First time.Duration
+		// Max caps the wait between two reviews.
+		Max time.Duration
+	}
+
+	// ReviewLoad is what a chat has to get through: the learned words whose review is due, and the
+	// words in its learning batch.
+	ReviewLoad struct {
+		Due     int
+		Batched int
+	}
+
+	// Pause is a chat's latest pause. A chat that was never paused has a zero one.
+	Pause struct {
+		From  time.Time
+		Until time.Time
+	}
+
+	// WordMisses is how often a word was answered wrong over some period.
+	WordMisses struct {
+		Word   string
+		Misses int
+	}
+
+	// AnswerSummary is the per-word side of a period's answers, which the per-day statistics cannot
+	// tell.
+	AnswerSummary struct {
+		// Hardest are the most missed words, most misses first.
+		Hardest []WordMisses
+		// Graduated are the words whose streak reached the learned threshold.
+		Graduated []string
+	}
+
+	// LeaderboardEntry is one chat's results over a leaderboard period. Name is empty for anonymous
+	// chats.
+	LeaderboardEntry struct {
+		ChatID    int64
+		Name      string
+		Anonymous bool
+		Answers   int
+		Guessed   int
+		NewWords  int
+		Learned   int
+	}
+
+	Challenge struct {
+		ID        int64
+		CreatedBy int64
+		Title     string
+		Metric    ChallengeMetric
+		Target    int
+		// StartsOn and EndsOn are days, both inclusive.
+		StartsOn time.Time
+		EndsOn   time.Time
+		// Progress is the metric summed over every chat taking part; it is only filled in by reads.
+		Progress int
+	}
+
+	// GroupAnswer is one member's answer in a group round.
+	GroupAnswer struct {
+		UserID      int64
+		Name        string
+		Guessed     bool
+		StreakAfter int
+	}
+
+	// GroupMemberScore is one member's results in a group over some period. Learned is not limited
+	// to the period: it is how many of the group's words the member has learned so far.
+	GroupMemberScore struct {
+		UserID  int64
+		Name    string
+		Answers int
+		Guessed int
+		Learned int
+	}
+
+	QuizCard struct {
+		Word    string
+		Graded  bool
+		Guessed bool
+	}
+
+	// CheckPace is how a chat keeps up with its word checks.
+	CheckPace struct {
+		// Unanswered counts the checks sent after the chat's last answer.
+		Unanswered int
+		// Answered counts the checks whose word has been answered since, and AnswerDelay is the median
+		// time that took.
+		Answered    int
+		AnswerDelay time.Duration
+	}
+
+	// LearningBatch is a chat's learning batch and the admission queue behind it.
+	LearningBatch struct {
+		// Size is how many words the batch is topped up to, and CustomSize whether it is the chat's
+		// own rather than the configured one.
+		Size       int
+		CustomSize bool
+		Batched    []WordTranslation
+		// Queued is in the order the words are admitted in.
+		Queued []WordTranslation
+		// Strategy is how the chat's batch is topped up; RefillTag is only set with RefillTag.
+		Strategy  RefillStrategy
+		RefillTag string
+	}
+
+	// RefillReport is what RefillLearningBatch did.
+	RefillReport struct {
+		Evicted int
+		// Drained counts the words admitted from the queue.
+		Drained int
+		// Fills says how many words each strategy added after that, in the order they ran: the
+		// chat's own and, when that ran out of words, RefillRandom.
+		Fills []RefillFill
+	}
+
+	RefillFill struct {
+		Strategy RefillStrategy
+		Added    int
+	}
+
+	// UndoneAnswer is the answer UndoLastAnswer took back. Streak is the word's streak again.
+	UndoneAnswer struct {
+		Word    string
+		Guessed bool
+		Streak  int
+	}
+
+	// TrashedWord is a deleted word that can still be restored.
+	TrashedWord struct {
+		Word          string
+		Translation   string
+		Description   string
+		GuessedStreak int
+		Tags          []string
+		CreatedAt     time.Time
+		DeletedAt     time.Time
+	}
+
+	// WordAuditEntry is one change to a word's text. The Old fields are the word as it was before the
+	// change and are empty for AuditCreate and AuditRestore; Translation and Description are the word
+	// as it is after it and are empty for AuditDelete and AuditPurge.
+	WordAuditEntry struct {
+		ID             int64
+		Word           string
+		Action         AuditAction
+		OldWord        string
+		OldTranslation string
+		OldDescription string
+		Translation    string
+		Description    string
+		ChangedAt      time.Time
+	}
+
+	// TagStreakLimit is the streak at which the words with Tag count as learned, unless a word has a
+	// limit of its own.
+	TagStreakLimit struct {
+		Tag   string
+		Limit int
+	}
+
+	// QuizSession is a run of cards practiced back to back. Position is the card being asked; it
+	// equals len(Cards) once every card has been graded.
+	QuizSession struct {
+		ID       int64
+		ChatID   int64
+		Source   QuizSource
+		Tag      string
+		Cards    []QuizCard
+		Position int
+		// Finished is set once every card has been graded or the session was stopped early.
+		Finished bool
 	}
 
 	WordTranslationsRepository interface {
@@ -87,25 +452,134 @@ type (
 		CreateWordTranslation(ctx context.Context, chatID int64, word, translation, description string) error
 		UpdateWordTranslation(ctx context.Context, chatID int64, word, updatedWord, translation, description string) error
 		DeleteWordTranslation(ctx context.Context, chatID int64, word string) error
+		// SetWordTags replaces the word's tags, reporting ErrNotFound for a word that does not exist.
+		SetWordTags(ctx context.Context, chatID int64, word string, tags []string) error
+		// SetWordStreakLimit and SetTagStreakLimit take 0 to remove the limit. SetWordStreakLimit
+		// reports ErrNotFound for a word that does not exist.
+		SetWordStreakLimit(ctx context.Context, chatID int64, word string, limit int) error
+		GetTagStreakLimits(ctx context.Context, chatID int64) ([]TagStreakLimit, error)
+		SetTagStreakLimit(ctx context.Context, chatID int64, tag string, limit int) error
+	}
+
+	// TrashRepository keeps deleted words until they are restored or purged, and the log of every change
+	// to a word's text.
+	TrashRepository interface {
+		GetTrash(ctx context.Context, chatID int64) ([]TrashedWord, error)
+		RestoreWordTranslation(ctx context.Context, chatID int64, word string) error
+		// PurgeTrash drops the words of every chat deleted before deletedBefore and returns how many.
+		PurgeTrash(ctx context.Context, deletedBefore time.Time) (int, error)
+		// GetWordAudit takes an empty word for the entries of every word.
+		GetWordAudit(ctx context.Context, chatID int64, word string, limit int) ([]WordAuditEntry, error)
 	}
 
 	// LearningRepository exposes learning progress as whole operations rather than as the individual
 	// statements they are made of. Anything that has to touch more than one table runs in a single
 	// transaction owned by the implementation, so callers cannot compose a half-applied update.
 	LearningRepository interface {
-		RegisterGuess(ctx context.Context, chatID int64, word string) error
-		RegisterMiss(ctx context.Context, chatID int64, word string) error
+		// GradeCallback grades the word check behind a callback at most once: a callback graded
+		// already is ErrAlreadyAnswered, an unknown or expired one ErrNotFound. It reports whether a
+		// miss made the word a leech.
+		GradeCallback(ctx context.Context, chatID int64, uuid string, guessed bool) (bool, error)
+		// UndoLastAnswer takes back the last answer given at or after since, and on the callback
+		// callbackID unless it is empty. It reports ErrNotFound when there is none.
+		UndoLastAnswer(ctx context.Context, chatID int64, callbackID string, since time.Time) (*UndoneAnswer, error)
 		MarkToReview(ctx context.Context, chatID int64, word string, toReview bool) error
 		MarkWordReviewed(ctx context.Context, chatID int64, word string) error
+		GetReviewLoad(ctx context.Context, chatID int64) (*ReviewLoad, error)
+		PostponeDrill(ctx context.Context, chatID int64, word string) error
+		// SetWordSuspension reports ErrNotFound for a word that does not exist, as does BuryWord.
+		SetWordSuspension(ctx context.Context, chatID int64, word string, suspended bool, buriedUntil time.Time) error
+		BuryWord(ctx context.Context, chatID int64, word string, until time.Time) error
 		ResetStreak(ctx context.Context, chatID int64, word string, addToBatch bool) error
 		ResolveWordConflict(ctx context.Context, chatID int64, word, translation, description string, resolution ConflictResolution) error
-		RefillLearningBatch(ctx context.Context, chatID int64) (evicted, added int, err error)
+		RefillLearningBatch(ctx context.Context, chatID int64) (*RefillReport, error)
+	}
+
+	// BatchRepository changes the learning batch by hand. Words still request membership through
+	// the same gate as a miss, so the batch size stays a hard cap and suspended words stay out.
+	BatchRepository interface {
+		GetLearningBatch(ctx context.Context, chatID int64) (*LearningBatch, error)
+		// AddToLearningBatch and RemoveFromLearningBatch return how many of the words they moved.
+		AddToLearningBatch(ctx context.Context, chatID int64, words []string) (int, error)
+		RemoveFromLearningBatch(ctx context.Context, chatID int64, words []string) (int, error)
+		ReorderLearningBatchQueue(ctx context.Context, chatID int64, words []string) error
+		RefillLearningBatch(ctx context.Context, chatID int64) (*RefillReport, error)
+		// SetBatchSize with 0 goes back to the configured size.
+		SetBatchSize(ctx context.Context, chatID int64, size int) error
+		// SetRefillStrategy takes a tag with RefillTag only.
+		SetRefillStrategy(ctx context.Context, chatID int64, strategy RefillStrategy, tag string) error
 	}
 
 	StatsRepository interface {
 		GetTotalStats(ctx context.Context, chatID int64) (*TotalStats, error)
 		GetStats(ctx context.Context, chatID int64, date time.Time) (*Stats, error)
 		GetStatsRange(ctx context.Context, chatID int64, from, to time.Time) ([]Stats, error)
+		GetAnswerSummary(ctx context.Context, chatID int64, from, to time.Time, limit int) (*AnswerSummary, error)
+		GetStreak(ctx context.Context, chatID int64, goal DailyGoal, today time.Time) (*Streak, error)
+		SettleStreak(ctx context.Context, chatID int64, rules StreakRules, today time.Time) (*StreakSettlement, error)
+	}
+
+	// PauseRepository keeps each chat's pause. Pausing an already paused chat only moves Until.
+	PauseRepository interface {
+		GetPause(ctx context.Context, chatID int64) (*Pause, error)
+		PauseChat(ctx context.Context, chatID int64, now, until time.Time) (*Pause, error)
+		// ResumeChat ends an active pause at now and reports whether there was one.
+		ResumeChat(ctx context.Context, chatID int64, now time.Time) (bool, error)
+	}
+
+	// LeaderboardRepository compares chats with each other. Every read takes the chats that may be
+	// compared - the bot's allowed chats - and only includes those of them that opted in.
+	LeaderboardRepository interface {
+		GetLeaderboardVisibility(ctx context.Context, chatID int64) (LeaderboardVisibility, string, error)
+		SetLeaderboardVisibility(ctx context.Context, chatID int64, visibility LeaderboardVisibility, name string) error
+		GetLeaderboard(ctx context.Context, chatIDs []int64, from, to time.Time) ([]LeaderboardEntry, error)
+		CreateChallenge(ctx context.Context, challenge Challenge) (*Challenge, error)
+		FindActiveChallenges(ctx context.Context, chatIDs []int64, today time.Time) ([]Challenge, error)
+		DeleteChallenge(ctx context.Context, chatID, id int64) error
+	}
+
+	// DeckRepository shares vocabulary between chats. A deck is a named set of its owner's words;
+	// subscribing copies them into the subscriber's own vocabulary, where they are learned like any
+	// other word, and the owner's later edits to them reach every subscriber as translation updates
+	// that leave progress alone (ResolveUpdateOnly).
+	DeckRepository interface {
+		CreateDeck(ctx context.Context, ownerChatID int64, name string) (*Deck, error)
+		DeleteDeck(ctx context.Context, ownerChatID, deckID int64) error
+		// FindDecks lists the decks owned by ownerChatIDs, marking the ones chatID subscribes to.
+		FindDecks(ctx context.Context, chatID int64, ownerChatIDs []int64) ([]Deck, error)
+		AddDeckWords(ctx context.Context, ownerChatID, deckID int64, words []string) (int, error)
+		RemoveDeckWords(ctx context.Context, ownerChatID, deckID int64, words []string) error
+		// SubscribeDeck reports ErrNotFound for a deck not owned by one of ownerChatIDs.
+		SubscribeDeck(ctx context.Context, chatID, deckID int64, ownerChatIDs []int64) (int, error)
+		UnsubscribeDeck(ctx context.Context, chatID, deckID int64) error
+	}
+
+	// GroupRepository keeps per-member progress in group chats. The group's words are ordinary
+	// word_translations under the group's chat ID; what every member knows of them is tracked
+	// separately, keyed by the Telegram user ID of whoever answered.
+	GroupRepository interface {
+		// FindGroupWord picks a random group word that at least one member has not learned yet.
+		FindGroupWord(ctx context.Context, groupChatID int64) (*WordTranslation, error)
+		// RegisterGroupAnswer applies a member's answer in a round and returns the member's new streak
+		// on the word. A second answer from the same member in the same round is ErrAlreadyExists.
+		RegisterGroupAnswer(ctx context.Context, groupChatID int64, roundID, word string, member GroupAnswer) (int, error)
+		// GetGroupRound returns the answers given in a round, in the order they came in.
+		GetGroupRound(ctx context.Context, groupChatID int64, roundID string) ([]GroupAnswer, error)
+		GetGroupScoreboard(ctx context.Context, groupChatID int64, from, to time.Time) ([]GroupMemberScore, error)
+	}
+
+	// QuizRepository keeps quiz sessions. A chat has at most one unfinished session: starting a new
+	// one finishes the previous one where it stands.
+	QuizRepository interface {
+		// StartQuiz picks up to size cards from source, leaving out suspended and buried words, and
+		// reports ErrNotFound if there are none. tag is only used by QuizTag.
+		StartQuiz(ctx context.Context, chatID int64, source QuizSource, tag string, size int) (*QuizSession, error)
+		FindActiveQuiz(ctx context.Context, chatID int64) (*QuizSession, error)
+		// GradeQuizCard applies the answer to the word's progress, exactly like GradeCallback, and
+		// moves the session on. Grading anything but the current card of the
+		// chat's active session is ErrNotFound, so a stale button cannot grade a card twice.
+		GradeQuizCard(ctx context.Context, chatID, quizID int64, word string, guessed bool) (*QuizSession, error)
+		StopQuiz(ctx context.Context, chatID int64) (*QuizSession, error)
 	}
 
 	AuthConfirmationRepository interface {
@@ -115,19 +589,154 @@ type (
 		DeleteAuthConfirmation(ctx context.Context, chatID int64, token string) error
 	}
 
+	// SessionRepository keeps the signed-in browsers and their refresh tokens, which are stored hashed.
+	// Revoked and expired sessions are left out of every read, and TouchSession reports ErrNotFound for
+	// them.
+	SessionRepository interface {
+		CreateSession(ctx context.Context, session Session, refreshToken string) error
+		RotateRefreshToken(ctx context.Context, token, newToken string, expiresAt time.Time) (*Session, error)
+		RevokeSessionByRefreshToken(ctx context.Context, token string) error
+		TouchSession(ctx context.Context, chatID int64, id string) error
+		GetSessions(ctx context.Context, chatID int64) ([]Session, error)
+		RevokeSession(ctx context.Context, chatID int64, id string) error
+		RevokeSessions(ctx context.Context, chatID int64) (int, error)
+	}
+
+	// APITokenRepository keeps the personal access tokens, which are stored hashed.
+	APITokenRepository interface {
+		CreateAPIToken(ctx context.Context, chatID int64, name string, scopes []TokenScope, expiresAt time.Time) (*APIToken, string, error)
+		AuthenticateAPIToken(ctx context.Context, token string) (*APIToken, error)
+		GetAPITokens(ctx context.Context, chatID int64) ([]APIToken, error)
+		RevokeAPIToken(ctx context.Context, chatID, id int64) error
+	}
+
+	CardTypeRepository interface {
+		GetCardType(ctx context.Context, chatID int64) (CardType, error)
+		SetCardType(ctx context.Context, chatID int64, cardType CardType) error
+	}
+
+	TimezoneRepository interface {
+		// SetTimezone with an empty name goes back to the configured schedule location.
+		SetTimezone(ctx context.Context, chatID int64, name string) error
+		GetTimezone(ctx context.Context, chatID int64) (string, error)
+		GetTimezones(ctx context.Context, chatIDs []int64) (map[int64]string, error)
+	}
+
 	CallbacksRepository interface {
 		InsertCallback(ctx context.Context, data CallbackData) (string, error)
 		FindCallback(ctx context.Context, chatID int64, uuid string) (*CallbackData, error)
+		GetCheckPace(ctx context.Context, chatID int64, since time.Time) (*CheckPace, error)
 	}
 
 	Repository interface {
 		WordTranslationsRepository
+		TrashRepository
+		BatchRepository
 		CallbacksRepository
 		AuthConfirmationRepository
+		SessionRepository
+		APITokenRepository
 		StatsRepository
+		PauseRepository
+		TimezoneRepository
+		CardTypeRepository
+		LeaderboardRepository
+		DeckRepository
+		GroupRepository
+		QuizRepository
 	}
 )
 
+// Added is how many words the refill admitted in all.
+func (r RefillReport) Added() int {
+	res := r.Drained
+	for _, f := range r.Fills {
+		res += f.Added
+	}
+	return res
+}
+
+// Next is the wait until the next review of a word that has just passed one: twice the last wait, or
+// half of the time the word has been learned for if that is longer, but no shorter than First and no
+// longer than Max.
+func (r ReviewRules) Next(last, learnedFor time.Duration) time.Duration {
+	return min(max(last*reviewGrowth, learnedFor/2, r.First), r.Max) //nolint:mnd // half
+}
+
+// Reached reports whether a word with lapses lapses has just become a leech, or is due to be flagged
+// as one again.
+func (l LeechRules) Reached(lapses int) bool {
+	if l.Threshold <= 0 || lapses < l.Threshold {
+		return false
+	}
+	return (lapses-l.Threshold)%max(l.Threshold/2, 1) == 0 //nolint:mnd // half the threshold
+}
+
+// Active reports whether the chat is paused at now.
+func (p Pause) Active(now time.Time) bool {
+	return now.Before(p.Until) && !now.Before(p.From)
+}
+
+// Covers reports whether any part of [from, to) falls within the pause.
+func (p Pause) Covers(from, to time.Time) bool {
+	return !p.Until.IsZero() && p.From.Before(to) && p.Until.After(from)
+}
+
 func (d StreakLimitDirection) String() string {
 	return [...]string{"<", ">="}[d]
 }
+
+// Current returns the card being asked, or false once there is none left.
+func (s *QuizSession) Current() (QuizCard, bool) {
+	if s.Finished || s.Position >= len(s.Cards) {
+		return QuizCard{}, false
+	}
+	return s.Cards[s.Position], true
+}
+
+// Score counts the graded cards and the right answers among them.
+func (s *QuizSession) Score() (guessed, graded int) {
+	for _, c := range s.Cards {
+		if !c.Graded {
+			continue
+		}
+		graded++
+		if c.Guessed {
+			guessed++
+		}
+	}
+	return guessed, graded
+}
+
+// Value returns what the entry scored on metric.
+func (e LeaderboardEntry) Value(metric ChallengeMetric) int {
+	switch metric {
+	case MetricAnswers:
+		return e.Answers
+	case MetricGuessed:
+		return e.Guessed
+	case MetricNewWords:
+		return e.NewWords
+	case MetricLearned:
+		return e.Learned
+	default:
+		return 0
+	}
+}
+
+// Accuracy is the share of right answers, in percent.
+func (e LeaderboardEntry) Accuracy() int {
+	return accuracy(e.Guessed, e.Answers)
+}
+
+// Accuracy is the share of right answers, in percent.
+func (s GroupMemberScore) Accuracy() int {
+	return accuracy(s.Guessed, s.Answers)
+}
+
+func accuracy(guessed, answers int) int {
+	if answers == 0 {
+		return 0
+	}
+	return guessed * 100 / answers //nolint:mnd // percent
+}
@@ -0,0 +1,93 @@
+package dal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// A learned word is reviewed on a schedule rather than at random: its first review is due
+// ReviewRules.First after it is learned, and every review it passes pushes the next one out further.
+// A miss makes it a word being learned again, with no schedule, until it is learned anew.
+
+// GetReviewLoad counts the chat's learned words that are due for review, and its batched words.
+func (r *SQLiteRepository) GetReviewLoad(ctx context.Context, chatID int64) (*ReviewLoad, error) {
+	sqlQuery, args, err := qb.Select("COUNT(*)").
+		From("word_translations wt").
+		Where(squirrel.Eq{"wt.chat_id": chatID}).
+		Where("wt.guessed_streak >= "+streakLimitOf("wt", r.streakLimit)).
+		Where(inRotation("wt")).
+		Where(reviewDue("wt")).
+		Where("wt.word NOT IN (SELECT word FROM learning_batches WHERE chat_id = ?)", chatID).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build count query: %w", err)
+	}
+
+	var res ReviewLoad
+	if err = r.db.QueryRowContext(ctx, sqlQuery, args...).Scan(&res.Due); err != nil {
+		return nil, fmt.Errorf("count due reviews: %w", err)
+	}
+	if res.Batched, err = batchedWordTranslationsCount(ctx, r.db, chatID); err != nil {
+		return nil, fmt.Errorf("get batched word translations count: %w", err)
+	}
+	return &res, nil
+}
+
+// reviewDue is the condition a learned word of the word_translations row aliased alias has to meet
+// to be due for review. A learned word without a date, such as one learned before reviews were
+// scheduled, is due.
+func reviewDue(alias string) string {
+	return fmt.Sprintf("(%[1]s.next_review_at IS NULL OR %[1]s.next_review_at <= datetime('now'))", alias)
+}
+
+// scheduleReview sets the next review of a word that has just been answered correctly, if that left
+// it learned: the first one if it has just been learned, a later one if this was a review it passed.
+func (r *SQLiteRepository) scheduleReview(ctx context.Context, e execer, chatID int64, word string) error {
+	sqlQuery, args, err := qb.Select("wt.guessed_streak", streakLimitOf("wt", r.streakLimit), "wt.learned_at", "wt.review_interval_seconds").
+		From("word_translations wt").
+		Where(squirrel.Eq{"wt.chat_id": chatID, "wt.word": word}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build select query: %w", err)
+	}
+
+	var (
+		streak    int
+		limit     int
+		learnedAt sql.NullTime
+		last      int64
+	)
+	err = e.QueryRowContext(ctx, sqlQuery, args...).Scan(&streak, &limit, &learnedAt, &last)
+	if errors.Is(err, sql.ErrNoRows) || err == nil && streak < limit {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("get review schedule: %w", err)
+	}
+
+	now := time.Now()
+	interval := r.review.First
+	if learnedAt.Valid {
+		interval = r.review.Next(time.Duration(last)*time.Second, now.Sub(learnedAt.Time))
+	} else {
+		learnedAt.Time = now
+	}
+
+	if sqlQuery, args, err = qb.Update("word_translations").
+		Set("learned_at", squirrel.Expr("datetime(?, 'unixepoch')", learnedAt.Time.Unix())).
+		Set("review_interval_seconds", int64(interval.Seconds())).
+		Set("next_review_at", squirrel.Expr("datetime(?, 'unixepoch')", now.Add(interval).Unix())).
+		Where(squirrel.Eq{"chat_id": chatID, "word": word}).
+		ToSql(); err != nil {
+		return fmt.Errorf("build update query: %w", err)
+	}
+	if _, err = e.ExecContext(ctx, sqlQuery, args...); err != nil {
+		return fmt.Errorf("schedule review: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package dal_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+)
+
+const day = 24 * time.Hour
+
+func TestReviewRulesNext(t *testing.T) {
+	rules := dal.ReviewRules{First: 3 * day, Max: 180 * day}
+
+	tests := []struct {
+		name             string
+		last, learnedFor time.Duration
+		want             time.Duration
+	}{
+		{name: "the wait doubles", last: 3 * day, learnedFor: 3 * day, want: 6 * day},
+		{name: "a word known for long waits longer", last: 3 * day, learnedFor: 2 * 365 * day, want: 180 * day},
+		{name: "half of the time it has been known", last: 3 * day, learnedFor: 40 * day, want: 20 * day},
+		{name: "never shorter than the first wait", last: 0, learnedFor: time.Hour, want: 3 * day},
+		{name: "never longer than the cap", last: 120 * day, learnedFor: 200 * day, want: 180 * day},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rules.Next(tt.last, tt.learnedFor); got != tt.want {
+				t.Errorf("Next(%v, %v) = %v, want %v", tt.last, tt.learnedFor, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGuessSchedulesReviews(t *testing.T) {
+	r := dal.NewTestRepo(t)
+	r.AddWord("nearly", dal.TestStreakLimit-2)
+
+	// Still being learned: nothing to schedule.
+	if err := r.Guess(dal.TestChatID, "nearly"); err != nil {
+		t.Fatalf("Guess: %v", err)
+	}
+	if _, _, ok := r.ReviewOf("nearly"); ok {
+		t.Fatal("a word still being learned got a review scheduled")
+	}
+
+	// Learned: the first review is due after the first wait.
+	if err := r.Guess(dal.TestChatID, "nearly"); err != nil {
+		t.Fatalf("Guess: %v", err)
+	}
+	first := dal.TestReviewRules.First
+	if interval, dueIn, ok := r.ReviewOf("nearly"); !ok || interval != first || dueIn != first {
+		t.Fatalf("review = %v due in %v (scheduled %t), want %v due in %v", interval, dueIn, ok, first, first)
+	}
+
+	// A passed review pushes the next one further out.
+	if err := r.Guess(dal.TestChatID, "nearly"); err != nil {
+		t.Fatalf("Guess: %v", err)
+	}
+	if interval, dueIn, _ := r.ReviewOf("nearly"); interval != 2*first || dueIn != 2*first {
+		t.Errorf("review = %v due in %v, want %v", interval, dueIn, 2*first)
+	}
+
+	// A miss makes it a word being learned again.
+	if _, err := r.Miss(dal.TestChatID, "nearly"); err != nil {
+		t.Fatalf("Miss: %v", err)
+	}
+	if _, _, ok := r.ReviewOf("nearly"); ok {
+		t.Error("a missed word kept its review schedule")
+	}
+}
+
+func TestDueReviews(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+	r.AddWord("overdue", dal.TestStreakLimit)
+	r.ScheduleReview("overdue", 30*day, 6*day, -day)
+	r.AddWord("later", dal.TestStreakLimit)
+	r.ScheduleReview("later", 30*day, 6*day, day)
+	r.AddWord("learning", 3)
+	r.SeedBatch("learning")
+
+	load, err := r.GetReviewLoad(ctx, dal.TestChatID)
+	if err != nil {
+		t.Fatalf("GetReviewLoad: %v", err)
+	}
+	if load.Due != 1 || load.Batched != 1 {
+		t.Errorf("load = %+v, want 1 due and 1 batched", *load)
+	}
+
+	filter := reviewFilter()
+	filter.Due = true
+	for range 5 {
+		wt, err := r.FindRandomWordTranslation(ctx, dal.TestChatID, filter)
+		if err != nil {
+			t.Fatalf("FindRandomWordTranslation: %v", err)
+		}
+		if wt.Word != "overdue" {
+			t.Fatalf("picked %q, want the only word due", wt.Word)
+		}
+	}
+
+	r.ScheduleReview("overdue", 30*day, 12*day, 12*day)
+	if _, err = r.FindRandomWordTranslation(ctx, dal.TestChatID, filter); !errors.Is(err, dal.ErrNotFound) {
+		t.Errorf("error = %v, want ErrNotFound with nothing due", err)
+	}
+}
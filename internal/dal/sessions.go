@@ -0,0 +1,273 @@
+package dal
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// activeSession matches the sessions that are neither revoked nor expired.
+const activeSession = "revoked_at IS NULL AND expires_at > datetime('now')"
+
+// CreateSession stores a new session along with its first refresh token.
+func (r *SQLiteRepository) CreateSession(ctx context.Context, s Session, refreshToken string) error {
+	return r.inTx(ctx, func(e execer) error {
+		sqlQuery, args, err := qb.Insert("auth_sessions").
+			Columns("id", "chat_id", "user_agent", "ip", "expires_at", "mini_app").
+			Values(s.ID, s.ChatID, s.UserAgent, s.IP, squirrel.Expr("datetime(?, 'unixepoch')", s.ExpiresAt.Unix()), s.MiniApp).
+			ToSql()
+		if err != nil {
+			return fmt.Errorf("build insert query: %w", err)
+		}
+		if _, err = e.ExecContext(ctx, sqlQuery, args...); err != nil {
+			return fmt.Errorf("create session: %w", err)
+		}
+		return insertRefreshToken(ctx, e, s.ID, refreshToken)
+	})
+}
+
+// RotateRefreshToken exchanges a refresh token for newToken and keeps the session going until
+// expiresAt. It reports ErrNotFound for a token of no active session, and ErrRefreshTokenReused, having
+// revoked the session, for a token that was exchanged before.
+func (r *SQLiteRepository) RotateRefreshToken(
+	ctx context.Context, token, newToken string, expiresAt time.Time,
+) (*Session, error) {
+	var (
+		res    Session
+		reused bool
+	)
+	err := r.inTx(ctx, func(e execer) error {
+		sqlQuery, args, err := qb.Select("s.id", "s.chat_id", "s.mini_app", "t.used_at IS NOT NULL").
+			From("auth_refresh_tokens t").
+			Join("auth_sessions s ON s.id = t.session_id").
+			Where(squirrel.Eq{"t.token_hash": hashToken(token)}).
+			Where("s.revoked_at IS NULL AND s.expires_at > datetime('now')").
+			ToSql()
+		if err != nil {
+			return fmt.Errorf("build select query: %w", err)
+		}
+		if err = e.QueryRowContext(ctx, sqlQuery, args...).Scan(&res.ID, &res.ChatID, &res.MiniApp, &reused); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrNotFound
+			}
+			return fmt.Errorf("find refresh token: %w", err)
+		}
+
+		if reused {
+			return revokeSession(ctx, e, res.ID)
+		}
+
+		// Only one of two concurrent rotations of the same token marks it used; the other is a reuse.
+		sqlQuery, args, err = qb.Update("auth_refresh_tokens").
+			Set("used_at", squirrel.Expr("CURRENT_TIMESTAMP")).
+			Where(squirrel.Eq{"token_hash": hashToken(token), "used_at": nil}).
+			ToSql()
+		if err != nil {
+			return fmt.Errorf("build update query: %w", err)
+		}
+		marked, err := e.ExecContext(ctx, sqlQuery, args...)
+		if err != nil {
+			return fmt.Errorf("mark refresh token used: %w", err)
+		}
+		affected, err := marked.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("get rows affected: %w", err)
+		}
+		if affected == 0 {
+			reused = true
+			return revokeSession(ctx, e, res.ID)
+		}
+		if err = insertRefreshToken(ctx, e, res.ID, newToken); err != nil {
+			return err
+		}
+
+		sqlQuery, args, err = qb.Update("auth_sessions").
+			Set("expires_at", squirrel.Expr("datetime(?, 'unixepoch')", expiresAt.Unix())).
+			Set("last_seen_at", squirrel.Expr("CURRENT_TIMESTAMP")).
+			Where(squirrel.Eq{"id": res.ID}).
+			ToSql()
+		if err != nil {
+			return fmt.Errorf("build update query: %w", err)
+		}
+		if _, err = e.ExecContext(ctx, sqlQuery, args...); err != nil {
+			return fmt.Errorf("extend session: %w", err)
+		}
+		res.ExpiresAt = expiresAt
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if reused {
+		return nil, ErrRefreshTokenReused
+	}
+	return &res, nil
+}
+
+// RevokeSessionByRefreshToken signs out the session a refresh token was given to, reporting ErrNotFound
+// unless that is an active session.
+func (r *SQLiteRepository) RevokeSessionByRefreshToken(ctx context.Context, token string) error {
+	revoked, err := r.revokeSessions(ctx, squirrel.Expr(
+		"id = (SELECT session_id FROM auth_refresh_tokens WHERE token_hash = ?)", hashToken(token)))
+	if err != nil {
+		return err
+	}
+	if revoked == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// TouchSession marks the session as seen now, reporting ErrNotFound unless it is active.
+func (r *SQLiteRepository) TouchSession(ctx context.Context, chatID int64, id string) error {
+	sqlQuery, args, err := qb.Update("auth_sessions").
+		Set("last_seen_at", squirrel.Expr("CURRENT_TIMESTAMP")).
+		Where(squirrel.Eq{"chat_id": chatID, "id": id}).
+		Where(activeSession).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build update query: %w", err)
+	}
+	res, err := r.db.ExecContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return fmt.Errorf("touch session: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected: %w", err)
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// GetSessions lists the chat's active sessions, most recently seen first.
+func (r *SQLiteRepository) GetSessions(ctx context.Context, chatID int64) ([]Session, error) {
+	sqlQuery, args, err := qb.Select("id", "chat_id", "user_agent", "ip", "created_at", "last_seen_at", "expires_at").
+		From("auth_sessions").
+		Where(squirrel.Eq{"chat_id": chatID}).
+		Where(activeSession).
+		OrderBy("last_seen_at DESC", "id").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build select query: %w", err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("get sessions: %w", err)
+	}
+	defer rows.Close()
+
+	res := make([]Session, 0)
+	for rows.Next() {
+		var s Session
+		if err = rows.Scan(&s.ID, &s.ChatID, &s.UserAgent, &s.IP, &s.CreatedAt, &s.LastSeenAt, &s.ExpiresAt); err != nil {
+			return nil, fmt.Errorf("scan session: %w", err)
+		}
+		res = append(res, s)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate sessions: %w", err)
+	}
+	return res, nil
+}
+
+// RevokeSession signs the session out, reporting ErrNotFound unless it is an active session of the
+// chat.
+func (r *SQLiteRepository) RevokeSession(ctx context.Context, chatID int64, id string) error {
+	revoked, err := r.revokeSessions(ctx, squirrel.Eq{"chat_id": chatID, "id": id})
+	if err != nil {
+		return err
+	}
+	if revoked == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// RevokeSessions signs the chat out of every session and returns how many there were.
+func (r *SQLiteRepository) RevokeSessions(ctx context.Context, chatID int64) (int, error) {
+	return r.revokeSessions(ctx, squirrel.Eq{"chat_id": chatID})
+}
+
+func (r *SQLiteRepository) revokeSessions(ctx context.Context, where squirrel.Sqlizer) (int, error) {
+	sqlQuery, args, err := qb.Update("auth_sessions").
+		Set("revoked_at", squirrel.Expr("CURRENT_TIMESTAMP")).
+		Where(where).
+		Where(activeSession).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("build update query: %w", err)
+	}
+	res, err := r.db.ExecContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return 0, fmt.Errorf("revoke sessions: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("get rows affected: %w", err)
+	}
+	return int(affected), nil
+}
+
+func revokeSession(ctx context.Context, e execer, id string) error {
+	sqlQuery, args, err := qb.Update("auth_sessions").
+		Set("revoked_at", squirrel.Expr("CURRENT_TIMESTAMP")).
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build update query: %w", err)
+	}
+	if _, err = e.ExecContext(ctx, sqlQuery, args...); err != nil {
+		return fmt.Errorf("revoke session: %w", err)
+	}
+	return nil
+}
+
+func insertRefreshToken(ctx context.Context, e execer, sessionID, token string) error {
+	sqlQuery, args, err := qb.Insert("auth_refresh_tokens").
+		Columns("token_hash", "session_id").
+		Values(hashToken(token), sessionID).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build insert query: %w", err)
+	}
+	if _, err = e.ExecContext(ctx, sqlQuery, args...); err != nil {
+		return fmt.Errorf("insert refresh token: %w", err)
+	}
+	return nil
+}
+
+// hashToken is what is stored of an opaque token, so that the database holds nothing that could be used
+// to sign in. The tokens are random, so an unsalted hash is enough.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// cleanupSessions drops the expired sessions, revoked or not, and the refresh tokens they were given.
+func (r *SQLiteRepository) cleanupSessions(ctx context.Context) {
+	queries := []squirrel.Sqlizer{
+		qb.Delete("auth_sessions").Where("expires_at < datetime('now')"),
+		qb.Delete("auth_refresh_tokens").Where("session_id NOT IN (SELECT id FROM auth_sessions)"),
+	}
+	for _, q := range queries {
+		sqlQuery, args, err := q.ToSql()
+		if err != nil {
+			r.log.ErrorContext(ctx, "failed to build cleanup query", "error", err)
+			return
+		}
+		if _, err = r.db.ExecContext(ctx, sqlQuery, args...); err != nil {
+			r.log.ErrorContext(ctx, "failed to cleanup sessions", "error", err)
+			return
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package dal_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+)
+
+func TestSessions(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+	expiresAt := time.Now().Add(time.Hour)
+
+	for _, id := range []string{"laptop", "phone"} {
+		session := dal.Session{ID: id, ChatID: dal.TestChatID, UserAgent: "test", IP: "127.0.0.1", ExpiresAt: expiresAt}
+		if err := r.CreateSession(ctx, session, id+"-refresh"); err != nil {
+			t.Fatalf("CreateSession(%s): %v", id, err)
+		}
+	}
+	if err := r.CreateSession(ctx, dal.Session{ID: "stale", ChatID: dal.TestChatID, ExpiresAt: time.Now().Add(-time.Hour)}, "stale-refresh"); err != nil {
+		t.Fatalf("CreateSession(stale): %v", err)
+	}
+
+	if err := r.TouchSession(ctx, dal.TestChatID, "laptop"); err != nil {
+		t.Errorf("TouchSession(laptop) = %v, want nil", err)
+	}
+	if err := r.TouchSession(ctx, dal.TestChatID+1, "laptop"); !errors.Is(err, dal.ErrNotFound) {
+		t.Errorf("TouchSession of another chat = %v, want ErrNotFound", err)
+	}
+	if err := r.TouchSession(ctx, dal.TestChatID, "stale"); !errors.Is(err, dal.ErrNotFound) {
+		t.Errorf("TouchSession of an expired session = %v, want ErrNotFound", err)
+	}
+
+	sessions, err := r.GetSessions(ctx, dal.TestChatID)
+	if err != nil {
+		t.Fatalf("GetSessions: %v", err)
+	}
+	if len(sessions) != 2 || sessions[0].UserAgent != "test" || sessions[0].IP != "127.0.0.1" {
+		t.Fatalf("sessions = %+v, want laptop and phone", sessions)
+	}
+
+	if err = r.RevokeSession(ctx, dal.TestChatID, "phone"); err != nil {
+		t.Fatalf("RevokeSession(phone): %v", err)
+	}
+	if err = r.RevokeSession(ctx, dal.TestChatID, "phone"); !errors.Is(err, dal.ErrNotFound) {
+		t.Errorf("second RevokeSession(phone) = %v, want ErrNotFound", err)
+	}
+	if err = r.TouchSession(ctx, dal.TestChatID, "phone"); !errors.Is(err, dal.ErrNotFound) {
+		t.Errorf("TouchSession of a revoked session = %v, want ErrNotFound", err)
+	}
+
+	revoked, err := r.RevokeSessions(ctx, dal.TestChatID)
+	if err != nil || revoked != 1 {
+		t.Fatalf("RevokeSessions = %d, %v; want the laptop only", revoked, err)
+	}
+	if sessions, err = r.GetSessions(ctx, dal.TestChatID); err != nil || len(sessions) != 0 {
+		t.Errorf("sessions after signing out everywhere = %+v (%v), want none", sessions, err)
+	}
+}
+
+func TestRotateRefreshToken(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+
+	if err := r.CreateSession(ctx, dal.Session{ID: "laptop", ChatID: dal.TestChatID, ExpiresAt: time.Now().Add(time.Hour)}, "first"); err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+
+	session, err := r.RotateRefreshToken(ctx, "first", "second", time.Now().Add(24*time.Hour))
+	if err != nil {
+		t.Fatalf("RotateRefreshToken(first): %v", err)
+	}
+	if session.ID != "laptop" || session.ChatID != dal.TestChatID {
+		t.Errorf("rotated session = %+v, want laptop of the test chat", session)
+	}
+
+	if err = r.CreateSession(ctx, dal.Session{
+		ID: "phone", ChatID: dal.TestChatID, ExpiresAt: time.Now().Add(time.Hour), MiniApp: true,
+	}, "mini"); err != nil {
+		t.Fatalf("CreateSession(phone): %v", err)
+	}
+	if session, err = r.RotateRefreshToken(ctx, "mini", "mini-2", time.Now().Add(time.Hour)); err != nil || !session.MiniApp {
+		t.Errorf("rotated mini app session = %+v (%v), want it still marked as the Mini App's", session, err)
+	}
+	if _, err = r.RotateRefreshToken(ctx, "unknown", "third", time.Now().Add(time.Hour)); !errors.Is(err, dal.ErrNotFound) {
+		t.Errorf("RotateRefreshToken(unknown) = %v, want ErrNotFound", err)
+	}
+
+	if _, err = r.RotateRefreshToken(ctx, "first", "third", time.Now().Add(time.Hour)); !errors.Is(err, dal.ErrRefreshTokenReused) {
+		t.Fatalf("reusing the first token = %v, want ErrRefreshTokenReused", err)
+	}
+	if err = r.TouchSession(ctx, dal.TestChatID, "laptop"); !errors.Is(err, dal.ErrNotFound) {
+		t.Errorf("TouchSession after reuse = %v, want the session revoked", err)
+	}
+	if _, err = r.RotateRefreshToken(ctx, "second", "third", time.Now().Add(time.Hour)); !errors.Is(err, dal.ErrNotFound) {
+		t.Errorf("RotateRefreshToken(second) after reuse = %v, want ErrNotFound", err)
+	}
+}
+
+func TestRevokeSessionByRefreshToken(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+
+	if err := r.CreateSession(ctx, dal.Session{ID: "laptop", ChatID: dal.TestChatID, ExpiresAt: time.Now().Add(time.Hour)}, "first"); err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	if err := r.RevokeSessionByRefreshToken(ctx, "first"); err != nil {
+		t.Fatalf("RevokeSessionByRefreshToken: %v", err)
+	}
+	if err := r.RevokeSessionByRefreshToken(ctx, "first"); !errors.Is(err, dal.ErrNotFound) {
+		t.Errorf("second RevokeSessionByRefreshToken = %v, want ErrNotFound", err)
+	}
+	if _, err := r.RotateRefreshToken(ctx, "first", "second", time.Now().Add(time.Hour)); !errors.Is(err, dal.ErrNotFound) {
+		t.Errorf("RotateRefreshToken of a signed-out session = %v, want ErrNotFound", err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/Masterminds/squirrel"
 )
@@ -17,6 +18,7 @@ type (
 	// one so that they can run either standalone or as part of a transaction opened by inTx.
 	execer interface {
 		ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+		QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
 		QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
 	}
 
@@ -25,16 +27,27 @@ type (
 		// streakLimit is the number of consecutive correct answers after which a word counts as
 		// learned. It is the single source of truth for every "is this learned?" query.
 		streakLimit int
-		// batchSize is the hard cap on how many words learning_batches may hold per chat. It is the
-		// single source of truth for every admission decision - RefillLearningBatch and
-		// requestBatchMembership both read it here instead of taking it as a parameter.
+		// batchSize is the hard cap on how many words learning_batches may hold per chat, unless the
+		// chat has set its own (see SetBatchSize). It is the single source of truth for every
+		// admission decision - RefillLearningBatch and requestBatchMembership both read it here
+		// instead of taking it as a parameter.
 		batchSize int
-		log       *slog.Logger
+		// drillIntervals are the waits between the asks of a word in the drill; see drill.go. Empty
+		// turns the drill off.
+		drillIntervals []time.Duration
+		// leech says when a word that keeps being missed is tagged as a leech; see leech.go.
+		leech LeechRules
+		// review spaces out the reviews of learned words; see review.go.
+		review ReviewRules
+		log    *slog.Logger
 	}
 )
 
-func NewSQLiteRepository(ctx context.Context, client *sql.DB, streakLimit, batchSize int, log *slog.Logger) *SQLiteRepository {
-	res := newSQLRepository(client, streakLimit, batchSize, log)
+func NewSQLiteRepository(
+	ctx context.Context, client *sql.DB, streakLimit, batchSize int, drillIntervals []time.Duration, leech LeechRules,
+	review ReviewRules, log *slog.Logger,
+) *SQLiteRepository {
+	res := newSQLRepository(client, streakLimit, batchSize, drillIntervals, leech, review, log)
 	go res.cleanupCallbacksJob(ctx)
 	go res.cleanupAuthConfirmations(ctx)
 	return res
@@ -67,6 +80,12 @@ func (r *SQLiteRepository) inTx(ctx context.Context, fn func(e execer) error) er
 	return nil
 }
 
-func newSQLRepository(db *sql.DB, streakLimit, batchSize int, log *slog.Logger) *SQLiteRepository {
-	return &SQLiteRepository{db: db, streakLimit: streakLimit, batchSize: batchSize, log: log}
+func newSQLRepository(
+	db *sql.DB, streakLimit, batchSize int, drillIntervals []time.Duration, leech LeechRules, review ReviewRules,
+	log *slog.Logger,
+) *SQLiteRepository {
+	return &SQLiteRepository{
+		db: db, streakLimit: streakLimit, batchSize: batchSize, drillIntervals: drillIntervals, leech: leech,
+		review: review, log: log,
+	}
 }
@@ -16,15 +16,20 @@ const nearlyLearnedWidth = 5
 
 func (r *SQLiteRepository) GetTotalStats(ctx context.Context, chatID int64) (*TotalStats, error) {
 	// Buckets are derived from the streak limit so that they keep meaning something when it is
-	// retuned: [limit, ∞) is learned, the five below it are nearly there, the rest are early.
+	// retuned: [limit, ∞) is learned, the five below it are nearly there, the rest are early. Each
+	// word is bucketed by its own limit; the configured one only labels the buckets.
 	nearlyFrom := max(1, r.streakLimit-nearlyLearnedWidth)
 	query := qb.Select("chat_id").
-		Column("SUM(CASE WHEN guessed_streak >= ? THEN 1 ELSE 0 END) AS learned", r.streakLimit).
-		Column("SUM(CASE WHEN guessed_streak BETWEEN ? AND ? THEN 1 ELSE 0 END) AS nearly", nearlyFrom, r.streakLimit-1).
-		Column("SUM(CASE WHEN guessed_streak BETWEEN 1 AND ? THEN 1 ELSE 0 END) AS early", nearlyFrom-1).
+		Column("SUM(CASE WHEN guessed_streak >= streak_limit THEN 1 ELSE 0 END) AS learned").
+		Column("SUM(CASE WHEN guessed_streak < streak_limit AND guessed_streak >= MAX(1, streak_limit - ?) THEN 1 ELSE 0 END) AS nearly",
+			nearlyLearnedWidth).
+		Column("SUM(CASE WHEN guessed_streak >= 1 AND guessed_streak < MAX(1, streak_limit - ?) THEN 1 ELSE 0 END) AS early",
+			nearlyLearnedWidth).
 		Column("COUNT(*) AS total_words").
-		From("word_translations").
-		Where(squirrel.Eq{"chat_id": chatID}).
+		Column("SUM(CASE WHEN to_review THEN 1 ELSE 0 END) AS to_review").
+		FromSelect(squirrel.Select("wt.chat_id", "wt.guessed_streak", "wt.to_review", streakLimitOf("wt", r.streakLimit)+" AS streak_limit").
+			From("word_translations wt").
+			Where("wt.chat_id = ?", chatID), "wt").
 		GroupBy("chat_id")
 
 	sqlQuery, args, err := query.ToSql()
@@ -41,6 +46,7 @@ func (r *SQLiteRepository) GetTotalStats(ctx context.Context, chatID int64) (*To
 		&stats.Nearly,
 		&stats.Early,
 		&stats.Total,
+		&stats.ToReview,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -118,7 +124,9 @@ func (r *SQLiteRepository) GetStatsRange(ctx context.Context, chatID int64, from
 	).
 		From("statistics").
 		Where(squirrel.Eq{"chat_id": chatID}).
-		Where(squirrel.Expr("date BETWEEN ? AND ?", from, to)).
+		// Bound as plain dates: a time.Time would be bound as a full timestamp, which sorts after the
+		// bare date stored for the same day and silently drops `from` itself.
+		Where(squirrel.Expr("date BETWEEN ? AND ?", from.Format(dateLayout), to.Format(dateLayout))).
 		OrderBy("date")
 
 	sql, args, err := query.ToSql()
@@ -197,6 +205,25 @@ func incrementWordMissed(ctx context.Context, e execer, chatID int64) error {
 	return nil
 }
 
+// incrementWordsAdded counts a brand-new word towards today's "new words per day" goal.
+func incrementWordsAdded(ctx context.Context, e execer, chatID int64) error {
+	query := qb.Insert("statistics").
+		Columns("chat_id", "date", "words_added").
+		Values(chatID, squirrel.Expr("date('now', 'localtime')"), 1).
+		Suffix("ON CONFLICT (chat_id, date) DO UPDATE SET words_added = statistics.words_added + 1")
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return fmt.Errorf("build query: %w", err)
+	}
+
+	_, err = e.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("increment words added: %w", err)
+	}
+	return nil
+}
+
 // updateTotalWordsLearned recomputes today's learned count from the vocabulary itself.
 //
 // It inserts today's row as well as updating it: a streak reset from the UI can be the first thing
@@ -206,8 +233,8 @@ func updateTotalWordsLearned(ctx context.Context, e execer, chatID int64, streak
 	// The subquery is inlined with "?" placeholders so that the outer builder's Dollar format is
 	// applied exactly once, over the whole statement.
 	learned := squirrel.Expr(
-		"(SELECT COUNT(*) FROM word_translations WHERE chat_id = ? AND guessed_streak >= ?)",
-		chatID, streakLimit)
+		"(SELECT COUNT(*) FROM word_translations wt WHERE wt.chat_id = ? AND wt.guessed_streak >= "+streakLimitOf("wt", streakLimit)+")",
+		chatID)
 
 	query := qb.Insert("statistics").
 		Columns("chat_id", "date", "total_words_learned").
@@ -104,6 +104,27 @@ func TestGetTotalStatsBuckets(t *testing.T) {
 	}
 }
 
+func TestGetTotalStatsCountsToReview(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+	r.AddWord("apple", 0)
+	r.AddWord("pear", 20)
+	r.AddWord("plum", 3)
+	for _, word := range []string{"apple", "pear"} {
+		if err := r.MarkToReview(ctx, dal.TestChatID, word, true); err != nil {
+			t.Fatalf("MarkToReview: %v", err)
+		}
+	}
+
+	got, err := r.GetTotalStats(ctx, dal.TestChatID)
+	if err != nil {
+		t.Fatalf("GetTotalStats: %v", err)
+	}
+	if got.ToReview != 2 {
+		t.Errorf("ToReview = %d, want 2", got.ToReview)
+	}
+}
+
 func TestGetTotalStatsNoWords(t *testing.T) {
 	r := dal.NewTestRepo(t)
 	r.SetStreakLimit(8)
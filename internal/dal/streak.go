@@ -0,0 +1,261 @@
+package dal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// dateLayout is how statistics.date stores a day.
+const dateLayout = "2006-01-02"
+
+// streakDay is one row of statistics, reduced to what the streak cares about.
+type streakDay struct {
+	date    string
+	answers int
+	added   int
+	frozen  bool
+}
+
+// counts reports whether the day keeps the streak going: its goal was met, or a freeze covered it.
+func (d streakDay) counts(goal DailyGoal) bool {
+	return d.frozen || (d.answers >= goal.Answers && d.added >= goal.NewWords)
+}
+
+// GetStreak reports the chat's learning streak as of today, together with today's progress towards
+// the daily goal.
+//
+// The streak is computed from statistics on every call rather than stored, so retuning the goal
+// re-evaluates history instead of leaving a count that was earned under different rules. Freezes are
+// the exception: spending one is recorded on the day it covered (see SettleStreak), so a covered day
+// stays covered.
+func (r *SQLiteRepository) GetStreak(ctx context.Context, chatID int64, goal DailyGoal, today time.Time) (*Streak, error) {
+	res := &Streak{Goal: goal}
+
+	day, err := streakDayOf(ctx, r.db, chatID, today)
+	if err != nil {
+		return nil, fmt.Errorf("get today's progress: %w", err)
+	}
+	res.TodayAnswers = day.answers
+	res.TodayNewWords = day.added
+	res.GoalMet = day.counts(goal)
+
+	// An unfinished today neither extends nor breaks the streak: it still ends yesterday.
+	end := today
+	if !res.GoalMet {
+		end = today.AddDate(0, 0, -1)
+	}
+	if res.Days, err = streakEndingAt(ctx, r.db, chatID, goal, end); err != nil {
+		return nil, fmt.Errorf("count streak: %w", err)
+	}
+
+	if _, res.Freezes, err = chatStreakState(ctx, r.db, chatID); err != nil {
+		return nil, fmt.Errorf("get streak freezes: %w", err)
+	}
+
+	return res, nil
+}
+
+// SettleStreak closes the books on yesterday: a missed goal spends a freeze, if one is left and there
+// is a streak to protect, and a met goal that completes a multiple of FreezeEvery days earns one. A
+// missed day the chat was paused for, even partly, is covered without spending a freeze.
+//
+// It is idempotent per day - the settled day is recorded in chat_settings and settling it again does
+// nothing - so the schedule can call it as often as it likes. Days before yesterday are never
+// settled retroactively: if the bot was down for a while, the days it missed are simply missed.
+func (r *SQLiteRepository) SettleStreak(ctx context.Context, chatID int64, rules StreakRules, today time.Time) (*StreakSettlement, error) {
+	res := &StreakSettlement{}
+	yesterday := today.AddDate(0, 0, -1)
+	yesterdayStr := yesterday.Format(dateLayout)
+
+	err := r.inTx(ctx, func(e execer) error {
+		settledThrough, freezes, err := chatStreakState(ctx, e, chatID)
+		if err != nil {
+			return fmt.Errorf("get streak state: %w", err)
+		}
+		if settledThrough >= yesterdayStr {
+			return nil
+		}
+
+		day, err := streakDayOf(ctx, e, chatID, yesterday)
+		if err != nil {
+			return fmt.Errorf("get day: %w", err)
+		}
+		pause, err := chatPause(ctx, e, chatID)
+		if err != nil {
+			return fmt.Errorf("get pause: %w", err)
+		}
+		dayStart := time.Date(yesterday.Year(), yesterday.Month(), yesterday.Day(), 0, 0, 0, 0, yesterday.Location())
+		paused := pause.Covers(dayStart, dayStart.AddDate(0, 0, 1))
+
+		switch {
+		case day.counts(rules.Goal):
+			if rules.FreezeEvery <= 0 || freezes >= rules.MaxFreezes {
+				break
+			}
+			days, err := streakEndingAt(ctx, e, chatID, rules.Goal, yesterday)
+			if err != nil {
+				return fmt.Errorf("count streak: %w", err)
+			}
+			if days%rules.FreezeEvery == 0 {
+				freezes++
+				res.Awarded = true
+			}
+		case paused || freezes > 0:
+			before, err := streakEndingAt(ctx, e, chatID, rules.Goal, yesterday.AddDate(0, 0, -1))
+			if err != nil {
+				return fmt.Errorf("count streak: %w", err)
+			}
+			if before == 0 {
+				break
+			}
+			if err = freezeDay(ctx, e, chatID, yesterdayStr); err != nil {
+				return fmt.Errorf("freeze day: %w", err)
+			}
+			// A paused day is covered for free; only a day missed otherwise spends a freeze.
+			if paused {
+				res.Paused = true
+			} else {
+				freezes--
+				res.Frozen = true
+			}
+		}
+
+		if err = saveChatStreakState(ctx, e, chatID, yesterdayStr, freezes); err != nil {
+			return fmt.Errorf("save streak state: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// streakDayOf returns the given day's row, or an empty day if the chat did nothing that day.
+func streakDayOf(ctx context.Context, e execer, chatID int64, date time.Time) (streakDay, error) {
+	query := qb.Select("date", "words_guessed + words_missed", "words_added", "streak_frozen").
+		From("statistics").
+		Where(squirrel.Eq{"chat_id": chatID, "date": date.Format(dateLayout)})
+
+	sqlQuery, args, err := query.ToSql()
+	if err != nil {
+		return streakDay{}, fmt.Errorf("build query: %w", err)
+	}
+
+	var day streakDay
+	err = e.QueryRowContext(ctx, sqlQuery, args...).Scan(&day.date, &day.answers, &day.added, &day.frozen)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return streakDay{date: date.Format(dateLayout)}, nil
+		}
+		return streakDay{}, fmt.Errorf("get day: %w", err)
+	}
+	return day, nil
+}
+
+// streakEndingAt counts the consecutive days, walking back from end inclusive, that keep the streak
+// going. A day without a statistics row is a day nothing happened, so it ends the walk like any other
+// missed day.
+func streakEndingAt(ctx context.Context, e execer, chatID int64, goal DailyGoal, end time.Time) (int, error) {
+	query := qb.Select("date", "words_guessed + words_missed", "words_added", "streak_frozen").
+		From("statistics").
+		Where(squirrel.Eq{"chat_id": chatID}).
+		Where(squirrel.LtOrEq{"date": end.Format(dateLayout)}).
+		OrderBy("date DESC")
+
+	sqlQuery, args, err := query.ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("build query: %w", err)
+	}
+
+	rows, err := e.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return 0, fmt.Errorf("get days: %w", err)
+	}
+	defer rows.Close()
+
+	days := 0
+	expected := end
+	for rows.Next() {
+		var day streakDay
+		if err = rows.Scan(&day.date, &day.answers, &day.added, &day.frozen); err != nil {
+			return 0, fmt.Errorf("scan day: %w", err)
+		}
+		if day.date != expected.Format(dateLayout) || !day.counts(goal) {
+			break
+		}
+		days++
+		expected = expected.AddDate(0, 0, -1)
+	}
+	if err = rows.Err(); err != nil {
+		return 0, fmt.Errorf("iterate days: %w", err)
+	}
+
+	return days, nil
+}
+
+// chatStreakState returns the last settled day ("" if none) and the unspent freezes. A chat without
+// a chat_settings row has settled nothing and holds no freezes.
+func chatStreakState(ctx context.Context, e execer, chatID int64) (string, int, error) {
+	query := qb.Select("COALESCE(streak_settled_through, '')", "streak_freezes").
+		From("chat_settings").
+		Where(squirrel.Eq{"chat_id": chatID})
+
+	sqlQuery, args, err := query.ToSql()
+	if err != nil {
+		return "", 0, fmt.Errorf("build query: %w", err)
+	}
+
+	var (
+		settledThrough string
+		freezes        int
+	)
+	if err = e.QueryRowContext(ctx, sqlQuery, args...).Scan(&settledThrough, &freezes); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", 0, nil
+		}
+		return "", 0, fmt.Errorf("get chat settings: %w", err)
+	}
+	return settledThrough, freezes, nil
+}
+
+func saveChatStreakState(ctx context.Context, e execer, chatID int64, settledThrough string, freezes int) error {
+	query := qb.Insert("chat_settings").
+		Columns("chat_id", "streak_settled_through", "streak_freezes").
+		Values(chatID, settledThrough, freezes).
+		Suffix("ON CONFLICT (chat_id) DO UPDATE SET " +
+			"streak_settled_through = EXCLUDED.streak_settled_through, streak_freezes = EXCLUDED.streak_freezes")
+
+	sqlQuery, args, err := query.ToSql()
+	if err != nil {
+		return fmt.Errorf("build query: %w", err)
+	}
+
+	if _, err = e.ExecContext(ctx, sqlQuery, args...); err != nil {
+		return fmt.Errorf("save chat settings: %w", err)
+	}
+	return nil
+}
+
+func freezeDay(ctx context.Context, e execer, chatID int64, date string) error {
+	query := qb.Insert("statistics").
+		Columns("chat_id", "date", "streak_frozen").
+		Values(chatID, date, 1).
+		Suffix("ON CONFLICT (chat_id, date) DO UPDATE SET streak_frozen = 1")
+
+	sqlQuery, args, err := query.ToSql()
+	if err != nil {
+		return fmt.Errorf("build query: %w", err)
+	}
+
+	if _, err = e.ExecContext(ctx, sqlQuery, args...); err != nil {
+		return fmt.Errorf("freeze day: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package dal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// A word counts as learned once its streak reaches its streak limit: its own if it has one, else the
+// highest limit among its tags, else the configured one the repository was built with. Everything
+// that asks "is this word learned?" compares against streakLimitOf rather than a single number.
+
+// streakLimitOf is the effective streak limit of the word_translations row aliased alias, as SQL.
+// defaultLimit is an int, so formatting it into the statement is safe.
+func streakLimitOf(alias string, defaultLimit int) string {
+	return fmt.Sprintf("COALESCE(%[1]s.streak_limit, (SELECT MAX(tsl.streak_limit) FROM word_tags wtg "+
+		"JOIN tag_streak_limits tsl ON tsl.chat_id = wtg.chat_id AND tsl.tag = wtg.tag "+
+		"WHERE wtg.chat_id = %[1]s.chat_id AND wtg.word = %[1]s.word), %[2]d)", alias, defaultLimit)
+}
+
+// streakLimitOfWord is streakLimitOf for a table that only refers to a word, such as answer_log: the
+// limit of the word in chatColumn with the name in wordColumn, or defaultLimit once it is deleted.
+func streakLimitOfWord(chatColumn, wordColumn string, defaultLimit int) string {
+	return fmt.Sprintf("COALESCE((SELECT %s FROM word_translations lw WHERE lw.chat_id = %s AND lw.word = %s), %d)",
+		streakLimitOf("lw", defaultLimit), chatColumn, wordColumn, defaultLimit)
+}
+
+// SetWordStreakLimit gives a word a streak limit of its own; 0 removes it, so the word goes back to
+// its tags' limit or the configured one. It reports ErrNotFound for a word that does not exist.
+func (r *SQLiteRepository) SetWordStreakLimit(ctx context.Context, chatID int64, word string, limit int) error {
+	if limit < 0 {
+		return fmt.Errorf("streak limit %d must not be negative", limit)
+	}
+	var value any
+	if limit > 0 {
+		value = limit
+	}
+
+	return r.inTx(ctx, func(e execer) error {
+		sqlQuery, args, err := qb.Update("word_translations").
+			Set("streak_limit", value).
+			Where(squirrel.Eq{"chat_id": chatID, "word": word}).
+			ToSql()
+		if err != nil {
+			return fmt.Errorf("build update query: %w", err)
+		}
+		res, err := e.ExecContext(ctx, sqlQuery, args...)
+		if err != nil {
+			return fmt.Errorf("set streak limit: %w", err)
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("get rows affected: %w", err)
+		}
+		if affected == 0 {
+			return ErrNotFound
+		}
+
+		if err = updateTotalWordsLearned(ctx, e, chatID, r.streakLimit); err != nil {
+			return fmt.Errorf("update total words learned: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetTagStreakLimits returns the chat's tag limits by tag.
+func (r *SQLiteRepository) GetTagStreakLimits(ctx context.Context, chatID int64) ([]TagStreakLimit, error) {
+	sqlQuery, args, err := qb.Select("tag", "streak_limit").
+		From("tag_streak_limits").
+		Where(squirrel.Eq{"chat_id": chatID}).
+		OrderBy("tag").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build select query: %w", err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("get tag streak limits: %w", err)
+	}
+	defer rows.Close()
+
+	var res []TagStreakLimit
+	for rows.Next() {
+		var l TagStreakLimit
+		if err = rows.Scan(&l.Tag, &l.Limit); err != nil {
+			return nil, fmt.Errorf("scan tag streak limit: %w", err)
+		}
+		res = append(res, l)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate tag streak limits: %w", err)
+	}
+	return res, nil
+}
+
+// SetTagStreakLimit sets the streak limit of the words with a tag; 0 removes it. The tag does not
+// have to be on any word yet.
+func (r *SQLiteRepository) SetTagStreakLimit(ctx context.Context, chatID int64, tag string, limit int) error {
+	tag = normalizeTag(tag)
+	switch {
+	case tag == "":
+		return errors.New("tag must not be empty")
+	case strings.Contains(tag, ","):
+		return fmt.Errorf("tag %q must not contain a comma", tag)
+	case limit < 0:
+		return fmt.Errorf("streak limit %d must not be negative", limit)
+	}
+
+	return r.inTx(ctx, func(e execer) error {
+		var (
+			sqlQuery string
+			args     []interface{}
+			err      error
+		)
+		if limit == 0 {
+			sqlQuery, args, err = qb.Delete("tag_streak_limits").Where(squirrel.Eq{"chat_id": chatID, "tag": tag}).ToSql()
+		} else {
+			sqlQuery, args, err = qb.Insert("tag_streak_limits").
+				Columns("chat_id", "tag", "streak_limit").
+				Values(chatID, tag, limit).
+				Suffix("ON CONFLICT (chat_id, tag) DO UPDATE SET streak_limit = EXCLUDED.streak_limit").
+				ToSql()
+		}
+		if err != nil {
+			return fmt.Errorf("build query: %w", err)
+		}
+		if _, err = e.ExecContext(ctx, sqlQuery, args...); err != nil {
+			return fmt.Errorf("set tag streak limit: %w", err)
+		}
+
+		if err = updateTotalWordsLearned(ctx, e, chatID, r.streakLimit); err != nil {
+			return fmt.Errorf("update total words learned: %w", err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,119 @@
+package dal_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+)
+
+func TestStreakLimits(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+	r.AddWord("cat", 5)
+	r.AddWord("dog", 5)
+	r.AddWord("perfunctory", dal.TestStreakLimit)
+	r.AddWord("plain", dal.TestStreakLimit)
+	r.SeedBatch("cat", "dog", "perfunctory", "plain")
+
+	if err := r.SetWordStreakLimit(ctx, dal.TestChatID, "cat", 5); err != nil {
+		t.Fatalf("SetWordStreakLimit: %v", err)
+	}
+	if err := r.SetWordTags(ctx, dal.TestChatID, "perfunctory", []string{"hard", "formal"}); err != nil {
+		t.Fatalf("SetWordTags: %v", err)
+	}
+	if err := r.SetTagStreakLimit(ctx, dal.TestChatID, "Hard", 18); err != nil {
+		t.Fatalf("SetTagStreakLimit: %v", err)
+	}
+	if err := r.SetTagStreakLimit(ctx, dal.TestChatID, "formal", 17); err != nil {
+		t.Fatalf("SetTagStreakLimit: %v", err)
+	}
+
+	limits, err := r.GetTagStreakLimits(ctx, dal.TestChatID)
+	if err != nil {
+		t.Fatalf("GetTagStreakLimits: %v", err)
+	}
+	if want := []dal.TagStreakLimit{{Tag: "formal", Limit: 17}, {Tag: "hard", Limit: 18}}; !slices.Equal(limits, want) {
+		t.Errorf("tag limits = %+v, want %+v", limits, want)
+	}
+
+	// cat reached its own limit; perfunctory is short of the highest of its tags'.
+	stats, err := r.GetTotalStats(ctx, dal.TestChatID)
+	if err != nil {
+		t.Fatalf("GetTotalStats: %v", err)
+	}
+	if stats.Learned != 2 || stats.Nearly != 1 || stats.Early != 1 {
+		t.Errorf("learned/nearly/early = %d/%d/%d, want 2/1/1", stats.Learned, stats.Nearly, stats.Early)
+	}
+
+	learned, _, err := r.FindWordTranslations(ctx, dal.TestChatID, dal.WordTranslationsFilter{Guessed: dal.GuessedLearned, Limit: 10})
+	if err != nil {
+		t.Fatalf("FindWordTranslations: %v", err)
+	}
+	if got := words(learned); !slices.Equal(got, []string{"cat", "plain"}) {
+		t.Errorf("learned = %v, want [cat plain]", got)
+	}
+
+	report, err := r.RefillLearningBatch(ctx, dal.TestChatID)
+	if err != nil {
+		t.Fatalf("RefillLearningBatch: %v", err)
+	}
+	if report.Evicted != 2 || !r.IsBatched("perfunctory") || !r.IsBatched("dog") {
+		t.Errorf("evicted %d, batch %v; want cat and plain evicted", report.Evicted, r.BatchWords())
+	}
+
+	// Removing the limits puts both back on the configured one.
+	if err = r.SetWordStreakLimit(ctx, dal.TestChatID, "cat", 0); err != nil {
+		t.Fatalf("SetWordStreakLimit: %v", err)
+	}
+	if err = r.SetTagStreakLimit(ctx, dal.TestChatID, "hard", 0); err != nil {
+		t.Fatalf("SetTagStreakLimit: %v", err)
+	}
+	if err = r.SetTagStreakLimit(ctx, dal.TestChatID, "formal", 0); err != nil {
+		t.Fatalf("SetTagStreakLimit: %v", err)
+	}
+	if stats, err = r.GetTotalStats(ctx, dal.TestChatID); err != nil {
+		t.Fatalf("GetTotalStats: %v", err)
+	}
+	if stats.Learned != 2 || stats.Early != 2 {
+		t.Errorf("learned/early = %d/%d, want 2/2", stats.Learned, stats.Early)
+	}
+
+	if err = r.SetWordStreakLimit(ctx, dal.TestChatID, "missing", 5); !errors.Is(err, dal.ErrNotFound) {
+		t.Errorf("SetWordStreakLimit on a missing word = %v, want ErrNotFound", err)
+	}
+}
+
+func TestStreakLimitDecidesReviews(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+	r.AddWord("cat", 4)
+	r.AddWord("plain", 4)
+	if err := r.SetWordStreakLimit(ctx, dal.TestChatID, "cat", 5); err != nil {
+		t.Fatalf("SetWordStreakLimit: %v", err)
+	}
+
+	// The guess that reaches the word's own limit schedules its first review.
+	for _, word := range []string{"cat", "plain"} {
+		if err := r.Guess(dal.TestChatID, word); err != nil {
+			t.Fatalf("Guess(%q): %v", word, err)
+		}
+	}
+	if _, _, ok := r.ReviewOf("cat"); !ok {
+		t.Error("cat learned by its own limit got no review scheduled")
+	}
+	if _, _, ok := r.ReviewOf("plain"); ok {
+		t.Error("plain, short of the configured limit, got a review scheduled")
+	}
+
+	filter := dal.FindRandomWordFilter{Learned: true, Order: dal.OrderLeastRecentlyReviewed}
+	wt, err := r.FindRandomWordTranslation(ctx, dal.TestChatID, filter)
+	if err != nil {
+		t.Fatalf("FindRandomWordTranslation: %v", err)
+	}
+	if wt.Word != "cat" || wt.StreakLimit != 5 {
+		t.Errorf("picked %q with limit %d, want cat with 5", wt.Word, wt.StreakLimit)
+	}
+}
@@ -0,0 +1,218 @@
+package dal_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+)
+
+var (
+	testGoal  = dal.DailyGoal{Answers: 10}
+	testRules = dal.StreakRules{Goal: testGoal, FreezeEvery: 3, MaxFreezes: 2}
+	// testToday is fixed so that day arithmetic never depends on when the test runs.
+	testToday = time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+)
+
+func daysAgo(n int) time.Time {
+	return testToday.AddDate(0, 0, -n)
+}
+
+func TestGetStreak(t *testing.T) {
+	tests := []struct {
+		name string
+		// answers per day, index 0 is today; -1 means no row at all
+		answers     []int
+		wantDays    int
+		wantGoalMet bool
+	}{
+		{name: "no history", answers: nil, wantDays: 0},
+		{name: "unfinished today does not break the streak", answers: []int{3, 10, 12}, wantDays: 2},
+		{name: "met today extends it", answers: []int{10, 10, 12}, wantDays: 3, wantGoalMet: true},
+		{name: "a missed day ends it", answers: []int{10, 10, 4, 10}, wantDays: 2, wantGoalMet: true},
+		{name: "a day without a row ends it", answers: []int{-1, 10, -1, 10}, wantDays: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := dal.NewTestRepo(t)
+			for i, answers := range tt.answers {
+				if answers >= 0 {
+					r.SeedDay(daysAgo(i), answers, 0)
+				}
+			}
+
+			got, err := r.GetStreak(context.Background(), dal.TestChatID, testGoal, testToday)
+			if err != nil {
+				t.Fatalf("GetStreak: %v", err)
+			}
+			if got.Days != tt.wantDays {
+				t.Errorf("Days = %d, want %d", got.Days, tt.wantDays)
+			}
+			if got.GoalMet != tt.wantGoalMet {
+				t.Errorf("GoalMet = %v, want %v", got.GoalMet, tt.wantGoalMet)
+			}
+		})
+	}
+}
+
+func TestGetStreakRequiresNewWords(t *testing.T) {
+	r := dal.NewTestRepo(t)
+	r.SeedDay(daysAgo(0), 10, 1)
+	r.SeedDay(daysAgo(1), 10, 2)
+
+	got, err := r.GetStreak(context.Background(), dal.TestChatID, dal.DailyGoal{Answers: 10, NewWords: 2}, testToday)
+	if err != nil {
+		t.Fatalf("GetStreak: %v", err)
+	}
+	if got.GoalMet {
+		t.Error("today counts with 1 of 2 new words")
+	}
+	if got.Days != 1 {
+		t.Errorf("Days = %d, want 1", got.Days)
+	}
+	if got.TodayAnswers != 10 || got.TodayNewWords != 1 {
+		t.Errorf("today's progress = %d answers / %d new words, want 10 / 1", got.TodayAnswers, got.TodayNewWords)
+	}
+}
+
+func TestCreateWordTranslationCountsTowardsNewWordsGoal(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+
+	if err := r.CreateWordTranslation(ctx, dal.TestChatID, "apple", "яблуко", ""); err != nil {
+		t.Fatalf("CreateWordTranslation: %v", err)
+	}
+
+	got, err := r.GetStreak(ctx, dal.TestChatID, testGoal, time.Now())
+	if err != nil {
+		t.Fatalf("GetStreak: %v", err)
+	}
+	if got.TodayNewWords != 1 {
+		t.Errorf("TodayNewWords = %d, want 1", got.TodayNewWords)
+	}
+}
+
+func TestSettleStreakSpendsFreezeOnMissedDay(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+	r.SeedFreezes(1)
+	r.SeedDay(daysAgo(2), 10, 0)
+	r.SeedDay(daysAgo(3), 10, 0)
+	// yesterday: nothing
+
+	res, err := r.SettleStreak(ctx, dal.TestChatID, testRules, testToday)
+	if err != nil {
+		t.Fatalf("SettleStreak: %v", err)
+	}
+	if !res.Frozen {
+		t.Fatal("missed day was not frozen")
+	}
+
+	got, err := r.GetStreak(ctx, dal.TestChatID, testGoal, testToday)
+	if err != nil {
+		t.Fatalf("GetStreak: %v", err)
+	}
+	if got.Days != 3 {
+		t.Errorf("Days = %d, want 3: two met days plus the frozen one", got.Days)
+	}
+	if got.Freezes != 0 {
+		t.Errorf("Freezes = %d, want 0", got.Freezes)
+	}
+}
+
+func TestSettleStreakKeepsFreezeWithoutStreak(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+	r.SeedFreezes(1)
+
+	res, err := r.SettleStreak(ctx, dal.TestChatID, testRules, testToday)
+	if err != nil {
+		t.Fatalf("SettleStreak: %v", err)
+	}
+	if res.Frozen {
+		t.Error("a freeze was spent with no streak to protect")
+	}
+}
+
+func TestSettleStreakAwardsFreeze(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+	for i := 1; i <= 3; i++ {
+		r.SeedDay(daysAgo(i), 10, 0)
+	}
+
+	res, err := r.SettleStreak(ctx, dal.TestChatID, testRules, testToday)
+	if err != nil {
+		t.Fatalf("SettleStreak: %v", err)
+	}
+	if !res.Awarded {
+		t.Fatal("a 3-day streak did not earn a freeze")
+	}
+
+	// Settling the same day again must not award it twice.
+	res, err = r.SettleStreak(ctx, dal.TestChatID, testRules, testToday)
+	if err != nil {
+		t.Fatalf("SettleStreak: %v", err)
+	}
+	if res.Awarded {
+		t.Error("the same day earned a second freeze")
+	}
+
+	got, err := r.GetStreak(ctx, dal.TestChatID, testGoal, testToday)
+	if err != nil {
+		t.Fatalf("GetStreak: %v", err)
+	}
+	if got.Freezes != 1 {
+		t.Errorf("Freezes = %d, want 1", got.Freezes)
+	}
+}
+
+func TestSettleStreakRespectsMaxFreezes(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+	r.SeedFreezes(testRules.MaxFreezes)
+	for i := 1; i <= 3; i++ {
+		r.SeedDay(daysAgo(i), 10, 0)
+	}
+
+	res, err := r.SettleStreak(ctx, dal.TestChatID, testRules, testToday)
+	if err != nil {
+		t.Fatalf("SettleStreak: %v", err)
+	}
+	if res.Awarded {
+		t.Error("a freeze was awarded above MaxFreezes")
+	}
+}
+
+func TestSettleStreakCoversPausedDay(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+	r.SeedFreezes(1)
+	r.SeedDay(daysAgo(2), 10, 0)
+	r.SeedDay(daysAgo(3), 10, 0)
+	// Paused the evening before yesterday, still paused: yesterday had nothing.
+	if _, err := r.PauseChat(ctx, dal.TestChatID, daysAgo(2).Add(8*time.Hour), testToday.AddDate(0, 0, 5)); err != nil {
+		t.Fatalf("PauseChat: %v", err)
+	}
+
+	res, err := r.SettleStreak(ctx, dal.TestChatID, testRules, testToday)
+	if err != nil {
+		t.Fatalf("SettleStreak: %v", err)
+	}
+	if !res.Paused || res.Frozen {
+		t.Fatalf("settlement = %+v, want the day covered by the pause", res)
+	}
+
+	got, err := r.GetStreak(ctx, dal.TestChatID, testGoal, testToday)
+	if err != nil {
+		t.Fatalf("GetStreak: %v", err)
+	}
+	if got.Days != 3 {
+		t.Errorf("Days = %d, want 3: two met days plus the paused one", got.Days)
+	}
+	if got.Freezes != 1 {
+		t.Errorf("Freezes = %d, want 1: a pause does not spend a freeze", got.Freezes)
+	}
+}
@@ -0,0 +1,104 @@
+package dal
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// A suspended word is out of rotation until it is unsuspended, a buried one until a given time, such
+// as the end of the day for "skip for today". Neither is admitted to the learning batch, nor drawn by
+// FindRandomWordTranslation for a check, a drill or a review. Suspending also takes the word out of
+// the batch, the queue behind it and the drill, since it is not coming back any time soon; a buried
+// word keeps its place and is simply passed over until then.
+
+// SetWordSuspension replaces both of a word's states. A zero buriedUntil unburies it.
+func (r *SQLiteRepository) SetWordSuspension(
+	ctx context.Context, chatID int64, word string, suspended bool, buriedUntil time.Time,
+) error {
+	return r.inTx(ctx, func(e execer) error {
+		set := map[string]any{"suspended": suspended, "buried_until": buriedAt(buriedUntil)}
+		if err := updateSuspension(ctx, e, chatID, word, set); err != nil {
+			return err
+		}
+		if suspended {
+			return takeOutOfRotation(ctx, e, chatID, word)
+		}
+		return nil
+	})
+}
+
+// BuryWord keeps a word from being asked until until, leaving its suspension alone.
+func (r *SQLiteRepository) BuryWord(ctx context.Context, chatID int64, word string, until time.Time) error {
+	return updateSuspension(ctx, r.db, chatID, word, map[string]any{"buried_until": buriedAt(until)})
+}
+
+// buriedAt stores until in UTC in the format datetime('now') compares with, or NULL for the zero
+// time.
+func buriedAt(until time.Time) any {
+	if until.IsZero() {
+		return nil
+	}
+	return squirrel.Expr("datetime(?, 'unixepoch')", until.Unix())
+}
+
+func updateSuspension(ctx context.Context, e execer, chatID int64, word string, set map[string]any) error {
+	sqlQuery, args, err := qb.Update("word_translations").
+		SetMap(set).
+		Where(squirrel.Eq{"chat_id": chatID, "word": word}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build update query: %w", err)
+	}
+
+	res, err := e.ExecContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return fmt.Errorf("update suspension: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected: %w", err)
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// inRotation is the condition a word of the word_translations row aliased alias has to meet to be
+// asked: neither suspended nor buried.
+func inRotation(alias string) string {
+	return fmt.Sprintf("%[1]s.suspended = 0 AND (%[1]s.buried_until IS NULL OR %[1]s.buried_until <= datetime('now'))", alias)
+}
+
+func wordSuspended(ctx context.Context, e execer, chatID int64, word string) (bool, error) {
+	sqlQuery, args, err := qb.Select("COUNT(*)").
+		From("word_translations").
+		Where(squirrel.Eq{"chat_id": chatID, "word": word, "suspended": true}).
+		ToSql()
+	if err != nil {
+		return false, fmt.Errorf("build select query: %w", err)
+	}
+
+	var count int
+	if err = e.QueryRowContext(ctx, sqlQuery, args...).Scan(&count); err != nil {
+		return false, fmt.Errorf("check suspension: %w", err)
+	}
+	return count > 0, nil
+}
+
+// takeOutOfRotation removes the word from the batch, the queue behind it and the drill.
+func takeOutOfRotation(ctx context.Context, e execer, chatID int64, word string) error {
+	for _, table := range []string{"learning_batches", "learning_batch_queue"} {
+		sqlQuery, args, err := qb.Delete(table).Where(squirrel.Eq{"chat_id": chatID, "word": word}).ToSql()
+		if err != nil {
+			return fmt.Errorf("build delete query: %w", err)
+		}
+		if _, err = e.ExecContext(ctx, sqlQuery, args...); err != nil {
+			return fmt.Errorf("delete from %s: %w", table, err)
+		}
+	}
+	return deleteDrillWord(ctx, e, chatID, word)
+}
@@ -0,0 +1,124 @@
+package dal_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+)
+
+func TestSetWordSuspensionTakesWordOutOfRotation(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+	r.SetDefaultBatchSize(1)
+	r.AddWord("batched", 0)
+	r.AddWord("queued", 0)
+	r.SeedBatch("batched")
+	r.SeedQueue("queued")
+
+	for _, w := range []string{"batched", "queued"} {
+		if err := r.SetWordSuspension(ctx, dal.TestChatID, w, true, time.Time{}); err != nil {
+			t.Fatalf("SetWordSuspension(%q): %v", w, err)
+		}
+	}
+	if r.IsBatched("batched") || r.IsQueued("queued") {
+		t.Fatal("suspended words are still batched or queued")
+	}
+
+	// Neither a miss nor a refill brings a suspended word back.
+	if _, err := r.Miss(dal.TestChatID, "batched"); err != nil {
+		t.Fatalf("Miss: %v", err)
+	}
+	if _, err := r.RefillLearningBatch(ctx, dal.TestChatID); err != nil {
+		t.Fatalf("RefillLearningBatch: %v", err)
+	}
+	if got := r.BatchWords(); len(got) != 0 {
+		t.Errorf("batch = %v, want it empty", got)
+	}
+	assertDrill(t, r, "batched", nil)
+
+	got, total, err := r.FindWordTranslations(ctx, dal.TestChatID, dal.WordTranslationsFilter{
+		Guessed: dal.GuessedSuspended,
+		Limit:   10,
+	})
+	if err != nil {
+		t.Fatalf("FindWordTranslations: %v", err)
+	}
+	if total != 2 || len(got) != 2 {
+		t.Errorf("got %d of %d suspended words, want 2", len(got), total)
+	}
+}
+
+func TestBuriedWordIsPassedOverUntilDue(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+	r.AddWord("buried", 0)
+	r.SeedBatch("buried")
+
+	until := time.Now().Add(time.Hour)
+	if err := r.BuryWord(ctx, dal.TestChatID, "buried", until); err != nil {
+		t.Fatalf("BuryWord: %v", err)
+	}
+
+	wt, err := r.FindWordTranslation(ctx, dal.TestChatID, "buried")
+	if err != nil {
+		t.Fatalf("FindWordTranslation: %v", err)
+	}
+	if !wt.BuriedUntil.Equal(until.Truncate(time.Second)) || wt.Suspended {
+		t.Errorf("BuriedUntil = %v, Suspended = %v, want %v and false", wt.BuriedUntil, wt.Suspended, until)
+	}
+	// A buried word keeps its place in the batch, it is only not asked.
+	if !r.IsBatched("buried") {
+		t.Error("buried word left the batch")
+	}
+	if _, err = r.FindRandomWordTranslation(ctx, dal.TestChatID, dal.FindRandomWordFilter{Batched: true}); !errors.Is(err, dal.ErrNotFound) {
+		t.Errorf("batched pick = %v, want ErrNotFound", err)
+	}
+
+	if err = r.BuryWord(ctx, dal.TestChatID, "buried", time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("BuryWord: %v", err)
+	}
+	wt, err = r.FindRandomWordTranslation(ctx, dal.TestChatID, dal.FindRandomWordFilter{Batched: true})
+	if err != nil {
+		t.Fatalf("FindRandomWordTranslation: %v", err)
+	}
+	if wt.Word != "buried" {
+		t.Errorf("picked %q, want buried", wt.Word)
+	}
+}
+
+func TestBuriedWordStaysQueued(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+	r.AddWord("buried", 0)
+	r.AddWord("next", 0)
+	r.SeedQueue("buried", "next")
+
+	if err := r.BuryWord(ctx, dal.TestChatID, "buried", time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("BuryWord: %v", err)
+	}
+	if _, err := r.RefillLearningBatch(ctx, dal.TestChatID); err != nil {
+		t.Fatalf("RefillLearningBatch: %v", err)
+	}
+	if got := r.BatchWords(); !slices.Equal(got, []string{"next"}) {
+		t.Errorf("batch = %v, want [next]", got)
+	}
+	if !r.IsQueued("buried") {
+		t.Error("buried word lost its place in the queue")
+	}
+}
+
+func TestSuspensionOfMissingWord(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+
+	if err := r.SetWordSuspension(ctx, dal.TestChatID, "missing", true, time.Time{}); !errors.Is(err, dal.ErrNotFound) {
+		t.Errorf("SetWordSuspension = %v, want ErrNotFound", err)
+	}
+	if err := r.BuryWord(ctx, dal.TestChatID, "missing", time.Now()); !errors.Is(err, dal.ErrNotFound) {
+		t.Errorf("BuryWord = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,96 @@
+package dal
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// SetWordTags replaces the tags of a word. Tags are trimmed and lowercased, so "Travel" and
+// "travel " are the same tag, and empty ones are dropped. A tag cannot contain a comma: tags are
+// read back as one comma-separated column.
+func (r *SQLiteRepository) SetWordTags(ctx context.Context, chatID int64, word string, tags []string) error {
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = normalizeTag(tag)
+		if strings.Contains(tag, ",") {
+			return fmt.Errorf("tag %q must not contain a comma", tag)
+		}
+		if tag != "" {
+			normalized = append(normalized, tag)
+		}
+	}
+
+	return r.inTx(ctx, func(e execer) error {
+		sqlQuery, args, err := qb.Select("COUNT(*)").
+			From("word_translations").
+			Where(squirrel.Eq{"chat_id": chatID, "word": word}).
+			ToSql()
+		if err != nil {
+			return fmt.Errorf("build select query: %w", err)
+		}
+		var count int
+		if err = e.QueryRowContext(ctx, sqlQuery, args...).Scan(&count); err != nil {
+			return fmt.Errorf("find word: %w", err)
+		}
+		if count == 0 {
+			return ErrNotFound
+		}
+
+		if err = deleteWordTags(ctx, e, chatID, word); err != nil {
+			return err
+		}
+		if len(normalized) == 0 {
+			return nil
+		}
+
+		query := qb.Insert("word_tags").Columns("chat_id", "word", "tag")
+		for _, tag := range normalized {
+			query = query.Values(chatID, word, tag)
+		}
+		if sqlQuery, args, err = query.Suffix("ON CONFLICT (chat_id, word, tag) DO NOTHING").ToSql(); err != nil {
+			return fmt.Errorf("build insert query: %w", err)
+		}
+		if _, err = e.ExecContext(ctx, sqlQuery, args...); err != nil {
+			return fmt.Errorf("insert tags: %w", err)
+		}
+		return nil
+	})
+}
+
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+func deleteWordTags(ctx context.Context, e execer, chatID int64, word string) error {
+	sqlQuery, args, err := qb.Delete("word_tags").Where(squirrel.Eq{"chat_id": chatID, "word": word}).ToSql()
+	if err != nil {
+		return fmt.Errorf("build delete query: %w", err)
+	}
+	if _, err = e.ExecContext(ctx, sqlQuery, args...); err != nil {
+		return fmt.Errorf("delete tags: %w", err)
+	}
+	return nil
+}
+
+// renameWordTags moves a word's tags along with a rename. Foreign keys are not enforced on the
+// connection, so the ON UPDATE CASCADE in the schema never fires.
+func renameWordTags(ctx context.Context, e execer, chatID int64, word, updatedWord string) error {
+	if word == updatedWord {
+		return nil
+	}
+
+	sqlQuery, args, err := qb.Update("word_tags").
+		Set("word", updatedWord).
+		Where(squirrel.Eq{"chat_id": chatID, "word": word}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build update query: %w", err)
+	}
+	if _, err = e.ExecContext(ctx, sqlQuery, args...); err != nil {
+		return fmt.Errorf("rename tags: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package dal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// SetTimezone changes the IANA timezone, such as "Europe/Kyiv", the chat's digests are sent in. An
+// empty name goes back to BOT_SCHEDULE_LOCATION.
+func (r *SQLiteRepository) SetTimezone(ctx context.Context, chatID int64, name string) error {
+	if name != "" {
+		if _, err := LoadTimezone(name); err != nil {
+			return err
+		}
+	}
+
+	sqlQuery, args, err := qb.Insert("chat_settings").
+		Columns("chat_id", "timezone").
+		Values(chatID, name).
+		Suffix("ON CONFLICT (chat_id) DO UPDATE SET timezone = EXCLUDED.timezone").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build query: %w", err)
+	}
+	if _, err = r.db.ExecContext(ctx, sqlQuery, args...); err != nil {
+		return fmt.Errorf("set timezone: %w", err)
+	}
+	return nil
+}
+
+// GetTimezone returns the chat's timezone, or an empty string if it has not set one.
+func (r *SQLiteRepository) GetTimezone(ctx context.Context, chatID int64) (string, error) {
+	sqlQuery, args, err := qb.Select("timezone").
+		From("chat_settings").
+		Where(squirrel.Eq{"chat_id": chatID}).
+		ToSql()
+	if err != nil {
+		return "", fmt.Errorf("build query: %w", err)
+	}
+
+	var name string
+	err = r.db.QueryRowContext(ctx, sqlQuery, args...).Scan(&name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("get timezone: %w", err)
+	}
+	return name, nil
+}
+
+// GetTimezones returns the timezone of every chat in chatIDs that has set one.
+func (r *SQLiteRepository) GetTimezones(ctx context.Context, chatIDs []int64) (map[int64]string, error) {
+	sqlQuery, args, err := qb.Select("chat_id", "timezone").
+		From("chat_settings").
+		Where(squirrel.Eq{"chat_id": chatIDs}).
+		Where(squirrel.NotEq{"timezone": ""}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build query: %w", err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("get timezones: %w", err)
+	}
+	defer rows.Close()
+
+	res := make(map[int64]string, len(chatIDs))
+	for rows.Next() {
+		var (
+			chatID int64
+			name   string
+		)
+		if err = rows.Scan(&chatID, &name); err != nil {
+			return nil, fmt.Errorf("scan timezone: %w", err)
+		}
+		res[chatID] = name
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate timezones: %w", err)
+	}
+	return res, nil
+}
+
+// LoadTimezone loads an IANA timezone by name. Unlike time.LoadLocation it refuses "Local", which is
+// whatever the server happens to run in.
+func LoadTimezone(name string) (*time.Location, error) {
+	if name == "" || name == "Local" {
+		return nil, fmt.Errorf("unknown timezone: %q", name)
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown timezone: %q", name)
+	}
+	return loc, nil
+}
@@ -0,0 +1,51 @@
+package dal_test
+
+import (
+	"context"
+	"maps"
+	"testing"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+)
+
+func TestSetTimezone(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+	const otherChatID = dal.TestChatID + 1
+
+	got, err := r.GetTimezone(ctx, dal.TestChatID)
+	if err != nil {
+		t.Fatalf("GetTimezone: %v", err)
+	}
+	if got != "" {
+		t.Errorf("timezone of a new chat = %q, want none", got)
+	}
+
+	for _, name := range []string{"Mars/Olympus", "Local"} {
+		if err = r.SetTimezone(ctx, dal.TestChatID, name); err == nil {
+			t.Errorf("SetTimezone accepted %q", name)
+		}
+	}
+
+	if err = r.SetTimezone(ctx, dal.TestChatID, "America/New_York"); err != nil {
+		t.Fatalf("SetTimezone: %v", err)
+	}
+	if err = r.SetTimezone(ctx, otherChatID, "Asia/Tokyo"); err != nil {
+		t.Fatalf("SetTimezone: %v", err)
+	}
+	if got, err = r.GetTimezone(ctx, dal.TestChatID); err != nil || got != "America/New_York" {
+		t.Errorf("GetTimezone = %q, %v, want America/New_York", got, err)
+	}
+
+	// Resetting one leaves it out of the batch lookup.
+	if err = r.SetTimezone(ctx, otherChatID, ""); err != nil {
+		t.Fatalf("SetTimezone: %v", err)
+	}
+	all, err := r.GetTimezones(ctx, []int64{dal.TestChatID, otherChatID})
+	if err != nil {
+		t.Fatalf("GetTimezones: %v", err)
+	}
+	if want := map[int64]string{dal.TestChatID: "America/New_York"}; !maps.Equal(all, want) {
+		t.Errorf("GetTimezones = %v, want %v", all, want)
+	}
+}
@@ -0,0 +1,294 @@
+package dal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// RestoreWordTranslation brings a deleted word back as it was when it was deleted, back into the
+// batch if it was batched or queued. It reports ErrNotFound for a word that is not in the trash, and
+// ErrAlreadyExists when a word of the same name has been added since.
+//
+// The trash row is marked as restoring while the word is inserted, which keeps the insert trigger from
+// logging it as created; the restore is logged here instead.
+func (r *SQLiteRepository) RestoreWordTranslation(ctx context.Context, chatID int64, word string) error {
+	return r.inTx(ctx, func(e execer) error {
+		var (
+			tags    string
+			batched bool
+		)
+		sqlQuery, args, err := qb.Select("tags", "batched").
+			From("word_trash").
+			Where(squirrel.Eq{"chat_id": chatID, "word": word}).
+			ToSql()
+		if err != nil {
+			return fmt.Errorf("build select query: %w", err)
+		}
+		if err = e.QueryRowContext(ctx, sqlQuery, args...).Scan(&tags, &batched); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrNotFound
+			}
+			return fmt.Errorf("find trashed word: %w", err)
+		}
+
+		if sqlQuery, args, err = qb.Update("word_trash").
+			Set("restoring", true).
+			Where(squirrel.Eq{"chat_id": chatID, "word": word}).
+			ToSql(); err != nil {
+			return fmt.Errorf("build update query: %w", err)
+		}
+		if _, err = e.ExecContext(ctx, sqlQuery, args...); err != nil {
+			return fmt.Errorf("mark restoring: %w", err)
+		}
+
+		// The WHERE clause is what lets SQLite tell the upsert clause apart from a join constraint.
+		if sqlQuery, args, err = qb.Insert("word_translations").
+			Columns(
+				"chat_id", "word", "translation", "description", "guessed_streak", "to_review", "lapses", "suspended",
+				"streak_limit", "learned_at", "review_interval_seconds", "next_review_at", "created_at",
+			).
+			Select(squirrel.Select(
+				"chat_id", "word", "translation", "description", "guessed_streak", "to_review", "lapses", "suspended",
+				"streak_limit", "learned_at", "review_interval_seconds", "next_review_at", "created_at",
+			).
+				From("word_trash").
+				Where("chat_id = ? AND word = ?", chatID, word)).
+			Suffix("ON CONFLICT (chat_id, word) DO NOTHING").
+			ToSql(); err != nil {
+			return fmt.Errorf("build insert query: %w", err)
+		}
+		res, err := e.ExecContext(ctx, sqlQuery, args...)
+		if err != nil {
+			return fmt.Errorf("restore word: %w", err)
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("get rows affected: %w", err)
+		}
+		if affected == 0 {
+			return ErrAlreadyExists
+		}
+		if sqlQuery, args, err = qb.Insert("word_audit").
+			Columns("chat_id", "word", "action", "translation", "description").
+			Select(squirrel.Select("chat_id", "word").
+				Column("?", AuditRestore).
+				Columns("translation", "description").
+				From("word_trash").
+				Where(squirrel.Eq{"chat_id": chatID, "word": word})).
+			ToSql(); err != nil {
+			return fmt.Errorf("build insert query: %w", err)
+		}
+		if _, err = e.ExecContext(ctx, sqlQuery, args...); err != nil {
+			return fmt.Errorf("log restore: %w", err)
+		}
+
+		if tags != "" {
+			query := qb.Insert("word_tags").Columns("chat_id", "word", "tag")
+			for _, tag := range strings.Split(tags, ",") {
+				query = query.Values(chatID, word, tag)
+			}
+			if sqlQuery, args, err = query.Suffix("ON CONFLICT (chat_id, word, tag) DO NOTHING").ToSql(); err != nil {
+				return fmt.Errorf("build insert query: %w", err)
+			}
+			if _, err = e.ExecContext(ctx, sqlQuery, args...); err != nil {
+				return fmt.Errorf("restore tags: %w", err)
+			}
+		}
+		if batched {
+			if err = requestBatchMembership(ctx, e, chatID, word, r.batchSize); err != nil {
+				return fmt.Errorf("request batch membership: %w", err)
+			}
+		}
+
+		if sqlQuery, args, err = qb.Delete("word_trash").Where(squirrel.Eq{"chat_id": chatID, "word": word}).ToSql(); err != nil {
+			return fmt.Errorf("build delete query: %w", err)
+		}
+		if _, err = e.ExecContext(ctx, sqlQuery, args...); err != nil {
+			return fmt.Errorf("empty trash: %w", err)
+		}
+		if err = updateTotalWordsLearned(ctx, e, chatID, r.streakLimit); err != nil {
+			return fmt.Errorf("update total words learned: %w", err)
+		}
+		return nil
+	})
+}
+
+// discardRevivedTrash drops the trashed versions, among those matching where, of words that exist
+// again: a word created anew under a deleted one's name replaces it for good, rather than leaving a
+// stale copy to restore over it.
+func discardRevivedTrash(ctx context.Context, e execer, where squirrel.Sqlizer) error {
+	revived := squirrel.Select("1").
+		From("word_translations wt").
+		Where("wt.chat_id = word_trash.chat_id AND wt.word = word_trash.word")
+
+	sqlQuery, args, err := qb.Delete("word_trash").Where(where).Where(squirrel.Expr("EXISTS (?)", revived)).ToSql()
+	if err != nil {
+		return fmt.Errorf("build delete query: %w", err)
+	}
+	if _, err = e.ExecContext(ctx, sqlQuery, args...); err != nil {
+		return fmt.Errorf("discard revived trash: %w", err)
+	}
+	return nil
+}
+
+// GetTrash lists the chat's deleted words, most recently deleted first.
+func (r *SQLiteRepository) GetTrash(ctx context.Context, chatID int64) ([]TrashedWord, error) {
+	sqlQuery, args, err := qb.Select(
+		"word", "translation", "COALESCE(description, '')", "guessed_streak", "tags", "created_at", "deleted_at",
+	).
+		From("word_trash").
+		Where(squirrel.Eq{"chat_id": chatID}).
+		OrderBy("deleted_at DESC", "word").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build select query: %w", err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("get trash: %w", err)
+	}
+	defer rows.Close()
+
+	res := make([]TrashedWord, 0)
+	for rows.Next() {
+		var (
+			w    TrashedWord
+			tags string
+		)
+		if err = rows.Scan(&w.Word, &w.Translation, &w.Description, &w.GuessedStreak, &tags, &w.CreatedAt, &w.DeletedAt); err != nil {
+			return nil, fmt.Errorf("scan trashed word: %w", err)
+		}
+		if tags != "" {
+			w.Tags = strings.Split(tags, ",")
+		}
+		res = append(res, w)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate trash: %w", err)
+	}
+	return res, nil
+}
+
+// PurgeTrash drops, across every chat, the words deleted before deletedBefore, logging each one as
+// purged. It returns how many it dropped.
+func (r *SQLiteRepository) PurgeTrash(ctx context.Context, deletedBefore time.Time) (int, error) {
+	var purged int
+	err := r.inTx(ctx, func(e execer) error {
+		expired := squirrel.Expr("deleted_at < datetime(?, 'unixepoch')", deletedBefore.Unix())
+
+		sqlQuery, args, err := qb.Insert("word_audit").
+			Columns("chat_id", "word", "action", "old_word", "old_translation", "old_description").
+			Select(squirrel.Select("chat_id", "word").
+				Column("?", AuditPurge).
+				Columns("word", "translation", "description").
+				From("word_trash").
+				Where(expired)).
+			ToSql()
+		if err != nil {
+			return fmt.Errorf("build insert query: %w", err)
+		}
+		if _, err = e.ExecContext(ctx, sqlQuery, args...); err != nil {
+			return fmt.Errorf("log purge: %w", err)
+		}
+
+		if sqlQuery, args, err = qb.Delete("word_trash").Where(expired).ToSql(); err != nil {
+			return fmt.Errorf("build delete query: %w", err)
+		}
+		res, err := e.ExecContext(ctx, sqlQuery, args...)
+		if err != nil {
+			return fmt.Errorf("purge trash: %w", err)
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("get rows affected: %w", err)
+		}
+		purged = int(affected)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return purged, nil
+}
+
+// GetWordAudit returns up to limit of the chat's audit entries, newest first: those about word, under
+// its current name or an old one, or all of them when word is empty.
+func (r *SQLiteRepository) GetWordAudit(ctx context.Context, chatID int64, word string, limit int) ([]WordAuditEntry, error) {
+	query := qb.Select(
+		"id", "word", "action", "old_word", "old_translation", "old_description", "translation", "description", "changed_at",
+	).
+		From("word_audit").
+		Where(squirrel.Eq{"chat_id": chatID}).
+		OrderBy("id DESC").
+		Limit(uint64(limit)) //nolint:gosec // limit is validated by the caller
+	if word != "" {
+		query = query.Where(squirrel.Or{squirrel.Eq{"word": word}, squirrel.Eq{"old_word": word}})
+	}
+
+	sqlQuery, args, err := query.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build select query: %w", err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("get word audit: %w", err)
+	}
+	defer rows.Close()
+
+	res := make([]WordAuditEntry, 0, limit)
+	for rows.Next() {
+		var (
+			a                                       WordAuditEntry
+			oldWord, oldTranslation, oldDescription sql.NullString
+			translation, description                sql.NullString
+		)
+		if err = rows.Scan(
+			&a.ID, &a.Word, &a.Action, &oldWord, &oldTranslation, &oldDescription, &translation, &description, &a.ChangedAt,
+		); err != nil {
+			return nil, fmt.Errorf("scan audit entry: %w", err)
+		}
+		a.OldWord, a.OldTranslation, a.OldDescription = oldWord.String, oldTranslation.String, oldDescription.String
+		a.Translation, a.Description = translation.String, description.String
+		res = append(res, a)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate word audit: %w", err)
+	}
+	return res, nil
+}
+
+// trashWord keeps the word about to be deleted in word_trash, in place of any earlier version of it.
+func trashWord(ctx context.Context, e execer, chatID int64, word string) error {
+	sqlQuery, args, err := qb.Replace("word_trash").
+		Columns(
+			"chat_id", "word", "translation", "description", "guessed_streak", "to_review", "lapses", "suspended",
+			"streak_limit", "learned_at", "review_interval_seconds", "next_review_at", "tags", "batched", "created_at",
+		).
+		Select(squirrel.Select(
+			"wt.chat_id", "wt.word", "wt.translation", "wt.description", "wt.guessed_streak", "wt.to_review", "wt.lapses",
+			"wt.suspended", "wt.streak_limit", "wt.learned_at", "wt.review_interval_seconds", "wt.next_review_at",
+		).
+			Column("COALESCE((SELECT group_concat(tg.tag, ',' ORDER BY tg.tag) FROM word_tags tg "+
+				"WHERE tg.chat_id = wt.chat_id AND tg.word = wt.word), '')").
+			Column("EXISTS (SELECT 1 FROM learning_batches lb WHERE lb.chat_id = wt.chat_id AND lb.word = wt.word) OR "+
+				"EXISTS (SELECT 1 FROM learning_batch_queue lbq WHERE lbq.chat_id = wt.chat_id AND lbq.word = wt.word)").
+			Column("wt.created_at").
+			From("word_translations wt").
+			Where("wt.chat_id = ? AND wt.word = ?", chatID, word)).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build insert query: %w", err)
+	}
+	if _, err = e.ExecContext(ctx, sqlQuery, args...); err != nil {
+		return fmt.Errorf("move to trash: %w", err)
+	}
+	return nil
+}
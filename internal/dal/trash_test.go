@@ -0,0 +1,201 @@
+package dal_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+)
+
+func TestDeleteAndRestoreWord(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+	r.AddWord("apple", 3)
+	r.SetLapses("apple", 2)
+	r.SeedBatch("apple")
+	if err := r.SetWordTags(ctx, dal.TestChatID, "apple", []string{"fruit", "food"}); err != nil {
+		t.Fatalf("SetWordTags: %v", err)
+	}
+
+	if err := r.DeleteWordTranslation(ctx, dal.TestChatID, "apple"); err != nil {
+		t.Fatalf("DeleteWordTranslation: %v", err)
+	}
+	if _, err := r.FindWordTranslation(ctx, dal.TestChatID, "apple"); !errors.Is(err, dal.ErrNotFound) {
+		t.Fatalf("FindWordTranslation after delete = %v, want ErrNotFound", err)
+	}
+	if r.IsBatched("apple") {
+		t.Error("the deleted word stayed in the batch")
+	}
+
+	trash, err := r.GetTrash(ctx, dal.TestChatID)
+	if err != nil {
+		t.Fatalf("GetTrash: %v", err)
+	}
+	if len(trash) != 1 || trash[0].Word != "apple" || trash[0].GuessedStreak != 3 || !slices.Equal(trash[0].Tags, []string{"food", "fruit"}) {
+		t.Fatalf("trash = %+v, want apple with its streak and tags", trash)
+	}
+
+	if err = r.RestoreWordTranslation(ctx, dal.TestChatID, "apple"); err != nil {
+		t.Fatalf("RestoreWordTranslation: %v", err)
+	}
+	wt, err := r.FindWordTranslation(ctx, dal.TestChatID, "apple")
+	if err != nil {
+		t.Fatalf("FindWordTranslation after restore: %v", err)
+	}
+	if wt.GuessedStreak != 3 || wt.Lapses != 2 || !slices.Equal(wt.Tags, []string{"food", "fruit"}) {
+		t.Errorf("restored streak %d, lapses %d, tags %v; want the word as it was", wt.GuessedStreak, wt.Lapses, wt.Tags)
+	}
+	if !r.IsBatched("apple") {
+		t.Error("the restored word did not go back into the batch")
+	}
+	if trash, err = r.GetTrash(ctx, dal.TestChatID); err != nil || len(trash) != 0 {
+		t.Errorf("trash after restore = %+v (%v), want it empty", trash, err)
+	}
+
+	if err = r.RestoreWordTranslation(ctx, dal.TestChatID, "apple"); !errors.Is(err, dal.ErrNotFound) {
+		t.Errorf("second restore = %v, want ErrNotFound", err)
+	}
+}
+
+func TestCreateWordDropsTrashedWord(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+	r.AddWord("apple", 3)
+
+	if err := r.DeleteWordTranslation(ctx, dal.TestChatID, "apple"); err != nil {
+		t.Fatalf("DeleteWordTranslation: %v", err)
+	}
+	if err := r.CreateWordTranslation(ctx, dal.TestChatID, "apple", "яблуко", ""); err != nil {
+		t.Fatalf("CreateWordTranslation: %v", err)
+	}
+
+	if trash, err := r.GetTrash(ctx, dal.TestChatID); err != nil || len(trash) != 0 {
+		t.Errorf("trash = %+v (%v), want the deleted word dropped", trash, err)
+	}
+	if err := r.RestoreWordTranslation(ctx, dal.TestChatID, "apple"); !errors.Is(err, dal.ErrNotFound) {
+		t.Errorf("RestoreWordTranslation = %v, want ErrNotFound", err)
+	}
+	entries, err := r.GetWordAudit(ctx, dal.TestChatID, "apple", 1)
+	if err != nil {
+		t.Fatalf("GetWordAudit: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Action != dal.AuditCreate {
+		t.Errorf("latest entry = %+v, want the word logged as created", entries)
+	}
+}
+
+func TestRestoreWordKeepsNewerWord(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+	r.AddWord("apple", 3)
+	r.AddWord("pear", 0)
+
+	if err := r.DeleteWordTranslation(ctx, dal.TestChatID, "apple"); err != nil {
+		t.Fatalf("DeleteWordTranslation: %v", err)
+	}
+	if err := r.UpdateWordTranslation(ctx, dal.TestChatID, "pear", "apple", "яблуко", ""); err != nil {
+		t.Fatalf("UpdateWordTranslation: %v", err)
+	}
+
+	if err := r.RestoreWordTranslation(ctx, dal.TestChatID, "apple"); !errors.Is(err, dal.ErrAlreadyExists) {
+		t.Fatalf("RestoreWordTranslation = %v, want ErrAlreadyExists", err)
+	}
+	if r.StreakOf("apple") != 0 {
+		t.Error("the restore overwrote the newer word")
+	}
+	if trash, err := r.GetTrash(ctx, dal.TestChatID); err != nil || len(trash) != 1 {
+		t.Errorf("trash = %+v (%v), want the deleted word kept", trash, err)
+	}
+}
+
+func TestPurgeTrash(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+	r.AddWord("apple", 0)
+	r.AddWord("pear", 0)
+	for _, word := range []string{"apple", "pear"} {
+		if err := r.DeleteWordTranslation(ctx, dal.TestChatID, word); err != nil {
+			t.Fatalf("DeleteWordTranslation(%s): %v", word, err)
+		}
+	}
+	r.AgeTrash("apple", 31*day)
+
+	purged, err := r.PurgeTrash(ctx, time.Now().Add(-30*day))
+	if err != nil {
+		t.Fatalf("PurgeTrash: %v", err)
+	}
+	if purged != 1 {
+		t.Errorf("purged = %d, want 1", purged)
+	}
+	trash, err := r.GetTrash(ctx, dal.TestChatID)
+	if err != nil {
+		t.Fatalf("GetTrash: %v", err)
+	}
+	if len(trash) != 1 || trash[0].Word != "pear" {
+		t.Errorf("trash = %+v, want only pear", trash)
+	}
+	if err = r.RestoreWordTranslation(ctx, dal.TestChatID, "apple"); !errors.Is(err, dal.ErrNotFound) {
+		t.Errorf("restoring a purged word = %v, want ErrNotFound", err)
+	}
+}
+
+func TestGetWordAudit(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+
+	if err := r.CreateWordTranslation(ctx, dal.TestChatID, "aple", "яблуко", ""); err != nil {
+		t.Fatalf("CreateWordTranslation: %v", err)
+	}
+	if err := r.UpdateWordTranslation(ctx, dal.TestChatID, "aple", "apple", "яблуко", "a fruit"); err != nil {
+		t.Fatalf("UpdateWordTranslation: %v", err)
+	}
+	// Only progress changes: not logged.
+	if err := r.Guess(dal.TestChatID, "apple"); err != nil {
+		t.Fatalf("Guess: %v", err)
+	}
+	if err := r.DeleteWordTranslation(ctx, dal.TestChatID, "apple"); err != nil {
+		t.Fatalf("DeleteWordTranslation: %v", err)
+	}
+	if err := r.RestoreWordTranslation(ctx, dal.TestChatID, "apple"); err != nil {
+		t.Fatalf("RestoreWordTranslation: %v", err)
+	}
+	r.AddWord("pear", 0)
+
+	entries, err := r.GetWordAudit(ctx, dal.TestChatID, "aple", 10)
+	if err != nil {
+		t.Fatalf("GetWordAudit: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("entries = %+v, want the create and the rename", entries)
+	}
+	rename, create := entries[0], entries[1]
+	if create.Action != dal.AuditCreate || create.Word != "aple" || create.Translation != "яблуко" {
+		t.Errorf("create = %+v", create)
+	}
+	if rename.Action != dal.AuditUpdate || rename.OldWord != "aple" || rename.Word != "apple" ||
+		rename.OldDescription != "" || rename.Description != "a fruit" {
+		t.Errorf("rename = %+v", rename)
+	}
+
+	entries, err = r.GetWordAudit(ctx, dal.TestChatID, "apple", 10)
+	if err != nil {
+		t.Fatalf("GetWordAudit: %v", err)
+	}
+	var actions []dal.AuditAction
+	for _, e := range entries {
+		actions = append(actions, e.Action)
+	}
+	if want := []dal.AuditAction{dal.AuditRestore, dal.AuditDelete, dal.AuditUpdate}; !slices.Equal(actions, want) {
+		t.Errorf("actions = %v, want %v", actions, want)
+	}
+	if entries[1].OldDescription != "a fruit" {
+		t.Errorf("delete = %+v, want the old values kept", entries[1])
+	}
+
+	if entries, err = r.GetWordAudit(ctx, dal.TestChatID, "", 2); err != nil || len(entries) != 2 || entries[0].Word != "pear" {
+		t.Errorf("GetWordAudit of every word = %+v (%v), want the 2 latest, pear first", entries, err)
+	}
+}
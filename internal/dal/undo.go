@@ -0,0 +1,273 @@
+package dal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// UndoLastAnswer takes back the chat's last answer, if it was given at or after since and, unless
+// callbackID is empty, on that callback. It reports ErrNotFound when there is no such answer, or its
+// word has been deleted or renamed since.
+//
+// Before an answer changes anything, recordAnswer keeps the state it is about to change in
+// answer_undo: the word's progress, its leech tag, its place in the batch or the queue and its drill.
+// UndoLastAnswer puts all of that back and takes the answer out of answer_log and the day's counters.
+// Only the chat's last answer is kept, so only that one can be undone, and only once. An answer given
+// on a word check opens the check again, so that it can be graded anew.
+func (r *SQLiteRepository) UndoLastAnswer(ctx context.Context, chatID int64, callbackID string, since time.Time) (*UndoneAnswer, error) {
+	var res *UndoneAnswer
+	err := r.inTx(ctx, func(e execer) error {
+		u, err := findAnswerUndo(ctx, e, chatID, callbackID, since)
+		if err != nil {
+			return err
+		}
+		if err = forgetAnswer(ctx, e, chatID); err != nil {
+			return err
+		}
+
+		if err = restoreWord(ctx, e, chatID, u); err != nil {
+			return err
+		}
+		if !u.leech {
+			sqlQuery, args, err := qb.Delete("word_tags").Where(squirrel.Eq{"chat_id": chatID, "word": u.word, "tag": LeechTag}).ToSql()
+			if err != nil {
+				return fmt.Errorf("build delete query: %w", err)
+			}
+			if _, err = e.ExecContext(ctx, sqlQuery, args...); err != nil {
+				return fmt.Errorf("untag leech: %w", err)
+			}
+		}
+		if err = restoreRotation(ctx, e, chatID, u); err != nil {
+			return err
+		}
+		if err = unlogAnswer(ctx, e, chatID, u); err != nil {
+			return err
+		}
+		if u.callbackID.Valid {
+			if err = reopenCallback(ctx, e, chatID, u.callbackID.String); err != nil {
+				return err
+			}
+		}
+		if err = updateTotalWordsLearned(ctx, e, chatID, r.streakLimit); err != nil {
+			return fmt.Errorf("update total words learned: %w", err)
+		}
+
+		res = &UndoneAnswer{Word: u.word, Guessed: u.guessed, Streak: u.streak}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// answerUndo is a row of answer_undo.
+type answerUndo struct {
+	word      string
+	guessed   bool
+	statsDate string
+
+	streak, lapses int
+	suspended      bool
+	learnedAt      sql.NullTime
+	interval       int64
+	nextReviewAt   sql.NullTime
+
+	leech, batched bool
+	queuedSeq      sql.NullInt64
+
+	drillStep, drillGuessed sql.NullInt64
+	drillDueAt              sql.NullTime
+
+	callbackID sql.NullString
+}
+
+// recordAnswer keeps the state of word that an answer is about to change, in place of the chat's last
+// answer, and the callback the answer was given on, if any. It has to run before anything else the
+// answer does.
+func recordAnswer(ctx context.Context, e execer, chatID int64, word string, guessed bool, callbackID string) error {
+	if err := forgetAnswer(ctx, e, chatID); err != nil {
+		return err
+	}
+
+	query := qb.Insert("answer_undo").
+		Columns(
+			"chat_id", "word", "guessed", "stats_date",
+			"guessed_streak", "lapses", "suspended", "learned_at", "review_interval_seconds", "next_review_at",
+			"leech", "batched", "queued_seq", "drill_step", "drill_guessed", "drill_due_at", "callback_id",
+		).
+		Select(squirrel.Select("wt.chat_id", "wt.word").
+			Column("?", guessed).
+			Column("date('now', 'localtime')").
+			Columns("wt.guessed_streak", "wt.lapses", "wt.suspended", "wt.learned_at", "wt.review_interval_seconds", "wt.next_review_at").
+			Column("EXISTS (SELECT 1 FROM word_tags t WHERE t.chat_id = wt.chat_id AND t.word = wt.word AND t.tag = ?)", LeechTag).
+			Column("EXISTS (SELECT 1 FROM learning_batches lb WHERE lb.chat_id = wt.chat_id AND lb.word = wt.word)").
+			Column("(SELECT lbq.queued_seq FROM learning_batch_queue lbq WHERE lbq.chat_id = wt.chat_id AND lbq.word = wt.word)").
+			Columns("dw.step", "dw.guessed", "dw.due_at").
+			Column("NULLIF(?, '')", callbackID).
+			From("word_translations wt").
+			LeftJoin("drill_words dw ON dw.chat_id = wt.chat_id AND dw.word = wt.word").
+			Where("wt.chat_id = ? AND wt.word = ?", chatID, word))
+
+	sqlQuery, args, err := query.ToSql()
+	if err != nil {
+		return fmt.Errorf("build insert query: %w", err)
+	}
+	if _, err = e.ExecContext(ctx, sqlQuery, args...); err != nil {
+		return fmt.Errorf("record answer: %w", err)
+	}
+	return nil
+}
+
+// forgetAnswer drops the chat's last answer, so that it cannot be undone any more.
+func forgetAnswer(ctx context.Context, e execer, chatID int64) error {
+	sqlQuery, args, err := qb.Delete("answer_undo").Where(squirrel.Eq{"chat_id": chatID}).ToSql()
+	if err != nil {
+		return fmt.Errorf("build delete query: %w", err)
+	}
+	if _, err = e.ExecContext(ctx, sqlQuery, args...); err != nil {
+		return fmt.Errorf("forget answer: %w", err)
+	}
+	return nil
+}
+
+func findAnswerUndo(ctx context.Context, e execer, chatID int64, callbackID string, since time.Time) (*answerUndo, error) {
+	query := qb.Select(
+		"word", "guessed", "stats_date",
+		"guessed_streak", "lapses", "suspended", "learned_at", "review_interval_seconds", "next_review_at",
+		"leech", "batched", "queued_seq", "drill_step", "drill_guessed", "drill_due_at", "callback_id",
+	).
+		From("answer_undo").
+		Where(squirrel.Eq{"chat_id": chatID}).
+		Where("answered_at >= datetime(?, 'unixepoch')", since.Unix())
+	if callbackID != "" {
+		query = query.Where(squirrel.Eq{"callback_id": callbackID})
+	}
+
+	sqlQuery, args, err := query.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build select query: %w", err)
+	}
+
+	var u answerUndo
+	err = e.QueryRowContext(ctx, sqlQuery, args...).Scan(
+		&u.word, &u.guessed, &u.statsDate,
+		&u.streak, &u.lapses, &u.suspended, &u.learnedAt, &u.interval, &u.nextReviewAt,
+		&u.leech, &u.batched, &u.queuedSeq, &u.drillStep, &u.drillGuessed, &u.drillDueAt, &u.callbackID,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("find last answer: %w", err)
+	}
+	return &u, nil
+}
+
+// nullUnix is t as datetime(?, 'unixepoch') takes it, or NULL.
+func nullUnix(t sql.NullTime) any {
+	if !t.Valid {
+		return nil
+	}
+	return t.Time.Unix()
+}
+
+func restoreWord(ctx context.Context, e execer, chatID int64, u *answerUndo) error {
+	sqlQuery, args, err := qb.Update("word_translations").
+		Set("guessed_streak", u.streak).
+		Set("lapses", u.lapses).
+		Set("suspended", u.suspended).
+		Set("learned_at", squirrel.Expr("datetime(?, 'unixepoch')", nullUnix(u.learnedAt))).
+		Set("review_interval_seconds", u.interval).
+		Set("next_review_at", squirrel.Expr("datetime(?, 'unixepoch')", nullUnix(u.nextReviewAt))).
+		Where(squirrel.Eq{"chat_id": chatID, "word": u.word}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build update query: %w", err)
+	}
+	res, err := e.ExecContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return fmt.Errorf("restore word: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected: %w", err)
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// restoreRotation puts the word back where it was: in the batch, in its old place in the queue, or in
+// neither, and in the drill as it stood.
+func restoreRotation(ctx context.Context, e execer, chatID int64, u *answerUndo) error {
+	if err := takeOutOfRotation(ctx, e, chatID, u.word); err != nil {
+		return fmt.Errorf("take out of rotation: %w", err)
+	}
+
+	if u.batched {
+		if err := addToLearningBatch(ctx, e, chatID, u.word); err != nil {
+			return fmt.Errorf("add to learning batch: %w", err)
+		}
+	}
+	if u.queuedSeq.Valid {
+		sqlQuery, args, err := qb.Insert("learning_batch_queue").
+			Columns("chat_id", "word", "queued_seq").
+			Values(chatID, u.word, u.queuedSeq.Int64).
+			Suffix("ON CONFLICT DO NOTHING").
+			ToSql()
+		if err != nil {
+			return fmt.Errorf("build insert query: %w", err)
+		}
+		if _, err = e.ExecContext(ctx, sqlQuery, args...); err != nil {
+			return fmt.Errorf("requeue: %w", err)
+		}
+	}
+	if u.drillDueAt.Valid {
+		sqlQuery, args, err := qb.Insert("drill_words").
+			Columns("chat_id", "word", "step", "guessed", "due_at").
+			Values(chatID, u.word, u.drillStep.Int64, u.drillGuessed.Int64, squirrel.Expr("datetime(?, 'unixepoch')", u.drillDueAt.Time.Unix())).
+			ToSql()
+		if err != nil {
+			return fmt.Errorf("build insert query: %w", err)
+		}
+		if _, err = e.ExecContext(ctx, sqlQuery, args...); err != nil {
+			return fmt.Errorf("restore drill: %w", err)
+		}
+	}
+	return nil
+}
+
+// unlogAnswer takes the answer out of answer_log and the counters of the day it was given on.
+func unlogAnswer(ctx context.Context, e execer, chatID int64, u *answerUndo) error {
+	sqlQuery, args, err := qb.Delete("answer_log").
+		Where("id = (SELECT MAX(id) FROM answer_log WHERE chat_id = ? AND word = ?)", chatID, u.word).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build delete query: %w", err)
+	}
+	if _, err = e.ExecContext(ctx, sqlQuery, args...); err != nil {
+		return fmt.Errorf("delete from answer log: %w", err)
+	}
+
+	counter := "words_missed"
+	if u.guessed {
+		counter = "words_guessed"
+	}
+	if sqlQuery, args, err = qb.Update("statistics").
+		Set(counter, squirrel.Expr("MAX(0, "+counter+" - 1)")).
+		Where(squirrel.Eq{"chat_id": chatID, "date": u.statsDate}).
+		ToSql(); err != nil {
+		return fmt.Errorf("build update query: %w", err)
+	}
+	if _, err = e.ExecContext(ctx, sqlQuery, args...); err != nil {
+		return fmt.Errorf("uncount answer: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package dal_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+)
+
+func TestUndoLastAnswerRestoresMiss(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+	r.SetLeechRules(dal.LeechRules{Threshold: 1})
+	r.AddWord("apple", dal.TestStreakLimit)
+	r.ScheduleReview("apple", 30*day, 6*day, -day)
+	since := time.Now().Add(-time.Minute)
+
+	if _, err := r.Miss(dal.TestChatID, "apple"); err != nil {
+		t.Fatalf("Miss: %v", err)
+	}
+	if r.StreakOf("apple") != 0 || !r.IsBatched("apple") {
+		t.Fatal("the miss did not reset the word and put it into the batch")
+	}
+
+	undone, err := r.UndoLastAnswer(ctx, dal.TestChatID, "", since)
+	if err != nil {
+		t.Fatalf("UndoLastAnswer: %v", err)
+	}
+	if *undone != (dal.UndoneAnswer{Word: "apple", Guessed: false, Streak: dal.TestStreakLimit}) {
+		t.Errorf("undone = %+v", *undone)
+	}
+
+	wt, err := r.FindWordTranslation(ctx, dal.TestChatID, "apple")
+	if err != nil {
+		t.Fatalf("FindWordTranslation: %v", err)
+	}
+	if wt.GuessedStreak != dal.TestStreakLimit || wt.Lapses != 0 || len(wt.Tags) != 0 {
+		t.Errorf("streak %d, lapses %d, tags %v; want the word as it was", wt.GuessedStreak, wt.Lapses, wt.Tags)
+	}
+	if r.IsBatched("apple") || r.IsQueued("apple") {
+		t.Error("the word stayed in the batch")
+	}
+	if _, _, _, ok := r.DrillOf("apple"); ok {
+		t.Error("the word stayed in the drill")
+	}
+	if interval, dueIn, ok := r.ReviewOf("apple"); !ok || interval != 6*day || dueIn != -day {
+		t.Errorf("review = %v due in %v (scheduled %t), want the old schedule back", interval, dueIn, ok)
+	}
+	if guessed, missed, learned := r.TodayStats(); guessed != 0 || missed != 0 || learned != 1 {
+		t.Errorf("today = %d guessed, %d missed, %d learned; want 0, 0, 1", guessed, missed, learned)
+	}
+	today := time.Now()
+	summary, err := r.GetAnswerSummary(ctx, dal.TestChatID, today, today, 5)
+	if err != nil {
+		t.Fatalf("GetAnswerSummary: %v", err)
+	}
+	if len(summary.Hardest) != 0 {
+		t.Errorf("hardest = %+v, want the miss gone from the log", summary.Hardest)
+	}
+
+	// An answer is only undone once.
+	if _, err = r.UndoLastAnswer(ctx, dal.TestChatID, "", since); !errors.Is(err, dal.ErrNotFound) {
+		t.Errorf("second undo = %v, want ErrNotFound", err)
+	}
+}
+
+func TestUndoLastAnswerOnlyTheLast(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+	r.AddWord("first", 3)
+	r.AddWord("second", 5)
+	r.SeedBatch("first", "second")
+	since := time.Now().Add(-time.Minute)
+
+	var firstID string
+	for _, word := range []string{"first", "second"} {
+		id, err := r.InsertCallback(ctx, dal.CallbackData{ChatID: dal.TestChatID, Word: word, ExpiresAt: time.Now().Add(time.Hour)})
+		if err != nil {
+			t.Fatalf("InsertCallback(%q): %v", word, err)
+		}
+		if _, err = r.GradeCallback(ctx, dal.TestChatID, id, true); err != nil {
+			t.Fatalf("GradeCallback(%q): %v", word, err)
+		}
+		if firstID == "" {
+			firstID = id
+		}
+	}
+
+	if _, err := r.UndoLastAnswer(ctx, dal.TestChatID, firstID, since); !errors.Is(err, dal.ErrNotFound) {
+		t.Errorf("undo of an earlier answer = %v, want ErrNotFound", err)
+	}
+	if _, err := r.UndoLastAnswer(ctx, dal.TestChatID, "", time.Now().Add(time.Minute)); !errors.Is(err, dal.ErrNotFound) {
+		t.Errorf("undo outside the window = %v, want ErrNotFound", err)
+	}
+
+	undone, err := r.UndoLastAnswer(ctx, dal.TestChatID, "", since)
+	if err != nil {
+		t.Fatalf("UndoLastAnswer: %v", err)
+	}
+	if undone.Word != "second" || !undone.Guessed || undone.Streak != 5 {
+		t.Errorf("undone = %+v, want the guess on second", *undone)
+	}
+	if r.StreakOf("first") != 4 || r.StreakOf("second") != 5 || !r.IsBatched("second") {
+		t.Errorf("streaks = %d and %d, want 4 and 5", r.StreakOf("first"), r.StreakOf("second"))
+	}
+	if guessed, _, _ := r.TodayStats(); guessed != 1 {
+		t.Errorf("today's guesses = %d, want 1", guessed)
+	}
+}
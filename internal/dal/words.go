@@ -14,7 +14,8 @@ import (
 // CreateWordTranslation stores a word that is not supposed to exist yet, reporting ErrAlreadyExists
 // instead of overwriting one that does, and requests batch membership for it - a brand-new word wants
 // practicing exactly like a missed or deliberately reset one, so it goes through the same admission
-// gate rather than waiting for the next hourly refill's random pick.
+// gate rather than waiting for the next hourly refill's random pick. It also counts towards today's
+// new-words goal.
 //
 // The check is the insert itself rather than a preceding lookup: two concurrent creates would both
 // find nothing and the second would silently discard the first, along with its learning progress.
@@ -44,9 +45,15 @@ func (r *SQLiteRepository) CreateWordTranslation(ctx context.Context, chatID int
 			return ErrAlreadyExists
 		}
 
+		if err := discardRevivedTrash(ctx, e, squirrel.Eq{"chat_id": chatID, "word": word}); err != nil {
+			return fmt.Errorf("discard trashed word: %w", err)
+		}
 		if err := requestBatchMembership(ctx, e, chatID, word, r.batchSize); err != nil {
 			return fmt.Errorf("request batch membership: %w", err)
 		}
+		if err := incrementWordsAdded(ctx, e, chatID); err != nil {
+			return fmt.Errorf("increment words added: %w", err)
+		}
 		return nil
 	})
 }
@@ -66,6 +73,9 @@ func upsertWordTranslation(ctx context.Context, e execer, chatID int64, word, tr
 	if err != nil {
 		return fmt.Errorf("add translation: %w", err)
 	}
+	if err = discardRevivedTrash(ctx, e, squirrel.Eq{"chat_id": chatID, "word": word}); err != nil {
+		return fmt.Errorf("discard trashed word: %w", err)
+	}
 	return nil
 }
 
@@ -94,11 +104,16 @@ func (r *SQLiteRepository) FindWordTranslations(ctx context.Context, chatID int6
 	switch filter.Guessed {
 	case "", GuessedAll:
 	case GuessedLearned:
-		baseQuery = baseQuery.Where(squirrel.Expr("wt.guessed_streak >= ?", r.streakLimit))
+		baseQuery = baseQuery.Where("wt.guessed_streak >= " + streakLimitOf("wt", r.streakLimit))
 	case GuessedBatched:
 		baseQuery = baseQuery.Where("EXISTS (SELECT 1 FROM learning_batches lb WHERE lb.chat_id = wt.chat_id AND lb.word = wt.word)")
 	case GuessedToLearn:
 		baseQuery = baseQuery.Where("wt.guessed_streak = 0")
+	case GuessedSuspended:
+		baseQuery = baseQuery.Where("wt.suspended = 1")
+	case GuessedLeech:
+		baseQuery = baseQuery.Where(
+			"EXISTS (SELECT 1 FROM word_tags lt WHERE lt.chat_id = wt.chat_id AND lt.word = wt.word AND lt.tag = ?)", LeechTag)
 	}
 
 	selectQuery2 := baseQuery.
@@ -161,20 +176,42 @@ func (r *SQLiteRepository) FindWordTranslations(ctx context.Context, chatID int6
 	return res, total, nil
 }
 
+// DeleteWordTranslation moves the word to the trash, from which RestoreWordTranslation can bring it
+// back until PurgeTrash drops it; see trash.go.
 func (r *SQLiteRepository) DeleteWordTranslation(ctx context.Context, chatID int64, word string) error {
-	query := qb.Delete("word_translations").
-		Where(squirrel.Eq{"chat_id": chatID, "word": word})
+	return r.inTx(ctx, func(e execer) error {
+		if err := trashWord(ctx, e, chatID, word); err != nil {
+			return err
+		}
 
-	sql, args, err := query.ToSql()
-	if err != nil {
-		return fmt.Errorf("build delete query: %w", err)
-	}
+		query := qb.Delete("word_translations").
+			Where(squirrel.Eq{"chat_id": chatID, "word": word})
 
-	_, err = r.db.ExecContext(ctx, sql, args...)
-	if err != nil {
-		return fmt.Errorf("delete translation: %w", err)
-	}
-	return nil
+		sql, args, err := query.ToSql()
+		if err != nil {
+			return fmt.Errorf("build delete query: %w", err)
+		}
+
+		_, err = e.ExecContext(ctx, sql, args...)
+		if err != nil {
+			return fmt.Errorf("delete translation: %w", err)
+		}
+
+		if err = deleteWordTags(ctx, e, chatID, word); err != nil {
+			return err
+		}
+		// Foreign keys are not enforced, so nothing cascades to the batch and the queue either.
+		if err = takeOutOfRotation(ctx, e, chatID, word); err != nil {
+			return err
+		}
+		if err = removeFromDecks(ctx, e, chatID, word); err != nil {
+			return fmt.Errorf("remove from decks: %w", err)
+		}
+		if err = updateTotalWordsLearned(ctx, e, chatID, r.streakLimit); err != nil {
+			return fmt.Errorf("update total words learned: %w", err)
+		}
+		return nil
+	})
 }
 
 func addToLearningBatch(ctx context.Context, e execer, chatID int64, word string) error {
@@ -242,14 +279,25 @@ func enqueueForLearningBatch(ctx context.Context, e execer, chatID int64, word s
 }
 
 // requestBatchMembership is the single admission gate every producer of "this word should be
-// practiced" goes through: RegisterMiss, ResetStreak, ResolveWordConflict's reset_and_batch, and
+// practiced" goes through: a miss, ResetStreak, ResolveWordConflict's reset_and_batch, and
 // CreateWordTranslation for brand-new words.
 //
 // It admits immediately if there is room (today's "instant" UX for a miss or a deliberate reset), or
 // appends to the FIFO queue otherwise so the word is delayed, never lost. It is idempotent - a word
 // already in the batch or already queued is left exactly where it is, so two producers racing on the
 // same word (or the same producer firing twice) never duplicates a row or reorders the queue.
-func requestBatchMembership(ctx context.Context, e execer, chatID int64, word string, batchSize int) error {
+//
+// A suspended word is never admitted, nor queued: it is out of rotation until it is unsuspended.
+// defaultSize is the batch size of chats that have not set their own.
+func requestBatchMembership(ctx context.Context, e execer, chatID int64, word string, defaultSize int) error {
+	suspended, err := wordSuspended(ctx, e, chatID, word)
+	if err != nil {
+		return fmt.Errorf("check suspension: %w", err)
+	}
+	if suspended {
+		return nil
+	}
+
 	awaiting, err := wordInBatchOrQueue(ctx, e, chatID, word)
 	if err != nil {
 		return fmt.Errorf("check batch admission state: %w", err)
@@ -262,6 +310,10 @@ func requestBatchMembership(ctx context.Context, e execer, chatID int64, word st
 	if err != nil {
 		return fmt.Errorf("get batched word translations count: %w", err)
 	}
+	batchSize, _, err := batchSizeOf(ctx, e, chatID, defaultSize)
+	if err != nil {
+		return fmt.Errorf("get batch size: %w", err)
+	}
 
 	if batched < batchSize {
 		if err := addToLearningBatch(ctx, e, chatID, word); err != nil {
@@ -282,7 +334,8 @@ func requestBatchMembership(ctx context.Context, e execer, chatID int64, word st
 //
 // The guessed_streak filter is defensive: nothing should ever queue a word above guessedStreakLimit,
 // since every producer resets the streak to 0 before requesting membership, but a stale row must not
-// be promoted if that invariant is ever violated.
+// be promoted if that invariant is ever violated. Suspended and buried words are passed over and keep
+// their place in the queue.
 //
 // The delete is safe precisely because of the batch/queue mutual-exclusivity invariant: within this
 // transaction, the only rows that can be in both tables at this point are the ones the insert above
@@ -293,7 +346,8 @@ func drainLearningBatchQueue(ctx context.Context, e execer, chatID int64, guesse
 		Select(squirrel.Select("lbq.chat_id", "lbq.word").
 			From("learning_batch_queue lbq").
 			Join("word_translations wt ON wt.chat_id = lbq.chat_id AND wt.word = lbq.word").
-			Where("lbq.chat_id = ? AND wt.guessed_streak < ?", chatID, guessedStreakLimit).
+			Where("lbq.chat_id = ? AND wt.guessed_streak < "+streakLimitOf("wt", guessedStreakLimit), chatID).
+			Where(inRotation("wt")).
 			OrderBy("lbq.queued_seq ASC").
 			Limit(uint64(limit))). //nolint:gosec // limit is room, itself bounded by batchSize
 		Suffix("ON CONFLICT DO NOTHING")
@@ -342,9 +396,14 @@ func increaseGuessedStreak(ctx context.Context, e execer, chatID int64, word str
 	return nil
 }
 
+// resetGuessedStreak also drops the word's review schedule: it is not learned any more, and is
+// scheduled afresh once it is learned again.
 func resetGuessedStreak(ctx context.Context, e execer, chatID int64, word string) error {
 	query := qb.Update("word_translations").
 		Set("guessed_streak", 0).
+		Set("learned_at", nil).
+		Set("review_interval_seconds", 0).
+		Set("next_review_at", nil).
 		Where(squirrel.Eq{"chat_id": chatID, "word": word})
 
 	sql, args, err := query.ToSql()
@@ -376,23 +435,37 @@ func (r *SQLiteRepository) MarkToReview(ctx context.Context, chatID int64, word
 	return nil
 }
 
+// UpdateWordTranslation edits a word and hands the edit on to the subscribers of any deck holding
+// it; see propagateDeckWordEdit.
 func (r *SQLiteRepository) UpdateWordTranslation(ctx context.Context, chatID int64, word, updatedWord, updatedTranslation, description string) error {
-	query := qb.Update("word_translations").
-		Set("word", updatedWord).
-		Set("translation", updatedTranslation).
-		Set("description", description).
-		Where(squirrel.Eq{"chat_id": chatID, "word": word})
+	return r.inTx(ctx, func(e execer) error {
+		query := qb.Update("word_translations").
+			Set("word", updatedWord).
+			Set("translation", updatedTranslation).
+			Set("description", description).
+			Where(squirrel.Eq{"chat_id": chatID, "word": word})
 
-	sql, args, err := query.ToSql()
-	if err != nil {
-		return fmt.Errorf("build update query: %w", err)
-	}
+		sql, args, err := query.ToSql()
+		if err != nil {
+			return fmt.Errorf("build update query: %w", err)
+		}
 
-	_, err = r.db.ExecContext(ctx, sql, args...)
-	if err != nil {
-		return fmt.Errorf("update translation: %w", err)
-	}
-	return nil
+		_, err = e.ExecContext(ctx, sql, args...)
+		if err != nil {
+			return fmt.Errorf("update translation: %w", err)
+		}
+
+		if err = renameWordTags(ctx, e, chatID, word, updatedWord); err != nil {
+			return err
+		}
+		if err = renameDrillWord(ctx, e, chatID, word, updatedWord); err != nil {
+			return err
+		}
+		if err = propagateDeckWordEdit(ctx, e, chatID, word, updatedWord); err != nil {
+			return fmt.Errorf("propagate to deck subscribers: %w", err)
+		}
+		return nil
+	})
 }
 
 func batchedWordTranslationsCount(ctx context.Context, e execer, chatID int64) (int, error) {
@@ -456,14 +529,32 @@ func (r *SQLiteRepository) FindWordTranslation(ctx context.Context, chatID int64
 func (r *SQLiteRepository) FindRandomWordTranslation(ctx context.Context, chatID int64, filter FindRandomWordFilter) (*WordTranslation, error) {
 	var query2 squirrel.SelectBuilder
 
-	if filter.Batched {
+	switch {
+	case filter.Drill:
+		query2 = qb.Select(wordTranslationColumns()...).
+			From("word_translations wt").
+			Join("drill_words dw ON wt.chat_id = dw.chat_id AND wt.word = dw.word").
+			Where(squirrel.Eq{"wt.chat_id": chatID}).
+			Where(inRotation("wt")).
+			Where("dw.due_at <= datetime('now')").
+			OrderBy("dw.due_at ASC").
+			Limit(1)
+	case filter.ToReview:
+		query2 = qb.Select(wordTranslationColumns()...).
+			From("word_translations wt").
+			Where(squirrel.Eq{"wt.chat_id": chatID, "wt.to_review": true}).
+			Where(inRotation("wt")).
+			OrderBy("wt.last_reviewed_seq ASC").
+			Limit(1)
+	case filter.Batched:
 		query2 = qb.Select(wordTranslationColumns()...).
 			From("word_translations wt").
 			Join("learning_batches lb ON wt.chat_id = lb.chat_id AND wt.word = lb.word").
 			Where(squirrel.Eq{"wt.chat_id": chatID}).
+			Where(inRotation("wt")).
 			OrderBy("random()").
 			Limit(1)
-	} else {
+	default:
 		// NULL sorts first in SQLite's ASC, so words that have never been reviewed come out ahead
 		// of any that have.
 		orderBy := "random()"
@@ -474,10 +565,18 @@ func (r *SQLiteRepository) FindRandomWordTranslation(ctx context.Context, chatID
 		query2 = qb.Select(wordTranslationColumns()...).
 			From("word_translations wt").
 			Where(squirrel.Eq{"wt.chat_id": chatID}).
-			Where(squirrel.Expr("wt.guessed_streak "+filter.StreakLimitDirection.String()+" ?", filter.StreakLimit)).
+			Where(inRotation("wt")).
 			Where("wt.word NOT IN (SELECT word FROM learning_batches WHERE chat_id = ?)", chatID).
 			OrderBy(orderBy).
 			Limit(1)
+		if filter.Learned {
+			query2 = query2.Where("wt.guessed_streak >= " + streakLimitOf("wt", r.streakLimit))
+		} else {
+			query2 = query2.Where(squirrel.Expr("wt.guessed_streak "+filter.StreakLimitDirection.String()+" ?", filter.StreakLimit))
+		}
+		if filter.Due {
+			query2 = query2.Where(reviewDue("wt"))
+		}
 	}
 
 	var r2 squirrel.Sqlizer = query2
@@ -501,8 +600,8 @@ func (r *SQLiteRepository) FindRandomWordTranslation(ctx context.Context, chatID
 
 func deleteFromLearningBatchGeGuessedStreak(ctx context.Context, e execer, chatID int64, guessedStreakLimit int) (int, error) {
 	query := qb.Delete("learning_batches").
-		Where("chat_id = ? AND word IN (SELECT word FROM word_translations WHERE chat_id = ? AND guessed_streak >= ?)",
-			chatID, chatID, guessedStreakLimit)
+		Where("chat_id = ? AND word IN (SELECT word FROM word_translations wt WHERE wt.chat_id = ? AND wt.guessed_streak >= "+
+			streakLimitOf("wt", guessedStreakLimit)+")", chatID, chatID)
 
 	sql, args, err := query.ToSql()
 	if err != nil {
@@ -532,7 +631,8 @@ func wordTranslationColumns() []string {
 	return []string{
 		"wt.chat_id", "wt.word", "wt.translation",
 		"COALESCE(wt.description, '')", "wt.guessed_streak",
-		"wt.to_review", "wt.created_at", "wt.updated_at",
+		"wt.to_review", "wt.lapses", "wt.suspended", "wt.buried_until", "wt.next_review_at", streakLimitOf("wt", 0),
+		"wt.created_at", "wt.updated_at",
 		// Folds in the admission queue: requesting membership again is a no-op whether the word is
 		// sitting in the batch or waiting behind it, so the conflict-resolution "would this change
 		// anything?" question (see api.WordTranslation.InBatch) should get the same answer either
@@ -540,13 +640,20 @@ func wordTranslationColumns() []string {
 		// a queued word is not actively being asked about right now and should not show up there.
 		"EXISTS (SELECT 1 FROM learning_batches blb WHERE blb.chat_id = wt.chat_id AND blb.word = wt.word) OR " +
 			"EXISTS (SELECT 1 FROM learning_batch_queue blq WHERE blq.chat_id = wt.chat_id AND blq.word = wt.word)",
+		"COALESCE((SELECT group_concat(tg.tag, ',' ORDER BY tg.tag) FROM word_tags tg " +
+			"WHERE tg.chat_id = wt.chat_id AND tg.word = wt.word), '')",
 	}
 }
 
 func hydrateWordTranslation(row interface {
 	Scan(dest ...interface{}) error
 }) (*WordTranslation, error) {
-	var wt WordTranslation
+	var (
+		wt           WordTranslation
+		buriedUntil  sql.NullTime
+		nextReviewAt sql.NullTime
+		tags         string
+	)
 	err := row.Scan(
 		&wt.ChatID,
 		&wt.Word,
@@ -554,12 +661,23 @@ func hydrateWordTranslation(row interface {
 		&wt.Description,
 		&wt.GuessedStreak,
 		&wt.ToReview,
+		&wt.Lapses,
+		&wt.Suspended,
+		&buriedUntil,
+		&nextReviewAt,
+		&wt.StreakLimit,
 		&wt.CreatedAt,
 		&wt.UpdatedAt,
 		&wt.InBatch,
+		&tags,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("scan word translation: %w", err)
 	}
+	wt.BuriedUntil = buriedUntil.Time
+	wt.NextReviewAt = nextReviewAt.Time
+	if tags != "" {
+		wt.Tags = strings.Split(tags, ",")
+	}
 	return &wt, nil
 }
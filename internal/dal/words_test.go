@@ -204,8 +204,8 @@ func TestReviewRotationAfterDemotionAndRelearning(t *testing.T) {
 	}
 
 	// Answered wrong: streak resets and the word is demoted into the batch.
-	if err = r.RegisterMiss(ctx, dal.TestChatID, first.Word); err != nil {
-		t.Fatalf("RegisterMiss: %v", err)
+	if _, err = r.Miss(dal.TestChatID, first.Word); err != nil {
+		t.Fatalf("Miss: %v", err)
 	}
 	if got, err := r.FindRandomWordTranslation(ctx, dal.TestChatID, reviewFilter()); err != nil {
 		t.Fatalf("FindRandomWordTranslation: %v", err)
@@ -313,3 +313,44 @@ func TestFindWordTranslationsReportBatchMembership(t *testing.T) {
 		t.Error("unbatched word reported as batched")
 	}
 }
+
+// Words marked to review come up whether or not they are still being learned, longest waiting first.
+func TestFindRandomWordTranslationToReview(t *testing.T) {
+	ctx := context.Background()
+	r := dal.NewTestRepo(t)
+
+	r.AddWord("batched", 2)
+	r.AddWord("learned", 20)
+	r.AddWord("unmarked", 20)
+	r.SeedBatch("batched")
+	for _, word := range []string{"batched", "learned"} {
+		if err := r.MarkToReview(ctx, dal.TestChatID, word, true); err != nil {
+			t.Fatalf("MarkToReview: %v", err)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for range 2 {
+		wt, err := r.FindRandomWordTranslation(ctx, dal.TestChatID, dal.FindRandomWordFilter{ToReview: true})
+		if err != nil {
+			t.Fatalf("FindRandomWordTranslation: %v", err)
+		}
+		seen[wt.Word] = true
+		if err = r.MarkWordReviewed(ctx, dal.TestChatID, wt.Word); err != nil {
+			t.Fatalf("MarkWordReviewed: %v", err)
+		}
+	}
+	if !seen["batched"] || !seen["learned"] {
+		t.Errorf("picked %v, want both marked words", seen)
+	}
+
+	if err := r.MarkToReview(ctx, dal.TestChatID, "batched", false); err != nil {
+		t.Fatalf("MarkToReview: %v", err)
+	}
+	if err := r.MarkToReview(ctx, dal.TestChatID, "learned", false); err != nil {
+		t.Fatalf("MarkToReview: %v", err)
+	}
+	if _, err := r.FindRandomWordTranslation(ctx, dal.TestChatID, dal.FindRandomWordFilter{ToReview: true}); !errors.Is(err, dal.ErrNotFound) {
+		t.Errorf("error = %v, want ErrNotFound once every mark is cleared", err)
+	}
+}
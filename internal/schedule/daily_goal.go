@@ -0,0 +1,110 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"gopkg.in/telebot.v3"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+)
+
+type (
+	DailyGoalConfig struct {
+		ChatIDs []int64
+		Rules   dal.StreakRules
+		// ReminderHour is the hour, in Location, at which chats that have not met their goal yet are
+		// reminded.
+		ReminderHour int
+		Location     *time.Location
+	}
+
+	// DailyGoalRepository is what the daily goal schedule needs: the streaks to settle and the pauses
+	// that hold reminders back.
+	DailyGoalRepository interface {
+		dal.StatsRepository
+		dal.PauseRepository
+	}
+
+	GoalReminder interface {
+		SendGoalReminder(ctx context.Context, chatID int64) error
+	}
+)
+
+// StartDailyGoalSchedule wakes up at the top of every hour. Each run settles yesterday's streak for
+// every chat - spending or earning freezes - and, at ReminderHour, reminds the chats that have not met
+// today's goal yet, unless they are paused.
+//
+// Settling is idempotent per day, so running it hourly rather than once just after midnight means a
+// restart or a failed run is made up for an hour later instead of costing somebody their streak.
+func StartDailyGoalSchedule(ctx context.Context, conf DailyGoalConfig, repo DailyGoalRepository, r GoalReminder, log *slog.Logger) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.ErrorContext(ctx, "panic", "error", r)
+		}
+	}()
+
+	log.InfoContext(ctx, "daily goal schedule started")
+	defer log.InfoContext(ctx, "daily goal schedule stopped")
+	runIn := time.After(time.Second)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-runIn:
+			now := time.Now()
+			runIn = time.After(now.Truncate(time.Hour).Add(time.Hour).Sub(now))
+
+			log.DebugContext(ctx, "daily goal execution started")
+			for _, chatID := range conf.ChatIDs {
+				settleStreak(ctx, conf, repo, chatID, now, log)
+			}
+
+			if now.In(conf.Location).Hour() == conf.ReminderHour {
+				for _, chatID := range conf.ChatIDs {
+					remindDailyGoal(ctx, repo, r, chatID, now, log)
+				}
+			}
+			log.DebugContext(ctx, "daily goal execution finished")
+		}
+	}
+}
+
+func settleStreak(ctx context.Context, conf DailyGoalConfig, repo dal.StatsRepository, chatID int64, now time.Time, log *slog.Logger) {
+	ctx, cancel := context.WithTimeout(ctx, processTimeout)
+	defer cancel()
+
+	res, err := repo.SettleStreak(ctx, chatID, conf.Rules, now)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to settle streak", "error", err, "chat_id", chatID)
+		return
+	}
+	if res.Frozen || res.Awarded {
+		log.InfoContext(ctx, "streak settled", "chat_id", chatID, "frozen", res.Frozen, "awarded", res.Awarded)
+	}
+}
+
+func remindDailyGoal(ctx context.Context, repo dal.PauseRepository, r GoalReminder, chatID int64, now time.Time, log *slog.Logger) {
+	ctx, cancel := context.WithTimeout(ctx, publishTimeout)
+	defer cancel()
+
+	pause, err := repo.GetPause(ctx, chatID)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to get pause", "error", err, "chat_id", chatID)
+		return
+	}
+	if pause.Active(now) {
+		log.DebugContext(ctx, "goal reminder skipped, chat is paused", "chat_id", chatID)
+		return
+	}
+
+	if err := r.SendGoalReminder(ctx, chatID); err != nil {
+		if errors.Is(err, telebot.ErrBlockedByUser) {
+			log.InfoContext(ctx, "user blocked bot", "chat_id", chatID)
+			return
+		}
+		log.ErrorContext(ctx, "failed to send goal reminder", "error", err, "chat_id", chatID)
+	}
+}
@@ -0,0 +1,181 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"gopkg.in/telebot.v3"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/config"
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+	"github.com/Roma7-7-7/english-learning-bot/internal/telegram"
+)
+
+type (
+	DigestConfig struct {
+		ChatIDs []int64
+		// Weekly and Monthly are when, in each chat's timezone, each digest is sent. A nil one is not
+		// sent.
+		Weekly  *config.Cron
+		Monthly *config.Cron
+		// Location is the timezone of the chats that have not picked one.
+		Location *time.Location
+	}
+
+	DigestSender interface {
+		SendDigest(ctx context.Context, chatID int64, period telegram.DigestPeriod, now time.Time) error
+	}
+
+	ChatTimezones interface {
+		GetTimezones(ctx context.Context, chatIDs []int64) (map[int64]string, error)
+	}
+
+	// chatDigest is the digests due for one chat, with the timezone they are due in.
+	chatDigest struct {
+		chatID   int64
+		location *time.Location
+		periods  []telegram.DigestPeriod
+	}
+)
+
+// digestReplanInterval is the longest the digest schedule sleeps before looking at the chats'
+// timezones again, so that a changed one is not missed.
+const digestReplanInterval = time.Hour
+
+// StartDigestSchedule sleeps until the next fire of Weekly or Monthly in any chat's timezone and sends
+// the digests due then. Unlike the other schedules it does not run right after start: a restart just
+// after a digest went out would otherwise send it twice.
+func StartDigestSchedule(ctx context.Context, conf DigestConfig, tz ChatTimezones, s DigestSender, log *slog.Logger) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.ErrorContext(ctx, "panic", "error", r)
+		}
+	}()
+
+	log.InfoContext(ctx, "digest schedule started")
+	defer log.InfoContext(ctx, "digest schedule stopped")
+	for {
+		at, due := nextDigests(conf, chatLocations(ctx, conf, tz, log), time.Now())
+		if at.IsZero() {
+			log.InfoContext(ctx, "no digests to send")
+			return
+		}
+
+		wait := time.Until(at)
+		replan := wait > digestReplanInterval
+		if replan {
+			wait = digestReplanInterval
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+			if replan {
+				continue
+			}
+			log.DebugContext(ctx, "digest execution started", "chats", len(due))
+			for _, d := range due {
+				for _, period := range d.periods {
+					sendDigest(ctx, s, d.chatID, period, at.In(d.location), log)
+				}
+			}
+			log.DebugContext(ctx, "digest execution finished", "chats", len(due))
+		}
+	}
+}
+
+// chatLocations loads the timezones the chats have picked. Chats without one, or with one that no
+// longer loads, are left out and fall back to Location.
+func chatLocations(ctx context.Context, conf DigestConfig, tz ChatTimezones, log *slog.Logger) map[int64]*time.Location {
+	ctx, cancel := context.WithTimeout(ctx, publishTimeout)
+	defer cancel()
+
+	names, err := tz.GetTimezones(ctx, conf.ChatIDs)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to get chat timezones", "error", err)
+		return nil
+	}
+
+	res := make(map[int64]*time.Location, len(names))
+	for chatID, name := range names {
+		loc, err := dal.LoadTimezone(name)
+		if err != nil {
+			log.WarnContext(ctx, "failed to load chat timezone", "error", err, "chat_id", chatID)
+			continue
+		}
+		res[chatID] = loc
+	}
+	return res
+}
+
+// nextDigests returns when the next digest is due after now in any chat, and the digests of every chat
+// due at that time. Chats missing from locations are in Location. It returns the zero time when no
+// digest is scheduled.
+func nextDigests(conf DigestConfig, locations map[int64]*time.Location, now time.Time) (time.Time, []chatDigest) {
+	var (
+		at  time.Time
+		due []chatDigest
+	)
+	for _, chatID := range conf.ChatIDs {
+		loc, ok := locations[chatID]
+		if !ok {
+			loc = conf.Location
+		}
+
+		next, periods := nextDigest(conf, now.In(loc))
+		d := chatDigest{chatID: chatID, location: loc, periods: periods}
+		switch {
+		case next.IsZero():
+		case at.IsZero() || next.Before(at):
+			at, due = next, []chatDigest{d}
+		case next.Equal(at):
+			due = append(due, d)
+		}
+	}
+	return at, due
+}
+
+// nextDigest returns when the next digest is due after now, and every period due at that time. It
+// returns the zero time when no digest is scheduled.
+func nextDigest(conf DigestConfig, now time.Time) (time.Time, []telegram.DigestPeriod) {
+	var (
+		at      time.Time
+		periods []telegram.DigestPeriod
+	)
+	for _, d := range []struct {
+		schedule *config.Cron
+		period   telegram.DigestPeriod
+	}{
+		{schedule: conf.Weekly, period: telegram.DigestWeekly},
+		{schedule: conf.Monthly, period: telegram.DigestMonthly},
+	} {
+		if d.schedule == nil {
+			continue
+		}
+		next := d.schedule.Next(now)
+		switch {
+		case next.IsZero():
+		case at.IsZero() || next.Before(at):
+			at, periods = next, []telegram.DigestPeriod{d.period}
+		case next.Equal(at):
+			periods = append(periods, d.period)
+		}
+	}
+	return at, periods
+}
+
+func sendDigest(ctx context.Context, s DigestSender, chatID int64, period telegram.DigestPeriod, now time.Time, log *slog.Logger) {
+	ctx, cancel := context.WithTimeout(ctx, publishTimeout)
+	defer cancel()
+
+	if err := s.SendDigest(ctx, chatID, period, now); err != nil {
+		if errors.Is(err, telebot.ErrBlockedByUser) {
+			log.InfoContext(ctx, "user blocked bot", "chat_id", chatID)
+			return
+		}
+		log.ErrorContext(ctx, "failed to send digest", "error", err, "chat_id", chatID, "period", period)
+	}
+}
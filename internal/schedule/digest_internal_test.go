@@ -0,0 +1,100 @@
+package schedule
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/config"
+	"github.com/Roma7-7-7/english-learning-bot/internal/telegram"
+)
+
+func TestNextDigest(t *testing.T) {
+	weekly := config.MustParseCron("0 19 * * 0")
+	monthly := config.MustParseCron("0 19 L * *")
+	at := func(month time.Month, day, hour int) time.Time {
+		return time.Date(2026, month, day, hour, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name        string
+		conf        DigestConfig
+		now         time.Time
+		wantAt      time.Time
+		wantPeriods []telegram.DigestPeriod
+	}{
+		{
+			name:        "weekly first",
+			conf:        DigestConfig{Weekly: &weekly, Monthly: &monthly},
+			now:         at(time.May, 20, 12),
+			wantAt:      at(time.May, 24, 19),
+			wantPeriods: []telegram.DigestPeriod{telegram.DigestWeekly},
+		},
+		{
+			name:        "both on a Sunday that ends the month",
+			conf:        DigestConfig{Weekly: &weekly, Monthly: &monthly},
+			now:         at(time.May, 25, 12),
+			wantAt:      at(time.May, 31, 19),
+			wantPeriods: []telegram.DigestPeriod{telegram.DigestWeekly, telegram.DigestMonthly},
+		},
+		{
+			name:        "monthly only",
+			conf:        DigestConfig{Monthly: &monthly},
+			now:         at(time.May, 31, 19),
+			wantAt:      at(time.June, 30, 19),
+			wantPeriods: []telegram.DigestPeriod{telegram.DigestMonthly},
+		},
+		{
+			name: "none",
+			now:  at(time.May, 20, 12),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotAt, gotPeriods := nextDigest(tt.conf, tt.now)
+			if !gotAt.Equal(tt.wantAt) || !slices.Equal(gotPeriods, tt.wantPeriods) {
+				t.Errorf("nextDigest() = %s, %v, want %s, %v", gotAt, gotPeriods, tt.wantAt, tt.wantPeriods)
+			}
+		})
+	}
+}
+
+func TestNextDigests(t *testing.T) {
+	weekly := config.MustParseCron("0 19 * * 0")
+	kyiv, err := time.LoadLocation("Europe/Kyiv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := DigestConfig{ChatIDs: []int64{1, 2, 3}, Weekly: &weekly, Location: kyiv}
+	// Chat 2 is in Tokyo, chats 1 and 3 follow Location.
+	locations := map[int64]*time.Location{2: tokyo}
+
+	// Tokyo reaches Sunday 19:00 first.
+	now := time.Date(2026, time.May, 20, 12, 0, 0, 0, time.UTC)
+	at, due := nextDigests(conf, locations, now)
+	if want := time.Date(2026, time.May, 24, 19, 0, 0, 0, tokyo); !at.Equal(want) {
+		t.Errorf("at = %s, want %s", at, want)
+	}
+	if len(due) != 1 || due[0].chatID != 2 || due[0].location != tokyo {
+		t.Errorf("due = %+v, want chat 2 in Tokyo", due)
+	}
+
+	// Then both Kyiv chats at once.
+	at, due = nextDigests(conf, locations, at)
+	if want := time.Date(2026, time.May, 24, 19, 0, 0, 0, kyiv); !at.Equal(want) {
+		t.Errorf("at = %s, want %s", at, want)
+	}
+	if len(due) != 2 || due[0].chatID != 1 || due[1].chatID != 3 {
+		t.Errorf("due = %+v, want chats 1 and 3", due)
+	}
+	for _, d := range due {
+		if !slices.Equal(d.periods, []telegram.DigestPeriod{telegram.DigestWeekly}) {
+			t.Errorf("periods of chat %d = %v, want weekly", d.chatID, d.periods)
+		}
+	}
+}
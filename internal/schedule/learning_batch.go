@@ -5,6 +5,7 @@ import (
 	"log/slog"
 	"time"
 
+	"github.com/Roma7-7-7/english-learning-bot/internal/config"
 	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
 )
 
@@ -12,7 +13,16 @@ const (
 	processTimeout = 10 * time.Second
 )
 
-func StartUpdateBatchSchedule(ctx context.Context, chatIDs []int64, repo dal.Repository, log *slog.Logger) {
+type BatchRefillConfig struct {
+	ChatIDs []int64
+	// Schedule is when, in Location, the learning batches are topped up.
+	Schedule config.Cron
+	Location *time.Location
+}
+
+// StartUpdateBatchSchedule tops up every chat's learning batch right after start, then on every fire of
+// Schedule.
+func StartUpdateBatchSchedule(ctx context.Context, conf BatchRefillConfig, repo dal.Repository, log *slog.Logger) {
 	defer func() {
 		if r := recover(); r != nil {
 			log.ErrorContext(ctx, "panic", "error", r)
@@ -27,17 +37,19 @@ func StartUpdateBatchSchedule(ctx context.Context, chatIDs []int64, repo dal.Rep
 		case <-ctx.Done():
 			return
 		case <-runIn:
-			runIn = time.After(1 * time.Hour)
+			next := conf.Schedule.Next(time.Now().In(conf.Location))
+			runIn = time.After(time.Until(next))
 
-			log.DebugContext(ctx, "update learning batch execution started")
-			for _, chatID := range chatIDs {
+			log.DebugContext(ctx, "update learning batch execution started", "next_run", next)
+			for _, chatID := range conf.ChatIDs {
 				ctx, cancel := context.WithTimeout(ctx, processTimeout)
 
-				evicted, added, err := repo.RefillLearningBatch(ctx, chatID)
+				report, err := repo.RefillLearningBatch(ctx, chatID)
 				if err != nil {
 					log.ErrorContext(ctx, "failed to refill learning batch", "error", err, "chat_id", chatID)
 				} else {
-					log.DebugContext(ctx, "learning batch refilled", "chat_id", chatID, "evicted", evicted, "added", added)
+					log.DebugContext(ctx, "learning batch refilled", "chat_id", chatID,
+						"evicted", report.Evicted, "drained", report.Drained, "fills", report.Fills)
 				}
 				cancel()
 			}
@@ -0,0 +1,43 @@
+package schedule
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+)
+
+// trashPurgeInterval is how often the trash is checked for words kept past their retention.
+const trashPurgeInterval = time.Hour
+
+// StartTrashPurgeSchedule drops, right after start and then every hour, the deleted words of every
+// chat that were deleted more than retention ago.
+func StartTrashPurgeSchedule(ctx context.Context, retention time.Duration, repo dal.TrashRepository, log *slog.Logger) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.ErrorContext(ctx, "panic", "error", r)
+		}
+	}()
+
+	log.InfoContext(ctx, "trash purge schedule started")
+	defer log.InfoContext(ctx, "trash purge schedule stopped")
+	runIn := time.After(time.Second)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-runIn:
+			runIn = time.After(trashPurgeInterval)
+
+			ctx, cancel := context.WithTimeout(ctx, processTimeout)
+			purged, err := repo.PurgeTrash(ctx, time.Now().Add(-retention))
+			if err != nil {
+				log.ErrorContext(ctx, "failed to purge trash", "error", err)
+			} else if purged > 0 {
+				log.InfoContext(ctx, "trash purged", "words", purged)
+			}
+			cancel()
+		}
+	}
+}
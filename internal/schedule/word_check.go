@@ -4,66 +4,280 @@ import (
 	"context"
 	"errors"
 	"log/slog"
+	"slices"
 	"time"
 
 	"gopkg.in/telebot.v3"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/config"
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
 )
 
 const (
 	publishTimeout = 1 * time.Minute
+
+	// paceWindow is how far back a chat's pace is measured.
+	paceWindow = 24 * time.Hour
+	// minPaceAnswers is how many answered checks it takes for their speed to mean anything.
+	minPaceAnswers = 3
+	// maxCronSkips bounds how many windows nextOpen looks through for a cron fire before it gives up
+	// on a cron that never fires inside them.
+	maxCronSkips = 1000
 )
 
 type (
 	WordCheckConfig struct {
-		ChatIDs  []int64
+		ChatIDs []int64
+		// GroupChatIDs get a check every Interval without any pacing: their answers are kept apart
+		// from the answer log the pacing reads.
+		GroupChatIDs []int64
+		// Interval is the time between checks, unless Cron is set. Either way a backoff starts from
+		// it.
 		Interval time.Duration
-		HourFrom int
-		HourTo   int
+		// Cron, when set, is when checks are sent instead of every Interval.
+		Cron *config.Cron
+		// Windows are the daily windows checks are sent in.
+		Windows  []config.TimeSlot
 		Location *time.Location
+
+		// MaxUnanswered, MaxBackoff, FastAnswer and the study slots pace each chat; see
+		// nextWordCheck.
+		MaxUnanswered     int
+		MaxBackoff        time.Duration
+		FastAnswer        time.Duration
+		StudySlots        []config.TimeSlot
+		StudySlotInterval time.Duration
+	}
+
+	// WordCheckRepository is what the word check schedule reads: the pace of each chat and whether it
+	// is paused.
+	WordCheckRepository interface {
+		dal.CallbacksRepository
+		dal.PauseRepository
 	}
 
 	Publisher interface {
 		SendWordCheck(ctx context.Context, chatID int64) error
 	}
+
+	// chatCheck is when a chat's next word check is due, and the last wait if it was a backoff.
+	chatCheck struct {
+		due     time.Time
+		backoff time.Duration
+	}
 )
 
-func StartWordCheckSchedule(ctx context.Context, conf WordCheckConfig, p Publisher, log *slog.Logger) {
+// StartWordCheckSchedule sends word checks at a pace that follows each chat: it stops while checks go
+// unanswered, speeds up while they are answered quickly, and bursts during study slots. Paused chats
+// get nothing until their pause ends. Every chat's
+// first check is due one Interval after start, or at the first fire of Cron.
+//
+// Rather than polling, the schedule works out when each chat is due next and sleeps until the
+// earliest of them.
+func StartWordCheckSchedule(ctx context.Context, conf WordCheckConfig, repo WordCheckRepository, p Publisher, log *slog.Logger) {
 	defer func() {
 		if r := recover(); r != nil {
 			log.ErrorContext(ctx, "panic", "error", r)
 		}
 	}()
 
-	log.InfoContext(ctx, "word check schedule started")
+	start := time.Now().In(conf.Location)
+	first := nextOpen(conf, start.Add(conf.Interval))
+	if conf.Cron != nil {
+		first = nextOpen(conf, start.Add(time.Nanosecond))
+	}
+	if first.IsZero() {
+		log.ErrorContext(ctx, "word check schedule never fires within its windows", "cron", conf.Cron)
+		return
+	}
+	checks := make(map[int64]*chatCheck, len(conf.ChatIDs)+len(conf.GroupChatIDs))
+	for _, chatID := range slices.Concat(conf.ChatIDs, conf.GroupChatIDs) {
+		checks[chatID] = &chatCheck{due: first}
+	}
+
+	log.InfoContext(ctx, "word check schedule started", "first_check", first)
 	defer log.InfoContext(ctx, "word check schedule stopped")
 	for {
+		timer := time.NewTimer(time.Until(earliestDue(checks)))
 		select {
 		case <-ctx.Done():
+			timer.Stop()
 			if errors.Is(ctx.Err(), context.Canceled) {
 				log.DebugContext(ctx, "word check schedule stopped")
 			} else {
 				log.ErrorContext(ctx, "word check schedule stopped", "error", ctx.Err())
 			}
-		case <-time.After(conf.Interval):
-			log.DebugContext(ctx, "word check execution started")
+			return
+		case <-timer.C:
 			now := time.Now().In(conf.Location)
-			if now.Hour() < conf.HourFrom || now.Hour() >= conf.HourTo {
-				log.DebugContext(ctx, "word check execution skipped", "current_hour", now.Hour())
-				continue
-			}
-
 			for _, chatID := range conf.ChatIDs {
-				ctx, cancel := context.WithTimeout(ctx, publishTimeout)
-				log.DebugContext(ctx, "sending word check", "chat_id", chatID)
-				if err := p.SendWordCheck(ctx, chatID); err != nil {
-					if errors.Is(err, telebot.ErrBlockedByUser) {
-						log.InfoContext(ctx, "user blocked bot", "chat_id", chatID)
-						continue
-					}
-					log.ErrorContext(ctx, "failed to send word check", "error", err, "chat_id", chatID)
-				}
-				cancel()
+				runWordCheck(ctx, conf, checks[chatID], repo, true, p, chatID, now, log)
 			}
+			for _, chatID := range conf.GroupChatIDs {
+				runWordCheck(ctx, conf, checks[chatID], repo, false, p, chatID, now, log)
+			}
+		}
+	}
+}
+
+// earliestDue returns when the first chat is due. A chat whose due time is zero is never due again.
+func earliestDue(checks map[int64]*chatCheck) time.Time {
+	var res time.Time
+	for _, c := range checks {
+		if !c.due.IsZero() && (res.IsZero() || c.due.Before(res)) {
+			res = c.due
+		}
+	}
+	if res.IsZero() {
+		return time.Now().Add(paceWindow)
+	}
+	return res
+}
+
+// runWordCheck sends the chat a check if it is due, it is not paused and its pace allows, and
+// schedules the next one. An unpaced chat gets a check whenever it is due.
+func runWordCheck(
+	ctx context.Context, conf WordCheckConfig, check *chatCheck, repo WordCheckRepository, paced bool, p Publisher,
+	chatID int64, now time.Time, log *slog.Logger,
+) {
+	if check.due.IsZero() || now.Before(check.due) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, publishTimeout)
+	defer cancel()
+
+	if pause, err := repo.GetPause(ctx, chatID); err != nil {
+		log.ErrorContext(ctx, "failed to get pause", "error", err, "chat_id", chatID)
+	} else if pause.Active(now) {
+		// Looked at again as if a check went out, so a /resume takes effect without a restart.
+		_, check.due, _ = nextWordCheck(conf, now, nil, 0)
+		check.backoff = 0
+		log.DebugContext(ctx, "word check skipped, chat is paused", "chat_id", chatID, "paused_until", pause.Until)
+		return
+	}
+
+	var pace *dal.CheckPace
+	if paced && inWordCheckHours(conf, now) {
+		var err error
+		if pace, err = repo.GetCheckPace(ctx, chatID, now.Add(-paceWindow)); err != nil {
+			// Better an unpaced check than none at all.
+			log.ErrorContext(ctx, "failed to get check pace", "error", err, "chat_id", chatID)
+		}
+	}
+
+	send, due, backoff := nextWordCheck(conf, now, pace, check.backoff)
+	check.due, check.backoff = due, backoff
+	if !send {
+		log.DebugContext(ctx, "word check skipped", "chat_id", chatID, "next_check", due)
+		return
+	}
+
+	log.DebugContext(ctx, "sending word check", "chat_id", chatID, "next_check", due)
+	if err := p.SendWordCheck(ctx, chatID); err != nil {
+		if errors.Is(err, telebot.ErrBlockedByUser) {
+			log.InfoContext(ctx, "user blocked bot", "chat_id", chatID)
+			return
 		}
+		log.ErrorContext(ctx, "failed to send word check", "error", err, "chat_id", chatID)
 	}
 }
+
+// nextWordCheck decides whether a chat that is due gets a check now, and when it is due again. pace
+// is nil for a chat that is not paced.
+//
+// Outside the windows and the study slots nothing is sent and the chat is due again when the next
+// one opens. Once MaxUnanswered checks are waiting, the chat gets nothing until it answers one: it is
+// looked at again after Interval, then twice that, and so on up to MaxBackoff. Otherwise a study slot
+// sends every StudySlotInterval, and a chat that answers within FastAnswer gets checks twice as often
+// - unless they follow Cron, whose fires are kept as they are.
+func nextWordCheck(conf WordCheckConfig, now time.Time, pace *dal.CheckPace, backoff time.Duration) (send bool, due time.Time, nextBackoff time.Duration) {
+	inSlot := inStudySlot(conf, now)
+	if !inSlot && !inWindows(conf, now) {
+		return false, nextOpen(conf, now), 0
+	}
+
+	if pace != nil && conf.MaxUnanswered > 0 && pace.Unanswered >= conf.MaxUnanswered {
+		backoff = min(max(2*backoff, conf.Interval), conf.MaxBackoff)
+		return false, nextOpen(conf, now.Add(backoff)), backoff
+	}
+
+	switch {
+	case inSlot:
+		return true, nextOpen(conf, now.Add(conf.StudySlotInterval)), 0
+	case conf.Cron != nil:
+		return true, nextOpen(conf, now.Add(time.Nanosecond)), 0
+	case pace != nil && conf.FastAnswer > 0 && pace.Answered >= minPaceAnswers && pace.AnswerDelay <= conf.FastAnswer:
+		return true, nextOpen(conf, now.Add(conf.Interval/2)), 0 //nolint:mnd // twice as often
+	default:
+		return true, nextOpen(conf, now.Add(conf.Interval)), 0
+	}
+}
+
+// nextOpen returns the first time from t on at which a check may be sent: t itself if it falls in a
+// window or a study slot, else whichever of them opens first. With a Cron, only its fires count
+// outside the study slots.
+func nextOpen(conf WordCheckConfig, t time.Time) time.Time {
+	if inStudySlot(conf, t) {
+		return t
+	}
+
+	var res time.Time
+	earliest := func(c time.Time) {
+		if !c.IsZero() && (res.IsZero() || c.Before(res)) {
+			res = c
+		}
+	}
+	for _, s := range conf.StudySlots {
+		earliest(s.NextStart(t))
+	}
+
+	if conf.Cron == nil {
+		if inWindows(conf, t) {
+			return t
+		}
+		earliest(nextWindowStart(conf, t))
+		return res
+	}
+
+	// Next is strictly after its argument, so step back to let a fire at t itself count.
+	fire := conf.Cron.Next(t.Add(-time.Nanosecond))
+	for range maxCronSkips {
+		if fire.IsZero() || !res.IsZero() && !fire.Before(res) {
+			break
+		}
+		if inWindows(conf, fire) {
+			return fire
+		}
+		// Skip to the next window rather than fire by fire.
+		open := nextWindowStart(conf, fire)
+		if open.IsZero() {
+			break
+		}
+		fire = conf.Cron.Next(open.Add(-time.Nanosecond))
+	}
+	return res
+}
+
+func nextWindowStart(conf WordCheckConfig, after time.Time) time.Time {
+	var res time.Time
+	for _, w := range conf.Windows {
+		if s := w.NextStart(after); res.IsZero() || s.Before(res) {
+			res = s
+		}
+	}
+	return res
+}
+
+// inWordCheckHours reports whether checks may be sent at now at all.
+func inWordCheckHours(conf WordCheckConfig, now time.Time) bool {
+	return inWindows(conf, now) || inStudySlot(conf, now)
+}
+
+func inStudySlot(conf WordCheckConfig, now time.Time) bool {
+	return slices.ContainsFunc(conf.StudySlots, func(s config.TimeSlot) bool { return s.Contains(now) })
+}
+
+func inWindows(conf WordCheckConfig, now time.Time) bool {
+	return slices.ContainsFunc(conf.Windows, func(s config.TimeSlot) bool { return s.Contains(now) })
+}
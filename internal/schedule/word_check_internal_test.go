@@ -0,0 +1,141 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/config"
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+)
+
+func TestNextWordCheck(t *testing.T) {
+	conf := WordCheckConfig{
+		Interval:      30 * time.Minute,
+		Windows:       []config.TimeSlot{{From: 9 * time.Hour, To: 22 * time.Hour}},
+		MaxUnanswered: 3,
+		MaxBackoff:    2 * time.Hour,
+		FastAnswer:    2 * time.Minute,
+		// 07:00-08:00, before the regular hours start.
+		StudySlots:        []config.TimeSlot{{From: 7 * time.Hour, To: 8 * time.Hour}},
+		StudySlotInterval: 5 * time.Minute,
+	}
+	at := func(hour int) time.Time {
+		return time.Date(2026, time.March, 2, hour, 15, 0, 0, time.UTC)
+	}
+	nextDay := func(hour, minute int) time.Time {
+		return time.Date(2026, time.March, 3, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name        string
+		now         time.Time
+		pace        *dal.CheckPace
+		backoff     time.Duration
+		wantSend    bool
+		wantDue     time.Time
+		wantBackoff time.Duration
+	}{
+		{
+			name: "regular pace", now: at(12),
+			pace:     &dal.CheckPace{Unanswered: 1, Answered: 5, AnswerDelay: 10 * time.Minute},
+			wantSend: true, wantDue: at(12).Add(30 * time.Minute),
+		},
+		{
+			name: "quick answers double the rate", now: at(12),
+			pace:     &dal.CheckPace{Answered: 5, AnswerDelay: time.Minute},
+			wantSend: true, wantDue: at(12).Add(15 * time.Minute),
+		},
+		{
+			name: "too few answers to judge", now: at(12),
+			pace:     &dal.CheckPace{Answered: 2, AnswerDelay: time.Minute},
+			wantSend: true, wantDue: at(12).Add(30 * time.Minute),
+		},
+		{
+			name: "a pile of unanswered checks starts the backoff", now: at(12),
+			pace:     &dal.CheckPace{Unanswered: 3},
+			wantSend: false, wantDue: at(12).Add(30 * time.Minute), wantBackoff: 30 * time.Minute,
+		},
+		{
+			name: "the backoff doubles", now: at(12),
+			pace: &dal.CheckPace{Unanswered: 3}, backoff: 30 * time.Minute,
+			wantSend: false, wantDue: at(12).Add(time.Hour), wantBackoff: time.Hour,
+		},
+		{
+			name: "up to the cap", now: at(12),
+			pace: &dal.CheckPace{Unanswered: 4}, backoff: 90 * time.Minute,
+			wantSend: false, wantDue: at(12).Add(2 * time.Hour), wantBackoff: 2 * time.Hour,
+		},
+		{
+			name: "an answer ends the backoff", now: at(12),
+			pace: &dal.CheckPace{}, backoff: 2 * time.Hour,
+			wantSend: true, wantDue: at(12).Add(30 * time.Minute),
+		},
+		{
+			name: "study slot outside the regular hours", now: at(7),
+			pace:     &dal.CheckPace{},
+			wantSend: true, wantDue: at(7).Add(5 * time.Minute),
+		},
+		{
+			name: "study slot still waits for unanswered checks", now: at(7),
+			pace:     &dal.CheckPace{Unanswered: 3},
+			wantSend: false, wantDue: at(7).Add(30 * time.Minute), wantBackoff: 30 * time.Minute,
+		},
+		{
+			name: "a backoff into the quiet hours waits for the next slot", now: at(21),
+			pace: &dal.CheckPace{Unanswered: 3}, backoff: 90 * time.Minute,
+			wantSend: false, wantDue: nextDay(7, 0), wantBackoff: 2 * time.Hour,
+		},
+		{
+			name: "quiet hours", now: at(23),
+			wantSend: false, wantDue: nextDay(7, 0),
+		},
+		{
+			name: "unpaced chat", now: at(12),
+			wantSend: true, wantDue: at(12).Add(30 * time.Minute),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			send, due, backoff := nextWordCheck(conf, tt.now, tt.pace, tt.backoff)
+			if send != tt.wantSend || !due.Equal(tt.wantDue) || backoff != tt.wantBackoff {
+				t.Errorf("nextWordCheck() = %v, %v, %v, want %v, %v, %v",
+					send, due, backoff, tt.wantSend, tt.wantDue, tt.wantBackoff)
+			}
+		})
+	}
+}
+
+func TestNextOpenCron(t *testing.T) {
+	cron := config.MustParseCron("0,30 * * * 1-5")
+	conf := WordCheckConfig{
+		Cron: &cron,
+		Windows: []config.TimeSlot{
+			{From: 8 * time.Hour, To: 9 * time.Hour},
+			{From: 18 * time.Hour, To: 30 * time.Minute},
+		},
+	}
+	// A Monday.
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, time.March, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name string
+		t    time.Time
+		want time.Time
+	}{
+		{name: "a fire in a window", t: at(2, 8, 30), want: at(2, 8, 30)},
+		{name: "the next fire in a window", t: at(2, 8, 31), want: at(2, 18, 0)},
+		{name: "past midnight", t: at(2, 23, 45), want: at(3, 0, 0)},
+		{name: "the window closes before the fire", t: at(3, 0, 1), want: at(3, 8, 0)},
+		{name: "over the weekend", t: at(6, 23, 31), want: at(9, 0, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextOpen(conf, tt.t); !got.Equal(tt.want) {
+				t.Errorf("nextOpen(%s) = %s, want %s", tt.t, got, tt.want)
+			}
+		})
+	}
+}
@@ -21,12 +21,35 @@ const (
 	commandStats  = "/stats"
 	commandRandom = "/random"
 
+	commandLeaderboard = "/leaderboard"
+	commandChallenge   = "/challenge"
+	commandDeck        = "/deck"
+	commandQuiz        = "/quiz"
+	commandReview      = "/review"
+	commandPause       = "/pause"
+	commandResume      = "/resume"
+	commandTimezone    = "/timezone"
+	commandCards       = "/cards"
+	commandUndo        = "/undo"
+	commandLogoutAll   = "/logout_all"
+	commandToken       = "/token"
+
 	callbackAuthConfirm    = "callback#auth#confirm"
 	callbackAuthDecline    = "callback#auth#decline"
 	callbackSeeTranslation = "callback#see_translation"
+	callbackRevealCloze    = "callback#cloze#reveal"
 	callbackWordGuessed    = "callback#word#guessed"
 	callbackWordMissed     = "callback#word#missed"
 	callbackWordToReview   = "callback#word#to_review"
+	callbackWordResolved   = "callback#word#resolved"
+	callbackWordSkip       = "callback#word#skip"
+	callbackWordUndo       = "callback#word#undo"
+	callbackGroupReveal    = "callback#group#reveal"
+	callbackGroupGuessed   = "callback#group#guessed"
+	callbackGroupMissed    = "callback#group#missed"
+	callbackQuizReveal     = "callback#quiz#reveal"
+	callbackQuizGuessed    = "callback#quiz#guessed"
+	callbackQuizMissed     = "callback#quiz#missed"
 
 	somethingWentWrongMsg = "something went wrong"
 
@@ -37,6 +60,14 @@ const (
 	// reviewPrefix marks a word that is being re-tested after having been learned, so it is obvious
 	// that a wrong answer will cost a streak that was already complete.
 	reviewPrefix = "🔁 "
+	// toReviewPrefix marks a word that was flagged with ❓, so it is clear why it came up out of turn.
+	toReviewPrefix = "❓ "
+	// drillPrefix marks a recently missed word that is being drilled, so it is clear why it is back
+	// this soon.
+	drillPrefix = "🎯 "
+	// clozePrefix marks a fill-in-the-blank card, so the blank is not mistaken for a formatting
+	// glitch.
+	clozePrefix = "📝 "
 )
 
 type (
@@ -44,10 +75,25 @@ type (
 		bot  *tb.Bot
 		repo dal.Repository
 
-		// streakLimit is the streak at which a word counts as learned and becomes eligible for
-		// review; reviewRatePercent is the share of scheduled checks spent on those reviews.
-		streakLimit       int
+		// reviewRatePercent caps the share of scheduled checks spent on reviewing learned words.
 		reviewRatePercent int
+		// toReviewRatePercent is the share of scheduled checks spent on the words marked to review.
+		toReviewRatePercent int
+		// clozeRatePercent is the share of word checks sent as a fill-in-the-blank sentence to the
+		// chats on dal.CardMixed, for the words that have one.
+		clozeRatePercent int
+		// leechSuspend says whether leeches are taken out of the learning batch, for the message that
+		// tells the chat about a new one.
+		leechSuspend bool
+		// undoWindow is how long an answer can be taken back for; 0 disables undo. See undo.go.
+		undoWindow time.Duration
+		// goal is what a day needs for it to count towards the learning streak.
+		goal dal.DailyGoal
+		// teamChatIDs are the chats the leaderboard and team challenges compare, the opted-in ones
+		// among them at least.
+		teamChatIDs []int64
+		// groupChatIDs are the group chats quizzed as a whole; see group.go.
+		groupChatIDs []int64
 
 		middlewares []tb.MiddlewareFunc
 
@@ -61,7 +107,10 @@ type (
 	noOpReplier struct{}
 )
 
-func NewBot(token string, repo dal.Repository, conf config.Learning, log *slog.Logger, middlewares ...tb.MiddlewareFunc) (*Bot, error) {
+func NewBot(
+	token string, repo dal.Repository, conf config.Learning, goals config.Goals, teamChatIDs, groupChatIDs []int64,
+	log *slog.Logger, middlewares ...tb.MiddlewareFunc,
+) (*Bot, error) {
 	b, err := tb.NewBot(tb.Settings{
 		Token: token,
 		Poller: &tb.LongPoller{
@@ -73,12 +122,18 @@ func NewBot(token string, repo dal.Repository, conf config.Learning, log *slog.L
 	}
 
 	return &Bot{
-		bot:               b,
-		repo:              repo,
-		streakLimit:       conf.StreakLimit,
-		reviewRatePercent: conf.ReviewRatePercent,
-		middlewares:       middlewares,
-		log:               log,
+		bot:                 b,
+		repo:                repo,
+		reviewRatePercent:   conf.ReviewRatePercent,
+		toReviewRatePercent: conf.ToReviewRatePercent,
+		clozeRatePercent:    conf.ClozeRatePercent,
+		leechSuspend:        conf.LeechSuspend,
+		undoWindow:          conf.UndoWindow,
+		goal:                dal.DailyGoal{Answers: goals.DailyAnswers, NewWords: goals.DailyNewWords},
+		teamChatIDs:         teamChatIDs,
+		groupChatIDs:        groupChatIDs,
+		middlewares:         middlewares,
+		log:                 log,
 	}, nil
 }
 
@@ -86,6 +141,18 @@ func (b *Bot) Start(ctx context.Context) {
 	b.bot.Handle(commandStart, b.HandleStart, b.middlewares...)
 	b.bot.Handle(commandStats, b.HandleStats, b.middlewares...)
 	b.bot.Handle(commandRandom, b.HandleRandom, b.middlewares...)
+	b.bot.Handle(commandLeaderboard, b.privateOnly(b.HandleLeaderboard), b.middlewares...)
+	b.bot.Handle(commandChallenge, b.privateOnly(b.HandleChallenge), b.middlewares...)
+	b.bot.Handle(commandDeck, b.HandleDeck, b.middlewares...)
+	b.bot.Handle(commandQuiz, b.privateOnly(b.HandleQuiz), b.middlewares...)
+	b.bot.Handle(commandReview, b.privateOnly(b.HandleReview), b.middlewares...)
+	b.bot.Handle(commandPause, b.privateOnly(b.HandlePause), b.middlewares...)
+	b.bot.Handle(commandResume, b.privateOnly(b.HandleResume), b.middlewares...)
+	b.bot.Handle(commandTimezone, b.privateOnly(b.HandleTimezone), b.middlewares...)
+	b.bot.Handle(commandCards, b.privateOnly(b.HandleCards), b.middlewares...)
+	b.bot.Handle(commandUndo, b.privateOnly(b.HandleUndo), b.middlewares...)
+	b.bot.Handle(commandLogoutAll, b.privateOnly(b.HandleLogoutAll), b.middlewares...)
+	b.bot.Handle(commandToken, b.privateOnly(b.HandleToken), b.middlewares...)
 	b.bot.Handle(tb.OnCallback, b.HandleCallback, b.middlewares...)
 
 	go func() {
@@ -108,6 +175,10 @@ func (b *Bot) HandleStats(m tb.Context) error {
 	ctx, cancel := processCtx()
 	defer cancel()
 
+	if b.isGroup(m.Chat().ID) {
+		return b.replyGroupScoreboard(ctx, m)
+	}
+
 	totalStats, err := b.repo.GetTotalStats(ctx, m.Chat().ID)
 	if err != nil {
 		b.log.ErrorContext(ctx, "failed to get stats", "error", err)
@@ -120,6 +191,18 @@ func (b *Bot) HandleStats(m tb.Context) error {
 		return m.Reply("failed to get stats")
 	}
 
+	streak, err := b.repo.GetStreak(ctx, m.Chat().ID, b.goal, time.Now())
+	if err != nil {
+		b.log.ErrorContext(ctx, "failed to get streak", "error", err)
+		return m.Reply("failed to get stats")
+	}
+
+	pause, err := b.repo.GetPause(ctx, m.Chat().ID)
+	if err != nil {
+		b.log.ErrorContext(ctx, "failed to get pause", "error", err)
+		return m.Reply("failed to get stats")
+	}
+
 	msg := totalStatsMessage(totalStats)
 
 	if stats != nil {
@@ -127,9 +210,67 @@ func (b *Bot) HandleStats(m tb.Context) error {
 			stats.WordsGuessed, stats.WordsMissed)
 	}
 
+	msg += "\n\n" + streakMessage(streak)
+	if paused := pauseMessage(pause, time.Now()); paused != "" {
+		msg += "\n\n" + paused
+	}
+
 	return m.Reply(msg)
 }
 
+// streakMessage renders today's progress towards the daily goal and the streak it feeds.
+func streakMessage(s *dal.Streak) string {
+	lines := []string{fmt.Sprintf("Daily goal: %d/%d answers", s.TodayAnswers, s.Goal.Answers)}
+	if s.Goal.NewWords > 0 {
+		lines = append(lines, fmt.Sprintf("New words: %d/%d", s.TodayNewWords, s.Goal.NewWords))
+	}
+	if s.GoalMet {
+		lines = append(lines, "Today's goal is met ✅")
+	}
+	lines = append(lines, fmt.Sprintf("Streak: %s 🔥", pluralize(s.Days, "day")))
+	lines = append(lines, fmt.Sprintf("Streak freezes: %d 🧊", s.Freezes))
+
+	return strings.Join(lines, "\n")
+}
+
+func pluralize(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// SendGoalReminder nudges a chat that has not met today's goal yet. It is a no-op once the goal is
+// met, so the schedule does not have to check first.
+func (b *Bot) SendGoalReminder(ctx context.Context, chatID int64) error {
+	streak, err := b.repo.GetStreak(ctx, chatID, b.goal, time.Now())
+	if err != nil {
+		return fmt.Errorf("get streak: %w", err)
+	}
+	if streak.GoalMet {
+		return nil
+	}
+
+	_, err = b.bot.Send(tb.ChatID(chatID), goalReminderMessage(streak), tb.Silent)
+	return err //nolint:wrapcheck // lets ignore it here
+}
+
+func goalReminderMessage(s *dal.Streak) string {
+	lines := []string{fmt.Sprintf("⏰ Today's goal is not met yet: %d/%d answers", s.TodayAnswers, s.Goal.Answers)}
+	if s.Goal.NewWords > 0 {
+		lines = append(lines, fmt.Sprintf("New words: %d/%d", s.TodayNewWords, s.Goal.NewWords))
+	}
+	switch {
+	case s.Days > 0 && s.Freezes > 0:
+		lines = append(lines, fmt.Sprintf("Finish it to keep your %d-day streak going, or it will cost a freeze 🧊.", s.Days))
+	case s.Days > 0:
+		lines = append(lines, fmt.Sprintf("Finish it to keep your %d-day streak going.", s.Days))
+	}
+	lines = append(lines, "Send "+commandRandom+" to practice.")
+
+	return strings.Join(lines, "\n")
+}
+
 // totalStatsMessage renders the overall progress breakdown, skipping the buckets that a small
 // streak limit squeezes out of existence: with a limit of 6 or less the early band has no room left
 // and would be labelled with the inverted range "1-0".
@@ -147,6 +288,9 @@ func totalStatsMessage(s *dal.TotalStats) string {
 	lines = append(lines, fmt.Sprintf("Total: %d", s.Total))
 	lines = append(lines, fmt.Sprintf("In learning batch: %d", s.Batched))
 	lines = append(lines, fmt.Sprintf("Waiting in queue: %d", s.Queued))
+	if s.ToReview > 0 {
+		lines = append(lines, fmt.Sprintf("To review: %d, see %s", s.ToReview, commandReview))
+	}
 
 	return strings.Join(lines, "\n")
 }
@@ -155,24 +299,45 @@ func (b *Bot) HandleRandom(m tb.Context) error {
 	ctx, cancel := processCtx()
 	defer cancel()
 
+	if b.isGroup(m.Chat().ID) {
+		return b.sendGroupRound(ctx, m.Chat().ID, m)
+	}
 	return b.sendWordCheck(ctx, m.Chat().ID, dal.FindRandomWordFilter{StreakLimitDirection: dal.LimitDirectionGreaterThanOrEqual, StreakLimit: 0}, m)
 }
 
 // SendWordCheck sends one scheduled word check.
 //
-// Most checks come from the active learning batch, but ReviewRatePercent of them re-test a word that
-// has already been learned. Without that, a word never comes back once its streak crosses the limit,
-// so the "learned" count drifts away from what is actually remembered.
+// Most checks come from the active learning batch, but some of them re-test a learned word whose
+// review is due. Without that, a word never comes back once its streak crosses the limit, so the
+// "learned" count drifts away from what is actually remembered. How many follows how many reviews are
+// due, up to ReviewRatePercent; see reviewShare. Words marked to review come
+// before either: ToReviewRatePercent of the checks go to them while there are any. A recently missed
+// word that is due in the drill comes before all of them.
+//
+// A group gets a round instead, which all of its members answer.
 func (b *Bot) SendWordCheck(ctx context.Context, chatID int64) error {
+	if b.isGroup(chatID) {
+		return b.sendGroupRound(ctx, chatID, &noOpReplier{})
+	}
+
+	drill, err := b.repo.FindRandomWordTranslation(ctx, chatID, dal.FindRandomWordFilter{Drill: true})
+	switch {
+	case err == nil:
+		return b.sendDrill(ctx, chatID, drill)
+	case !errors.Is(err, dal.ErrNotFound):
+		// Same as a failed review draw: the check goes on without the drill.
+		b.log.ErrorContext(ctx, "failed to find drill word", "error", err)
+	}
+
 	// Any failure to pick a review falls back to the batch, same as a check that was never going to
 	// be a review: pickReview has already logged whatever went wrong, and losing the review is
 	// better than losing the whole check.
-	review, err := b.pickReview(ctx, chatID)
+	review, prefix, err := b.pickReview(ctx, chatID)
 	if err != nil {
 		return b.sendWordCheck(ctx, chatID, dal.FindRandomWordFilter{Batched: true}, &noOpReplier{})
 	}
 
-	if err = b.sendWord(ctx, chatID, review, reviewPrefix); err != nil {
+	if err = b.sendWord(ctx, chatID, review, prefix); err != nil {
 		return err
 	}
 	// Stamped on send rather than on answer, so an ignored message still advances the rotation.
@@ -182,34 +347,80 @@ func (b *Bot) SendWordCheck(ctx context.Context, chatID int64) error {
 	return nil
 }
 
+// sendDrill asks a word that is due in the drill, and puts off its next ask until it is answered.
+func (b *Bot) sendDrill(ctx context.Context, chatID int64, wt *dal.WordTranslation) error {
+	if err := b.sendWord(ctx, chatID, wt, drillPrefix); err != nil {
+		return err
+	}
+	if err := b.repo.PostponeDrill(ctx, chatID, wt.Word); err != nil {
+		b.log.ErrorContext(ctx, "failed to postpone drill", "error", err, "word", wt.Word)
+	}
+	return nil
+}
+
 // errNoReviewDue means this check must fall back to the active learning batch: either the draw did
-// not land on a review, or there is nothing learned to review yet.
+// not land on a review, or there is nothing to review yet.
 var errNoReviewDue = errors.New("no review due")
 
-// pickReview returns a learned word to re-test, or errNoReviewDue when the check belongs to the
-// active batch instead.
-func (b *Bot) pickReview(ctx context.Context, chatID int64) (*dal.WordTranslation, error) {
+// pickReview returns a word to re-test and the prefix to send it with, or errNoReviewDue when the
+// check belongs to the active batch instead. Words marked to review get the first draw, learned
+// words the second.
+func (b *Bot) pickReview(ctx context.Context, chatID int64) (*dal.WordTranslation, string, error) {
+	wt, err := b.drawReview(ctx, chatID, b.toReviewRatePercent, dal.FindRandomWordFilter{ToReview: true})
+	if !errors.Is(err, errNoReviewDue) {
+		return wt, toReviewPrefix, err
+	}
+
 	if b.reviewRatePercent <= 0 {
+		return nil, "", errNoReviewDue
+	}
+	load, err := b.repo.GetReviewLoad(ctx, chatID)
+	if err != nil {
+		b.log.ErrorContext(ctx, "failed to get review load", "error", err)
+		return nil, "", errors.New(somethingWentWrongMsg)
+	}
+
+	wt, err = b.drawReview(ctx, chatID, reviewShare(load, b.reviewRatePercent), dal.FindRandomWordFilter{
+		Learned: true,
+		Order:   dal.OrderLeastRecentlyReviewed,
+		Due:     true,
+	})
+	return wt, reviewPrefix, err
+}
+
+// reviewShare is the percentage of checks that go to due reviews: their share of everything there
+// is to ask, up to maxPercent. A chat with nothing left to learn gets nothing but reviews, and one
+// with nothing due none at all.
+func reviewShare(load *dal.ReviewLoad, maxPercent int) int {
+	switch {
+	case load.Due == 0:
+		return 0
+	case load.Batched == 0:
+		return 100 //nolint:mnd // every check
+	default:
+		return min(maxPercent, max(1, 100*load.Due/(load.Due+load.Batched))) //nolint:mnd // percent
+	}
+}
+
+// drawReview rolls percent and, on a hit, picks the word filter selects.
+func (b *Bot) drawReview(ctx context.Context, chatID int64, percent int, filter dal.FindRandomWordFilter) (*dal.WordTranslation, error) {
+	if percent <= 0 {
 		return nil, errNoReviewDue
 	}
 
-	rnd, err := rand.Int(rand.Reader, big.NewInt(100)) //nolint:mnd // percentages are out of 100
+	hit, err := rollPercent(percent)
 	if err != nil {
 		b.log.ErrorContext(ctx, "failed to generate random number", "error", err)
 		return nil, errors.New(somethingWentWrongMsg)
 	}
-	if rnd.Int64() >= int64(b.reviewRatePercent) {
+	if !hit {
 		return nil, errNoReviewDue
 	}
 
-	wt, err := b.repo.FindRandomWordTranslation(ctx, chatID, dal.FindRandomWordFilter{
-		StreakLimitDirection: dal.LimitDirectionGreaterThanOrEqual,
-		StreakLimit:          b.streakLimit,
-		Order:                dal.OrderLeastRecentlyReviewed,
-	})
+	wt, err := b.repo.FindRandomWordTranslation(ctx, chatID, filter)
 	if err != nil {
 		if errors.Is(err, dal.ErrNotFound) {
-			b.log.DebugContext(ctx, "no learned words to review", "chat_id", chatID)
+			b.log.DebugContext(ctx, "no words to review", "chat_id", chatID, "to_review", filter.ToReview)
 			return nil, errNoReviewDue
 		}
 		b.log.ErrorContext(ctx, "failed to get word to review", "error", err)
@@ -243,6 +454,15 @@ func (b *Bot) sendWordCheck(ctx context.Context, chatID int64, filter dal.FindRa
 	return nil
 }
 
+// rollPercent reports whether a roll out of 100 lands within percent.
+func rollPercent(percent int) (bool, error) {
+	rnd, err := rand.Int(rand.Reader, big.NewInt(100)) //nolint:mnd // percentages are out of 100
+	if err != nil {
+		return false, fmt.Errorf("generate random number: %w", err)
+	}
+	return rnd.Int64() < int64(percent), nil
+}
+
 func (b *Bot) sendWord(ctx context.Context, chatID int64, wt *dal.WordTranslation, prefix string) error {
 	data := dal.CallbackData{
 		ChatID:    chatID,
@@ -255,12 +475,59 @@ func (b *Bot) sendWord(ctx context.Context, chatID int64, wt *dal.WordTranslatio
 		return fmt.Errorf("insert callback data: %w", err)
 	}
 
-	_, err = b.bot.Send(tb.ChatID(chatID), prefix+normalizeMessage(fmt.Sprintf("**%s**", wt.Word)),
+	// Cloze cards go out as plain text: the sentence is free-form user input and the blank itself
+	// would read as MarkdownV2 italics.
+	if c, ok := b.pickCloze(ctx, chatID, wt); ok {
+		_, err = b.bot.Send(tb.ChatID(chatID), prefix+clozePrefix+c.Masked, tb.Silent, revealClozeMarkup(callbackID))
+		return err //nolint:wrapcheck // lets ignore it here
+	}
+
+	_, err = b.bot.Send(tb.ChatID(chatID), prefix+"*"+normalizeMessage(wt.Word)+"*",
 		tb.ModeMarkdownV2, tb.Silent, seeTranslationMarkup(callbackID),
 	)
 	return err //nolint:wrapcheck // lets ignore it here
 }
 
+// pickCloze decides whether wt goes out as a cloze card, by the chat's card type. The card type is
+// rolled first and the sentence looked up second, so a word without one lowers the effective cloze
+// share rather than handing its turn to another word.
+func (b *Bot) pickCloze(ctx context.Context, chatID int64, wt *dal.WordTranslation) (cloze, bool) {
+	cardType, err := b.repo.GetCardType(ctx, chatID)
+	if err != nil {
+		b.log.ErrorContext(ctx, "failed to get card type", "error", err, "chat_id", chatID)
+		cardType = dal.CardMixed
+	}
+
+	share := clozeShare(cardType, b.clozeRatePercent)
+	if share <= 0 {
+		return cloze{}, false
+	}
+
+	hit, err := rollPercent(share)
+	if err != nil {
+		b.log.ErrorContext(ctx, "failed to generate random number", "error", err)
+		return cloze{}, false
+	}
+	if !hit {
+		return cloze{}, false
+	}
+
+	return findCloze(wt.Word, wt.Description)
+}
+
+// clozeShare is the percentage of word checks sent as cloze cards with cardType, where ratePercent is
+// the configured share for CardMixed.
+func clozeShare(cardType dal.CardType, ratePercent int) int {
+	switch cardType {
+	case dal.CardClassic:
+		return 0
+	case dal.CardCloze:
+		return 100 //nolint:mnd // every card
+	default:
+		return ratePercent
+	}
+}
+
 func (r *noOpReplier) Reply(any, ...any) error {
 	return nil
 }
@@ -274,11 +541,49 @@ func seeTranslationMarkup(uuid string) *tb.ReplyMarkup {
 					Data: fmt.Sprintf("%s:%s", callbackSeeTranslation, uuid),
 				},
 			},
+			skipButtonRow(uuid),
 		},
 	}
 }
 
-func guessedResponseMarkup(uuid string) *tb.ReplyMarkup {
+func revealClozeMarkup(uuid string) *tb.ReplyMarkup {
+	return &tb.ReplyMarkup{
+		InlineKeyboard: [][]tb.InlineButton{
+			{
+				{
+					Text: "Reveal",
+					Data: fmt.Sprintf("%s:%s", callbackRevealCloze, uuid),
+				},
+			},
+			skipButtonRow(uuid),
+		},
+	}
+}
+
+// skipButtonRow buries the word on a card for the rest of the day.
+func skipButtonRow(uuid string) []tb.InlineButton {
+	return []tb.InlineButton{
+		{
+			Text: "⏭ Skip for today",
+			Data: fmt.Sprintf("%s:%s", callbackWordSkip, uuid),
+		},
+	}
+}
+
+// guessedResponseMarkup grades a revealed word. A word already marked to review gets a button that
+// clears the mark instead of one that sets it.
+func guessedResponseMarkup(uuid string, toReview bool) *tb.ReplyMarkup {
+	reviewButton := tb.InlineButton{
+		Text: "[      ❓      ]",
+		Data: fmt.Sprintf("%s:%s", callbackWordToReview, uuid),
+	}
+	if toReview {
+		reviewButton = tb.InlineButton{
+			Text: "[  ✔️ resolved  ]",
+			Data: fmt.Sprintf("%s:%s", callbackWordResolved, uuid),
+		}
+	}
+
 	return &tb.ReplyMarkup{
 		InlineKeyboard: [][]tb.InlineButton{
 			{
@@ -290,10 +595,7 @@ func guessedResponseMarkup(uuid string) *tb.ReplyMarkup {
 					Text: "[      ❌      ]",
 					Data: fmt.Sprintf("%s:%s", callbackWordMissed, uuid),
 				},
-				{
-					Text: "[      ❓      ]",
-					Data: fmt.Sprintf("%s:%s", callbackWordToReview, uuid),
-				},
+				reviewButton,
 			},
 		},
 	}
@@ -303,19 +605,26 @@ func processCtx() (context.Context, context.CancelFunc) {
 	return context.WithTimeout(context.Background(), processTimeout)
 }
 
-//nolint:gochecknoglobals // it's a list of characters to escape
-var toEscape = []string{
-	"#",
-	"=",
-	"-",
-	"(",
-	")",
+// markdownV2Special is every character MarkdownV2 reserves. Outside of an entity each one has to be
+// escaped, or Telegram rejects the whole message - a single unescaped "." in a description was
+// enough.
+const markdownV2Special = "\\_*[]()~`>#+-=|{}.!"
+
+// escapeMarkdownV2 makes s safe to embed in a MarkdownV2 message as plain text. Markup is added
+// around the escaped text by the caller, never inside it.
+func escapeMarkdownV2(s string) string {
+	var sb strings.Builder
+	sb.Grow(len(s))
+	for _, r := range s {
+		if strings.ContainsRune(markdownV2Special, r) {
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
 }
 
+// normalizeMessage lowercases and trims user-entered text and escapes it for MarkdownV2.
 func normalizeMessage(s string) string {
-	res := strings.TrimSpace(strings.ToLower(s))
-	for _, esc := range toEscape {
-		res = strings.ReplaceAll(res, esc, "\\"+esc)
-	}
-	return res
+	return escapeMarkdownV2(strings.TrimSpace(strings.ToLower(s)))
 }
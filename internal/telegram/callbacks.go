@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
 	tb "gopkg.in/telebot.v3"
@@ -44,24 +45,46 @@ func (b *Bot) HandleCallback(c tb.Context) error {
 	}
 
 	switch data.Action {
+	case callbackGroupReveal, callbackGroupGuessed, callbackGroupMissed:
+		// The rest of the group still has to answer, so a round is never deleted.
+		return b.handleGroupCallback(ctx, c, data.Action, cData)
 	case callbackSeeTranslation:
 		err = b.handleSeeTranslationCallback(ctx, c, cData)
+	case callbackRevealCloze:
+		err = b.handleRevealClozeCallback(ctx, c, cData)
 	case callbackWordGuessed:
 		err = b.handleWordGuessedCallback(ctx, c, cData)
 	case callbackWordMissed:
 		err = b.handleWordMissedCallback(ctx, c, cData)
 	case callbackWordToReview:
 		err = b.handleWordToReviewCallback(ctx, c, cData)
+	case callbackWordResolved:
+		err = b.handleWordResolvedCallback(ctx, c, cData)
+	case callbackWordSkip:
+		err = b.handleWordSkipCallback(ctx, c, cData)
+	case callbackWordUndo:
+		err = b.handleWordUndoCallback(ctx, c, cData)
+	case callbackQuizReveal:
+		err = b.handleQuizRevealCallback(ctx, c, cData)
+	case callbackQuizGuessed, callbackQuizMissed:
+		err = b.handleQuizGradeCallback(ctx, c, cData, data.Action == callbackQuizGuessed)
 	default:
 		b.log.Warn("unknown callback action", "action", data.Action)
 		return c.RespondText(somethingWentWrongMsg)
 	}
 
+	if errors.Is(err, dal.ErrAlreadyAnswered) {
+		return c.RespondText(alreadyAnsweredMessage(cData.Answer))
+	}
 	if err != nil {
 		b.log.ErrorContext(ctx, "failed to process callback", "error", err)
 		return c.RespondText(somethingWentWrongMsg)
 	}
 
+	// A graded card stays, showing the answer, for as long as it can be undone.
+	if (data.Action == callbackWordGuessed || data.Action == callbackWordMissed) && b.undoWindow > 0 {
+		return c.Edit(answeredMessage(cData.Word, data.Action == callbackWordGuessed), undoMarkup(cData.ID))
+	}
 	return c.Delete()
 }
 
@@ -87,23 +110,51 @@ func (b *Bot) handleSeeTranslationCallback(ctx context.Context, c tb.Context, da
 		b.log.ErrorContext(ctx, "failed to get word translation", "error", err)
 		return c.RespondText(somethingWentWrongMsg)
 	}
-	msg := fmt.Sprintf("**%s**", wt.Translation)
+	msg := "*" + normalizeMessage(wt.Translation) + "*"
 	if wt.Description != "" {
-		msg += fmt.Sprintf(": _%s_", wt.Description)
+		msg += ": _" + normalizeMessage(wt.Description) + "_"
 	}
-	return c.Send(normalizeMessage(msg), guessedResponseMarkup(data.ID), tb.ModeMarkdownV2, tb.Silent)
+	return c.Send(msg, guessedResponseMarkup(data.ID, wt.ToReview), tb.ModeMarkdownV2, tb.Silent)
 }
 
+// handleRevealClozeCallback shows the whole sentence behind a cloze card, followed by the word and its
+// translation. The card is regenerated from the description rather than stored, so if the
+// description has been edited since and no longer uses the word, the classic answer is shown
+// instead.
+func (b *Bot) handleRevealClozeCallback(ctx context.Context, c tb.Context, data *dal.CallbackData) error {
+	wt, err := b.repo.FindWordTranslation(ctx, c.Chat().ID, data.Word)
+	if err != nil {
+		b.log.ErrorContext(ctx, "failed to get word translation", "error", err)
+		return c.RespondText(somethingWentWrongMsg)
+	}
+
+	cl, ok := findCloze(wt.Word, wt.Description)
+	if !ok {
+		return b.handleSeeTranslationCallback(ctx, c, data)
+	}
+
+	msg := fmt.Sprintf("%s\n\n%s — %s", cl.Sentence, wt.Word, wt.Translation)
+	return c.Send(msg, guessedResponseMarkup(data.ID, wt.ToReview), tb.Silent)
+}
+
+// handleWordGuessedCallback and handleWordMissedCallback grade the card through its callback, which
+// can be graded only once: a second tap reports dal.ErrAlreadyAnswered.
 func (b *Bot) handleWordGuessedCallback(ctx context.Context, c tb.Context, data *dal.CallbackData) error {
-	if err := b.repo.RegisterGuess(ctx, c.Chat().ID, data.Word); err != nil {
-		return fmt.Errorf("register guess: %w", err)
+	if _, err := b.repo.GradeCallback(ctx, c.Chat().ID, data.ID, true); err != nil {
+		return fmt.Errorf("grade guess: %w", err)
 	}
 	return nil
 }
 
 func (b *Bot) handleWordMissedCallback(ctx context.Context, c tb.Context, cData *dal.CallbackData) error {
-	if err := b.repo.RegisterMiss(ctx, c.Chat().ID, cData.Word); err != nil {
-		return fmt.Errorf("register miss: %w", err)
+	leech, err := b.repo.GradeCallback(ctx, c.Chat().ID, cData.ID, false)
+	if err != nil {
+		return fmt.Errorf("grade miss: %w", err)
+	}
+	if leech {
+		if err = c.Send(leechMessage(cData.Word, b.leechSuspend), tb.Silent); err != nil {
+			b.log.ErrorContext(ctx, "failed to send leech message", "error", err)
+		}
 	}
 	return nil
 }
@@ -115,6 +166,40 @@ func (b *Bot) handleWordToReviewCallback(ctx context.Context, c tb.Context, cDat
 	return nil
 }
 
+func (b *Bot) handleWordResolvedCallback(ctx context.Context, c tb.Context, cData *dal.CallbackData) error {
+	if err := b.repo.MarkToReview(ctx, c.Chat().ID, cData.Word, false); err != nil {
+		return fmt.Errorf("clear to review: %w", err)
+	}
+	return nil
+}
+
+// handleWordSkipCallback buries the word until midnight, so that it is not asked again today. It
+// keeps its place in the learning batch and its streak.
+func (b *Bot) handleWordSkipCallback(ctx context.Context, c tb.Context, cData *dal.CallbackData) error {
+	if err := b.repo.BuryWord(ctx, c.Chat().ID, cData.Word, endOfDay(time.Now())); err != nil {
+		return fmt.Errorf("bury word: %w", err)
+	}
+	return nil
+}
+
+// alreadyAnsweredMessage is the reply to a card that has been graded already. answer is empty when
+// the card was graded concurrently, after it was looked up.
+func alreadyAnsweredMessage(answer dal.CallbackAnswer) string {
+	switch answer {
+	case dal.CallbackGuessed:
+		return "Already answered " + answerMark(true)
+	case dal.CallbackMissed:
+		return "Already answered " + answerMark(false)
+	default:
+		return "Already answered"
+	}
+}
+
+// endOfDay is the midnight that ends now's day.
+func endOfDay(now time.Time) time.Time {
+	return time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
+}
+
 func parseCallbackData(val string) (callbackData, error) {
 	val = strings.TrimSpace(val)
 	parts := strings.Split(val, ":")
@@ -0,0 +1,45 @@
+package telegram
+
+import (
+	"fmt"
+	"strings"
+
+	tb "gopkg.in/telebot.v3"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+)
+
+var cardsUsage = fmt.Sprintf("Usage: %s [%s | %s | %s]", commandCards, dal.CardClassic, dal.CardCloze, dal.CardMixed)
+
+//nolint:gochecknoglobals // read-only lookup table
+var cardTypeDescriptions = map[dal.CardType]string{
+	dal.CardClassic: "the word itself",
+	dal.CardCloze:   "a sentence with the word blanked out, when its description has one",
+	dal.CardMixed:   "sometimes the word, sometimes a sentence with it blanked out",
+}
+
+// HandleCards shows or changes the kind of card the chat's word checks are sent as.
+func (b *Bot) HandleCards(m tb.Context) error {
+	ctx, cancel := processCtx()
+	defer cancel()
+
+	payload := strings.ToLower(strings.TrimSpace(m.Message().Payload))
+	if payload == "" {
+		cardType, err := b.repo.GetCardType(ctx, m.Chat().ID)
+		if err != nil {
+			b.log.ErrorContext(ctx, "failed to get card type", "error", err)
+			return m.Reply(somethingWentWrongMsg)
+		}
+		return m.Reply(fmt.Sprintf("Word checks show %s (%s). %s", cardTypeDescriptions[cardType], cardType, cardsUsage))
+	}
+
+	cardType := dal.CardType(payload)
+	if _, ok := cardTypeDescriptions[cardType]; !ok {
+		return m.Reply(cardsUsage)
+	}
+	if err := b.repo.SetCardType(ctx, m.Chat().ID, cardType); err != nil {
+		b.log.ErrorContext(ctx, "failed to set card type", "error", err)
+		return m.Reply(somethingWentWrongMsg)
+	}
+	return m.Reply(fmt.Sprintf("🃏 Word checks will show %s", cardTypeDescriptions[cardType]))
+}
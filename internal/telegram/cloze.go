@@ -0,0 +1,340 @@
+package telegram
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// clozeBlank replaces the target word in a cloze card.
+const clozeBlank = "___"
+
+type (
+	// cloze is a fill-in-the-blank card: Masked is shown first, Sentence once the user asks for the
+	// answer.
+	cloze struct {
+		Sentence string
+		Masked   string
+	}
+
+	// token is one word of a sentence, with the byte range it occupies in it so that the match can
+	// be cut out without disturbing the punctuation and spacing around it.
+	token struct {
+		text       string
+		start, end int
+	}
+)
+
+// irregularForms covers the common words - mostly verbs - whose inflections the suffix rules in
+// inflections cannot produce. It does not need to be exhaustive: a word it misses simply gets no cloze card and falls
+// back to the classic one.
+//
+//nolint:gochecknoglobals // read-only lookup table
+var irregularForms = map[string][]string{
+	"be":         {"am", "is", "are", "was", "were", "been", "being"},
+	"have":       {"has", "had", "having"},
+	"do":         {"does", "did", "done", "doing"},
+	"go":         {"goes", "went", "gone", "going"},
+	"say":        {"said"},
+	"make":       {"made"},
+	"take":       {"took", "taken"},
+	"come":       {"came"},
+	"see":        {"saw", "seen"},
+	"know":       {"knew", "known"},
+	"get":        {"got", "gotten"},
+	"give":       {"gave", "given"},
+	"find":       {"found"},
+	"think":      {"thought"},
+	"tell":       {"told"},
+	"become":     {"became"},
+	"leave":      {"left"},
+	"feel":       {"felt"},
+	"bring":      {"brought"},
+	"begin":      {"began", "begun"},
+	"keep":       {"kept"},
+	"hold":       {"held"},
+	"write":      {"wrote", "written"},
+	"stand":      {"stood"},
+	"hear":       {"heard"},
+	"mean":       {"meant"},
+	"meet":       {"met"},
+	"run":        {"ran"},
+	"pay":        {"paid"},
+	"sit":        {"sat"},
+	"speak":      {"spoke", "spoken"},
+	"lie":        {"lay", "lain", "lying"},
+	"lead":       {"led"},
+	"grow":       {"grew", "grown"},
+	"lose":       {"lost"},
+	"fall":       {"fell", "fallen"},
+	"send":       {"sent"},
+	"build":      {"built"},
+	"understand": {"understood"},
+	"draw":       {"drew", "drawn"},
+	"break":      {"broke", "broken"},
+	"spend":      {"spent"},
+	"rise":       {"rose", "risen"},
+	"drive":      {"drove", "driven"},
+	"buy":        {"bought"},
+	"wear":       {"wore", "worn"},
+	"choose":     {"chose", "chosen"},
+	"seek":       {"sought"},
+	"throw":      {"threw", "thrown"},
+	"catch":      {"caught"},
+	"teach":      {"taught"},
+	"fight":      {"fought"},
+	"forget":     {"forgot", "forgotten"},
+	"forgive":    {"forgave", "forgiven"},
+	"hide":       {"hid", "hidden"},
+	"shake":      {"shook", "shaken"},
+	"swear":      {"swore", "sworn"},
+	"bear":       {"bore", "borne"},
+	"tear":       {"tore", "torn"},
+	"good":       {"better", "best"},
+	"bad":        {"worse", "worst"},
+	"child":      {"children"},
+	"man":        {"men"},
+	"woman":      {"women"},
+	"foot":       {"feet"},
+	"tooth":      {"teeth"},
+	"mouse":      {"mice"},
+}
+
+// findCloze looks through text for a sentence that uses word, and blanks the word out of it.
+//
+// The word is matched case-insensitively and in any of its regular inflections (plurals, -ed, -ing,
+// comparatives, -ly adverbs, doubled final consonants, dropped final e, y → i) plus the irregular
+// forms of common verbs, so "anxious" finds "She was anxious", "stop" finds "He stopped", and
+// "give up" finds "They gave up". Multi-word entries may be inflected on their first or last word.
+//
+// Only the first sentence that uses the word is considered, which keeps the card stable: the reveal
+// callback regenerates it from the same text instead of storing it. A sentence made of the word
+// alone is no cloze at all, so ok is false unless something is left around the blank.
+func findCloze(word, text string) (cloze, bool) {
+	target := strings.Fields(strings.ToLower(word))
+	if len(target) > 1 && target[0] == "to" {
+		target = target[1:]
+	}
+	if len(target) == 0 || strings.TrimSpace(text) == "" {
+		return cloze{}, false
+	}
+
+	first := inflections(target[0])
+	last := first
+	if len(target) > 1 {
+		last = inflections(target[len(target)-1])
+	}
+
+	for _, sentence := range splitSentences(text) {
+		tokens := tokenize(sentence)
+		for i := 0; i+len(target) <= len(tokens); i++ {
+			if !matchesAt(tokens[i:i+len(target)], target, first, last) {
+				continue
+			}
+			if len(tokens) == len(target) {
+				break
+			}
+
+			start, end := tokens[i].start, tokens[i+len(target)-1].end
+			return cloze{
+				Sentence: sentence,
+				Masked:   sentence[:start] + clozeBlank + sentence[end:],
+			}, true
+		}
+	}
+
+	return cloze{}, false
+}
+
+// matchesAt reports whether tokens spell target, allowing the first and last words to appear in any
+// of the given inflected forms. For a single-word target first and last are the same set.
+func matchesAt(tokens []token, target []string, first, last map[string]struct{}) bool {
+	for j, want := range target {
+		got := strings.ToLower(tokens[j].text)
+		switch {
+		case got == want:
+		case j == 0 && inSet(first, got):
+		case j == len(target)-1 && inSet(last, got):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func inSet(set map[string]struct{}, s string) bool {
+	_, ok := set[s]
+	return ok
+}
+
+// inflections returns w together with the forms English grammar regularly derives from it. It
+// over-generates on purpose ("visit" also yields "visitted"): a form nobody writes can never match,
+// while a missing one costs a card.
+func inflections(w string) map[string]struct{} {
+	forms := map[string]struct{}{w: {}}
+	add := func(s ...string) {
+		for _, f := range s {
+			forms[f] = struct{}{}
+		}
+	}
+
+	add(irregularForms[w]...)
+
+	n := len(w)
+	if n < 2 { //nolint:mnd // one-letter words have no inflections worth guessing
+		return forms
+	}
+
+	last := rune(w[n-1])
+	stemY := endsWithConsonantY(w)
+
+	// plural and third person singular
+	add(w + "s")
+	if hasAnySuffix(w, "s", "x", "z", "ch", "sh", "o") {
+		add(w + "es")
+	}
+	if stemY {
+		add(w[:n-1] + "ies")
+	}
+	if strings.HasSuffix(w, "f") {
+		add(w[:n-1] + "ves")
+	}
+	if strings.HasSuffix(w, "fe") {
+		add(w[:n-2] + "ves")
+	}
+
+	// past tense and participles, comparatives
+	for _, suffix := range []string{"ed", "er", "est"} {
+		switch {
+		case last == 'e':
+			add(w + suffix[1:])
+		case stemY:
+			add(w[:n-1] + "i" + suffix)
+		default:
+			add(w + suffix)
+		}
+		if endsWithCVC(w) {
+			add(w + string(last) + suffix)
+		}
+	}
+
+	// present participle
+	switch {
+	case strings.HasSuffix(w, "ie"):
+		add(w[:n-2] + "ying")
+	case last == 'e' && !strings.HasSuffix(w, "ee"):
+		add(w[:n-1] + "ing")
+	}
+	add(w + "ing")
+	if endsWithCVC(w) {
+		add(w + string(last) + "ing")
+	}
+
+	// adverbs
+	switch {
+	case stemY:
+		add(w[:n-1] + "ily")
+	case strings.HasSuffix(w, "le"):
+		add(w[:n-1] + "y")
+	case strings.HasSuffix(w, "ic"):
+		add(w + "ally")
+	}
+	add(w + "ly")
+
+	return forms
+}
+
+func hasAnySuffix(s string, suffixes ...string) bool {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(s, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func isVowel(r rune) bool {
+	return strings.ContainsRune("aeiou", r)
+}
+
+func endsWithConsonantY(w string) bool {
+	n := len(w)
+	return n >= 2 && w[n-1] == 'y' && !isVowel(rune(w[n-2]))
+}
+
+// endsWithCVC reports whether w ends consonant-vowel-consonant, the shape whose final consonant is
+// doubled before a vowel suffix (stop → stopped, big → bigger).
+func endsWithCVC(w string) bool {
+	n := len(w)
+	if n < 3 { //nolint:mnd // three letters make the pattern
+		return false
+	}
+	c1, v, c2 := rune(w[n-3]), rune(w[n-2]), rune(w[n-1])
+	return !isVowel(c1) && isVowel(v) && !isVowel(c2) && !strings.ContainsRune("wxy", c2)
+}
+
+// splitSentences breaks text at sentence-ending punctuation and line breaks, trimming the pieces and
+// dropping the empty ones. The punctuation stays with the sentence it ends.
+func splitSentences(text string) []string {
+	var (
+		res   []string
+		start int
+	)
+	flush := func(end int) {
+		if s := strings.TrimSpace(text[start:end]); s != "" {
+			res = append(res, s)
+		}
+		start = end
+	}
+
+	for i, r := range text {
+		switch r {
+		case '\n':
+			flush(i)
+		case '.', '!', '?':
+			next := i + utf8.RuneLen(r)
+			if next >= len(text) || text[next] == ' ' || text[next] == '\n' {
+				flush(next)
+			}
+		}
+	}
+	flush(len(text))
+
+	return res
+}
+
+// tokenize splits a sentence into words. Apostrophes and hyphens inside a word belong to it, so
+// "don't" and "well-known" are one token each.
+func tokenize(sentence string) []token {
+	var (
+		res   []token
+		start = -1
+	)
+	isWordRune := func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsDigit(r)
+	}
+
+	for i, r := range sentence {
+		switch {
+		case isWordRune(r):
+			if start < 0 {
+				start = i
+			}
+		case start >= 0 && (r == '\'' || r == '’' || r == '-'):
+			next := i + utf8.RuneLen(r)
+			if nr, _ := utf8.DecodeRuneInString(sentence[next:]); next < len(sentence) && isWordRune(nr) {
+				continue
+			}
+			res = append(res, token{text: sentence[start:i], start: start, end: i})
+			start = -1
+		case start >= 0:
+			res = append(res, token{text: sentence[start:i], start: start, end: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		res = append(res, token{text: sentence[start:], start: start, end: len(sentence)})
+	}
+
+	return res
+}
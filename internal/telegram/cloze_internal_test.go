@@ -0,0 +1,138 @@
+// findCloze is unexported and pure, so it is tested directly rather than through a telebot context.
+
+package telegram
+
+import (
+	"testing"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+)
+
+func TestFindCloze(t *testing.T) {
+	tests := []struct {
+		name       string
+		word, text string
+		wantOK     bool
+		wantMasked string
+		wantFull   string
+	}{
+		{
+			name:       "exact match",
+			word:       "anxious",
+			text:       "worried. She was anxious about the outcome.",
+			wantOK:     true,
+			wantMasked: "She was ___ about the outcome.",
+			wantFull:   "She was anxious about the outcome.",
+		},
+		{
+			name:       "case insensitive",
+			word:       "Perfunctory",
+			text:       "Perfunctory checks missed the leak.",
+			wantOK:     true,
+			wantMasked: "___ checks missed the leak.",
+		},
+		{
+			name:       "doubled consonant past tense",
+			word:       "stop",
+			text:       "He stopped at the door.",
+			wantOK:     true,
+			wantMasked: "He ___ at the door.",
+		},
+		{
+			name:       "dropped e before -ing",
+			word:       "make",
+			text:       "They are making progress!",
+			wantOK:     true,
+			wantMasked: "They are ___ progress!",
+		},
+		{
+			name:       "y to i plural",
+			word:       "city",
+			text:       "Big cities never sleep.",
+			wantOK:     true,
+			wantMasked: "Big ___ never sleep.",
+		},
+		{
+			name:       "irregular verb",
+			word:       "to give up",
+			text:       "They gave up too early.",
+			wantOK:     true,
+			wantMasked: "They ___ too early.",
+		},
+		{
+			name:       "adverb from -le adjective",
+			word:       "gentle",
+			text:       "Close the lid gently.",
+			wantOK:     true,
+			wantMasked: "Close the lid ___.",
+		},
+		{
+			name:       "first sentence that uses the word wins",
+			word:       "run",
+			text:       "I ran home.\nShe runs daily.",
+			wantOK:     true,
+			wantMasked: "I ___ home.",
+		},
+		{
+			name:   "substring of another word is not a match",
+			word:   "cat",
+			text:   "The category was wrong.",
+			wantOK: false,
+		},
+		{
+			name:   "description without the word",
+			word:   "apple",
+			text:   "a round fruit",
+			wantOK: false,
+		},
+		{
+			name:   "a sentence of just the word is no cloze",
+			word:   "apple",
+			text:   "Apple.",
+			wantOK: false,
+		},
+		{
+			name:   "empty description",
+			word:   "apple",
+			text:   "",
+			wantOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := findCloze(tt.word, tt.text)
+			if ok != tt.wantOK {
+				t.Fatalf("findCloze(%q, %q) ok = %v, want %v (%+v)", tt.word, tt.text, ok, tt.wantOK, got)
+			}
+			if !ok {
+				return
+			}
+			if got.Masked != tt.wantMasked {
+				t.Errorf("Masked = %q, want %q", got.Masked, tt.wantMasked)
+			}
+			if tt.wantFull != "" && got.Sentence != tt.wantFull {
+				t.Errorf("Sentence = %q, want %q", got.Sentence, tt.wantFull)
+			}
+		})
+	}
+}
+
+func TestClozeShare(t *testing.T) {
+	tests := []struct {
+		cardType dal.CardType
+		want     int
+	}{
+		{cardType: dal.CardClassic, want: 0},
+		{cardType: dal.CardCloze, want: 100},
+		{cardType: dal.CardMixed, want: 30},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.cardType), func(t *testing.T) {
+			if got := clozeShare(tt.cardType, 30); got != tt.want {
+				t.Errorf("clozeShare(%q, 30) = %d, want %d", tt.cardType, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package telegram
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tb "gopkg.in/telebot.v3"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+)
+
+const deckUsage = "Usage: " + commandDeck + " [new NAME | delete ID | add ID word, word | remove ID word, word | " +
+	"subscribe ID | unsubscribe ID]"
+
+// HandleDeck lists the team's decks or manages them: "/deck new NAME" and "/deck add ID apple, pear"
+// for owners, "/deck subscribe ID" for everybody else. A group can only subscribe, which is how its
+// words are filled: it has no vocabulary of its own to publish.
+func (b *Bot) HandleDeck(m tb.Context) error {
+	ctx, cancel := processCtx()
+	defer cancel()
+
+	chatID := m.Chat().ID
+	args := m.Args()
+	if len(args) > 0 && b.isGroup(chatID) && args[0] != "subscribe" && args[0] != "unsubscribe" {
+		return m.Reply("A group can only " + commandDeck + " subscribe ID or unsubscribe ID; manage decks in a private chat with me")
+	}
+	if len(args) == 0 {
+		decks, err := b.repo.FindDecks(ctx, chatID, b.teamChatIDs)
+		if err != nil {
+			b.log.ErrorContext(ctx, "failed to find decks", "error", err)
+			return m.Reply(somethingWentWrongMsg)
+		}
+		return m.Reply(decksMessage(chatID, decks))
+	}
+
+	if args[0] == "new" {
+		name := strings.TrimSpace(strings.TrimPrefix(m.Message().Payload, args[0]))
+		if name == "" {
+			return m.Reply(deckUsage)
+		}
+		deck, err := b.repo.CreateDeck(ctx, chatID, name)
+		if err != nil {
+			if errors.Is(err, dal.ErrAlreadyExists) {
+				return m.Reply("You already have a deck with this name")
+			}
+			b.log.ErrorContext(ctx, "failed to create deck", "error", err)
+			return m.Reply(somethingWentWrongMsg)
+		}
+		return m.Reply(fmt.Sprintf("Deck #%d created. Add words with %s add %d word, word", deck.ID, commandDeck, deck.ID))
+	}
+
+	if len(args) < 2 { //nolint:mnd // the action and the deck ID
+		return m.Reply(deckUsage)
+	}
+	deckID, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return m.Reply(deckUsage)
+	}
+
+	var msg string
+	switch args[0] {
+	case "delete":
+		err = b.repo.DeleteDeck(ctx, chatID, deckID)
+		msg = "Deck deleted. Its subscribers keep the words"
+	case "add", "remove":
+		words := deckWords(m.Message().Payload)
+		if len(words) == 0 {
+			return m.Reply(deckUsage)
+		}
+		if args[0] == "remove" {
+			err = b.repo.RemoveDeckWords(ctx, chatID, deckID, words)
+			msg = "Words removed from the deck"
+			break
+		}
+		var added int
+		added, err = b.repo.AddDeckWords(ctx, chatID, deckID, words)
+		msg = fmt.Sprintf("Added %s of %d. Only words you already have can go into a deck", pluralize(added, "word"), len(words))
+	case "subscribe":
+		var copied int
+		copied, err = b.repo.SubscribeDeck(ctx, chatID, deckID, b.teamChatIDs)
+		msg = fmt.Sprintf("Subscribed, %s copied to your vocabulary", pluralize(copied, "word"))
+	case "unsubscribe":
+		err = b.repo.UnsubscribeDeck(ctx, chatID, deckID)
+		msg = "Unsubscribed. The words you got stay yours"
+	default:
+		return m.Reply(deckUsage)
+	}
+
+	switch {
+	case errors.Is(err, dal.ErrNotFound):
+		return m.Reply("No such deck")
+	case errors.Is(err, dal.ErrOwnDeck):
+		return m.Reply("This is your own deck")
+	case err != nil:
+		b.log.ErrorContext(ctx, "failed to update deck", "action", args[0], "error", err)
+		return m.Reply(somethingWentWrongMsg)
+	}
+	return m.Reply(msg)
+}
+
+// deckWords reads the comma-separated words after "ACTION ID" in the command payload, so words may
+// contain spaces.
+func deckWords(payload string) []string {
+	// The action, the ID and the words.
+	const parts = 3
+	fields := strings.SplitN(strings.TrimSpace(payload), " ", parts)
+	if len(fields) < parts {
+		return nil
+	}
+
+	var res []string
+	for _, w := range strings.Split(fields[2], ",") {
+		if w = strings.TrimSpace(w); w != "" {
+			res = append(res, w)
+		}
+	}
+	return res
+}
+
+func decksMessage(chatID int64, decks []dal.Deck) string {
+	if len(decks) == 0 {
+		return "No decks yet. Create one with " + commandDeck + " new NAME"
+	}
+
+	lines := []string{"📚 Decks:"}
+	for _, d := range decks {
+		var mark string
+		switch {
+		case d.OwnerChatID == chatID:
+			mark = fmt.Sprintf(" — yours, %s", pluralize(d.Subscribers, "subscriber"))
+		case d.Subscribed:
+			mark = " ✅"
+		}
+		lines = append(lines, fmt.Sprintf("#%d %s: %s%s", d.ID, d.Name, pluralize(d.Words, "word"), mark))
+	}
+	lines = append(lines, "", "Subscribe with "+commandDeck+" subscribe ID")
+	return strings.Join(lines, "\n")
+}
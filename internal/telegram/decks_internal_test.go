@@ -0,0 +1,44 @@
+package telegram
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+)
+
+func TestDeckWords(t *testing.T) {
+	tests := []struct {
+		payload string
+		want    []string
+	}{
+		{payload: "add 3 apple, pear tree ,, plum", want: []string{"apple", "pear tree", "plum"}},
+		{payload: "remove 3 apple", want: []string{"apple"}},
+		{payload: "add 3", want: nil},
+		{payload: "add 3 , ", want: nil},
+	}
+
+	for _, tt := range tests {
+		if got := deckWords(tt.payload); !slices.Equal(got, tt.want) {
+			t.Errorf("deckWords(%q) = %q, want %q", tt.payload, got, tt.want)
+		}
+	}
+}
+
+func TestDecksMessage(t *testing.T) {
+	decks := []dal.Deck{
+		{ID: 1, OwnerChatID: 42, Name: "Fruit", Words: 12, Subscribers: 1},
+		{ID: 2, OwnerChatID: 43, Name: "Phrasal verbs", Words: 1, Subscribed: true},
+		{ID: 3, OwnerChatID: 43, Name: "Idioms", Words: 0},
+	}
+
+	want := "📚 Decks:\n" +
+		"#1 Fruit: 12 words — yours, 1 subscriber\n" +
+		"#2 Phrasal verbs: 1 word ✅\n" +
+		"#3 Idioms: 0 words\n" +
+		"\nSubscribe with /deck subscribe ID"
+
+	if got := decksMessage(42, decks); got != want {
+		t.Errorf("decksMessage() =\n%s\n\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,195 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+	tb "gopkg.in/telebot.v3"
+)
+
+type DigestPeriod string
+
+const (
+	DigestWeekly  DigestPeriod = "weekly"
+	DigestMonthly DigestPeriod = "monthly"
+
+	// digestHardestLimit and digestGraduatedLimit keep a busy period's digest readable on a phone.
+	digestHardestLimit   = 5
+	digestGraduatedLimit = 10
+)
+
+type (
+	// digestWindow is the period a digest reports on and the one it is compared against. Both ends are
+	// inclusive days.
+	digestWindow struct {
+		From, To         time.Time
+		PrevFrom, PrevTo time.Time
+	}
+
+	periodTotals struct {
+		Answers int
+		Guessed int
+		Learned int
+	}
+
+	digestReport struct {
+		Period    DigestPeriod
+		Window    digestWindow
+		Current   periodTotals
+		Previous  periodTotals
+		Hardest   []dal.WordMisses
+		Graduated []string
+		Total     *dal.TotalStats
+	}
+)
+
+// windowFor returns the days a digest sent at now covers. A weekly digest covers the seven days
+// ending today and compares them with the seven before; a monthly one covers the month so far and
+// compares it with the whole previous month.
+func windowFor(period DigestPeriod, now time.Time) digestWindow {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if period == DigestMonthly {
+		from := today.AddDate(0, 0, 1-today.Day())
+		return digestWindow{
+			From:     from,
+			To:       today,
+			PrevFrom: from.AddDate(0, -1, 0),
+			PrevTo:   from.AddDate(0, 0, -1),
+		}
+	}
+
+	from := today.AddDate(0, 0, -6) //nolint:mnd // a week back, today included
+	return digestWindow{
+		From:     from,
+		To:       today,
+		PrevFrom: from.AddDate(0, 0, -7), //nolint:mnd // the week before
+		PrevTo:   from.AddDate(0, 0, -1),
+	}
+}
+
+// SendDigest sends the chat a summary of the period ending at now, compared with the period before
+// it. A chat that answered nothing in either period gets nothing: there is no progress to report and
+// a digest of zeros only nags.
+func (b *Bot) SendDigest(ctx context.Context, chatID int64, period DigestPeriod, now time.Time) error {
+	report := digestReport{Period: period, Window: windowFor(period, now)}
+	w := report.Window
+
+	var err error
+	if report.Current, err = b.periodTotals(ctx, chatID, w.From, w.To); err != nil {
+		return fmt.Errorf("get current period: %w", err)
+	}
+	if report.Previous, err = b.periodTotals(ctx, chatID, w.PrevFrom, w.PrevTo); err != nil {
+		return fmt.Errorf("get previous period: %w", err)
+	}
+	if report.Current.Answers == 0 && report.Previous.Answers == 0 {
+		return nil
+	}
+
+	summary, err := b.repo.GetAnswerSummary(ctx, chatID, w.From, w.To, digestHardestLimit)
+	if err != nil {
+		return fmt.Errorf("get answer summary: %w", err)
+	}
+	report.Hardest = summary.Hardest
+	report.Graduated = summary.Graduated
+
+	if report.Total, err = b.repo.GetTotalStats(ctx, chatID); err != nil {
+		return fmt.Errorf("get total stats: %w", err)
+	}
+
+	_, err = b.bot.Send(tb.ChatID(chatID), digestMessage(report), tb.ModeMarkdownV2, tb.Silent)
+	return err //nolint:wrapcheck // lets ignore it here
+}
+
+// periodTotals sums statistics over the period and counts the words that graduated in it.
+func (b *Bot) periodTotals(ctx context.Context, chatID int64, from, to time.Time) (periodTotals, error) {
+	var res periodTotals
+
+	stats, err := b.repo.GetStatsRange(ctx, chatID, from, to)
+	if err != nil {
+		return res, fmt.Errorf("get stats range: %w", err)
+	}
+	for _, s := range stats {
+		res.Answers += s.WordsGuessed + s.WordsMissed
+		res.Guessed += s.WordsGuessed
+	}
+
+	summary, err := b.repo.GetAnswerSummary(ctx, chatID, from, to, 0)
+	if err != nil {
+		return res, fmt.Errorf("get answer summary: %w", err)
+	}
+	res.Learned = len(summary.Graduated)
+
+	return res, nil
+}
+
+func (t periodTotals) accuracy() int {
+	if t.Answers == 0 {
+		return 0
+	}
+	return t.Guessed * 100 / t.Answers //nolint:mnd // percent
+}
+
+// digestMessage renders a digest in MarkdownV2. Everything that comes from data - words, numbers,
+// dates - is escaped, so the only markup in the message is the one added here.
+func digestMessage(r digestReport) string {
+	title, prevName := "Weekly digest", "last week"
+	if r.Period == DigestMonthly {
+		title, prevName = "Monthly digest", "last month"
+	}
+
+	lines := []string{
+		"*📊 " + escapeMarkdownV2(title) + "*",
+		"_" + escapeMarkdownV2(r.Window.From.Format("2 Jan")+" – "+r.Window.To.Format("2 Jan 2006")) + "_",
+		"",
+		escapeMarkdownV2(fmt.Sprintf("Answers: %d (%s)", r.Current.Answers,
+			compare(r.Current.Answers-r.Previous.Answers, "", prevName))),
+	}
+
+	accuracy := fmt.Sprintf("Accuracy: %d%%", r.Current.accuracy())
+	if r.Current.Answers > 0 && r.Previous.Answers > 0 {
+		accuracy += fmt.Sprintf(" (%s)", compare(r.Current.accuracy()-r.Previous.accuracy(), " pp", prevName))
+	}
+	lines = append(lines, escapeMarkdownV2(accuracy))
+	lines = append(lines, escapeMarkdownV2(fmt.Sprintf("Words learned: %d (%s)", r.Current.Learned,
+		compare(r.Current.Learned-r.Previous.Learned, "", prevName))))
+
+	if len(r.Hardest) > 0 {
+		lines = append(lines, "", "*"+escapeMarkdownV2("Hardest words")+"*")
+		for _, wm := range r.Hardest {
+			lines = append(lines, escapeMarkdownV2("• ")+"*"+escapeMarkdownV2(wm.Word)+"*"+
+				escapeMarkdownV2(" — missed "+pluralize(wm.Misses, "time")))
+		}
+	}
+
+	if len(r.Graduated) > 0 {
+		shown := r.Graduated
+		more := ""
+		if len(shown) > digestGraduatedLimit {
+			more = fmt.Sprintf(" and %d more", len(shown)-digestGraduatedLimit)
+			shown = shown[:digestGraduatedLimit]
+		}
+		lines = append(lines, "", "*"+escapeMarkdownV2("Graduated 🎓")+"*",
+			escapeMarkdownV2(strings.Join(shown, ", ")+more))
+	}
+
+	if r.Total != nil {
+		lines = append(lines, "", escapeMarkdownV2(fmt.Sprintf("Overall: %d of %d words learned", r.Total.Learned, r.Total.Total)))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// compare describes a change against the previous period, e.g. "▲ +12 vs last week".
+func compare(delta int, unit, prevName string) string {
+	switch {
+	case delta > 0:
+		return fmt.Sprintf("▲ +%d%s vs %s", delta, unit, prevName)
+	case delta < 0:
+		return fmt.Sprintf("▼ %d%s vs %s", delta, unit, prevName)
+	default:
+		return "same as " + prevName
+	}
+}
@@ -0,0 +1,192 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	tb "gopkg.in/telebot.v3"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+)
+
+const (
+	// groupRoundPrefix marks a group round, so members know everybody is being asked.
+	groupRoundPrefix = "👥 "
+	// alertMaxLength is the most text Telegram shows in a callback alert.
+	alertMaxLength = 200
+
+	privateOnlyMsg = "This command only works in a private chat with me"
+)
+
+func (b *Bot) isGroup(chatID int64) bool {
+	return slices.Contains(b.groupChatIDs, chatID)
+}
+
+// privateOnly refuses a command anywhere but a private chat. It wraps the handlers that act on one
+// learner's words, settings, standing or sign-ins: run in a group, they would act on the group's chat
+// ID on behalf of whichever member sent them.
+func (b *Bot) privateOnly(next tb.HandlerFunc) tb.HandlerFunc {
+	return func(m tb.Context) error {
+		if m.Chat().Type != tb.ChatPrivate {
+			return m.Reply(privateOnlyMsg)
+		}
+		return next(m)
+	}
+}
+
+// sendGroupRound quizzes a whole group chat on one word. Every member reveals the translation
+// privately, as an alert only they can see, and then grades themselves on the same message, which
+// keeps a live scoreboard of who answered the round and how. The answer is attributed to the sender
+// rather than the chat, so each member's streak is their own.
+func (b *Bot) sendGroupRound(ctx context.Context, chatID int64, replier replier) error {
+	wt, err := b.repo.FindGroupWord(ctx, chatID)
+	if err != nil {
+		if errors.Is(err, dal.ErrNotFound) {
+			b.log.DebugContext(ctx, "no words to check", "chatID", chatID)
+			return replier.Reply("no words to check") //nolint:wrapcheck // lets ignore it here
+		}
+
+		b.log.ErrorContext(ctx, "failed to get group word", "error", err)
+		return replier.Reply(somethingWentWrongMsg) //nolint:wrapcheck // lets ignore it here
+	}
+
+	callbackID, err := b.repo.InsertCallback(ctx, dal.CallbackData{
+		ChatID:    chatID,
+		Word:      wt.Word,
+		ExpiresAt: time.Now().Add(callbackDataExpirationTime),
+	})
+	if err != nil {
+		b.log.ErrorContext(ctx, "failed to insert callback data", "error", err)
+		return fmt.Errorf("insert callback data: %w", err)
+	}
+
+	_, err = b.bot.Send(tb.ChatID(chatID), groupRoundMessage(wt.Word, nil), tb.Silent, groupRoundMarkup(callbackID))
+	return err //nolint:wrapcheck // lets ignore it here
+}
+
+func (b *Bot) handleGroupCallback(ctx context.Context, c tb.Context, action string, data *dal.CallbackData) error {
+	groupChatID := c.Chat().ID
+
+	if action == callbackGroupReveal {
+		wt, err := b.repo.FindWordTranslation(ctx, groupChatID, data.Word)
+		if err != nil {
+			b.log.ErrorContext(ctx, "failed to get word translation", "error", err)
+			return c.RespondText(somethingWentWrongMsg)
+		}
+		return c.Respond(&tb.CallbackResponse{Text: translationAlert(wt), ShowAlert: true})
+	}
+
+	sender := c.Sender()
+	streak, err := b.repo.RegisterGroupAnswer(ctx, groupChatID, data.ID, data.Word, dal.GroupAnswer{
+		UserID:  sender.ID,
+		Name:    memberName(sender),
+		Guessed: action == callbackGroupGuessed,
+	})
+	if err != nil {
+		if errors.Is(err, dal.ErrAlreadyExists) {
+			return c.RespondText("You have already answered this round")
+		}
+		b.log.ErrorContext(ctx, "failed to register group answer", "error", err)
+		return c.RespondText(somethingWentWrongMsg)
+	}
+
+	answers, err := b.repo.GetGroupRound(ctx, groupChatID, data.ID)
+	if err != nil {
+		b.log.ErrorContext(ctx, "failed to get group round", "error", err)
+		return c.RespondText(somethingWentWrongMsg)
+	}
+	if err = c.Edit(groupRoundMessage(data.Word, answers), groupRoundMarkup(data.ID)); err != nil {
+		// The answer is saved either way; only the scoreboard on the message is behind.
+		b.log.ErrorContext(ctx, "failed to update group round", "error", err)
+	}
+
+	return c.RespondText(fmt.Sprintf("Your streak: %d 🔥", streak))
+}
+
+func (b *Bot) replyGroupScoreboard(ctx context.Context, m tb.Context) error {
+	w := windowFor(DigestWeekly, time.Now())
+	scores, err := b.repo.GetGroupScoreboard(ctx, m.Chat().ID, w.From, w.To)
+	if err != nil {
+		b.log.ErrorContext(ctx, "failed to get group scoreboard", "error", err)
+		return m.Reply("failed to get stats")
+	}
+	return m.Reply(groupScoreboardMessage(w, scores))
+}
+
+// translationAlert is the translation and description of a word, cut down to what fits in an alert.
+func translationAlert(wt *dal.WordTranslation) string {
+	text := wt.Translation
+	if wt.Description != "" {
+		text += ": " + wt.Description
+	}
+	if runes := []rune(text); len(runes) > alertMaxLength {
+		text = string(runes[:alertMaxLength-1]) + "…"
+	}
+	return text
+}
+
+func memberName(u *tb.User) string {
+	name := strings.TrimSpace(u.FirstName + " " + u.LastName)
+	if name == "" {
+		name = u.Username
+	}
+	return name
+}
+
+// groupRoundMessage is the round's word followed by everybody who has answered it so far. It is sent
+// as plain text: member names are free-form and would need escaping everywhere in MarkdownV2.
+func groupRoundMessage(word string, answers []dal.GroupAnswer) string {
+	lines := []string{groupRoundPrefix + word}
+	if len(answers) > 0 {
+		lines = append(lines, "")
+	}
+	for _, a := range answers {
+		if a.Guessed {
+			lines = append(lines, fmt.Sprintf("%s ✅ %d 🔥", a.Name, a.StreakAfter))
+		} else {
+			lines = append(lines, a.Name+" ❌")
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func groupScoreboardMessage(w digestWindow, scores []dal.GroupMemberScore) string {
+	lines := []string{fmt.Sprintf("👥 Group scoreboard, %s – %s", w.From.Format("2 Jan"), w.To.Format("2 Jan"))}
+	if len(scores) == 0 {
+		return lines[0] + "\n\nNobody has answered yet."
+	}
+
+	lines = append(lines, "")
+	for i, s := range scores {
+		lines = append(lines, fmt.Sprintf("%d. %s — %s, %d%% right, %s learned",
+			i+1, s.Name, pluralize(s.Answers, "answer"), s.Accuracy(), pluralize(s.Learned, "word")))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func groupRoundMarkup(uuid string) *tb.ReplyMarkup {
+	return &tb.ReplyMarkup{
+		InlineKeyboard: [][]tb.InlineButton{
+			{
+				{
+					Text: "See translation",
+					Data: fmt.Sprintf("%s:%s", callbackGroupReveal, uuid),
+				},
+			},
+			{
+				{
+					Text: "[      ✅      ]",
+					Data: fmt.Sprintf("%s:%s", callbackGroupGuessed, uuid),
+				},
+				{
+					Text: "[      ❌      ]",
+					Data: fmt.Sprintf("%s:%s", callbackGroupMissed, uuid),
+				},
+			},
+		},
+	}
+}
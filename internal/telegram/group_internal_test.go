@@ -0,0 +1,142 @@
+package telegram
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	tb "gopkg.in/telebot.v3"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+)
+
+func TestGroupRoundMessage(t *testing.T) {
+	answers := []dal.GroupAnswer{
+		{UserID: 1, Name: "Alice", Guessed: true, StreakAfter: 3},
+		{UserID: 2, Name: "Bob"},
+	}
+
+	if got, want := groupRoundMessage("apple", nil), "👥 apple"; got != want {
+		t.Errorf("groupRoundMessage() before any answer = %q, want %q", got, want)
+	}
+	if got, want := groupRoundMessage("apple", answers), "👥 apple\n\nAlice ✅ 3 🔥\nBob ❌"; got != want {
+		t.Errorf("groupRoundMessage() =\n%s\n\nwant\n%s", got, want)
+	}
+}
+
+func TestGroupScoreboardMessage(t *testing.T) {
+	w := windowFor(DigestWeekly, time.Date(2026, 3, 15, 19, 0, 0, 0, time.UTC))
+	scores := []dal.GroupMemberScore{
+		{UserID: 2, Name: "Bob", Answers: 20, Guessed: 15, Learned: 1},
+		{UserID: 1, Name: "Alice", Answers: 1, Guessed: 1},
+	}
+
+	want := "👥 Group scoreboard, 9 Mar – 15 Mar\n\n" +
+		"1. Bob — 20 answers, 75% right, 1 word learned\n" +
+		"2. Alice — 1 answer, 100% right, 0 words learned"
+	if got := groupScoreboardMessage(w, scores); got != want {
+		t.Errorf("groupScoreboardMessage() =\n%s\n\nwant\n%s", got, want)
+	}
+}
+
+func TestTranslationAlertFitsAnAlert(t *testing.T) {
+	wt := &dal.WordTranslation{Translation: "яблуко", Description: strings.Repeat("я", 300)}
+
+	got := []rune(translationAlert(wt))
+	if len(got) != alertMaxLength || got[len(got)-1] != '…' {
+		t.Errorf("translationAlert() is %d runes ending in %q, want %d ending in an ellipsis",
+			len(got), got[len(got)-1], alertMaxLength)
+	}
+}
+
+func TestAllowedChatsChecksGroupMembership(t *testing.T) {
+	const (
+		privateChatID int64 = 42
+		groupChatID   int64 = -100
+		memberID      int64 = 1
+		strangerID    int64 = 2
+	)
+
+	lookups := 0
+	members := newMemberCache(func(c tb.Context) (bool, error) {
+		lookups++
+		return c.Sender().ID == memberID, nil
+	}, time.Minute)
+	handler := allowedChats([]int64{privateChatID}, []int64{groupChatID}, members)(func(tb.Context) error {
+		return nil
+	})
+
+	update := func(chatID, userID int64) tb.Context {
+		return (&tb.Bot{}).NewContext(tb.Update{Message: &tb.Message{
+			Chat:   &tb.Chat{ID: chatID},
+			Sender: &tb.User{ID: userID},
+		}})
+	}
+
+	if err := handler(update(privateChatID, privateChatID)); err != nil {
+		t.Errorf("allowed private chat rejected: %v", err)
+	}
+	if err := handler(update(43, 43)); err == nil {
+		t.Error("unknown private chat let through")
+	}
+	if err := handler(update(groupChatID, strangerID)); err == nil {
+		t.Error("somebody outside the group let through")
+	}
+	for range 2 {
+		if err := handler(update(groupChatID, memberID)); err != nil {
+			t.Errorf("group member rejected: %v", err)
+		}
+	}
+	if lookups != 2 {
+		t.Errorf("membership looked up %d times, want 2: once for the stranger, once for the member", lookups)
+	}
+
+	failing := allowedChats(nil, []int64{groupChatID}, newMemberCache(func(tb.Context) (bool, error) {
+		return false, errors.New("telegram is down")
+	}, time.Minute))(func(tb.Context) error { return nil })
+	if err := failing(update(groupChatID, memberID)); err == nil {
+		t.Error("failed membership lookup let the sender through")
+	}
+}
+
+// replyRecorder is a tb.Context of a chat that records the replies instead of sending them.
+type replyRecorder struct {
+	tb.Context
+
+	chat    *tb.Chat
+	replies []string
+}
+
+func (r *replyRecorder) Chat() *tb.Chat { return r.chat }
+
+func (r *replyRecorder) Reply(what any, _ ...any) error {
+	r.replies = append(r.replies, fmt.Sprint(what))
+	return nil
+}
+
+func TestPrivateOnly(t *testing.T) {
+	calls := 0
+	handler := (&Bot{}).privateOnly(func(tb.Context) error {
+		calls++
+		return nil
+	})
+
+	for _, chatType := range []tb.ChatType{tb.ChatGroup, tb.ChatSuperGroup, tb.ChatChannel} {
+		m := &replyRecorder{chat: &tb.Chat{ID: -100, Type: chatType}}
+		if err := handler(m); err != nil {
+			t.Fatalf("%s: %v", chatType, err)
+		}
+		if len(m.replies) != 1 || m.replies[0] != privateOnlyMsg {
+			t.Errorf("%s: replies = %v, want the command refused", chatType, m.replies)
+		}
+	}
+	if calls != 0 {
+		t.Fatalf("handler ran %d times outside a private chat", calls)
+	}
+
+	if err := handler(&replyRecorder{chat: &tb.Chat{ID: 42, Type: tb.ChatPrivate}}); err != nil || calls != 1 {
+		t.Errorf("private chat: err %v, %d calls; want the handler run", err, calls)
+	}
+}
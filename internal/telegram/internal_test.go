@@ -5,6 +5,7 @@ package telegram
 
 import (
 	"testing"
+	"time"
 
 	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
 )
@@ -41,6 +42,15 @@ func TestTotalStatsMessage(t *testing.T) {
 			},
 			want: "Overall Progress:\n1+: 4\nTotal: 6\nIn learning batch: 0\nWaiting in queue: 0",
 		},
+		{
+			name: "words marked to review point at the command",
+			stats: dal.TotalStats{
+				Learned: 1, Total: 2,
+				StreakLimit: 15, NearlyFrom: 10, ToReview: 2,
+			},
+			want: "Overall Progress:\n15+: 1\n10-14: 0\n1-9: 0\nTotal: 2\nIn learning batch: 0\nWaiting in queue: 0\n" +
+				"To review: 2, see /review",
+		},
 		{
 			name: "an empty vocabulary still labels the buckets",
 			stats: dal.TotalStats{
@@ -58,3 +68,110 @@ func TestTotalStatsMessage(t *testing.T) {
 		})
 	}
 }
+
+func TestStreakMessage(t *testing.T) {
+	tests := []struct {
+		name   string
+		streak dal.Streak
+		want   string
+	}{
+		{
+			name: "in progress",
+			streak: dal.Streak{
+				Days: 4, Freezes: 1, TodayAnswers: 7,
+				Goal: dal.DailyGoal{Answers: 20},
+			},
+			want: "Daily goal: 7/20 answers\nStreak: 4 days 🔥\nStreak freezes: 1 🧊",
+		},
+		{
+			name: "met, with a new words goal",
+			streak: dal.Streak{
+				Days: 1, TodayAnswers: 25, TodayNewWords: 3, GoalMet: true,
+				Goal: dal.DailyGoal{Answers: 20, NewWords: 3},
+			},
+			want: "Daily goal: 25/20 answers\nNew words: 3/3\nToday's goal is met ✅\nStreak: 1 day 🔥\nStreak freezes: 0 🧊",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := streakMessage(&tt.streak); got != tt.want {
+				t.Errorf("streakMessage() =\n%s\n\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+// Telegram rejects a MarkdownV2 message with any reserved character left unescaped, so a description
+// with a full stop used to make the answer fail to send.
+func TestEscapeMarkdownV2(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{in: "plain words", want: "plain words"},
+		{in: "e.g. a (small) thing!", want: "e\\.g\\. a \\(small\\) thing\\!"},
+		{in: "a_b*c[d]e~f`g>h#i+j-k=l|m{n}o", want: "a\\_b\\*c\\[d\\]e\\~f\\`g\\>h\\#i\\+j\\-k\\=l\\|m\\{n\\}o"},
+		{in: `back\slash`, want: `back\\slash`},
+		{in: "кириличний текст.", want: "кириличний текст\\."},
+	}
+
+	for _, tt := range tests {
+		if got := escapeMarkdownV2(tt.in); got != tt.want {
+			t.Errorf("escapeMarkdownV2(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestWindowFor(t *testing.T) {
+	sunday := time.Date(2026, 3, 15, 19, 0, 0, 0, time.UTC)
+	day := func(month time.Month, d int) time.Time {
+		return time.Date(2026, month, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	weekly := windowFor(DigestWeekly, sunday)
+	wantWeekly := digestWindow{From: day(3, 9), To: day(3, 15), PrevFrom: day(3, 2), PrevTo: day(3, 8)}
+	if weekly != wantWeekly {
+		t.Errorf("weekly window = %+v, want %+v", weekly, wantWeekly)
+	}
+
+	monthly := windowFor(DigestMonthly, time.Date(2026, 3, 31, 19, 0, 0, 0, time.UTC))
+	wantMonthly := digestWindow{From: day(3, 1), To: day(3, 31), PrevFrom: day(2, 1), PrevTo: day(2, 28)}
+	if monthly != wantMonthly {
+		t.Errorf("monthly window = %+v, want %+v", monthly, wantMonthly)
+	}
+}
+
+func TestDigestMessage(t *testing.T) {
+	report := digestReport{
+		Period:   DigestWeekly,
+		Window:   windowFor(DigestWeekly, time.Date(2026, 3, 15, 19, 0, 0, 0, time.UTC)),
+		Current:  periodTotals{Answers: 120, Guessed: 102, Learned: 2},
+		Previous: periodTotals{Answers: 100, Guessed: 90, Learned: 2},
+		Hardest: []dal.WordMisses{
+			{Word: "well-known", Misses: 3},
+			{Word: "cat", Misses: 1},
+		},
+		Graduated: []string{"apple", "e.g."},
+		Total:     &dal.TotalStats{Learned: 40, Total: 310},
+	}
+
+	want := "*📊 Weekly digest*\n" +
+		"_9 Mar – 15 Mar 2026_\n" +
+		"\n" +
+		"Answers: 120 \\(▲ \\+20 vs last week\\)\n" +
+		"Accuracy: 85% \\(▼ \\-5 pp vs last week\\)\n" +
+		"Words learned: 2 \\(same as last week\\)\n" +
+		"\n" +
+		"*Hardest words*\n" +
+		"• *well\\-known* — missed 3 times\n" +
+		"• *cat* — missed 1 time\n" +
+		"\n" +
+		"*Graduated 🎓*\n" +
+		"apple, e\\.g\\.\n" +
+		"\n" +
+		"Overall: 40 of 310 words learned"
+
+	if got := digestMessage(report); got != want {
+		t.Errorf("digestMessage() =\n%s\n\nwant\n%s", got, want)
+	}
+}
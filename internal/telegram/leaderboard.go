@@ -0,0 +1,240 @@
+package telegram
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	tb "gopkg.in/telebot.v3"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+)
+
+const (
+	// leaderboardTop is how many chats each ranking lists.
+	leaderboardTop = 5
+	// challengeDefaultDays is how long a challenge created without a duration runs, today included.
+	challengeDefaultDays = 7
+	// challengeMaxDays keeps a typo from creating a challenge that outlives everybody's interest.
+	challengeMaxDays = 92
+
+	anonymousName = "Anonymous learner"
+)
+
+func (b *Bot) HandleLeaderboard(m tb.Context) error {
+	ctx, cancel := processCtx()
+	defer cancel()
+
+	chatID := m.Chat().ID
+	if args := m.Args(); len(args) > 0 {
+		visibility := dal.LeaderboardVisibility(strings.ToLower(args[0]))
+		name := strings.TrimSpace(strings.Join(args[1:], " "))
+		if name == "" && m.Sender() != nil {
+			name = m.Sender().FirstName
+		}
+		if err := b.repo.SetLeaderboardVisibility(ctx, chatID, visibility, name); err != nil {
+			b.log.DebugContext(ctx, "failed to set leaderboard visibility", "error", err)
+			return m.Reply("Usage: " + commandLeaderboard + " public [name] | anonymous | hidden")
+		}
+		return m.Reply(visibilityMessage(visibility, name))
+	}
+
+	now := time.Now()
+	w := windowFor(DigestWeekly, now)
+	entries, err := b.repo.GetLeaderboard(ctx, b.teamChatIDs, w.From, w.To)
+	if err != nil {
+		b.log.ErrorContext(ctx, "failed to get leaderboard", "error", err)
+		return m.Reply(somethingWentWrongMsg)
+	}
+
+	challenges, err := b.repo.FindActiveChallenges(ctx, b.teamChatIDs, now)
+	if err != nil {
+		b.log.ErrorContext(ctx, "failed to find challenges", "error", err)
+		return m.Reply(somethingWentWrongMsg)
+	}
+
+	visibility, _, err := b.repo.GetLeaderboardVisibility(ctx, chatID)
+	if err != nil {
+		b.log.ErrorContext(ctx, "failed to get leaderboard visibility", "error", err)
+		return m.Reply(somethingWentWrongMsg)
+	}
+
+	msg := leaderboardMessage(chatID, w, entries, challenges)
+	if visibility == dal.LeaderboardHidden {
+		msg += "\n\nYou are not on the leaderboard. Join with " + commandLeaderboard + " public [name] or " +
+			commandLeaderboard + " anonymous."
+	}
+	return m.Reply(msg)
+}
+
+// HandleChallenge lists the active team challenges, creates one ("/challenge learned 30 [days]"), or
+// deletes one the chat created ("/challenge delete ID").
+func (b *Bot) HandleChallenge(m tb.Context) error {
+	ctx, cancel := processCtx()
+	defer cancel()
+
+	args := m.Args()
+	switch {
+	case len(args) == 0:
+		challenges, err := b.repo.FindActiveChallenges(ctx, b.teamChatIDs, time.Now())
+		if err != nil {
+			b.log.ErrorContext(ctx, "failed to find challenges", "error", err)
+			return m.Reply(somethingWentWrongMsg)
+		}
+		if len(challenges) == 0 {
+			return m.Reply("No active challenges. Start one with " + commandChallenge + " learned 30")
+		}
+		return m.Reply(challengesMessage(challenges))
+	case args[0] == "delete" && len(args) == 2: //nolint:mnd // "delete" and the ID
+		id, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return m.Reply("Usage: " + commandChallenge + " delete ID")
+		}
+		if err = b.repo.DeleteChallenge(ctx, m.Chat().ID, id); err != nil {
+			if errors.Is(err, dal.ErrNotFound) {
+				return m.Reply("No such challenge of yours")
+			}
+			b.log.ErrorContext(ctx, "failed to delete challenge", "error", err)
+			return m.Reply(somethingWentWrongMsg)
+		}
+		return m.Reply("Challenge deleted")
+	}
+
+	challenge, err := parseChallenge(args, time.Now())
+	if err != nil {
+		return m.Reply(err.Error() + "\nUsage: " + commandChallenge + " answers|guessed|new_words|learned TARGET [DAYS]")
+	}
+	challenge.CreatedBy = m.Chat().ID
+
+	created, err := b.repo.CreateChallenge(ctx, challenge)
+	if err != nil {
+		b.log.ErrorContext(ctx, "failed to create challenge", "error", err)
+		return m.Reply(somethingWentWrongMsg)
+	}
+	return m.Reply(fmt.Sprintf("🎯 Challenge #%d started: %s by %s", created.ID, created.Title, created.EndsOn.Format("2 Jan")))
+}
+
+// parseChallenge reads "METRIC TARGET [DAYS]" into a challenge that starts today.
+func parseChallenge(args []string, now time.Time) (dal.Challenge, error) {
+	if len(args) < 2 || len(args) > 3 {
+		return dal.Challenge{}, errors.New("wrong number of arguments")
+	}
+
+	metric := dal.ChallengeMetric(strings.ToLower(args[0]))
+	if challengeTitle(metric, 0) == "" {
+		return dal.Challenge{}, fmt.Errorf("unknown metric %q", args[0])
+	}
+	target, err := strconv.Atoi(args[1])
+	if err != nil || target <= 0 {
+		return dal.Challenge{}, fmt.Errorf("target %q must be a positive number", args[1])
+	}
+	days := challengeDefaultDays
+	if len(args) == 3 { //nolint:mnd // the optional duration
+		if days, err = strconv.Atoi(args[2]); err != nil || days <= 0 || days > challengeMaxDays {
+			return dal.Challenge{}, fmt.Errorf("days %q must be in range 1-%d", args[2], challengeMaxDays)
+		}
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	return dal.Challenge{
+		Title:    challengeTitle(metric, target),
+		Metric:   metric,
+		Target:   target,
+		StartsOn: today,
+		EndsOn:   today.AddDate(0, 0, days-1),
+	}, nil
+}
+
+// challengeTitle describes the goal of a challenge, or returns "" for an unknown metric.
+func challengeTitle(metric dal.ChallengeMetric, target int) string {
+	switch metric {
+	case dal.MetricAnswers:
+		return fmt.Sprintf("answer %d words", target)
+	case dal.MetricGuessed:
+		return fmt.Sprintf("get %d answers right", target)
+	case dal.MetricNewWords:
+		return fmt.Sprintf("add %d new words", target)
+	case dal.MetricLearned:
+		return fmt.Sprintf("learn %d words", target)
+	default:
+		return ""
+	}
+}
+
+func visibilityMessage(visibility dal.LeaderboardVisibility, name string) string {
+	switch visibility {
+	case dal.LeaderboardPublic:
+		return fmt.Sprintf("You are on the leaderboard as %q", name)
+	case dal.LeaderboardAnonymous:
+		return "You are on the leaderboard anonymously"
+	default:
+		return "You are no longer on the leaderboard"
+	}
+}
+
+// leaderboardMessage ranks the opted-in chats by answers, accuracy and words learned, marking the
+// reader's own row, and lists the team challenges.
+func leaderboardMessage(chatID int64, w digestWindow, entries []dal.LeaderboardEntry, challenges []dal.Challenge) string {
+	lines := []string{fmt.Sprintf("🏆 Leaderboard, %s – %s", w.From.Format("2 Jan"), w.To.Format("2 Jan"))}
+	if len(entries) == 0 {
+		lines = append(lines, "", "Nobody has joined yet.")
+	} else {
+		rankings := []struct {
+			title string
+			value func(dal.LeaderboardEntry) int
+			unit  string
+		}{
+			{"Answers", func(e dal.LeaderboardEntry) int { return e.Answers }, ""},
+			{"Accuracy", dal.LeaderboardEntry.Accuracy, "%"},
+			{"Words learned", func(e dal.LeaderboardEntry) int { return e.Learned }, ""},
+		}
+		for _, r := range rankings {
+			lines = append(lines, "", r.title+":")
+			for i, e := range rank(entries, r.value) {
+				name := e.Name
+				if e.Anonymous {
+					name = anonymousName
+				}
+				if e.ChatID == chatID {
+					name += " (you)"
+				}
+				lines = append(lines, fmt.Sprintf("%d. %s — %d%s", i+1, name, r.value(e), r.unit))
+			}
+		}
+	}
+
+	if len(challenges) > 0 {
+		lines = append(lines, "", challengesMessage(challenges))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// rank orders entries by value, highest first, and keeps the top leaderboardTop. Ties keep the
+// order the repository returned them in.
+func rank(entries []dal.LeaderboardEntry, value func(dal.LeaderboardEntry) int) []dal.LeaderboardEntry {
+	res := slices.Clone(entries)
+	slices.SortStableFunc(res, func(a, b dal.LeaderboardEntry) int {
+		return value(b) - value(a)
+	})
+	if len(res) > leaderboardTop {
+		res = res[:leaderboardTop]
+	}
+	return res
+}
+
+func challengesMessage(challenges []dal.Challenge) string {
+	lines := []string{"🎯 Team challenges:"}
+	for _, c := range challenges {
+		done := ""
+		if c.Progress >= c.Target {
+			done = " ✅"
+		}
+		lines = append(lines, fmt.Sprintf("#%d %s: %d/%d, until %s%s",
+			c.ID, c.Title, c.Progress, c.Target, c.EndsOn.Format("2 Jan"), done))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,60 @@
+package telegram
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+)
+
+func TestParseChallenge(t *testing.T) {
+	now := time.Date(2026, 3, 15, 19, 0, 0, 0, time.UTC)
+
+	got, err := parseChallenge([]string{"Learned", "30"}, now)
+	if err != nil {
+		t.Fatalf("parseChallenge: %v", err)
+	}
+	want := dal.Challenge{
+		Title:    "learn 30 words",
+		Metric:   dal.MetricLearned,
+		Target:   30,
+		StartsOn: time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC),
+		EndsOn:   time.Date(2026, 3, 21, 0, 0, 0, 0, time.UTC),
+	}
+	if got != want {
+		t.Errorf("parseChallenge() = %+v, want %+v", got, want)
+	}
+
+	for _, args := range [][]string{
+		{"learned"},
+		{"minutes", "30"},
+		{"learned", "-1"},
+		{"learned", "30", "0"},
+		{"learned", "30", "365"},
+	} {
+		if _, err = parseChallenge(args, now); err == nil {
+			t.Errorf("parseChallenge(%q) accepted invalid arguments", args)
+		}
+	}
+}
+
+func TestLeaderboardMessage(t *testing.T) {
+	w := windowFor(DigestWeekly, time.Date(2026, 3, 15, 19, 0, 0, 0, time.UTC))
+	entries := []dal.LeaderboardEntry{
+		{ChatID: 43, Anonymous: true, Answers: 20, Guessed: 15, Learned: 1},
+		{ChatID: 42, Name: "Roman", Answers: 10, Guessed: 9, Learned: 2},
+	}
+	challenges := []dal.Challenge{
+		{ID: 3, Title: "learn 3 words", Target: 3, Progress: 3, EndsOn: time.Date(2026, 3, 21, 0, 0, 0, 0, time.UTC)},
+	}
+
+	want := "🏆 Leaderboard, 9 Mar – 15 Mar\n" +
+		"\nAnswers:\n1. Anonymous learner — 20\n2. Roman (you) — 10\n" +
+		"\nAccuracy:\n1. Roman (you) — 90%\n2. Anonymous learner — 75%\n" +
+		"\nWords learned:\n1. Roman (you) — 2\n2. Anonymous learner — 1\n" +
+		"\n🎯 Team challenges:\n#3 learn 3 words: 3/3, until 21 Mar ✅"
+
+	if got := leaderboardMessage(42, w, entries, challenges); got != want {
+		t.Errorf("leaderboardMessage() =\n%s\n\nwant\n%s", got, want)
+	}
+}
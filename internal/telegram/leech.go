@@ -0,0 +1,13 @@
+package telegram
+
+import "fmt"
+
+// leechMessage tells the chat that word keeps being missed and suggests how to make it stick.
+func leechMessage(word string, suspended bool) string {
+	msg := fmt.Sprintf("🐌 You keep missing %q, so it is tagged as a leech. "+
+		"Try giving it a mnemonic or an example sentence in its description", word)
+	if suspended {
+		msg += ". It stays out of the learning batch until you remove the tag"
+	}
+	return msg
+}
@@ -1,13 +1,21 @@
 package telegram
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 	"runtime/debug"
+	"slices"
+	"sync"
+	"time"
 
 	tb "gopkg.in/telebot.v3"
 )
 
+// memberCacheTTL is how long a confirmed group membership is trusted before Telegram is asked again.
+// Without it every button press in a group would cost a getChatMember call.
+const memberCacheTTL = 10 * time.Minute
+
 func Recover(log *slog.Logger) tb.MiddlewareFunc {
 	return func(next tb.HandlerFunc) tb.HandlerFunc {
 		return func(c tb.Context) error {
@@ -33,7 +41,14 @@ func LogErrors(log *slog.Logger) tb.MiddlewareFunc {
 	}
 }
 
-func AllowedChats(ids []int64) tb.MiddlewareFunc {
+// AllowedChats lets through updates from the allowed private chats and from the configured groups.
+// In a group the chat alone proves nothing - anybody can be added to it - so the sender has to be a
+// current member as well.
+func AllowedChats(ids, groupIDs []int64) tb.MiddlewareFunc {
+	return allowedChats(ids, groupIDs, newMemberCache(isChatMember, memberCacheTTL))
+}
+
+func allowedChats(ids, groupIDs []int64, members *memberCache) tb.MiddlewareFunc {
 	idsMap := make(map[int64]struct{}, len(ids))
 	for _, id := range ids {
 		idsMap[id] = struct{}{}
@@ -41,6 +56,12 @@ func AllowedChats(ids []int64) tb.MiddlewareFunc {
 	return func(next tb.HandlerFunc) tb.HandlerFunc {
 		return func(c tb.Context) error {
 			chatID := c.Chat().ID
+			if slices.Contains(groupIDs, chatID) {
+				if err := members.check(c); err != nil {
+					return fmt.Errorf("chat %d: %w", chatID, err)
+				}
+				return next(c)
+			}
 			if _, ok := idsMap[chatID]; !ok {
 				return fmt.Errorf("chat %d is not allowed", chatID)
 			}
@@ -49,3 +70,71 @@ func AllowedChats(ids []int64) tb.MiddlewareFunc {
 		}
 	}
 }
+
+type (
+	memberKey struct {
+		chatID int64
+		userID int64
+	}
+
+	// memberCache remembers confirmed group members for a while. Refusals are not cached, so somebody
+	// who has just joined can answer straight away.
+	memberCache struct {
+		lookup    func(c tb.Context) (bool, error)
+		ttl       time.Duration
+		mx        sync.Mutex
+		confirmed map[memberKey]time.Time
+	}
+)
+
+func newMemberCache(lookup func(c tb.Context) (bool, error), ttl time.Duration) *memberCache {
+	return &memberCache{
+		lookup:    lookup,
+		ttl:       ttl,
+		confirmed: make(map[memberKey]time.Time),
+	}
+}
+
+func (m *memberCache) check(c tb.Context) error {
+	sender := c.Sender()
+	if sender == nil || sender.IsBot {
+		return errors.New("sender is not a group member")
+	}
+	key := memberKey{chatID: c.Chat().ID, userID: sender.ID}
+
+	m.mx.Lock()
+	confirmedAt, ok := m.confirmed[key]
+	m.mx.Unlock()
+	if ok && time.Since(confirmedAt) < m.ttl {
+		return nil
+	}
+
+	member, err := m.lookup(c)
+	if err != nil {
+		return fmt.Errorf("check membership of user %d: %w", sender.ID, err)
+	}
+	if !member {
+		return fmt.Errorf("user %d is not a group member", sender.ID)
+	}
+
+	m.mx.Lock()
+	m.confirmed[key] = time.Now()
+	m.mx.Unlock()
+	return nil
+}
+
+func isChatMember(c tb.Context) (bool, error) {
+	member, err := c.Bot().ChatMemberOf(c.Chat(), c.Sender())
+	if err != nil {
+		return false, err //nolint:wrapcheck // wrapped by the caller
+	}
+
+	switch member.Role {
+	case tb.Creator, tb.Administrator, tb.Member:
+		return true, nil
+	case tb.Restricted:
+		return member.Member, nil
+	default:
+		return false, nil
+	}
+}
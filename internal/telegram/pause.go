@@ -0,0 +1,89 @@
+package telegram
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tb "gopkg.in/telebot.v3"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+)
+
+// defaultPause is how long a bare /pause lasts.
+const defaultPause = 7 * 24 * time.Hour
+
+// pauseTimeLayout is how the end of a pause is shown.
+const pauseTimeLayout = "Mon, 2 Jan 15:04"
+
+var pauseUsage = fmt.Sprintf("Usage: %s [DURATION], e.g. 3d, 2w or 12h, at most %d days; %s ends it early",
+	commandPause, dal.MaxPauseDays, commandResume)
+
+// HandlePause pauses word checks and goal reminders, for a week unless a duration is given. The days
+// it covers do not break the streak. Pausing a paused chat extends the pause.
+func (b *Bot) HandlePause(m tb.Context) error {
+	ctx, cancel := processCtx()
+	defer cancel()
+
+	duration := defaultPause
+	if payload := strings.TrimSpace(m.Message().Payload); payload != "" {
+		var err error
+		if duration, err = parsePauseDuration(payload); err != nil {
+			return m.Reply(pauseUsage)
+		}
+	}
+
+	now := time.Now()
+	pause, err := b.repo.PauseChat(ctx, m.Chat().ID, now, now.Add(duration))
+	if err != nil {
+		b.log.ErrorContext(ctx, "failed to pause chat", "error", err)
+		return m.Reply(somethingWentWrongMsg)
+	}
+
+	return m.Reply(fmt.Sprintf("⏸ Paused until %s. Your streak is safe; send %s to come back earlier",
+		pause.Until.Format(pauseTimeLayout), commandResume))
+}
+
+func (b *Bot) HandleResume(m tb.Context) error {
+	ctx, cancel := processCtx()
+	defer cancel()
+
+	resumed, err := b.repo.ResumeChat(ctx, m.Chat().ID, time.Now())
+	if err != nil {
+		b.log.ErrorContext(ctx, "failed to resume chat", "error", err)
+		return m.Reply(somethingWentWrongMsg)
+	}
+	if !resumed {
+		return m.Reply("Not paused")
+	}
+	return m.Reply("▶️ Welcome back! Word checks are on again")
+}
+
+// parsePauseDuration reads a whole number of hours, days or weeks, such as "12h", "3d" or "2w", up to
+// MaxPauseDays.
+func parsePauseDuration(s string) (time.Duration, error) {
+	units := map[byte]time.Duration{'h': time.Hour, 'd': 24 * time.Hour, 'w': 7 * 24 * time.Hour}
+
+	s = strings.ToLower(s)
+	unit, ok := units[s[len(s)-1]]
+	if !ok {
+		return 0, fmt.Errorf("unknown unit in %q", s)
+	}
+	n, err := strconv.Atoi(s[:len(s)-1])
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	if res := time.Duration(n) * unit; res <= dal.MaxPauseDays*24*time.Hour {
+		return res, nil
+	}
+	return 0, fmt.Errorf("duration %q is longer than %d days", s, dal.MaxPauseDays)
+}
+
+// pauseMessage says until when the chat is paused, or nothing if it is not.
+func pauseMessage(p *dal.Pause, now time.Time) string {
+	if !p.Active(now) {
+		return ""
+	}
+	return fmt.Sprintf("⏸ Paused until %s, send %s to come back earlier", p.Until.Format(pauseTimeLayout), commandResume)
+}
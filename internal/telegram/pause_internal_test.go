@@ -0,0 +1,49 @@
+package telegram
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+)
+
+func TestParsePauseDuration(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{in: "12h", want: 12 * time.Hour},
+		{in: "3d", want: 72 * time.Hour},
+		{in: "2W", want: 14 * 24 * time.Hour},
+		{in: "90d", want: 90 * 24 * time.Hour},
+		{in: "91d", wantErr: true},
+		{in: "0d", wantErr: true},
+		{in: "7", wantErr: true},
+		{in: "d", wantErr: true},
+		{in: "1y", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parsePauseDuration(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parsePauseDuration(%q) = %v, %v, want %v, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestPauseMessage(t *testing.T) {
+	now := time.Date(2026, time.March, 2, 10, 0, 0, 0, time.UTC)
+	pause := &dal.Pause{From: now.Add(-time.Hour), Until: time.Date(2026, time.March, 9, 10, 0, 0, 0, time.UTC)}
+
+	want := "⏸ Paused until Mon, 9 Mar 10:00, send /resume to come back earlier"
+	if got := pauseMessage(pause, now); got != want {
+		t.Errorf("pauseMessage() = %q, want %q", got, want)
+	}
+	if got := pauseMessage(pause, pause.Until); got != "" {
+		t.Errorf("pauseMessage() after the pause = %q, want nothing", got)
+	}
+	if got := pauseMessage(&dal.Pause{}, now); got != "" {
+		t.Errorf("pauseMessage() for a chat never paused = %q, want nothing", got)
+	}
+}
@@ -0,0 +1,216 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tb "gopkg.in/telebot.v3"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+)
+
+const (
+	defaultQuizSize = 10
+	maxQuizSize     = 50
+)
+
+const quizUsage = "Usage: " + commandQuiz + " [N] [batch | review | misses | tag NAME] or " + commandQuiz + " stop"
+
+// quizArgs is a parsed /quiz command.
+type quizArgs struct {
+	Size   int
+	Source dal.QuizSource
+	Tag    string
+	Stop   bool
+}
+
+// HandleQuiz runs a focused practice round: "/quiz 20 misses" asks the 20 most recently missed words
+// back to back and sums the round up at the end. A bare /quiz resumes the round in progress, if any.
+func (b *Bot) HandleQuiz(m tb.Context) error {
+	ctx, cancel := processCtx()
+	defer cancel()
+
+	chatID := m.Chat().ID
+	args := m.Args()
+	if len(args) == 0 {
+		s, err := b.repo.FindActiveQuiz(ctx, chatID)
+		switch {
+		case errors.Is(err, dal.ErrNotFound):
+		case err != nil:
+			b.log.ErrorContext(ctx, "failed to find active quiz", "error", err)
+			return m.Reply(somethingWentWrongMsg)
+		default:
+			return b.sendQuizCard(ctx, s)
+		}
+	}
+
+	qa, ok := parseQuizArgs(args)
+	if !ok {
+		return m.Reply(quizUsage)
+	}
+
+	if qa.Stop {
+		s, err := b.repo.StopQuiz(ctx, chatID)
+		if err != nil {
+			if errors.Is(err, dal.ErrNotFound) {
+				return m.Reply("No quiz in progress")
+			}
+			b.log.ErrorContext(ctx, "failed to stop quiz", "error", err)
+			return m.Reply(somethingWentWrongMsg)
+		}
+		return m.Reply(quizSummaryMessage(s))
+	}
+
+	s, err := b.repo.StartQuiz(ctx, chatID, qa.Source, qa.Tag, qa.Size)
+	if err != nil {
+		if errors.Is(err, dal.ErrNotFound) {
+			return m.Reply("No words to quiz on")
+		}
+		b.log.ErrorContext(ctx, "failed to start quiz", "error", err)
+		return m.Reply(somethingWentWrongMsg)
+	}
+	return b.sendQuizCard(ctx, s)
+}
+
+// parseQuizArgs reads "[N] [SOURCE]" or "stop". The batch is the default source.
+func parseQuizArgs(args []string) (quizArgs, bool) {
+	res := quizArgs{Size: defaultQuizSize, Source: dal.QuizBatch}
+	if len(args) == 1 && args[0] == "stop" {
+		res.Stop = true
+		return res, true
+	}
+
+	if len(args) > 0 {
+		if n, err := strconv.Atoi(args[0]); err == nil {
+			if n <= 0 || n > maxQuizSize {
+				return res, false
+			}
+			res.Size = n
+			args = args[1:]
+		}
+	}
+	if len(args) == 0 {
+		return res, true
+	}
+
+	switch source := dal.QuizSource(args[0]); source {
+	case dal.QuizBatch, dal.QuizReview, dal.QuizMisses:
+		res.Source = source
+		return res, len(args) == 1
+	case dal.QuizTag:
+		res.Source = source
+		res.Tag = strings.Join(args[1:], " ")
+		return res, res.Tag != ""
+	default:
+		return res, false
+	}
+}
+
+// sendQuizCard asks the current card of s. The buttons carry the session ID, so once the card is
+// graded or the session replaced they stop working.
+func (b *Bot) sendQuizCard(ctx context.Context, s *dal.QuizSession) error {
+	card, ok := s.Current()
+	if !ok {
+		_, err := b.bot.Send(tb.ChatID(s.ChatID), quizSummaryMessage(s), tb.Silent)
+		return err //nolint:wrapcheck // lets ignore it here
+	}
+
+	callbackID, err := b.repo.InsertCallback(ctx, dal.CallbackData{
+		ChatID:    s.ChatID,
+		Word:      card.Word,
+		QuizID:    s.ID,
+		ExpiresAt: time.Now().Add(callbackDataExpirationTime),
+	})
+	if err != nil {
+		return fmt.Errorf("insert callback data: %w", err)
+	}
+
+	msg := fmt.Sprintf("🎯 %d/%d *%s*", s.Position+1, len(s.Cards), normalizeMessage(card.Word))
+	_, err = b.bot.Send(tb.ChatID(s.ChatID), msg, tb.ModeMarkdownV2, tb.Silent, quizRevealMarkup(callbackID))
+	return err //nolint:wrapcheck // lets ignore it here
+}
+
+func (b *Bot) handleQuizRevealCallback(ctx context.Context, c tb.Context, data *dal.CallbackData) error {
+	wt, err := b.repo.FindWordTranslation(ctx, c.Chat().ID, data.Word)
+	if err != nil {
+		return fmt.Errorf("get word translation: %w", err)
+	}
+	msg := "*" + normalizeMessage(wt.Translation) + "*"
+	if wt.Description != "" {
+		msg += ": _" + normalizeMessage(wt.Description) + "_"
+	}
+	return c.Send(msg, quizGradeMarkup(data.ID), tb.ModeMarkdownV2, tb.Silent) //nolint:wrapcheck // lets ignore it here
+}
+
+// handleQuizGradeCallback grades the card and asks the next one, or sums the session up after the
+// last. A card that is no longer current, because it was graded already or the session was stopped,
+// is just cleared away.
+func (b *Bot) handleQuizGradeCallback(ctx context.Context, c tb.Context, data *dal.CallbackData, guessed bool) error {
+	s, err := b.repo.GradeQuizCard(ctx, c.Chat().ID, data.QuizID, data.Word, guessed)
+	if err != nil {
+		if errors.Is(err, dal.ErrNotFound) {
+			return c.RespondText("This card is no longer in play") //nolint:wrapcheck // lets ignore it here
+		}
+		return fmt.Errorf("grade quiz card: %w", err)
+	}
+	return b.sendQuizCard(ctx, s)
+}
+
+// quizSummaryMessage sums up a finished session and lists the words to go over again.
+func quizSummaryMessage(s *dal.QuizSession) string {
+	guessed, graded := s.Score()
+	if graded == 0 {
+		return "🏁 Quiz stopped before any answer"
+	}
+
+	lines := []string{fmt.Sprintf("🏁 Quiz finished: %d/%d right (%.0f%%)", guessed, graded,
+		float64(guessed)*100/float64(graded))} //nolint:mnd // a percentage
+	if graded < len(s.Cards) {
+		lines[0] += fmt.Sprintf(", %d not reached", len(s.Cards)-graded)
+	}
+
+	var missed []string
+	for _, c := range s.Cards {
+		if c.Graded && !c.Guessed {
+			missed = append(missed, c.Word)
+		}
+	}
+	if len(missed) > 0 {
+		lines = append(lines, "", "Missed: "+strings.Join(missed, ", "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func quizRevealMarkup(uuid string) *tb.ReplyMarkup {
+	return &tb.ReplyMarkup{
+		InlineKeyboard: [][]tb.InlineButton{
+			{
+				{
+					Text: "See translation",
+					Data: fmt.Sprintf("%s:%s", callbackQuizReveal, uuid),
+				},
+			},
+		},
+	}
+}
+
+func quizGradeMarkup(uuid string) *tb.ReplyMarkup {
+	return &tb.ReplyMarkup{
+		InlineKeyboard: [][]tb.InlineButton{
+			{
+				{
+					Text: "[      ✅      ]",
+					Data: fmt.Sprintf("%s:%s", callbackQuizGuessed, uuid),
+				},
+				{
+					Text: "[      ❌      ]",
+					Data: fmt.Sprintf("%s:%s", callbackQuizMissed, uuid),
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,53 @@
+package telegram
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+)
+
+func TestParseQuizArgs(t *testing.T) {
+	tests := []struct {
+		args   string
+		want   quizArgs
+		wantOK bool
+	}{
+		{args: "", want: quizArgs{Size: 10, Source: dal.QuizBatch}, wantOK: true},
+		{args: "20", want: quizArgs{Size: 20, Source: dal.QuizBatch}, wantOK: true},
+		{args: "5 misses", want: quizArgs{Size: 5, Source: dal.QuizMisses}, wantOK: true},
+		{args: "review", want: quizArgs{Size: 10, Source: dal.QuizReview}, wantOK: true},
+		{args: "tag phrasal verbs", want: quizArgs{Size: 10, Source: dal.QuizTag, Tag: "phrasal verbs"}, wantOK: true},
+		{args: "stop", want: quizArgs{Size: 10, Source: dal.QuizBatch, Stop: true}, wantOK: true},
+		{args: "tag", wantOK: false},
+		{args: "0", wantOK: false},
+		{args: "51", wantOK: false},
+		{args: "review extra", wantOK: false},
+		{args: "everything", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseQuizArgs(strings.Fields(tt.args))
+		if ok != tt.wantOK || (ok && got != tt.want) {
+			t.Errorf("parseQuizArgs(%q) = %+v, %v, want %+v, %v", tt.args, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestQuizSummaryMessage(t *testing.T) {
+	s := &dal.QuizSession{
+		Cards: []dal.QuizCard{
+			{Word: "apple", Graded: true, Guessed: true},
+			{Word: "pear", Graded: true},
+			{Word: "plum", Graded: true, Guessed: true},
+			{Word: "fig"},
+		},
+		Position: 3,
+		Finished: true,
+	}
+
+	want := "🏁 Quiz finished: 2/3 right (67%), 1 not reached\n\nMissed: pear"
+	if got := quizSummaryMessage(s); got != want {
+		t.Errorf("quizSummaryMessage() =\n%s\n\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,110 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	tb "gopkg.in/telebot.v3"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+)
+
+// reviewListLimit caps how many words "/review list" shows, so a long backlog still fits a message.
+const reviewListLimit = 30
+
+const reviewUsage = "Usage: " + commandReview + " [list | done WORD]"
+
+// HandleReview works through the words marked to review with ❓: a bare /review asks the one that has
+// waited longest, "/review list" lists them and "/review done WORD" clears the mark. The mark can
+// also be cleared with the button under a revealed word that has it.
+func (b *Bot) HandleReview(m tb.Context) error {
+	ctx, cancel := processCtx()
+	defer cancel()
+
+	chatID := m.Chat().ID
+	args := m.Args()
+	switch {
+	case len(args) == 0:
+		return b.sendToReview(ctx, chatID, m)
+	case args[0] == "list" && len(args) == 1:
+		words, total, err := b.repo.FindWordTranslations(ctx, chatID, dal.WordTranslationsFilter{
+			ToReview: true,
+			Limit:    reviewListLimit,
+		})
+		if err != nil {
+			b.log.ErrorContext(ctx, "failed to find words to review", "error", err)
+			return m.Reply(somethingWentWrongMsg)
+		}
+		return m.Reply(toReviewListMessage(words, total))
+	case args[0] == "done":
+		word := strings.TrimSpace(strings.TrimPrefix(m.Message().Payload, args[0]))
+		if word == "" {
+			return m.Reply(reviewUsage)
+		}
+		return b.resolveToReview(ctx, chatID, word, m)
+	default:
+		return m.Reply(reviewUsage)
+	}
+}
+
+// sendToReview asks the word marked to review that has waited longest. Sending it moves the word to
+// the back of the line, like a scheduled review, so repeated /review commands go round all of them.
+func (b *Bot) sendToReview(ctx context.Context, chatID int64, m tb.Context) error {
+	wt, err := b.repo.FindRandomWordTranslation(ctx, chatID, dal.FindRandomWordFilter{ToReview: true})
+	if err != nil {
+		if errors.Is(err, dal.ErrNotFound) {
+			return m.Reply("Nothing to review. Mark a word with ❓ to come back to it")
+		}
+		b.log.ErrorContext(ctx, "failed to find word to review", "error", err)
+		return m.Reply(somethingWentWrongMsg)
+	}
+
+	if err = b.sendWord(ctx, chatID, wt, toReviewPrefix); err != nil {
+		b.log.ErrorContext(ctx, "failed to send word to review", "error", err)
+		return m.Reply(somethingWentWrongMsg)
+	}
+	if err = b.repo.MarkWordReviewed(ctx, chatID, wt.Word); err != nil {
+		b.log.ErrorContext(ctx, "failed to mark word reviewed", "error", err, "word", wt.Word)
+	}
+	return nil
+}
+
+func (b *Bot) resolveToReview(ctx context.Context, chatID int64, word string, m tb.Context) error {
+	wt, err := b.repo.FindWordTranslation(ctx, chatID, word)
+	if err != nil {
+		if errors.Is(err, dal.ErrNotFound) {
+			return m.Reply("No such word")
+		}
+		b.log.ErrorContext(ctx, "failed to find word translation", "error", err)
+		return m.Reply(somethingWentWrongMsg)
+	}
+	if !wt.ToReview {
+		return m.Reply("This word is not marked to review")
+	}
+
+	if err = b.repo.MarkToReview(ctx, chatID, wt.Word, false); err != nil {
+		b.log.ErrorContext(ctx, "failed to clear to review", "error", err)
+		return m.Reply(somethingWentWrongMsg)
+	}
+	return m.Reply("Done, " + wt.Word + " is no longer marked to review")
+}
+
+// toReviewListMessage lists the words marked to review; total counts them all, including the ones
+// that did not make the list.
+func toReviewListMessage(words []dal.WordTranslation, total int) string {
+	if total == 0 {
+		return "Nothing to review. Mark a word with ❓ to come back to it"
+	}
+
+	lines := []string{fmt.Sprintf("❓ To review: %d", total)}
+	for _, wt := range words {
+		lines = append(lines, wt.Word+" — "+wt.Translation)
+	}
+	if more := total - len(words); more > 0 {
+		lines = append(lines, fmt.Sprintf("…and %d more", more))
+	}
+	lines = append(lines, "", "Send "+commandReview+" to go over them, "+commandReview+" done WORD once one is resolved")
+	return strings.Join(lines, "\n")
+}
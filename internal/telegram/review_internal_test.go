@@ -0,0 +1,68 @@
+package telegram
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+)
+
+func TestToReviewListMessage(t *testing.T) {
+	words := []dal.WordTranslation{
+		{Word: "apple", Translation: "яблуко"},
+		{Word: "give up", Translation: "здаватися"},
+	}
+
+	want := "❓ To review: 3\n" +
+		"apple — яблуко\n" +
+		"give up — здаватися\n" +
+		"…and 1 more\n" +
+		"\nSend /review to go over them, /review done WORD once one is resolved"
+	if got := toReviewListMessage(words, 3); got != want {
+		t.Errorf("toReviewListMessage() =\n%s\n\nwant\n%s", got, want)
+	}
+
+	if got := toReviewListMessage(nil, 0); !strings.HasPrefix(got, "Nothing to review") {
+		t.Errorf("toReviewListMessage() = %q, want the empty message", got)
+	}
+}
+
+// A word that is already marked to review gets a button to clear the mark, not to set it again.
+func TestGuessedResponseMarkupReviewButton(t *testing.T) {
+	tests := []struct {
+		toReview bool
+		want     string
+	}{
+		{toReview: false, want: callbackWordToReview + ":uuid"},
+		{toReview: true, want: callbackWordResolved + ":uuid"},
+	}
+
+	for _, tt := range tests {
+		row := guessedResponseMarkup("uuid", tt.toReview).InlineKeyboard[0]
+		if got := row[len(row)-1].Data; got != tt.want {
+			t.Errorf("toReview = %v: last button = %q, want %q", tt.toReview, got, tt.want)
+		}
+	}
+}
+
+func TestReviewShare(t *testing.T) {
+	tests := []struct {
+		name string
+		load dal.ReviewLoad
+		want int
+	}{
+		{name: "nothing due", load: dal.ReviewLoad{Due: 0, Batched: 50}, want: 0},
+		{name: "a few due", load: dal.ReviewLoad{Due: 5, Batched: 45}, want: 10},
+		{name: "one due among many", load: dal.ReviewLoad{Due: 1, Batched: 500}, want: 1},
+		{name: "a backlog is capped", load: dal.ReviewLoad{Due: 200, Batched: 50}, want: 20},
+		{name: "nothing left to learn", load: dal.ReviewLoad{Due: 3, Batched: 0}, want: 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := reviewShare(&tt.load, 20); got != tt.want {
+				t.Errorf("reviewShare() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package telegram
+
+import (
+	"fmt"
+
+	tb "gopkg.in/telebot.v3"
+)
+
+// HandleLogoutAll signs the chat out of the web UI everywhere, for a lost or shared device.
+func (b *Bot) HandleLogoutAll(m tb.Context) error {
+	ctx, cancel := processCtx()
+	defer cancel()
+
+	revoked, err := b.repo.RevokeSessions(ctx, m.Chat().ID)
+	if err != nil {
+		b.log.ErrorContext(ctx, "failed to revoke sessions", "error", err)
+		return m.Reply(somethingWentWrongMsg)
+	}
+	return m.Reply(logoutAllMessage(revoked))
+}
+
+func logoutAllMessage(revoked int) string {
+	switch revoked {
+	case 0:
+		return "No signed-in sessions"
+	case 1:
+		return "🔒 Signed out of 1 session"
+	default:
+		return fmt.Sprintf("🔒 Signed out of %d sessions", revoked)
+	}
+}
@@ -0,0 +1,19 @@
+package telegram
+
+import "testing"
+
+func TestLogoutAllMessage(t *testing.T) {
+	tests := []struct {
+		revoked int
+		want    string
+	}{
+		{0, "No signed-in sessions"},
+		{1, "🔒 Signed out of 1 session"},
+		{3, "🔒 Signed out of 3 sessions"},
+	}
+	for _, tt := range tests {
+		if got := logoutAllMessage(tt.revoked); got != tt.want {
+			t.Errorf("logoutAllMessage(%d) = %q, want %q", tt.revoked, got, tt.want)
+		}
+	}
+}
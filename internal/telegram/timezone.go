@@ -0,0 +1,49 @@
+package telegram
+
+import (
+	"fmt"
+	"strings"
+
+	tb "gopkg.in/telebot.v3"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+)
+
+// timezoneReset is the /timezone argument that goes back to the configured schedule location.
+const timezoneReset = "reset"
+
+var timezoneUsage = fmt.Sprintf("Usage: %s [Area/City | %s], e.g. %s Europe/Kyiv", commandTimezone, timezoneReset, commandTimezone)
+
+// HandleTimezone shows or changes the timezone the chat's digests are sent in.
+func (b *Bot) HandleTimezone(m tb.Context) error {
+	ctx, cancel := processCtx()
+	defer cancel()
+
+	name := strings.TrimSpace(m.Message().Payload)
+	if name == "" {
+		current, err := b.repo.GetTimezone(ctx, m.Chat().ID)
+		if err != nil {
+			b.log.ErrorContext(ctx, "failed to get timezone", "error", err)
+			return m.Reply(somethingWentWrongMsg)
+		}
+		if current == "" {
+			return m.Reply("Digests follow the bot's default timezone. " + timezoneUsage)
+		}
+		return m.Reply(fmt.Sprintf("Your timezone is %s. %s", current, timezoneUsage))
+	}
+
+	if strings.EqualFold(name, timezoneReset) {
+		name = ""
+	} else if _, err := dal.LoadTimezone(name); err != nil {
+		return m.Reply(timezoneUsage)
+	}
+
+	if err := b.repo.SetTimezone(ctx, m.Chat().ID, name); err != nil {
+		b.log.ErrorContext(ctx, "failed to set timezone", "error", err)
+		return m.Reply(somethingWentWrongMsg)
+	}
+	if name == "" {
+		return m.Reply("🕒 Digests follow the bot's default timezone again")
+	}
+	return m.Reply(fmt.Sprintf("🕒 Digests will arrive in %s time", name))
+}
@@ -0,0 +1,134 @@
+package telegram
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tb "gopkg.in/telebot.v3"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+)
+
+const tokenUsage = "Usage: " + commandToken + " [new NAME SCOPE,SCOPE [DAYS] | revoke ID]. Scopes are " +
+	"read, words:write and stats:read; without DAYS the token never expires"
+
+// HandleToken lists the chat's personal access tokens or manages them: "/token new upload words:write 30"
+// creates one for 30 days and "/token revoke 3" deletes one. Tokens belong to the sender and are only
+// managed in a private chat, see privateOnly, so a plaintext token is never posted where others can
+// read it.
+func (b *Bot) HandleToken(m tb.Context) error {
+	ctx, cancel := processCtx()
+	defer cancel()
+
+	chatID := m.Sender().ID
+	args := m.Args()
+	if len(args) == 0 {
+		tokens, err := b.repo.GetAPITokens(ctx, chatID)
+		if err != nil {
+			b.log.ErrorContext(ctx, "failed to get api tokens", "error", err)
+			return m.Reply(somethingWentWrongMsg)
+		}
+		return m.Reply(tokensMessage(tokens, time.Now()))
+	}
+
+	switch args[0] {
+	case "new":
+		name, scopes, days, err := parseNewToken(args[1:])
+		if err != nil {
+			return m.Reply(tokenUsage)
+		}
+		var expiresAt time.Time
+		if days > 0 {
+			expiresAt = time.Now().AddDate(0, 0, days)
+		}
+		created, token, err := b.repo.CreateAPIToken(ctx, chatID, name, scopes, expiresAt)
+		if err != nil {
+			if errors.Is(err, dal.ErrAlreadyExists) {
+				return m.Reply("You already have a token with this name")
+			}
+			b.log.ErrorContext(ctx, "failed to create api token", "error", err)
+			return m.Reply(somethingWentWrongMsg)
+		}
+		return m.Reply(fmt.Sprintf("🔑 Token #%d %s created. Send it as \"Authorization: Bearer TOKEN\"; "+
+			"it is not shown again:\n\n%s", created.ID, created.Name, token))
+	case "revoke":
+		if len(args) != 2 { //nolint:mnd // the action and the token ID
+			return m.Reply(tokenUsage)
+		}
+		id, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return m.Reply(tokenUsage)
+		}
+		if err = b.repo.RevokeAPIToken(ctx, chatID, id); err != nil {
+			if errors.Is(err, dal.ErrNotFound) {
+				return m.Reply(fmt.Sprintf("You have no token #%d", id))
+			}
+			b.log.ErrorContext(ctx, "failed to revoke api token", "error", err)
+			return m.Reply(somethingWentWrongMsg)
+		}
+		return m.Reply(fmt.Sprintf("Token #%d revoked", id))
+	default:
+		return m.Reply(tokenUsage)
+	}
+}
+
+// parseNewToken reads the NAME SCOPE,SCOPE [DAYS] of "/token new".
+func parseNewToken(args []string) (string, []dal.TokenScope, int, error) {
+	if len(args) < 2 || len(args) > 3 {
+		return "", nil, 0, errors.New("want a name, scopes and optionally days")
+	}
+
+	var scopes []dal.TokenScope
+	for _, s := range strings.Split(args[1], ",") {
+		switch scope := dal.TokenScope(strings.TrimSpace(s)); scope {
+		case dal.ScopeRead, dal.ScopeWordsWrite, dal.ScopeStatsRead:
+			scopes = append(scopes, scope)
+		default:
+			return "", nil, 0, fmt.Errorf("unknown scope %q", s)
+		}
+	}
+
+	var days int
+	if len(args) == 3 { //nolint:mnd // the name, the scopes and the days
+		var err error
+		if days, err = strconv.Atoi(args[2]); err != nil || days <= 0 || days > dal.MaxAPITokenDays {
+			return "", nil, 0, fmt.Errorf("days %q must be in range 1-%d", args[2], dal.MaxAPITokenDays)
+		}
+	}
+	return args[0], scopes, days, nil
+}
+
+func tokensMessage(tokens []dal.APIToken, now time.Time) string {
+	if len(tokens) == 0 {
+		return "No API tokens yet. Create one with " + commandToken + " new NAME SCOPES"
+	}
+
+	lines := []string{"🔑 API tokens:"}
+	for _, t := range tokens {
+		scopes := make([]string, len(t.Scopes))
+		for i, s := range t.Scopes {
+			scopes[i] = string(s)
+		}
+
+		var expires string
+		switch {
+		case t.ExpiresAt.IsZero():
+			expires = "never expires"
+		case t.ExpiresAt.Before(now):
+			expires = "expired " + t.ExpiresAt.Format("2 Jan 2006")
+		default:
+			expires = "expires " + t.ExpiresAt.Format("2 Jan 2006")
+		}
+		used := "never used"
+		if !t.LastUsedAt.IsZero() {
+			used = "last used " + t.LastUsedAt.Format("2 Jan 2006")
+		}
+
+		lines = append(lines, fmt.Sprintf("#%d %s (%s): %s, %s", t.ID, t.Name, strings.Join(scopes, ", "), expires, used))
+	}
+	lines = append(lines, "", "Revoke with "+commandToken+" revoke ID")
+	return strings.Join(lines, "\n")
+}
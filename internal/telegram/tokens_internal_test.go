@@ -0,0 +1,54 @@
+package telegram
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+)
+
+func TestParseNewToken(t *testing.T) {
+	name, scopes, days, err := parseNewToken([]string{"upload", "words:write,stats:read", "30"})
+	if err != nil || name != "upload" || days != 30 ||
+		!slices.Equal(scopes, []dal.TokenScope{dal.ScopeWordsWrite, dal.ScopeStatsRead}) {
+		t.Errorf("parseNewToken = %q, %v, %d, %v; want upload with both scopes for 30 days", name, scopes, days, err)
+	}
+
+	if _, _, days, err = parseNewToken([]string{"backup", "read"}); err != nil || days != 0 {
+		t.Errorf("parseNewToken without days = %d, %v; want a token that never expires", days, err)
+	}
+
+	for _, args := range [][]string{
+		{"upload"},
+		{"upload", "admin"},
+		{"upload", "read", "0"},
+		{"upload", "read", "366"},
+		{"upload", "read", "30", "extra"},
+	} {
+		if _, _, _, err = parseNewToken(args); err == nil {
+			t.Errorf("parseNewToken(%q) accepted", args)
+		}
+	}
+}
+
+func TestTokensMessage(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	tokens := []dal.APIToken{
+		{ID: 2, Name: "upload", Scopes: []dal.TokenScope{dal.ScopeWordsWrite}, ExpiresAt: now.AddDate(0, 0, 30)},
+		{ID: 1, Name: "old", Scopes: []dal.TokenScope{dal.ScopeRead, dal.ScopeStatsRead}, ExpiresAt: now.AddDate(0, 0, -1), LastUsedAt: now.AddDate(0, 0, -2)},
+		{ID: 3, Name: "backup", Scopes: []dal.TokenScope{dal.ScopeRead}},
+	}
+
+	want := "🔑 API tokens:\n" +
+		"#2 upload (words:write): expires 17 Nov 2026, never used\n" +
+		"#1 old (read, stats:read): expired 17 Oct 2026, last used 16 Oct 2026\n" +
+		"#3 backup (read): never expires, never used\n" +
+		"\nRevoke with /token revoke ID"
+	if got := tokensMessage(tokens, now); got != want {
+		t.Errorf("tokensMessage() =\n%s\nwant\n%s", got, want)
+	}
+	if got := tokensMessage(nil, now); got != "No API tokens yet. Create one with /token new NAME SCOPES" {
+		t.Errorf("tokensMessage(nil) = %q", got)
+	}
+}
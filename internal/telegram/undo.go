@@ -0,0 +1,93 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	tb "gopkg.in/telebot.v3"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+)
+
+const nothingToUndoMsg = "Nothing to undo: only the last answer can be taken back, and only for a while"
+
+// HandleUndo takes back the chat's last answer and asks the word again.
+func (b *Bot) HandleUndo(m tb.Context) error {
+	ctx, cancel := processCtx()
+	defer cancel()
+
+	undone, err := b.undoAnswer(ctx, m.Chat().ID, "")
+	if errors.Is(err, dal.ErrNotFound) {
+		return m.Reply(nothingToUndoMsg)
+	}
+	if err != nil {
+		b.log.ErrorContext(ctx, "failed to undo answer", "error", err)
+		return m.Reply(somethingWentWrongMsg)
+	}
+
+	if err = m.Reply(undoneMessage(undone)); err != nil {
+		return err //nolint:wrapcheck // lets ignore it here
+	}
+	wt, err := b.repo.FindWordTranslation(ctx, m.Chat().ID, undone.Word)
+	if err != nil {
+		b.log.ErrorContext(ctx, "failed to get word translation", "error", err)
+		return nil
+	}
+	return b.sendWord(ctx, m.Chat().ID, wt, "")
+}
+
+// handleWordUndoCallback takes back the answer on a graded card and shows the card again, ready to be
+// answered. Only the answer given on this very card is taken back, never a later one on another card
+// of the same word.
+func (b *Bot) handleWordUndoCallback(ctx context.Context, c tb.Context, cData *dal.CallbackData) error {
+	_, err := b.undoAnswer(ctx, c.Chat().ID, cData.ID)
+	if errors.Is(err, dal.ErrNotFound) {
+		return c.RespondText(nothingToUndoMsg)
+	}
+	if err != nil {
+		return fmt.Errorf("undo answer: %w", err)
+	}
+	return b.handleSeeTranslationCallback(ctx, c, cData)
+}
+
+func (b *Bot) undoAnswer(ctx context.Context, chatID int64, callbackID string) (*dal.UndoneAnswer, error) {
+	if b.undoWindow <= 0 {
+		return nil, dal.ErrNotFound
+	}
+	undone, err := b.repo.UndoLastAnswer(ctx, chatID, callbackID, time.Now().Add(-b.undoWindow))
+	if err != nil {
+		return nil, fmt.Errorf("undo last answer: %w", err)
+	}
+	return undone, nil
+}
+
+// answeredMessage is what a graded card turns into while its answer can be undone.
+func answeredMessage(word string, guessed bool) string {
+	return answerMark(guessed) + " " + word
+}
+
+func undoneMessage(a *dal.UndoneAnswer) string {
+	return fmt.Sprintf("↩️ Took back %s for %q, its streak is %d again", answerMark(a.Guessed), a.Word, a.Streak)
+}
+
+func answerMark(guessed bool) string {
+	if guessed {
+		return "✅"
+	}
+	return "❌"
+}
+
+func undoMarkup(uuid string) *tb.ReplyMarkup {
+	return &tb.ReplyMarkup{
+		InlineKeyboard: [][]tb.InlineButton{
+			{
+				{
+					Text: "↩️ Undo",
+					Data: fmt.Sprintf("%s:%s", callbackWordUndo, uuid),
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,34 @@
+package telegram
+
+import (
+	"testing"
+
+	"github.com/Roma7-7-7/english-learning-bot/internal/dal"
+)
+
+func TestUndoMessages(t *testing.T) {
+	if got, want := answeredMessage("apple", true), "✅ apple"; got != want {
+		t.Errorf("answeredMessage() = %q, want %q", got, want)
+	}
+	if got, want := answeredMessage("apple", false), "❌ apple"; got != want {
+		t.Errorf("answeredMessage() = %q, want %q", got, want)
+	}
+
+	got := undoneMessage(&dal.UndoneAnswer{Word: "apple", Guessed: false, Streak: 14})
+	if want := `↩️ Took back ❌ for "apple", its streak is 14 again`; got != want {
+		t.Errorf("undoneMessage() = %q, want %q", got, want)
+	}
+}
+
+func TestAlreadyAnsweredMessage(t *testing.T) {
+	tests := map[dal.CallbackAnswer]string{
+		dal.CallbackGuessed: "Already answered ✅",
+		dal.CallbackMissed:  "Already answered ❌",
+		"":                  "Already answered",
+	}
+	for answer, want := range tests {
+		if got := alreadyAnsweredMessage(answer); got != want {
+			t.Errorf("alreadyAnsweredMessage(%q) = %q, want %q", answer, got, want)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+-- Adds what daily goals and the learning streak need: per-day counts of new words, per-day streak
+-- freezes, and the per-chat settings table that holds the freezes not spent yet.
+--
+-- Apply once to an existing database:
+--     sqlite3 data/db.sqlite < schema/migrations/003_daily_goals.sql
+--
+-- New databases created from schema/schema_sqlite.sql already include this.
+--
+-- No backfill: words_added starts counting from the day this is applied, so a "new words per day"
+-- goal is never met for earlier days. With the default goal of 0 new words that does not matter, and
+-- the streak is computed from words_guessed + words_missed, which go back as far as statistics does.
+
+ALTER TABLE statistics ADD COLUMN words_added INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE statistics ADD COLUMN streak_frozen INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE chat_settings
+(
+    chat_id                INTEGER   NOT NULL PRIMARY KEY,
+    streak_freezes         INTEGER   NOT NULL DEFAULT 0,
+    streak_settled_through TEXT,
+    created_at             TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
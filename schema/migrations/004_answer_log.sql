@@ -0,0 +1,27 @@
+-- Adds the per-answer log behind the progress digests' hardest and graduated words.
+--
+-- Apply once to an existing database:
+--     sqlite3 data/db.sqlite < schema/migrations/004_answer_log.sql
+--
+-- New databases created from schema/schema_sqlite.sql already include this.
+--
+-- No backfill is possible: statistics only has per-day totals. Digests covering days before this
+-- migration simply list no hardest or graduated words for them.
+
+CREATE TABLE answer_log
+(
+    id           INTEGER   NOT NULL PRIMARY KEY AUTOINCREMENT,
+    chat_id      INTEGER   NOT NULL,
+    word         TEXT      NOT NULL,
+    guessed      INTEGER   NOT NULL,
+    streak_after INTEGER   NOT NULL,
+    answered_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (chat_id, word)
+    REFERENCES word_translations (chat_id, word)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE
+);
+
+CREATE INDEX idx_answer_log_chat_id_answered_at
+    ON answer_log (chat_id, answered_at);
@@ -0,0 +1,27 @@
+-- Adds the opt-in leaderboard and team challenges: per-chat visibility and display name, and the
+-- challenges table.
+--
+-- Apply once to an existing database:
+--     sqlite3 data/db.sqlite < schema/migrations/005_leaderboard.sql
+--
+-- New databases created from schema/schema_sqlite.sql already include this.
+--
+-- Every existing chat starts hidden: nobody appears on the leaderboard until they opt in.
+
+ALTER TABLE chat_settings ADD COLUMN leaderboard_visibility TEXT NOT NULL DEFAULT 'hidden';
+ALTER TABLE chat_settings ADD COLUMN leaderboard_name TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE challenges
+(
+    id         INTEGER   NOT NULL PRIMARY KEY AUTOINCREMENT,
+    created_by INTEGER   NOT NULL,
+    title      TEXT      NOT NULL,
+    metric     TEXT      NOT NULL,
+    target     INTEGER   NOT NULL,
+    starts_on  TEXT      NOT NULL,
+    ends_on    TEXT      NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_challenges_ends_on
+    ON challenges (ends_on);
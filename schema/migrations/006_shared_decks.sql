@@ -0,0 +1,38 @@
+-- Adds shared decks: decks, the owner's words in them, and the chats subscribed to them.
+--
+-- Apply once to an existing database:
+--     sqlite3 data/db.sqlite < schema/migrations/006_shared_decks.sql
+--
+-- New databases created from schema/schema_sqlite.sql already include this.
+
+CREATE TABLE decks
+(
+    id            INTEGER   NOT NULL PRIMARY KEY AUTOINCREMENT,
+    owner_chat_id INTEGER   NOT NULL,
+    name          TEXT      NOT NULL,
+    created_at    TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE (owner_chat_id, name)
+);
+
+CREATE TABLE deck_words
+(
+    deck_id INTEGER NOT NULL,
+    word    TEXT    NOT NULL,
+
+    PRIMARY KEY (deck_id, word),
+    FOREIGN KEY (deck_id) REFERENCES decks (id) ON DELETE CASCADE
+);
+
+CREATE TABLE deck_subscriptions
+(
+    deck_id    INTEGER   NOT NULL,
+    chat_id    INTEGER   NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (deck_id, chat_id),
+    FOREIGN KEY (deck_id) REFERENCES decks (id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_deck_subscriptions_chat_id
+    ON deck_subscriptions (chat_id);
@@ -0,0 +1,48 @@
+-- Adds group chats: the members of a group, their progress on the group's words, and the answers
+-- they gave in each round.
+--
+-- Apply once to an existing database:
+--     sqlite3 data/db.sqlite < schema/migrations/007_group_chats.sql
+--
+-- New databases created from schema/schema_sqlite.sql already include this.
+
+CREATE TABLE group_members
+(
+    group_chat_id INTEGER   NOT NULL,
+    user_id       INTEGER   NOT NULL,
+    name          TEXT      NOT NULL DEFAULT '',
+    created_at    TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (group_chat_id, user_id)
+);
+
+-- Each member's streak on the group's words: the group counterpart of
+-- word_translations.guessed_streak.
+CREATE TABLE group_progress
+(
+    group_chat_id  INTEGER NOT NULL,
+    user_id        INTEGER NOT NULL,
+    word           TEXT    NOT NULL,
+    guessed_streak INTEGER NOT NULL DEFAULT 0,
+
+    PRIMARY KEY (group_chat_id, user_id, word)
+);
+
+-- One row per answer given in a group. A round is one quiz message, identified by its callback ID,
+-- and every member answers it at most once.
+CREATE TABLE group_answers
+(
+    round_id      TEXT      NOT NULL,
+    group_chat_id INTEGER   NOT NULL,
+    user_id       INTEGER   NOT NULL,
+    word          TEXT      NOT NULL,
+    guessed       INTEGER   NOT NULL,
+    -- The member's streak on the word right after the answer was applied.
+    streak_after  INTEGER   NOT NULL,
+    answered_at   TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (round_id, user_id)
+);
+
+CREATE INDEX idx_group_answers_group_chat_id_answered_at
+    ON group_answers (group_chat_id, answered_at);
@@ -0,0 +1,55 @@
+-- Adds word tags and quiz sessions.
+--
+-- Apply once to an existing database:
+--     sqlite3 data/db.sqlite < schema/migrations/008_quiz_sessions.sql
+--
+-- New databases created from schema/schema_sqlite.sql already include this.
+
+-- Free-form labels on words, such as "phrasal-verbs". Tags are stored lowercase and never contain
+-- commas.
+CREATE TABLE word_tags
+(
+    chat_id INTEGER NOT NULL,
+    word    TEXT    NOT NULL,
+    tag     TEXT    NOT NULL,
+
+    PRIMARY KEY (chat_id, word, tag),
+    FOREIGN KEY (chat_id, word)
+    REFERENCES word_translations (chat_id, word)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE
+);
+
+CREATE INDEX idx_word_tags_chat_id_tag
+    ON word_tags (chat_id, tag);
+
+-- Quiz sessions (/quiz): a run of cards practiced back to back. The cards are picked when the
+-- session starts and kept with their results, so a restart does not lose the session.
+CREATE TABLE quiz_sessions
+(
+    id          INTEGER   NOT NULL PRIMARY KEY AUTOINCREMENT,
+    chat_id     INTEGER   NOT NULL,
+    -- 'batch', 'review', 'misses' or 'tag'
+    source      TEXT      NOT NULL,
+    tag         TEXT      NOT NULL DEFAULT '',
+    -- The card being asked. It equals the number of cards once every card has been graded.
+    position    INTEGER   NOT NULL DEFAULT 0,
+    -- Set when the last card is graded or the session is stopped early.
+    finished_at TIMESTAMP,
+    created_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_quiz_sessions_chat_id
+    ON quiz_sessions (chat_id, finished_at);
+
+CREATE TABLE quiz_cards
+(
+    session_id INTEGER NOT NULL,
+    position   INTEGER NOT NULL,
+    word       TEXT    NOT NULL,
+    -- NULL until the card is graded.
+    guessed    INTEGER,
+
+    PRIMARY KEY (session_id, position),
+    FOREIGN KEY (session_id) REFERENCES quiz_sessions (id) ON DELETE CASCADE
+);
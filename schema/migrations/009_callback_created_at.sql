@@ -0,0 +1,12 @@
+-- Records when each callback was created, so the word check schedule can tell how many checks went
+-- unanswered and how quickly the others were answered.
+--
+-- Apply once to an existing database:
+--     sqlite3 data/db.sqlite < schema/migrations/009_callback_created_at.sql
+--
+-- New databases created from schema/schema_sqlite.sql already include this.
+--
+-- SQLite cannot add a column defaulting to CURRENT_TIMESTAMP, so existing rows get NULL and are
+-- ignored by the pacing; new rows always set it.
+
+ALTER TABLE callback_data ADD COLUMN created_at TIMESTAMP;
@@ -0,0 +1,10 @@
+-- Adds pause (vacation) mode: while a chat is paused it gets no word checks or goal reminders, and
+-- its streak is not broken by the days it skips.
+--
+-- Apply once to an existing database:
+--     sqlite3 data/db.sqlite < schema/migrations/010_chat_pause.sql
+--
+-- New databases created from schema/schema_sqlite.sql already include this.
+
+ALTER TABLE chat_settings ADD COLUMN paused_from TIMESTAMP;
+ALTER TABLE chat_settings ADD COLUMN paused_until TIMESTAMP;
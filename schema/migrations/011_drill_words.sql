@@ -0,0 +1,25 @@
+-- Adds the drill queue: missed words come back at short intervals until they are answered
+-- correctly twice in a row, on top of going back into the learning batch.
+--
+-- Apply once to an existing database:
+--     sqlite3 data/db.sqlite < schema/migrations/011_drill_words.sql
+--
+-- New databases created from schema/schema_sqlite.sql already include this.
+
+CREATE TABLE drill_words
+(
+    chat_id INTEGER   NOT NULL,
+    word    TEXT      NOT NULL,
+    step    INTEGER   NOT NULL DEFAULT 0,
+    guessed INTEGER   NOT NULL DEFAULT 0,
+    due_at  TIMESTAMP NOT NULL,
+
+    PRIMARY KEY (chat_id, word),
+    FOREIGN KEY (chat_id, word)
+    REFERENCES word_translations (chat_id, word)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE
+);
+
+CREATE INDEX idx_drill_words_chat_id_due_at
+    ON drill_words (chat_id, due_at);
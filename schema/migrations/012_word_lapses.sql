@@ -0,0 +1,9 @@
+-- Adds the per-word lapse count that leech detection is built on: every wrong answer bumps it, and a
+-- word that reaches the configured threshold is tagged as a leech.
+--
+-- Apply once to an existing database:
+--     sqlite3 data/db.sqlite < schema/migrations/012_word_lapses.sql
+--
+-- New databases created from schema/schema_sqlite.sql already include this.
+
+ALTER TABLE word_translations ADD COLUMN lapses INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,10 @@
+-- Adds suspended and buried words: a suspended word is out of rotation until it is unsuspended, a
+-- buried one until buried_until.
+--
+-- Apply once to an existing database:
+--     sqlite3 data/db.sqlite < schema/migrations/013_word_suspension.sql
+--
+-- New databases created from schema/schema_sqlite.sql already include this.
+
+ALTER TABLE word_translations ADD COLUMN suspended INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE word_translations ADD COLUMN buried_until TIMESTAMP;
@@ -0,0 +1,9 @@
+-- Adds a per-chat learning batch size, set through the batch management API. Chats without one keep
+-- using BOT_LEARNING_BATCH_SIZE.
+--
+-- Apply once to an existing database:
+--     sqlite3 data/db.sqlite < schema/migrations/014_chat_batch_size.sql
+--
+-- New databases created from schema/schema_sqlite.sql already include this.
+
+ALTER TABLE chat_settings ADD COLUMN batch_size INTEGER;
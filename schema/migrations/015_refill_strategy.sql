@@ -0,0 +1,10 @@
+-- Adds per-chat refill strategies: how the learning batch is topped up once its queue is drained.
+-- Chats keep the random pick they had until they choose another one.
+--
+-- Apply once to an existing database:
+--     sqlite3 data/db.sqlite < schema/migrations/015_refill_strategy.sql
+--
+-- New databases created from schema/schema_sqlite.sql already include this.
+
+ALTER TABLE chat_settings ADD COLUMN refill_strategy TEXT NOT NULL DEFAULT 'random';
+ALTER TABLE chat_settings ADD COLUMN refill_tag TEXT NOT NULL DEFAULT '';
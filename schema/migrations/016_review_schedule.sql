@@ -0,0 +1,19 @@
+-- Schedules the reviews of learned words by due date instead of drawing them at a fixed rate.
+--
+-- Words learned before this have no history to go by: they count as learned when they were last
+-- changed, and their first reviews are spread over the next 30 days so that they do not all fall
+-- due at once. 15 is the default BOT_LEARNING_STREAK_LIMIT; use yours if you changed it.
+--
+-- Apply once to an existing database:
+--     sqlite3 data/db.sqlite < schema/migrations/016_review_schedule.sql
+--
+-- New databases created from schema/schema_sqlite.sql already include this.
+
+ALTER TABLE word_translations ADD COLUMN learned_at TIMESTAMP;
+ALTER TABLE word_translations ADD COLUMN review_interval_seconds INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE word_translations ADD COLUMN next_review_at TIMESTAMP;
+
+UPDATE word_translations
+SET learned_at     = updated_at,
+    next_review_at = datetime('now', '+' || (abs(random()) % 30) || ' days')
+WHERE guessed_streak >= 15;
@@ -0,0 +1,18 @@
+-- Adds streak limits per word and per tag, so that an easy word can count as learned sooner than a
+-- hard one. Words without either keep the configured BOT_LEARNING_STREAK_LIMIT.
+--
+-- Apply once to an existing database:
+--     sqlite3 data/db.sqlite < schema/migrations/017_streak_limits.sql
+--
+-- New databases created from schema/schema_sqlite.sql already include this.
+
+ALTER TABLE word_translations ADD COLUMN streak_limit INTEGER;
+
+CREATE TABLE tag_streak_limits
+(
+    chat_id      INTEGER NOT NULL,
+    tag          TEXT    NOT NULL,
+    streak_limit INTEGER NOT NULL,
+
+    PRIMARY KEY (chat_id, tag)
+);
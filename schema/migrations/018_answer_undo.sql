@@ -0,0 +1,27 @@
+-- Keeps what the last graded answer of each chat changed, so that /undo can take the answer back.
+--
+-- Apply once to an existing database:
+--     sqlite3 data/db.sqlite < schema/migrations/018_answer_undo.sql
+--
+-- New databases created from schema/schema_sqlite.sql already include this.
+
+CREATE TABLE answer_undo
+(
+    chat_id                 INTEGER   NOT NULL PRIMARY KEY,
+    word                    TEXT      NOT NULL,
+    guessed                 INTEGER   NOT NULL,
+    answered_at             TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    stats_date              TEXT      NOT NULL,
+    guessed_streak          INTEGER   NOT NULL,
+    lapses                  INTEGER   NOT NULL,
+    suspended               INTEGER   NOT NULL,
+    learned_at              TIMESTAMP,
+    review_interval_seconds INTEGER   NOT NULL,
+    next_review_at          TIMESTAMP,
+    leech                   INTEGER   NOT NULL,
+    batched                 INTEGER   NOT NULL,
+    queued_seq              INTEGER,
+    drill_step              INTEGER,
+    drill_guessed           INTEGER,
+    drill_due_at            TIMESTAMP
+);
@@ -0,0 +1,84 @@
+-- Keeps deleted words in a trash they can be restored from until they are purged, and an audit log of
+-- every change to a word's text.
+--
+-- Apply once to an existing database:
+--     sqlite3 data/db.sqlite < schema/migrations/019_word_trash_audit.sql
+--
+-- New databases created from schema/schema_sqlite.sql already include this.
+
+CREATE TABLE word_trash
+(
+    chat_id                 INTEGER   NOT NULL,
+    word                    TEXT      NOT NULL,
+    translation             TEXT      NOT NULL,
+    description             TEXT,
+    guessed_streak          INTEGER   NOT NULL,
+    to_review               INTEGER   NOT NULL,
+    lapses                  INTEGER   NOT NULL,
+    suspended               INTEGER   NOT NULL,
+    streak_limit            INTEGER,
+    learned_at              TIMESTAMP,
+    review_interval_seconds INTEGER   NOT NULL,
+    next_review_at          TIMESTAMP,
+    -- The word's tags, comma-separated, and whether it was in the batch or queued behind it.
+    tags                    TEXT      NOT NULL DEFAULT '',
+    batched                 INTEGER   NOT NULL,
+    created_at              TIMESTAMP NOT NULL,
+    deleted_at              TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (chat_id, word)
+);
+
+CREATE INDEX idx_word_trash_deleted_at
+    ON word_trash (deleted_at);
+
+-- Every change to a word's text: 'create', 'update', 'delete', 'restore' and 'purge'. word is the word
+-- the change left, or the one it removed. The old_ columns hold the word as it was before the change,
+-- translation and description as it is after it; either side is NULL where there is none. Written by
+-- the triggers below, except for purges, which never touch word_translations.
+CREATE TABLE word_audit
+(
+    id              INTEGER   NOT NULL PRIMARY KEY AUTOINCREMENT,
+    chat_id         INTEGER   NOT NULL,
+    word            TEXT      NOT NULL,
+    action          TEXT      NOT NULL,
+    old_word        TEXT,
+    old_translation TEXT,
+    old_description TEXT,
+    translation     TEXT,
+    description     TEXT,
+    changed_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_word_audit_chat_id_word
+    ON word_audit (chat_id, word);
+
+CREATE INDEX idx_word_audit_chat_id_old_word
+    ON word_audit (chat_id, old_word);
+
+-- A word inserted while it is in the trash is being restored: RestoreWordTranslation only empties the
+-- trash afterwards.
+CREATE TRIGGER audit_word_translations_insert
+    AFTER INSERT ON word_translations
+    BEGIN
+        INSERT INTO word_audit (chat_id, word, action, translation, description)
+        VALUES (NEW.chat_id, NEW.word,
+                CASE WHEN EXISTS (SELECT 1 FROM word_trash WHERE chat_id = NEW.chat_id AND word = NEW.word)
+                    THEN 'restore' ELSE 'create' END,
+                NEW.translation, NEW.description);
+    END;
+
+CREATE TRIGGER audit_word_translations_update
+    AFTER UPDATE OF word, translation, description ON word_translations
+    WHEN OLD.word IS NOT NEW.word OR OLD.translation IS NOT NEW.translation OR OLD.description IS NOT NEW.description
+    BEGIN
+        INSERT INTO word_audit (chat_id, word, action, old_word, old_translation, old_description, translation, description)
+        VALUES (NEW.chat_id, NEW.word, 'update', OLD.word, OLD.translation, OLD.description, NEW.translation, NEW.description);
+    END;
+
+CREATE TRIGGER audit_word_translations_delete
+    AFTER DELETE ON word_translations
+    BEGIN
+        INSERT INTO word_audit (chat_id, word, action, old_word, old_translation, old_description)
+        VALUES (OLD.chat_id, OLD.word, 'delete', OLD.word, OLD.translation, OLD.description);
+    END;
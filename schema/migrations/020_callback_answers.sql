@@ -0,0 +1,11 @@
+-- Records when and how each word check was graded, so that a check is graded only once, and which
+-- check the last answer of a chat was given on, so that undoing it opens the check again.
+--
+-- Apply once to an existing database:
+--     sqlite3 data/db.sqlite < schema/migrations/020_callback_answers.sql
+--
+-- New databases created from schema/schema_sqlite.sql already include this.
+
+ALTER TABLE callback_data ADD COLUMN answered_at TIMESTAMP;
+ALTER TABLE callback_data ADD COLUMN answer TEXT;
+ALTER TABLE answer_undo ADD COLUMN callback_id TEXT;
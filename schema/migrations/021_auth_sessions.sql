@@ -0,0 +1,26 @@
+-- Keeps a session per access token, so that signing out, or out of every browser at once, revokes the
+-- token instead of only dropping the cookie.
+--
+-- Apply once to an existing database:
+--     sqlite3 data/db.sqlite < schema/migrations/021_auth_sessions.sql
+--
+-- New databases created from schema/schema_sqlite.sql already include this.
+--
+-- Access tokens issued before this have no session and are no longer accepted: everybody signs in once
+-- more.
+
+CREATE TABLE auth_sessions
+(
+    id           TEXT      NOT NULL PRIMARY KEY,
+    chat_id      INTEGER   NOT NULL,
+    user_agent   TEXT      NOT NULL DEFAULT '',
+    ip           TEXT      NOT NULL DEFAULT '',
+    created_at   TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_seen_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    -- When the access token expires, and when the session was signed out of, in UTC.
+    expires_at   TIMESTAMP NOT NULL,
+    revoked_at   TIMESTAMP
+);
+
+CREATE INDEX idx_auth_sessions_chat_id
+    ON auth_sessions (chat_id);
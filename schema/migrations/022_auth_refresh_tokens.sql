@@ -0,0 +1,20 @@
+-- Keeps the refresh tokens that the short-lived access tokens are renewed with, hashed, so that each
+-- can be used once and a reused one revokes its session.
+--
+-- Apply once to an existing database:
+--     sqlite3 data/db.sqlite < schema/migrations/022_auth_refresh_tokens.sql
+--
+-- New databases created from schema/schema_sqlite.sql already include this.
+--
+-- Sessions signed in before this have no refresh token and end when their access token does.
+
+CREATE TABLE auth_refresh_tokens
+(
+    token_hash TEXT      NOT NULL PRIMARY KEY,
+    session_id TEXT      NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    used_at    TIMESTAMP
+);
+
+CREATE INDEX idx_auth_refresh_tokens_session_id
+    ON auth_refresh_tokens (session_id);
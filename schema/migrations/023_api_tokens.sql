@@ -0,0 +1,23 @@
+-- Adds personal access tokens, so that scripts can call the API without the browser sign-in.
+--
+-- Apply once to an existing database:
+--     sqlite3 data/db.sqlite < schema/migrations/023_api_tokens.sql
+--
+-- New databases created from schema/schema_sqlite.sql already include this.
+
+CREATE TABLE api_tokens
+(
+    id           INTEGER   NOT NULL PRIMARY KEY AUTOINCREMENT,
+    chat_id      INTEGER   NOT NULL,
+    name         TEXT      NOT NULL,
+    -- The SHA-256 of the token, which is only ever shown when it is created.
+    token_hash   TEXT      NOT NULL UNIQUE,
+    -- Comma-separated: read, words:write, stats:read.
+    scopes       TEXT      NOT NULL,
+    created_at   TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    -- NULL for a token that never expires.
+    expires_at   TIMESTAMP,
+    last_used_at TIMESTAMP,
+
+    UNIQUE (chat_id, name)
+);
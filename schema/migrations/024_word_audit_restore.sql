@@ -0,0 +1,25 @@
+-- Logs every inserted word as created, instead of as restored whenever the trash still held a word of
+-- the same name. Restores are now labelled by RestoreWordTranslation itself.
+--
+-- Apply once to an existing database:
+--     sqlite3 data/db.sqlite < schema/migrations/024_word_audit_restore.sql
+--
+-- New databases created from schema/schema_sqlite.sql already include this.
+
+DROP TRIGGER audit_word_translations_insert;
+
+-- Every inserted word is logged as created; RestoreWordTranslation relabels the entry of a restore.
+CREATE TRIGGER audit_word_translations_insert
+    AFTER INSERT ON word_translations
+    BEGIN
+        INSERT INTO word_audit (chat_id, word, action, translation, description)
+        VALUES (NEW.chat_id, NEW.word, 'create', NEW.translation, NEW.description);
+    END;
+
+-- Drop the trashed copies of words that have been added again since, which could otherwise be
+-- restored over them.
+DELETE FROM word_trash
+WHERE EXISTS (SELECT 1
+              FROM word_translations wt
+              WHERE wt.chat_id = word_trash.chat_id
+                AND wt.word = word_trash.word);
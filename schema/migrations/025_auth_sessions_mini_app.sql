@@ -0,0 +1,9 @@
+-- Marks the sessions signed in from the Telegram Mini App, whose cookies have to be sent SameSite=None
+-- to reach the web interface framed by Telegram, refreshes included.
+--
+-- Apply once to an existing database:
+--     sqlite3 data/db.sqlite < schema/migrations/025_auth_sessions_mini_app.sql
+--
+-- New databases created from schema/schema_sqlite.sql already include this.
+
+ALTER TABLE auth_sessions ADD COLUMN mini_app INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,21 @@
+-- Logs restores explicitly: RestoreWordTranslation marks the trash row it is restoring, the insert
+-- trigger leaves such an insert alone, and the restore writes its own audit entry.
+--
+-- Apply once to an existing database:
+--     sqlite3 data/db.sqlite < schema/migrations/026_word_trash_restoring.sql
+--
+-- New databases created from schema/schema_sqlite.sql already include this.
+
+ALTER TABLE word_trash ADD COLUMN restoring INTEGER NOT NULL DEFAULT 0;
+
+DROP TRIGGER audit_word_translations_insert;
+
+-- Every inserted word is logged as created, except the one RestoreWordTranslation is restoring: it
+-- marks the word's trash row while it does, and logs the restore itself.
+CREATE TRIGGER audit_word_translations_insert
+    AFTER INSERT ON word_translations
+    WHEN NOT EXISTS (SELECT 1 FROM word_trash WHERE chat_id = NEW.chat_id AND word = NEW.word AND restoring)
+    BEGIN
+        INSERT INTO word_audit (chat_id, word, action, translation, description)
+        VALUES (NEW.chat_id, NEW.word, 'create', NEW.translation, NEW.description);
+    END;
@@ -0,0 +1,9 @@
+-- Lets every chat pick the timezone its weekly and monthly digests are sent in, instead of all of
+-- them following BOT_SCHEDULE_LOCATION.
+--
+-- Apply once to an existing database:
+--     sqlite3 data/db.sqlite < schema/migrations/027_chat_settings_timezone.sql
+--
+-- New databases created from schema/schema_sqlite.sql already include this.
+
+ALTER TABLE chat_settings ADD COLUMN timezone TEXT NOT NULL DEFAULT '';
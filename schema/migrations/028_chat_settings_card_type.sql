@@ -0,0 +1,9 @@
+-- Lets every chat choose whether its word checks are classic cards, cloze cards or a mix of both at
+-- BOT_LEARNING_CLOZE_RATE_PERCENT.
+--
+-- Apply once to an existing database:
+--     sqlite3 data/db.sqlite < schema/migrations/028_chat_settings_card_type.sql
+--
+-- New databases created from schema/schema_sqlite.sql already include this.
+
+ALTER TABLE chat_settings ADD COLUMN card_type TEXT NOT NULL DEFAULT 'mixed';
@@ -14,6 +14,21 @@ CREATE TABLE word_translations
     -- on the clock advancing between two reviews. Deliberately separate from updated_at, which the
     -- trigger below bumps on every edit.
     last_reviewed_seq INTEGER,
+    -- How many times the word has been answered wrong. A word missed often enough is a leech; see
+    -- the leech tag in word_tags.
+    lapses         INTEGER     NOT NULL DEFAULT 0,
+    -- A suspended word is out of rotation until it is unsuspended: it is never admitted to the
+    -- learning batch nor drawn for a check. A buried one only until buried_until, kept in UTC.
+    suspended      INTEGER     NOT NULL DEFAULT 0,
+    buried_until   TIMESTAMP,
+    -- The review schedule of a learned word, all NULL or 0 while it is being learned: when it was
+    -- learned, the last wait between two of its reviews, and when the next one is due, in UTC.
+    learned_at     TIMESTAMP,
+    review_interval_seconds INTEGER NOT NULL DEFAULT 0,
+    next_review_at TIMESTAMP,
+    -- The streak at which this word counts as learned, overriding its tags' limits and the configured
+    -- BOT_LEARNING_STREAK_LIMIT. NULL means it has none of its own.
+    streak_limit   INTEGER,
     created_at     TIMESTAMP   NOT NULL DEFAULT CURRENT_TIMESTAMP,
     updated_at     TIMESTAMP   NOT NULL DEFAULT CURRENT_TIMESTAMP,
 
@@ -34,6 +49,87 @@ CREATE INDEX idx_word_translations_chat_id
 CREATE INDEX idx_word_translations_review
     ON word_translations (chat_id, guessed_streak, last_reviewed_seq);
 
+-- Deleted words, kept until they are restored or purged BOT_TRASH_RETENTION_DAYS after the delete.
+-- Only the last deleted version of a word is kept. The word's progress is kept with it, so that a
+-- restored word picks up where it was left.
+CREATE TABLE word_trash
+(
+    chat_id                 INTEGER   NOT NULL,
+    word                    TEXT      NOT NULL,
+    translation             TEXT      NOT NULL,
+    description             TEXT,
+    guessed_streak          INTEGER   NOT NULL,
+    to_review               INTEGER   NOT NULL,
+    lapses                  INTEGER   NOT NULL,
+    suspended               INTEGER   NOT NULL,
+    streak_limit            INTEGER,
+    learned_at              TIMESTAMP,
+    review_interval_seconds INTEGER   NOT NULL,
+    next_review_at          TIMESTAMP,
+    -- The word's tags, comma-separated, and whether it was in the batch or queued behind it.
+    tags                    TEXT      NOT NULL DEFAULT '',
+    batched                 INTEGER   NOT NULL,
+    created_at              TIMESTAMP NOT NULL,
+    deleted_at              TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    -- Set by RestoreWordTranslation while it puts the word back, so the insert is not logged as a create.
+    restoring               INTEGER   NOT NULL DEFAULT 0,
+
+    PRIMARY KEY (chat_id, word)
+);
+
+CREATE INDEX idx_word_trash_deleted_at
+    ON word_trash (deleted_at);
+
+-- Every change to a word's text: 'create', 'update', 'delete', 'restore' and 'purge'. word is the word
+-- the change left, or the one it removed. The old_ columns hold the word as it was before the change,
+-- translation and description as it is after it; either side is NULL where there is none. Written by
+-- the triggers below, except for purges, which never touch word_translations, and restores, which
+-- RestoreWordTranslation logs itself.
+CREATE TABLE word_audit
+(
+    id              INTEGER   NOT NULL PRIMARY KEY AUTOINCREMENT,
+    chat_id         INTEGER   NOT NULL,
+    word            TEXT      NOT NULL,
+    action          TEXT      NOT NULL,
+    old_word        TEXT,
+    old_translation TEXT,
+    old_description TEXT,
+    translation     TEXT,
+    description     TEXT,
+    changed_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_word_audit_chat_id_word
+    ON word_audit (chat_id, word);
+
+CREATE INDEX idx_word_audit_chat_id_old_word
+    ON word_audit (chat_id, old_word);
+
+-- Every inserted word is logged as created, except the one RestoreWordTranslation is restoring: it
+-- marks the word's trash row while it does, and logs the restore itself.
+CREATE TRIGGER audit_word_translations_insert
+    AFTER INSERT ON word_translations
+    WHEN NOT EXISTS (SELECT 1 FROM word_trash WHERE chat_id = NEW.chat_id AND word = NEW.word AND restoring)
+    BEGIN
+        INSERT INTO word_audit (chat_id, word, action, translation, description)
+        VALUES (NEW.chat_id, NEW.word, 'create', NEW.translation, NEW.description);
+    END;
+
+CREATE TRIGGER audit_word_translations_update
+    AFTER UPDATE OF word, translation, description ON word_translations
+    WHEN OLD.word IS NOT NEW.word OR OLD.translation IS NOT NEW.translation OR OLD.description IS NOT NEW.description
+    BEGIN
+        INSERT INTO word_audit (chat_id, word, action, old_word, old_translation, old_description, translation, description)
+        VALUES (NEW.chat_id, NEW.word, 'update', OLD.word, OLD.translation, OLD.description, NEW.translation, NEW.description);
+    END;
+
+CREATE TRIGGER audit_word_translations_delete
+    AFTER DELETE ON word_translations
+    BEGIN
+        INSERT INTO word_audit (chat_id, word, action, old_word, old_translation, old_description)
+        VALUES (OLD.chat_id, OLD.word, 'delete', OLD.word, OLD.translation, OLD.description);
+    END;
+
 CREATE TABLE learning_batches
 (
     chat_id INTEGER NOT NULL,
@@ -49,7 +145,7 @@ CREATE TABLE learning_batches
 CREATE INDEX idx_learning_batches_chat_id
     ON learning_batches (chat_id);
 
--- FIFO admission queue: BOT_LEARNING_BATCH_SIZE is a hard cap on learning_batches, so a word that
+-- FIFO admission queue: the batch size is a hard cap on learning_batches, so a word that
 -- wants back in (a miss, a deliberate reset, a conflict resolution, or a brand-new word) while the
 -- batch is already full lands here instead of overflowing it or being lost. RefillLearningBatch
 -- drains it oldest-first as room frees up.
@@ -72,12 +168,93 @@ CREATE TABLE learning_batch_queue
 CREATE INDEX idx_learning_batch_queue_chat_id_seq
     ON learning_batch_queue (chat_id, queued_seq);
 
+-- Short-term relearning of missed words, next to the learning batch: a missed word comes back after
+-- the first drill interval, then the next one each time it is answered, until it is answered
+-- correctly twice in a row.
+CREATE TABLE drill_words
+(
+    chat_id INTEGER   NOT NULL,
+    word    TEXT      NOT NULL,
+    -- Which drill interval the word is waiting out; capped at the last one.
+    step    INTEGER   NOT NULL DEFAULT 0,
+    -- Correct answers in a row since the word was last missed.
+    guessed INTEGER   NOT NULL DEFAULT 0,
+    -- When the word is asked again, as SQLite's datetime('now') writes it (UTC).
+    due_at  TIMESTAMP NOT NULL,
+
+    PRIMARY KEY (chat_id, word),
+    FOREIGN KEY (chat_id, word)
+    REFERENCES word_translations (chat_id, word)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE
+);
+
+CREATE INDEX idx_drill_words_chat_id_due_at
+    ON drill_words (chat_id, due_at);
+
+-- One row per graded answer. statistics only keeps per-day totals; this keeps which word was
+-- answered how, for everything that needs per-word history (hardest words, graduations).
+CREATE TABLE answer_log
+(
+    id           INTEGER   NOT NULL PRIMARY KEY AUTOINCREMENT,
+    chat_id      INTEGER   NOT NULL,
+    word         TEXT      NOT NULL,
+    guessed      INTEGER   NOT NULL,
+    -- The word's guessed_streak right after the answer was applied.
+    streak_after INTEGER   NOT NULL,
+    answered_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (chat_id, word)
+    REFERENCES word_translations (chat_id, word)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE
+);
+
+CREATE INDEX idx_answer_log_chat_id_answered_at
+    ON answer_log (chat_id, answered_at);
+
+-- What the last graded answer of each chat changed, as it was before, so that the answer can be taken
+-- back (/undo). Every answer replaces the chat's row and undoing it deletes the row, so an answer is
+-- only ever undone once.
+CREATE TABLE answer_undo
+(
+    chat_id                 INTEGER   NOT NULL PRIMARY KEY,
+    word                    TEXT      NOT NULL,
+    guessed                 INTEGER   NOT NULL,
+    answered_at             TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    -- The statistics row the answer was counted in.
+    stats_date              TEXT      NOT NULL,
+    -- The word as it was.
+    guessed_streak          INTEGER   NOT NULL,
+    lapses                  INTEGER   NOT NULL,
+    suspended               INTEGER   NOT NULL,
+    learned_at              TIMESTAMP,
+    review_interval_seconds INTEGER   NOT NULL,
+    next_review_at          TIMESTAMP,
+    -- Whether it was tagged a leech, in the batch, or queued behind it, and where.
+    leech                   INTEGER   NOT NULL,
+    batched                 INTEGER   NOT NULL,
+    queued_seq              INTEGER,
+    -- Its drill state; all NULL when it was not being drilled.
+    drill_step              INTEGER,
+    drill_guessed           INTEGER,
+    drill_due_at            TIMESTAMP,
+    -- The word check the answer was given on, if it was; undoing the answer opens it again.
+    callback_id             TEXT
+);
+
 CREATE TABLE callback_data
 (
     chat_id    INTEGER NOT NULL,
     uuid       TEXT    NOT NULL,
     data       TEXT    NOT NULL,
     expires_at TIMESTAMP NOT NULL,
+    -- When the message carrying the buttons was sent. NULL for rows older than migration 009.
+    created_at TIMESTAMP          DEFAULT CURRENT_TIMESTAMP,
+    -- When a word check was graded with its buttons, and how: 'guessed' or 'missed'. A check is graded
+    -- at most once; undoing the answer clears both again.
+    answered_at TIMESTAMP,
+    answer      TEXT,
 
     PRIMARY KEY (chat_id, uuid)
 );
@@ -92,12 +269,70 @@ CREATE TABLE auth_confirmations
     PRIMARY KEY (chat_id, token)
 );
 
+-- Signed-in browsers, keyed by the JWT ID of their access tokens. A token is only accepted while its
+-- session is neither revoked nor expired, so signing out takes effect at once.
+CREATE TABLE auth_sessions
+(
+    id           TEXT      NOT NULL PRIMARY KEY,
+    chat_id      INTEGER   NOT NULL,
+    user_agent   TEXT      NOT NULL DEFAULT '',
+    ip           TEXT      NOT NULL DEFAULT '',
+    created_at   TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_seen_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    -- When the refresh token expires, and when the session was signed out of, in UTC.
+    expires_at   TIMESTAMP NOT NULL,
+    revoked_at   TIMESTAMP,
+    -- Signed in from the Telegram Mini App, so its cookies are sent SameSite=None.
+    mini_app     INTEGER   NOT NULL DEFAULT 0
+);
+
+CREATE INDEX idx_auth_sessions_chat_id
+    ON auth_sessions (chat_id);
+
+-- Every refresh token a session was given, by the SHA-256 of the token. A token is good for one
+-- refresh: used_at is set when it is exchanged for the next one, and presenting it again revokes the
+-- session, since one of the two presenting it must have stolen it.
+CREATE TABLE auth_refresh_tokens
+(
+    token_hash TEXT      NOT NULL PRIMARY KEY,
+    session_id TEXT      NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    used_at    TIMESTAMP
+);
+
+CREATE INDEX idx_auth_refresh_tokens_session_id
+    ON auth_refresh_tokens (session_id);
+
+-- Personal access tokens for scripts, sent as "Authorization: Bearer". A token is deleted when it is
+-- revoked.
+CREATE TABLE api_tokens
+(
+    id           INTEGER   NOT NULL PRIMARY KEY AUTOINCREMENT,
+    chat_id      INTEGER   NOT NULL,
+    name         TEXT      NOT NULL,
+    -- The SHA-256 of the token, which is only ever shown when it is created.
+    token_hash   TEXT      NOT NULL UNIQUE,
+    -- Comma-separated: read, words:write, stats:read.
+    scopes       TEXT      NOT NULL,
+    created_at   TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    -- NULL for a token that never expires.
+    expires_at   TIMESTAMP,
+    last_used_at TIMESTAMP,
+
+    UNIQUE (chat_id, name)
+);
+
 CREATE TABLE statistics (
     chat_id INTEGER NOT NULL,
     date TEXT NOT NULL,
     words_guessed INTEGER NOT NULL DEFAULT 0,
     words_missed INTEGER NOT NULL DEFAULT 0,
     total_words_learned INTEGER NOT NULL DEFAULT 0,
+    -- Brand-new words created that day, for the "new words per day" goal.
+    words_added INTEGER NOT NULL DEFAULT 0,
+    -- Set when a streak freeze or a pause covered the day: it counts towards the streak as if the
+    -- daily goal had been met.
+    streak_frozen INTEGER NOT NULL DEFAULT 0,
     created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
 
     PRIMARY KEY (chat_id, date)
@@ -105,3 +340,196 @@ CREATE TABLE statistics (
 
 CREATE INDEX idx_statistics_chat_id_date
     ON statistics (chat_id, date);
+
+-- Per-chat state that is not about any single word.
+CREATE TABLE chat_settings
+(
+    chat_id                INTEGER   NOT NULL PRIMARY KEY,
+    -- Streak freezes earned and not spent yet. Each one covers a single day the daily goal was
+    -- missed.
+    streak_freezes         INTEGER   NOT NULL DEFAULT 0,
+    -- The last day (YYYY-MM-DD) SettleStreak has already spent or earned freezes for, so that
+    -- running it again for the same day is a no-op.
+    streak_settled_through TEXT,
+    -- Whether the chat shows up on the shared leaderboard and counts towards team challenges:
+    -- 'hidden' (the default), 'anonymous' or 'public'.
+    leaderboard_visibility TEXT      NOT NULL DEFAULT 'hidden',
+    -- The name a public chat is listed under.
+    leaderboard_name       TEXT      NOT NULL DEFAULT '',
+    -- The latest pause: no word checks or goal reminders are sent between the two, and the days it
+    -- covers do not break the streak. paused_until is moved back to the resume time by /resume.
+    paused_from            TIMESTAMP,
+    paused_until           TIMESTAMP,
+    -- How many words the chat's learning batch is topped up to. NULL means BOT_LEARNING_BATCH_SIZE.
+    batch_size             INTEGER,
+    -- How the learning batch is topped up once its queue is drained (see dal.RefillStrategy), and
+    -- the tag the 'tag' strategy draws from.
+    refill_strategy        TEXT      NOT NULL DEFAULT 'random',
+    refill_tag             TEXT      NOT NULL DEFAULT '',
+    -- The IANA timezone, such as 'Europe/Kyiv', the chat's digests are sent in. '' means
+    -- BOT_SCHEDULE_LOCATION.
+    timezone               TEXT      NOT NULL DEFAULT '',
+    -- The kind of card word checks are sent as (see dal.CardType): 'mixed', 'classic' or 'cloze'.
+    card_type              TEXT      NOT NULL DEFAULT 'mixed',
+    created_at             TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Team challenges: a shared target for every chat on the leaderboard, such as "learn 30 words this
+-- week". Progress is not stored; it is summed from statistics and answer_log on read.
+CREATE TABLE challenges
+(
+    id         INTEGER   NOT NULL PRIMARY KEY AUTOINCREMENT,
+    created_by INTEGER   NOT NULL,
+    title      TEXT      NOT NULL,
+    -- 'answers', 'guessed', 'new_words' or 'learned'
+    metric     TEXT      NOT NULL,
+    target     INTEGER   NOT NULL,
+    -- First and last day (YYYY-MM-DD), both inclusive.
+    starts_on  TEXT      NOT NULL,
+    ends_on    TEXT      NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_challenges_ends_on
+    ON challenges (ends_on);
+
+-- Shared decks: a named set of one chat's words that other chats can subscribe to. Subscribing copies
+-- the words into the subscriber's own word_translations, so progress stays per chat; the owner's
+-- later edits are copied again as translation updates only.
+CREATE TABLE decks
+(
+    id            INTEGER   NOT NULL PRIMARY KEY AUTOINCREMENT,
+    owner_chat_id INTEGER   NOT NULL,
+    name          TEXT      NOT NULL,
+    created_at    TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE (owner_chat_id, name)
+);
+
+-- The owner's words in a deck. Kept in step with word_translations by the repository - renames and
+-- deletes are applied here explicitly.
+CREATE TABLE deck_words
+(
+    deck_id INTEGER NOT NULL,
+    word    TEXT    NOT NULL,
+
+    PRIMARY KEY (deck_id, word),
+    FOREIGN KEY (deck_id) REFERENCES decks (id) ON DELETE CASCADE
+);
+
+CREATE TABLE deck_subscriptions
+(
+    deck_id    INTEGER   NOT NULL,
+    chat_id    INTEGER   NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (deck_id, chat_id),
+    FOREIGN KEY (deck_id) REFERENCES decks (id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_deck_subscriptions_chat_id
+    ON deck_subscriptions (chat_id);
+
+-- Group chats: the group's words live in word_translations under the group's chat ID, but every
+-- member learns them separately. These are the members who answered at least once, under the name
+-- the round scoreboards show.
+CREATE TABLE group_members
+(
+    group_chat_id INTEGER   NOT NULL,
+    user_id       INTEGER   NOT NULL,
+    name          TEXT      NOT NULL DEFAULT '',
+    created_at    TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (group_chat_id, user_id)
+);
+
+-- Each member's streak on the group's words: the group counterpart of
+-- word_translations.guessed_streak.
+CREATE TABLE group_progress
+(
+    group_chat_id  INTEGER NOT NULL,
+    user_id        INTEGER NOT NULL,
+    word           TEXT    NOT NULL,
+    guessed_streak INTEGER NOT NULL DEFAULT 0,
+
+    PRIMARY KEY (group_chat_id, user_id, word)
+);
+
+-- One row per answer given in a group. A round is one quiz message, identified by its callback ID,
+-- and every member answers it at most once.
+CREATE TABLE group_answers
+(
+    round_id      TEXT      NOT NULL,
+    group_chat_id INTEGER   NOT NULL,
+    user_id       INTEGER   NOT NULL,
+    word          TEXT      NOT NULL,
+    guessed       INTEGER   NOT NULL,
+    -- The member's streak on the word right after the answer was applied.
+    streak_after  INTEGER   NOT NULL,
+    answered_at   TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (round_id, user_id)
+);
+
+CREATE INDEX idx_group_answers_group_chat_id_answered_at
+    ON group_answers (group_chat_id, answered_at);
+
+-- Free-form labels on words, such as "phrasal-verbs". Tags are stored lowercase and never contain
+-- commas.
+CREATE TABLE word_tags
+(
+    chat_id INTEGER NOT NULL,
+    word    TEXT    NOT NULL,
+    tag     TEXT    NOT NULL,
+
+    PRIMARY KEY (chat_id, word, tag),
+    FOREIGN KEY (chat_id, word)
+    REFERENCES word_translations (chat_id, word)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE
+);
+
+CREATE INDEX idx_word_tags_chat_id_tag
+    ON word_tags (chat_id, tag);
+
+-- The streak at which the words with a tag count as learned, unless a word has a limit of its own. A
+-- word with several such tags takes the highest of their limits.
+CREATE TABLE tag_streak_limits
+(
+    chat_id      INTEGER NOT NULL,
+    tag          TEXT    NOT NULL,
+    streak_limit INTEGER NOT NULL,
+
+    PRIMARY KEY (chat_id, tag)
+);
+
+-- Quiz sessions (/quiz): a run of cards practiced back to back. The cards are picked when the
+-- session starts and kept with their results, so a restart does not lose the session.
+CREATE TABLE quiz_sessions
+(
+    id          INTEGER   NOT NULL PRIMARY KEY AUTOINCREMENT,
+    chat_id     INTEGER   NOT NULL,
+    -- 'batch', 'review', 'misses' or 'tag'
+    source      TEXT      NOT NULL,
+    tag         TEXT      NOT NULL DEFAULT '',
+    -- The card being asked. It equals the number of cards once every card has been graded.
+    position    INTEGER   NOT NULL DEFAULT 0,
+    -- Set when the last card is graded or the session is stopped early.
+    finished_at TIMESTAMP,
+    created_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_quiz_sessions_chat_id
+    ON quiz_sessions (chat_id, finished_at);
+
+CREATE TABLE quiz_cards
+(
+    session_id INTEGER NOT NULL,
+    position   INTEGER NOT NULL,
+    word       TEXT    NOT NULL,
+    -- NULL until the card is graded.
+    guessed    INTEGER,
+
+    PRIMARY KEY (session_id, position),
+    FOREIGN KEY (session_id) REFERENCES quiz_sessions (id) ON DELETE CASCADE
+);
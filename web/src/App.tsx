@@ -4,6 +4,7 @@ import { Navbar } from "./components/Navbar";
 import { Home } from "./routes/Home";
 import { Stats } from "./routes/Stats";
 import { Login } from "./routes/Login";
+import { Tokens } from "./routes/Tokens";
 import {AuthenticationGuard} from "./components/AuthenticationGuard.tsx";
 import {ErrorPage} from "./routes/Error.tsx";
 
@@ -21,6 +22,7 @@ function App() {
                                 <Routes>
                                     <Route path="/" element={<Home />} />
                                     <Route path="/stats" element={<Stats />} />
+                                    <Route path="/tokens" element={<Tokens />} />
                                     <Route path="/error" element={<ErrorPage />} />
                                 </Routes>
                             </>
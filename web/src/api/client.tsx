@@ -8,6 +8,17 @@ export interface Status {
     authenticated: boolean;
 }
 
+/** What the Telegram Login Widget passes to its callback, posted to /auth/telegram-widget as is. */
+export interface TelegramWidgetUser {
+    id: number;
+    first_name: string;
+    last_name?: string;
+    username?: string;
+    photo_url?: string;
+    auth_date: number;
+    hash: string;
+}
+
 /** Build info of the backend that is actually serving this session. */
 export interface Health {
     status: string;
@@ -25,6 +36,8 @@ export interface TotalStats {
     batched: number;
     /** Words waiting in the admission queue for room to open up in the batch. */
     queued: number;
+    /** Words marked to review. */
+    to_review: number;
 }
 
 /** Used only until the first /stats/total response lands. */
@@ -47,7 +60,7 @@ export interface StatsRange {
 
 export interface WordsQueryParams {
     search: string;
-    guessed: 'all' | 'learned' | 'batched' | 'to_learn';
+    guessed: 'all' | 'learned' | 'batched' | 'to_learn' | 'suspended' | 'leech';
     to_review: boolean;
     offset: number;
     limit: number;
@@ -65,6 +78,12 @@ export interface Word {
     guessed_streak?: number;
     /** Read only: whether the word is currently in the active learning batch. */
     in_batch?: boolean;
+    /** Read only: how many times the word has been answered wrong. */
+    lapses?: number;
+    /** Read only: out of rotation until unsuspended with PUT /words/suspend. */
+    suspended?: boolean;
+    /** Read only: not asked until then. */
+    buried_until?: string;
     /** Create only. Omitted, a duplicate is refused with 409 instead of overwritten. */
     on_conflict?: ConflictResolution;
 }
@@ -91,14 +110,56 @@ export interface ResetStreakRequest {
     add_to_batch: boolean;
 }
 
+/** Replaces both rotation states of a word; leaving buried_until out unburies it. */
+export interface SuspendWordRequest {
+    word: string;
+    suspended: boolean;
+    buried_until?: string;
+}
+
+export type TokenScope = 'read' | 'words:write' | 'stats:read';
+
+/** A personal access token. `token` is only set in the response that creates it. */
+export interface APIToken {
+    id: number;
+    name: string;
+    scopes: TokenScope[];
+    token?: string;
+    created_at: string;
+    /** Null for a token that never expires. */
+    expires_at: string | null;
+    last_used_at: string | null;
+}
+
+export interface APITokens {
+    items: APIToken[];
+}
+
+export interface CreateTokenRequest {
+    name: string;
+    scopes: TokenScope[];
+    /** 0 makes a token that never expires. */
+    expires_in_days: number;
+}
+
 /** Error envelope used by every non-2xx response. Note the JSON key is `error`, not `message`. */
 export interface APIError {
     error: string;
 }
 
+/** Endpoints whose 401 means "not signed in" rather than "access token expired". */
+const NO_REFRESH_ENDPOINTS = [
+    '/auth/login', '/auth/status', '/auth/refresh', '/auth/logout', '/auth/telegram-widget', '/auth/webapp',
+];
+
 class ApiClient {
     private readonly baseUrl: string;
     private readonly defaultTimeout: number = 10000; // 10 seconds
+    /**
+     * The refresh in flight, shared by every request that hit a 401 meanwhile: a refresh token works
+     * once, and presenting it twice signs the session out.
+     */
+    private refreshing: Promise<boolean> | null = null;
 
     constructor() {
         this.baseUrl = import.meta.env.VITE_API_BASE_URL || window.location.origin;
@@ -161,6 +222,13 @@ class ApiClient {
         });
     }
 
+    async suspendWord(req: SuspendWordRequest): Promise<Response> {
+        return this.request('/words/suspend', {
+            method: 'PUT',
+            body: JSON.stringify(req),
+        });
+    }
+
     async resetStreak(req: ResetStreakRequest): Promise<Response> {
         return this.request('/words/reset', {
             method: 'POST',
@@ -183,12 +251,49 @@ class ApiClient {
         });
     }
 
+    async refresh(): Promise<Response> {
+        return this.request('/auth/refresh', {
+            method: 'POST',
+        });
+    }
+
+    async loginTelegramWidget(user: TelegramWidgetUser): Promise<Response> {
+        return this.request('/auth/telegram-widget', {
+            method: 'POST',
+            body: JSON.stringify(user),
+        });
+    }
+
+    async loginWebApp(initData: string): Promise<Response> {
+        return this.request('/auth/webapp', {
+            method: 'POST',
+            body: JSON.stringify({ init_data: initData }),
+        });
+    }
+
     async logout(): Promise<Response> {
         return this.request('/auth/logout', {
             method: 'POST',
         });
     }
 
+    async getTokens(): Promise<Response> {
+        return this.request('/auth/tokens');
+    }
+
+    async createToken(req: CreateTokenRequest): Promise<Response> {
+        return this.request('/auth/tokens', {
+            method: 'POST',
+            body: JSON.stringify(req),
+        });
+    }
+
+    async revokeToken(id: number): Promise<Response> {
+        return this.request(`/auth/tokens/${id}`, {
+            method: 'DELETE',
+        });
+    }
+
     async getHealth(): Promise<Response> {
         return this.request('/health');
     }
@@ -212,6 +317,30 @@ class ApiClient {
     private async request(
         endpoint: string,
         options: RequestInit = {},
+    ): Promise<Response> {
+        const response = await this.send(endpoint, options);
+        if (response.status !== 401 || NO_REFRESH_ENDPOINTS.some(e => endpoint.startsWith(e))) {
+            return response;
+        }
+
+        // The access token is short-lived: get a new one with the refresh cookie and try once more.
+        if (!this.refreshing) {
+            this.refreshing = this.refresh()
+                .then(r => r.ok)
+                .catch(() => false)
+                .finally(() => {
+                    this.refreshing = null;
+                });
+        }
+        if (!await this.refreshing) {
+            return response;
+        }
+        return this.send(endpoint, options);
+    }
+
+    private async send(
+        endpoint: string,
+        options: RequestInit = {},
     ): Promise<Response> {
         const controller = new AbortController();
         const timeoutId = setTimeout(() => controller.abort(), this.defaultTimeout);
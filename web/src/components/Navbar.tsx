@@ -74,6 +74,7 @@ export function Navbar() {
                                 </span>
                             </Link>
                         )}
+                        {state.user && <Link to="/tokens" className="nav-link me-lg-3">API tokens</Link>}
                         {state.user && <Button variant="outline-danger" onClick={handleLogout}>Log out</Button>}
                     </Nav>
                 </BSNavbar.Collapse>
@@ -0,0 +1,33 @@
+import { useEffect, useRef } from 'react';
+import type { TelegramWidgetUser } from '../api/client.tsx';
+
+interface TelegramLoginProps {
+    botUsername: string;
+    onAuth: (user: TelegramWidgetUser) => void;
+}
+
+/** Renders the Telegram Login Widget, which calls onAuth with the signed user data. */
+export function TelegramLogin({ botUsername, onAuth }: TelegramLoginProps) {
+    const container = useRef<HTMLDivElement>(null);
+
+    useEffect(() => {
+        window.onTelegramAuth = onAuth;
+
+        const script = document.createElement('script');
+        script.src = 'https://telegram.org/js/telegram-widget.js?22';
+        script.async = true;
+        script.setAttribute('data-telegram-login', botUsername);
+        script.setAttribute('data-size', 'large');
+        script.setAttribute('data-request-access', 'write');
+        script.setAttribute('data-onauth', 'onTelegramAuth(user)');
+
+        const element = container.current;
+        element?.appendChild(script);
+        return () => {
+            delete window.onTelegramAuth;
+            element?.replaceChildren();
+        };
+    }, [botUsername, onAuth]);
+
+    return <div ref={container} />;
+}
@@ -213,6 +213,25 @@ export function Home() {
       });
   };
 
+  const handleUnsuspend = (word: string) => {
+    if (error !== "") {
+      setError("");
+    }
+    client
+      .suspendWord({ word, suspended: false })
+      .then((r) => {
+        if (r.status === 200) {
+          setRefetchTrigger((prev) => prev + 1);
+        } else {
+          setError("Failed to unsuspend word");
+        }
+      })
+      .catch((e) => {
+        console.error("Error unsuspending word:", e);
+        setError("Failed to unsuspend word");
+      });
+  };
+
   const isWordLearned = (guessedStreak: number) => {
     return guessedStreak >= streakLimit;
   };
@@ -291,7 +310,9 @@ export function Home() {
                           | "all"
                           | "learned"
                           | "batched"
-                          | "to_learn",
+                          | "to_learn"
+                          | "suspended"
+                          | "leech",
                       };
                     });
                   }}
@@ -300,6 +321,8 @@ export function Home() {
                   <option value="learned">Learned</option>
                   <option value="batched">Batched</option>
                   <option value="to_learn">To Learn</option>
+                  <option value="suspended">Suspended</option>
+                  <option value="leech">Leeches</option>
                 </Form.Select>
               </Form.Group>
             </Col>
@@ -445,6 +468,17 @@ export function Home() {
                                 </span>
                               </Badge>
                             )}
+                            {item.suspended && (
+                              <Badge
+                                bg="warning"
+                                className="ms-1"
+                                role="button"
+                                title="Suspended, click to bring it back"
+                                onClick={() => handleUnsuspend(item.word)}
+                              >
+                                ⏸
+                              </Badge>
+                            )}
                           </td>
                           <td className="text-center">
                             <Form.Check
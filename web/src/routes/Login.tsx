@@ -1,7 +1,14 @@
-import React, { useState } from "react";
+import React, { useCallback, useEffect, useState } from "react";
 import { useNavigate } from "react-router";
-import client, { type Status } from "../api/client.tsx";
+import client, { type Status, type TelegramWidgetUser } from "../api/client.tsx";
 import { Row, Col, Form, Button, Alert, Container, Spinner } from 'react-bootstrap';
+import { TelegramLogin } from "../components/TelegramLogin.tsx";
+
+const botUsername = import.meta.env.VITE_TELEGRAM_BOT_USERNAME;
+
+function telegramLoginError(status: number): string {
+    return status === 403 ? "This Telegram account is not allowed" : "Could not log in with Telegram";
+}
 
 let intervalID = 0;
 
@@ -10,6 +17,32 @@ export function Login() {
     const [awaiting, setAwaiting] = useState(false);
     const navigate = useNavigate();
 
+    // Opened as a Telegram Mini App: Telegram has already told us who the user is.
+    useEffect(() => {
+        const webApp = window.Telegram?.WebApp;
+        if (!webApp?.initData) {
+            return;
+        }
+        webApp.ready();
+        client.loginWebApp(webApp.initData).then((r) => {
+            if (r.ok) {
+                navigate("/");
+            } else {
+                setErrorMessage(telegramLoginError(r.status));
+            }
+        }).catch((error) => setErrorMessage(error.message));
+    }, [navigate]);
+
+    const handleTelegramAuth = useCallback((user: TelegramWidgetUser) => {
+        client.loginTelegramWidget(user).then((r) => {
+            if (r.ok) {
+                navigate("/");
+            } else {
+                setErrorMessage(telegramLoginError(r.status));
+            }
+        }).catch((error) => setErrorMessage(error.message));
+    }, [navigate]);
+
     const handleSubmit = async (event: React.FormEvent<HTMLFormElement>) => {
         event.preventDefault();
         if (errorMessage) {
@@ -110,6 +143,14 @@ export function Login() {
                                         <Button type="submit" variant="primary" className="px-4">Submit</Button>
                                     </Col>
                                 </Row>
+                                {botUsername && (
+                                    <Row className="mt-4">
+                                        <Col className="d-flex flex-column align-items-center">
+                                            <span className="text-muted mb-2">or</span>
+                                            <TelegramLogin botUsername={botUsername} onAuth={handleTelegramAuth} />
+                                        </Col>
+                                    </Row>
+                                )}
                             </Form>
                         )}
                     </div>
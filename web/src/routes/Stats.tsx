@@ -133,6 +133,9 @@ export function Stats() {
                             <div className="text-secondary">
                                 Waiting in queue: {appState.stats?.queued ?? 0}
                             </div>
+                            <div className="text-secondary">
+                                To review: {appState.stats?.to_review ?? 0}
+                            </div>
                         </Card.Body>
                     </Card>
                 </Col>
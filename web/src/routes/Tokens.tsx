@@ -0,0 +1,135 @@
+import { useEffect, useState } from 'react';
+import { Alert, Button, Card, Container, Form, Table } from 'react-bootstrap';
+import { format } from 'date-fns';
+import client from '../api/client.tsx';
+import type { APIError, APIToken, APITokens, TokenScope } from '../api/client.tsx';
+
+const SCOPES: { scope: TokenScope; label: string }[] = [
+    { scope: 'read', label: 'Read everything' },
+    { scope: 'words:write', label: 'Read, add and edit words' },
+    { scope: 'stats:read', label: 'Read statistics' },
+];
+
+function formatDate(date: string | null, fallback: string): string {
+    return date ? format(new Date(date), 'MMM d, yyyy') : fallback;
+}
+
+export function Tokens() {
+    const [tokens, setTokens] = useState<APIToken[]>([]);
+    const [name, setName] = useState('');
+    const [scopes, setScopes] = useState<TokenScope[]>(['read']);
+    const [days, setDays] = useState(90);
+    const [created, setCreated] = useState<APIToken | null>(null);
+    const [error, setError] = useState<string | null>(null);
+
+    function loadTokens() {
+        client.getTokens()
+            .then(r => r.json())
+            .then((res: APITokens) => setTokens(res.items))
+            .catch(console.error);
+    }
+
+    useEffect(loadTokens, []);
+
+    function toggleScope(scope: TokenScope) {
+        setScopes(scopes.includes(scope) ? scopes.filter(s => s !== scope) : [...scopes, scope]);
+    }
+
+    async function handleCreate(e: React.FormEvent) {
+        e.preventDefault();
+        setError(null);
+        const r = await client.createToken({ name: name.trim(), scopes, expires_in_days: days });
+        if (!r.ok) {
+            const body = await r.json().catch(() => null) as APIError | null;
+            setError(body?.error ?? `Could not create the token (${r.status})`);
+            return;
+        }
+        setCreated(await r.json() as APIToken);
+        setName('');
+        loadTokens();
+    }
+
+    async function handleRevoke(token: APIToken) {
+        if (!window.confirm(`Revoke "${token.name}"? Scripts using it stop working.`)) {
+            return;
+        }
+        const r = await client.revokeToken(token.id);
+        if (!r.ok) {
+            setError(`Could not revoke the token (${r.status})`);
+            return;
+        }
+        loadTokens();
+    }
+
+    return (
+        <Container>
+            <Card className="mb-3">
+                <Card.Body>
+                    <Card.Title>New API token</Card.Title>
+                    <Card.Text className="text-muted">
+                        Scripts send it as <code>Authorization: Bearer TOKEN</code>.
+                    </Card.Text>
+                    {error && <Alert variant="danger">{error}</Alert>}
+                    {created?.token && (
+                        <Alert variant="success" onClose={() => setCreated(null)} dismissible>
+                            Copy the token now, it is not shown again:
+                            <div><code>{created.token}</code></div>
+                        </Alert>
+                    )}
+                    <Form onSubmit={handleCreate}>
+                        <Form.Group className="mb-2">
+                            <Form.Label>Name</Form.Label>
+                            <Form.Control value={name} maxLength={64} required onChange={e => setName(e.target.value)} />
+                        </Form.Group>
+                        <Form.Group className="mb-2">
+                            {SCOPES.map(({ scope, label }) => (
+                                <Form.Check
+                                    key={scope}
+                                    id={`scope-${scope}`}
+                                    label={`${label} (${scope})`}
+                                    checked={scopes.includes(scope)}
+                                    onChange={() => toggleScope(scope)}
+                                />
+                            ))}
+                        </Form.Group>
+                        <Form.Group className="mb-3">
+                            <Form.Label>Expires in</Form.Label>
+                            <Form.Select value={days} onChange={e => setDays(Number(e.target.value))}>
+                                <option value={30}>30 days</option>
+                                <option value={90}>90 days</option>
+                                <option value={365}>A year</option>
+                                <option value={0}>Never</option>
+                            </Form.Select>
+                        </Form.Group>
+                        <Button type="submit" disabled={!name.trim() || scopes.length === 0}>Create</Button>
+                    </Form>
+                </Card.Body>
+            </Card>
+
+            <Table striped hover responsive>
+                <thead>
+                    <tr>
+                        <th>Name</th>
+                        <th>Scopes</th>
+                        <th>Expires</th>
+                        <th>Last used</th>
+                        <th />
+                    </tr>
+                </thead>
+                <tbody>
+                    {tokens.map(t => (
+                        <tr key={t.id}>
+                            <td>{t.name}</td>
+                            <td>{t.scopes.join(', ')}</td>
+                            <td>{formatDate(t.expires_at, 'Never')}</td>
+                            <td>{formatDate(t.last_used_at, 'Never')}</td>
+                            <td className="text-end">
+                                <Button size="sm" variant="outline-danger" onClick={() => handleRevoke(t)}>Revoke</Button>
+                            </td>
+                        </tr>
+                    ))}
+                </tbody>
+            </Table>
+        </Container>
+    );
+}
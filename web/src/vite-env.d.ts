@@ -1 +1,18 @@
 /// <reference types="vite/client" />
+
+interface ImportMetaEnv {
+    readonly VITE_API_BASE_URL?: string;
+    /** Username of the bot, without the @, to show the Telegram Login Widget for. */
+    readonly VITE_TELEGRAM_BOT_USERNAME?: string;
+}
+
+interface Window {
+    /** Set by telegram-web-app.js; initData is empty unless the page is opened as a Mini App. */
+    Telegram?: {
+        WebApp?: {
+            initData: string;
+            ready(): void;
+        };
+    };
+    onTelegramAuth?: (user: import('./api/client.tsx').TelegramWidgetUser) => void;
+}